@@ -0,0 +1,80 @@
+package azkustodata
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+)
+
+// cancelTimeout is how long the client waits for the cluster to accept a `.cancel query` command.
+const cancelTimeout = 10 * time.Second
+
+// WithServerSideCancel configures the client to send a `.cancel query` command, identifying the query by its client
+// request id, when the context of a query is canceled or times out before its results are read, so abandoned queries
+// don't keep running on the cluster. The command is best-effort: it is sent in the background, and its failures are only
+// logged. Commands sent with Mgmt aren't canceled.
+func WithServerSideCancel() Option {
+	return func(c *Client) {
+		c.serverSideCancel = true
+	}
+}
+
+// watchCancellation cancels the query with the given client request id on the cluster if ctx is done before the returned
+// function is called.
+func (c *Conn) watchCancellation(ctx context.Context, clientRequestID string) func() {
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		select {
+		case <-ctx.Done():
+			// The query may have ended just before ctx, in which case there is nothing to cancel.
+			select {
+			case <-done:
+			default:
+				c.cancelQuery(clientRequestID)
+			}
+		case <-done:
+		}
+	}()
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// cancelQuery sends a `.cancel query` command for the query with the given client request id.
+func (c *Conn) cancelQuery(clientRequestID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel()
+
+	_, _, _, body, err := c.doRequest(ctx, execMgmt, "NetDefaultDB", kql.New(".cancel query ").AddString(clientRequestID),
+		requestProperties{Options: map[string]interface{}{}})
+	if err != nil {
+		c.logger.Log(ctx, slog.LevelWarn, "failed to cancel query", "clientRequestId", clientRequestID, "error", err)
+		return
+	}
+	_ = body.Close()
+	c.logger.Log(ctx, slog.LevelDebug, "canceled query", "clientRequestId", clientRequestID)
+}
+
+// stopOnDoneReader calls stop once the response body was read or closed.
+type stopOnDoneReader struct {
+	io.ReadCloser
+	stop func()
+}
+
+func (r stopOnDoneReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err != nil {
+		r.stop()
+	}
+	return n, err
+}
+
+func (r stopOnDoneReader) Close() error {
+	r.stop()
+	return r.ReadCloser.Close()
+}
@@ -0,0 +1,59 @@
+package azkustodata
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithServerSideCancel(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    []Option
+		wantCancel bool
+	}{
+		{name: "Disabled"},
+		{name: "Enabled", options: []Option{WithServerSideCancel()}, wantCancel: true},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			commands := make(chan string, 1)
+			srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v2/rest/query":
+					// A long-running query, which only ends when the client disconnects. The body must be read for the
+					// server to notice the disconnection.
+					_, _ = io.Copy(io.Discard, r.Body)
+					<-r.Context().Done()
+				default:
+					var msg queryMsg
+					_ = json.NewDecoder(r.Body).Decode(&msg)
+					commands <- msg.CSL
+					_, _ = w.Write([]byte(verifyTestShowVersion))
+				}
+			})
+			client := newTestKustoClient(t, srv, tt.options...)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			_, err := client.Query(ctx, "db", kql.New("T"), ClientRequestID("heavy-query"))
+			require.Error(t, err)
+
+			select {
+			case command := <-commands:
+				assert.True(t, tt.wantCancel)
+				assert.Equal(t, `.cancel query "heavy-query"`, command)
+			case <-time.After(time.Second):
+				assert.False(t, tt.wantCancel)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package azkustodata
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// parseCertificates parses a certificate chain and its private key, in PEM or PKCS#12 (PFX) format.
+// PKCS#12 bundles are decoded with go-pkcs12 rather than azidentity, as it also supports the AES based encryption used by
+// default by OpenSSL 3, Key Vault and recent Windows exports.
+func parseCertificates(data []byte, password []byte) ([]*x509.Certificate, crypto.PrivateKey, error) {
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		return azidentity.ParseCertificates(data, password)
+	}
+
+	key, cert, caCerts, err := pkcs12.DecodeChain(data, string(password))
+	if err != nil {
+		return nil, nil, err
+	}
+	return append([]*x509.Certificate{cert}, caCerts...), key, nil
+}
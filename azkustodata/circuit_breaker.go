@@ -0,0 +1,188 @@
+package azkustodata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+)
+
+// ErrCircuitOpen is returned, wrapped, by calls that were not sent because the client's circuit breaker is open.
+var ErrCircuitOpen = stdErrors.New("circuit breaker is open, the cluster is considered unhealthy")
+
+// CircuitState is the state of a client's circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed is the normal state, requests are sent.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails requests fast, without sending them, until the cool-down period is over.
+	CircuitOpen
+	// CircuitHalfOpen is the state while the cluster is probed after the cool-down period.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "Open"
+	case CircuitHalfOpen:
+		return "HalfOpen"
+	}
+	return "Closed"
+}
+
+// CircuitBreakerOptions configures the circuit breaker of a client.
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive transport-level failures, such as connection errors and timeouts, that
+	// open the circuit. Defaults to 5.
+	FailureThreshold int
+	// CoolDown is how long the circuit stays open before the cluster is probed with `.show version`. Defaults to 30 seconds.
+	CoolDown time.Duration
+	// ProbeTimeout bounds the probe, which keeps the circuit open if the cluster doesn't answer in time. The probe doesn't
+	// use the deadline of the call that triggered it. Defaults to 10 seconds.
+	ProbeTimeout time.Duration
+	// OnStateChange, if set, is called on every state change.
+	OnStateChange func(from, to CircuitState)
+}
+
+// WithCircuitBreaker configures the client to fail fast, with an error wrapping ErrCircuitOpen, after consecutive
+// transport-level failures, instead of waiting for every call to time out during a cluster outage.
+// Once the cool-down period is over, the next call probes the cluster with `.show version`, and closes the circuit if it
+// succeeds.
+func WithCircuitBreaker(options CircuitBreakerOptions) Option {
+	return func(c *Client) {
+		c.circuitBreaker = newCircuitBreaker(options)
+	}
+}
+
+type circuitBreaker struct {
+	threshold     int
+	coolDown      time.Duration
+	probeTimeout  time.Duration
+	onStateChange func(from, to CircuitState)
+
+	lock     sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(options CircuitBreakerOptions) *circuitBreaker {
+	b := &circuitBreaker{
+		threshold:     options.FailureThreshold,
+		coolDown:      options.CoolDown,
+		probeTimeout:  options.ProbeTimeout,
+		onStateChange: options.OnStateChange,
+	}
+	if b.threshold <= 0 {
+		b.threshold = 5
+	}
+	if b.coolDown <= 0 {
+		b.coolDown = 30 * time.Second
+	}
+	if b.probeTimeout <= 0 {
+		b.probeTimeout = 10 * time.Second
+	}
+	return b
+}
+
+// setState changes the state, and returns a function notifying the change, to be called once the lock is released.
+func (b *circuitBreaker) setState(state CircuitState) func() {
+	from := b.state
+	b.state = state
+	if state == CircuitOpen {
+		b.openedAt = time.Now()
+	}
+	if from == state || b.onStateChange == nil {
+		return func() {}
+	}
+	return func() { b.onStateChange(from, state) }
+}
+
+// allow returns an error if the request should not be sent. When the cool-down period is over, it probes the cluster.
+func (b *circuitBreaker) allow(ctx context.Context, probe func(context.Context) error) error {
+	b.lock.Lock()
+	switch {
+	case b.state == CircuitClosed:
+		b.lock.Unlock()
+		return nil
+	case b.state == CircuitHalfOpen || time.Since(b.openedAt) < b.coolDown:
+		b.lock.Unlock()
+		return ErrCircuitOpen
+	}
+	notify := b.setState(CircuitHalfOpen)
+	b.lock.Unlock()
+	notify()
+
+	// A probe that never ends would leave the circuit half-open, failing every call, and one canceled by its caller would
+	// reopen it without the cluster being at fault.
+	probeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), b.probeTimeout)
+	err := probe(probeCtx)
+	cancel()
+
+	b.lock.Lock()
+	if err != nil {
+		notify = b.setState(CircuitOpen)
+	} else {
+		b.failures = 0
+		notify = b.setState(CircuitClosed)
+	}
+	b.lock.Unlock()
+	notify()
+
+	if err != nil {
+		return fmt.Errorf("%w: probe failed: %v", ErrCircuitOpen, err)
+	}
+	return nil
+}
+
+// record counts the result of a request sent while the circuit is closed.
+func (b *circuitBreaker) record(err error) {
+	b.lock.Lock()
+	notify := func() {}
+	if isTransportFailure(err) {
+		b.failures++
+		if b.state == CircuitClosed && b.failures >= b.threshold {
+			notify = b.setState(CircuitOpen)
+		}
+	} else {
+		b.failures = 0
+	}
+	b.lock.Unlock()
+	notify()
+}
+
+// isTransportFailure returns whether err means the cluster couldn't be reached, or didn't answer in time. Errors returned by
+// the cluster, or caused by the caller canceling the request, don't count.
+func isTransportFailure(err error) bool {
+	if err == nil || stdErrors.Is(err, context.Canceled) {
+		return false
+	}
+	var httpErr *errors.HttpError
+	if stdErrors.As(err, &httpErr) {
+		return false
+	}
+	var kustoErr *errors.Error
+	return stdErrors.As(err, &kustoErr) && kustoErr.Kind == errors.KHTTPError
+}
+
+// probe checks that the cluster is reachable, by running `.show version`.
+func (c *Conn) probe(ctx context.Context) error {
+	body, err := json.Marshal(queryMsg{DB: "NetDefaultDB", CSL: ".show version"})
+	if err != nil {
+		return err
+	}
+	_, closer, err := c.doRequestImpl(ctx, errors.OpMgmt, c.endMgmt, io.NopCloser(bytes.NewReader(body)), c.getHeaders(requestProperties{}),
+		"circuit breaker probe")
+	if err != nil {
+		return err
+	}
+	return closer.Close()
+}
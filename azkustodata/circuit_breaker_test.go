@@ -0,0 +1,155 @@
+package azkustodata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// outageTransporter fails every request with a connection error while down is set, and holds them until they are
+// canceled while hang is set.
+type outageTransporter struct {
+	client *http.Client
+	down   atomic.Bool
+	hang   atomic.Bool
+	calls  atomic.Int32
+}
+
+func (o *outageTransporter) Do(req *http.Request) (*http.Response, error) {
+	o.calls.Add(1)
+	if o.down.Load() {
+		return nil, fmt.Errorf("dial tcp: connection refused")
+	}
+	if o.hang.Load() {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}
+	return o.client.Do(req)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	srv := newTestKustoServer(t, verifyTestHandler(http.StatusOK))
+
+	var lock sync.Mutex
+	var changes []string
+	transport := &outageTransporter{client: srv.Client()}
+	client, err := New(NewConnectionStringBuilder(srv.URL).WithTokenCredential(&fakeCredential{}), WithTransport(transport),
+		WithCircuitBreaker(CircuitBreakerOptions{
+			FailureThreshold: 2,
+			CoolDown:         20 * time.Millisecond,
+			OnStateChange: func(from, to CircuitState) {
+				lock.Lock()
+				defer lock.Unlock()
+				changes = append(changes, fmt.Sprintf("%s->%s", from, to))
+			},
+		}))
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+	mgmt := func() error {
+		_, err := client.Mgmt(ctx, "db", kql.New(".show version"))
+		return err
+	}
+	require.NoError(t, mgmt())
+
+	// Consecutive transport failures open the circuit.
+	transport.down.Store(true)
+	assert.Error(t, mgmt())
+	assert.Error(t, mgmt())
+	calls := transport.calls.Load()
+
+	// Calls fail fast while the circuit is open.
+	err = mgmt()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, calls, transport.calls.Load())
+
+	// A failed probe keeps the circuit open.
+	time.Sleep(30 * time.Millisecond)
+	assert.ErrorIs(t, mgmt(), ErrCircuitOpen)
+	assert.Equal(t, calls+1, transport.calls.Load())
+
+	// A successful probe closes it.
+	transport.down.Store(false)
+	time.Sleep(30 * time.Millisecond)
+	require.NoError(t, mgmt())
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []string{"Closed->Open", "Open->HalfOpen", "HalfOpen->Open", "Open->HalfOpen", "HalfOpen->Closed"}, changes)
+}
+
+func TestCircuitBreakerIgnoresServiceErrors(t *testing.T) {
+	srv := newTestKustoServer(t, verifyTestHandler(http.StatusBadRequest))
+
+	client := newTestKustoClient(t, srv, WithCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 1}))
+
+	for i := 0; i < 3; i++ {
+		_, err := client.Mgmt(context.Background(), "db", kql.New(".show version"))
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrCircuitOpen))
+	}
+}
+
+func TestCircuitBreakerProbeTimeout(t *testing.T) {
+	srv := newTestKustoServer(t, verifyTestHandler(http.StatusOK))
+
+	var lock sync.Mutex
+	var changes []string
+	transport := &outageTransporter{client: srv.Client()}
+	client, err := New(NewConnectionStringBuilder(srv.URL).WithTokenCredential(&fakeCredential{}), WithTransport(transport),
+		WithCircuitBreaker(CircuitBreakerOptions{
+			FailureThreshold: 1,
+			CoolDown:         10 * time.Millisecond,
+			ProbeTimeout:     20 * time.Millisecond,
+			OnStateChange: func(from, to CircuitState) {
+				lock.Lock()
+				defer lock.Unlock()
+				changes = append(changes, fmt.Sprintf("%s->%s", from, to))
+			},
+		}))
+	require.NoError(t, err)
+	defer client.Close()
+
+	mgmt := func(ctx context.Context) error {
+		_, err := client.Mgmt(ctx, "db", kql.New(".show version"))
+		return err
+	}
+	require.NoError(t, mgmt(context.Background()))
+	transport.down.Store(true)
+	assert.Error(t, mgmt(context.Background()))
+
+	// A probe the cluster doesn't answer reopens the circuit, although the call has no deadline.
+	transport.down.Store(false)
+	transport.hang.Store(true)
+	time.Sleep(20 * time.Millisecond)
+	done := make(chan error, 1)
+	go func() { done <- mgmt(context.Background()) }()
+	select {
+	case err = <-done:
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the probe didn't time out")
+	}
+
+	// A probe isn't canceled with the call that triggered it.
+	transport.hang.Store(false)
+	time.Sleep(20 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, mgmt(ctx))
+	require.NoError(t, mgmt(context.Background()))
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []string{"Closed->Open", "Open->HalfOpen", "HalfOpen->Open", "Open->HalfOpen", "HalfOpen->Closed"}, changes)
+}
@@ -98,6 +98,13 @@ func buildHeaderFormat(args ...StringPair) string {
 	}), "|")
 }
 
+func formatApplicationForTracing(name, version string) string {
+	if isEmpty(version) {
+		version = NONE
+	}
+	return buildHeaderFormat(StringPair{Key: "App." + escape(name), Value: version})
+}
+
 func setConnectorDetails(name, version, appName, appVersion string, sendUser bool, overrideUser string, additionalFields ...StringPair) (string, string) {
 	var additionalFieldsList []StringPair
 
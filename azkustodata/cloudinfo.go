@@ -9,8 +9,11 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // abstraction to query metadata and use this information for providing all
@@ -52,62 +55,122 @@ var defaultCloudInfo = CloudInfo{
 // cache to query it once per instance
 var cloudInfoCache sync.Map
 
-func GetMetadata(kustoUri string, httpClient *http.Client) (CloudInfo, error) {
-	// retrieve &return if exists
-	once, ok := cloudInfoCache.Load(kustoUri)
-	if !ok {
-		once = utils.NewOnce[CloudInfo]()
-		cloudInfoCache.Store(kustoUri, once)
-	}
+// cloudInfoTTL is how long fetched cloud info is cached for, in nanoseconds. Zero caches it for the life of the process.
+var cloudInfoTTL atomic.Int64
+
+// cachedCloudInfo is a cloud info cache entry. fetched is zero for entries set with SetCloudInfo, which never expire.
+type cachedCloudInfo struct {
+	info    CloudInfo
+	fetched time.Time
+}
+
+func (c cachedCloudInfo) expired() bool {
+	ttl := time.Duration(cloudInfoTTL.Load())
+	return ttl > 0 && !c.fetched.IsZero() && time.Since(c.fetched) >= ttl
+}
+
+// SetCloudInfo sets the cloud info of the cluster at kustoUri, so it is never fetched from the cluster.
+// This is useful in air-gapped environments, where the metadata endpoint can't be reached.
+// kustoUri must match the data source of the connection string exactly.
+func SetCloudInfo(kustoUri string, info CloudInfo) {
+	once := utils.NewOnce[cachedCloudInfo]()
+	_, _ = once.Do(func() (cachedCloudInfo, error) {
+		return cachedCloudInfo{info: info}, nil
+	})
+	cloudInfoCache.Store(kustoUri, once)
+}
+
+// InvalidateCloudInfo removes the cached cloud info of the cluster at kustoUri, so it is fetched again on next use.
+func InvalidateCloudInfo(kustoUri string) {
+	cloudInfoCache.Delete(kustoUri)
+}
+
+// SetCloudInfoTTL sets how long cloud info fetched from a cluster is cached for, so long-running services pick up changes
+// in the cluster's cloud, such as after a migration. A ttl of zero, the default, caches it for the life of the process.
+// Cloud info set with SetCloudInfo never expires.
+func SetCloudInfoTTL(ttl time.Duration) {
+	cloudInfoTTL.Store(int64(ttl))
+}
 
-	return once.(utils.Once[CloudInfo]).Do(func() (CloudInfo, error) {
-		u, err := url.Parse(kustoUri)
+func GetMetadata(kustoUri string, httpClient *http.Client) (CloudInfo, error) {
+	for {
+		// retrieve &return if exists
+		once, _ := cloudInfoCache.LoadOrStore(kustoUri, utils.NewOnce[cachedCloudInfo]())
+
+		cached, err := once.(utils.Once[cachedCloudInfo]).Do(func() (cachedCloudInfo, error) {
+			info, err := fetchMetadata(kustoUri, httpClient)
+			if err != nil {
+				return cachedCloudInfo{}, err
+			}
+			return cachedCloudInfo{info: info, fetched: time.Now()}, nil
+		})
 		if err != nil {
 			return CloudInfo{}, err
 		}
-		if !strings.HasPrefix(u.Path, "/") {
-			u.Path = "/" + u.Path
+		if !cached.expired() {
+			return cached.info, nil
 		}
-		u = u.JoinPath(metadataPath)
-		// TODO should we make this timeout configurable.
-		req, err := http.NewRequest("GET", u.String(), nil)
+		// Only the first caller to see the expired entry removes it, the rest retry with whichever entry replaced it.
+		cloudInfoCache.CompareAndDelete(kustoUri, once)
+	}
+}
 
-		if err != nil {
-			return CloudInfo{}, kustoErrors.E(kustoErrors.OpCloudInfo, kustoErrors.KHTTPError, err)
-		}
-		resp, err := httpClient.Do(req)
+func fetchMetadata(kustoUri string, httpClient *http.Client) (CloudInfo, error) {
+	u, err := url.Parse(kustoUri)
+	if err != nil {
+		return CloudInfo{}, err
+	}
+	if !strings.HasPrefix(u.Path, "/") {
+		u.Path = "/" + u.Path
+	}
+	u = u.JoinPath(metadataPath)
+	// TODO should we make this timeout configurable.
+	req, err := http.NewRequest("GET", u.String(), nil)
 
-		if err != nil {
-			return CloudInfo{}, err
-		}
+	if err != nil {
+		return CloudInfo{}, kustoErrors.E(kustoErrors.OpCloudInfo, kustoErrors.KHTTPError, err)
+	}
+	resp, err := httpClient.Do(req)
 
-		// Handle internal server error as a special case and return as an error (to be consistent with other SDK's)
-		if resp.StatusCode >= 300 && resp.StatusCode != 404 {
-			return CloudInfo{}, kustoErrors.E(kustoErrors.OpCloudInfo, kustoErrors.KHTTPError, fmt.Errorf("error %s when querying endpoint %s",
-				resp.Status, u.String()),
-			)
-		}
+	if err != nil {
+		return CloudInfo{}, err
+	}
 
-		defer resp.Body.Close()
+	// Handle internal server error as a special case and return as an error (to be consistent with other SDK's)
+	if resp.StatusCode >= 300 && resp.StatusCode != 404 {
+		return CloudInfo{}, kustoErrors.E(kustoErrors.OpCloudInfo, kustoErrors.KHTTPError, fmt.Errorf("error %s when querying endpoint %s",
+			resp.Status, u.String()),
+		)
+	}
 
-		b, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return CloudInfo{}, kustoErrors.E(kustoErrors.OpCloudInfo, kustoErrors.KHTTPError, err)
-		}
+	defer resp.Body.Close()
 
-		// Covers scenarios of 200/OK with no body or a 404 where there is no body
-		if len(b) == 0 {
-			return defaultCloudInfo, nil
-		}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return CloudInfo{}, kustoErrors.E(kustoErrors.OpCloudInfo, kustoErrors.KHTTPError, err)
+	}
 
-		md := metaResp{}
+	// Covers scenarios of 200/OK with no body or a 404 where there is no body
+	if len(b) == 0 {
+		return defaultCloudInfo, nil
+	}
 
-		if err := json.Unmarshal(b, &md); err != nil {
-			return CloudInfo{}, err
-		}
-		// this should be set in the map by now
-		return md.AzureAD, nil
-	})
+	md := metaResp{}
+
+	if err := json.Unmarshal(b, &md); err != nil {
+		return CloudInfo{}, err
+	}
+	// this should be set in the map by now
+	return md.AzureAD, nil
+}
+
+// firstPartyTenant returns the tenant of the cloud's first party authority, to be used when no authority was configured.
+func (ci *CloudInfo) firstPartyTenant() string {
+	u, err := url.Parse(ci.FirstPartyAuthorityURL)
+	if err != nil {
+		return ""
+	}
+	return path.Base(strings.TrimSuffix(u.Path, "/"))
 }
 
 func getEnvOrDefault(key, fallback string) string {
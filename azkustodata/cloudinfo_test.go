@@ -5,9 +5,14 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type server struct {
@@ -22,6 +27,41 @@ func newTestServ() *server {
 	return s
 }
 
+// testCloudMetadata is the metadata of the dummy cloud of the test servers.
+const testCloudMetadata = `{"AzureAD": {"LoginEndpoint": "https://login.microsofdummy.com","LoginMfaRequired": false,"KustoClientAppId": "db662dc1-0cfe-4e1c-a843-19a68e65xxxx","KustoClientRedirectUri": "https://microsoft/dummykustoclient","KustoServiceResourceId": "https://kusto.windows.net","FirstPartyAuthorityUrl": "https://login.microsofdummy.com/f8cdef31-a31e-4b4a-93e4-5f571e9xxxxx"}}`
+
+// testCloudMetadataWithResource returns testCloudMetadata, with another Kusto service resource ID.
+func testCloudMetadataWithResource(resourceID string) string {
+	return strings.Replace(testCloudMetadata, `"https://kusto.windows.net"`, strconv.Quote(resourceID), 1)
+}
+
+// newTestKustoServer starts a TLS test cluster with testKustoHandler. It is closed when the test ends.
+func newTestKustoServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	srv := httptest.NewTLSServer(testKustoHandler(handler))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newTestKustoClient returns a client of srv authenticated with a fakeCredential. It is closed when the test ends.
+func newTestKustoClient(t *testing.T, srv *httptest.Server, options ...Option) *Client {
+	client, err := New(NewConnectionStringBuilder(srv.URL).WithTokenCredential(&fakeCredential{}),
+		append([]Option{WithHttpClient(srv.Client())}, options...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// testKustoHandler serves testCloudMetadata on the metadata endpoint, and hands every other request to handler.
+func testKustoHandler(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == metadataPath {
+			_, _ = w.Write([]byte(testCloudMetadata))
+			return
+		}
+		handler(w, r)
+	}
+}
+
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	defer log.Println("server exited")
 	w.WriteHeader(s.code)
@@ -145,3 +185,65 @@ func TestGetMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestCloudInfoSovereignCloud(t *testing.T) {
+	s := newTestServ()
+	defer s.close()
+	s.code = 200
+	s.payload = []byte(`{"AzureAD": {"LoginEndpoint": "https://login.chinacloudapi.cn","LoginMfaRequired": false,"KustoClientAppId": "db662dc1-0cfe-4e1c-a843-19a68e65be58","KustoClientRedirectUri": "https://microsoft/kustoclient","KustoServiceResourceId": "https://kusto.kusto.chinacloudapi.cn","FirstPartyAuthorityUrl": "https://login.chinacloudapi.cn/a55a4d5b-9241-49b1-b4ff-befa8db00269"}}`)
+
+	shared := &azcore.ClientOptions{}
+	kcsb := NewConnectionStringBuilder(s.urlStr()).WithAadAppKey("clientID", "secret", "tenantID").AttachPolicyClientOptions(shared)
+	tkp, err := kcsb.newTokenProvider()
+	assert.NoError(t, err)
+	tkp.SetHttp(s.http.Client())
+
+	_, err = tkp.initOnce.DoWithInit()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://kusto.kusto.chinacloudapi.cn/.default"}, tkp.scopes)
+
+	ci, cliOpts, _, err := getCommonCloudInfo(kcsb, func() *http.Client { return s.http.Client() })
+	assert.NoError(t, err)
+	assert.Equal(t, "https://login.chinacloudapi.cn", cliOpts.Cloud.ActiveDirectoryAuthorityHost)
+	assert.Equal(t, "a55a4d5b-9241-49b1-b4ff-befa8db00269", ci.firstPartyTenant())
+
+	// The options the user passed in are left untouched, so they can be shared with clusters in other clouds.
+	assert.Equal(t, "", shared.Cloud.ActiveDirectoryAuthorityHost)
+	assert.Nil(t, shared.Transport)
+}
+
+func TestCloudInfoCache(t *testing.T) {
+	s := newTestServ()
+	defer s.close()
+	s.code = 200
+	s.payload = []byte(`{"AzureAD": {"LoginEndpoint": "https://login.fetched.com"}}`)
+
+	// Pre-seeded cloud info is used without reaching the cluster.
+	uri := s.urlStr() + "/test_cloud_info_cache"
+	SetCloudInfo(uri, CloudInfo{LoginEndpoint: "https://login.seeded.com"})
+	defer InvalidateCloudInfo(uri)
+	s.code = 500
+	res, err := GetMetadata(uri, s.http.Client())
+	assert.NoError(t, err)
+	assert.Equal(t, "https://login.seeded.com", res.LoginEndpoint)
+
+	// Invalidated entries are fetched again.
+	s.code = 200
+	InvalidateCloudInfo(uri)
+	res, err = GetMetadata(uri, s.http.Client())
+	assert.NoError(t, err)
+	assert.Equal(t, "https://login.fetched.com", res.LoginEndpoint)
+
+	// Without a TTL, fetched entries never expire.
+	s.payload = []byte(`{"AzureAD": {"LoginEndpoint": "https://login.migrated.com"}}`)
+	res, err = GetMetadata(uri, s.http.Client())
+	assert.NoError(t, err)
+	assert.Equal(t, "https://login.fetched.com", res.LoginEndpoint)
+
+	SetCloudInfoTTL(time.Millisecond)
+	defer SetCloudInfoTTL(0)
+	time.Sleep(2 * time.Millisecond)
+	res, err = GetMetadata(uri, s.http.Client())
+	assert.NoError(t, err)
+	assert.Equal(t, "https://login.migrated.com", res.LoginEndpoint)
+}
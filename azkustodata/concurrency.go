@@ -0,0 +1,130 @@
+package azkustodata
+
+import (
+	"context"
+	stdErrors "errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrConcurrencyLimit is returned, wrapped, by calls that were not sent because the client's concurrency limit was reached,
+// and its queue was full or the call waited longer than its wait timeout.
+var ErrConcurrencyLimit = stdErrors.New("concurrency limit reached")
+
+// ConcurrencyLimitOptions configures the concurrency limit of a client.
+type ConcurrencyLimitOptions struct {
+	// MaxConcurrent is the number of queries and commands that can be in progress at once. A call is in progress until
+	// its results are read, or the iterative dataset or reader returning them is closed.
+	MaxConcurrent int
+	// MaxQueued is the number of calls that can wait for another one to end. Calls made when the queue is full fail
+	// immediately. Zero means no limit.
+	MaxQueued int
+	// WaitTimeout is how long a call waits in the queue before failing. Zero means it waits as long as its context allows.
+	// It can be overridden for a single call with the ConcurrencyWaitTimeout query option.
+	WaitTimeout time.Duration
+}
+
+// ConcurrencyStats are the current counts of a client's concurrency limiter, for monitoring.
+type ConcurrencyStats struct {
+	// InFlight is the number of calls in progress.
+	InFlight int
+	// Queued is the number of calls waiting for a call in progress to end.
+	Queued int
+}
+
+// WithConcurrencyLimit caps the number of queries and commands the client has in progress at once, so bursts of calls
+// don't exceed the cluster's request limits. Calls over the limit wait for another call to end, and fail with an error
+// wrapping ErrConcurrencyLimit if the queue is full or they waited longer than the wait timeout.
+// MaxConcurrent must be positive, otherwise the option is ignored.
+func WithConcurrencyLimit(options ConcurrencyLimitOptions) Option {
+	return func(c *Client) {
+		if options.MaxConcurrent <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = newLimiter(options)
+	}
+}
+
+// ConcurrencyWaitTimeout overrides the client's concurrency limit wait timeout for this call, see WithConcurrencyLimit.
+func ConcurrencyWaitTimeout(d time.Duration) QueryOption {
+	return func(q *queryOptions) error {
+		q.concurrencyWaitTimeout = d
+		return nil
+	}
+}
+
+// ConcurrencyStats returns the current in-flight and queued counts of the client's concurrency limiter.
+// Both are zero if WithConcurrencyLimit wasn't used.
+func (c *Client) ConcurrencyStats() ConcurrencyStats {
+	if c.limiter == nil {
+		return ConcurrencyStats{}
+	}
+	return c.limiter.stats()
+}
+
+type limiter struct {
+	slots       chan struct{}
+	maxQueued   int32
+	waitTimeout time.Duration
+
+	queued atomic.Int32
+}
+
+func newLimiter(options ConcurrencyLimitOptions) *limiter {
+	return &limiter{
+		slots:       make(chan struct{}, options.MaxConcurrent),
+		maxQueued:   int32(options.MaxQueued),
+		waitTimeout: options.WaitTimeout,
+	}
+}
+
+// acquire waits for a free slot, and returns the function releasing it, which can be called several times.
+// waitTimeout overrides the limiter's if positive.
+func (l *limiter) acquire(ctx context.Context, waitTimeout time.Duration) (func(), error) {
+	select {
+	case l.slots <- struct{}{}:
+		return l.releaser(), nil
+	default:
+	}
+
+	if queued := l.queued.Add(1); l.maxQueued > 0 && queued > l.maxQueued {
+		l.queued.Add(-1)
+		return nil, ErrConcurrencyLimit
+	}
+	defer l.queued.Add(-1)
+
+	if waitTimeout <= 0 {
+		waitTimeout = l.waitTimeout
+	}
+	var timeout <-chan time.Time
+	if waitTimeout > 0 {
+		timer := time.NewTimer(waitTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.releaser(), nil
+	case <-timeout:
+		return nil, ErrConcurrencyLimit
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *limiter) releaser() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-l.slots })
+	}
+}
+
+func (l *limiter) stats() ConcurrencyStats {
+	return ConcurrencyStats{
+		InFlight: len(l.slots),
+		Queued:   int(l.queued.Load()),
+	}
+}
@@ -0,0 +1,53 @@
+package azkustodata
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(verifyTestShowVersion))
+	})
+
+	client := newTestKustoClient(t, srv, WithConcurrencyLimit(ConcurrencyLimitOptions{MaxConcurrent: 1, MaxQueued: 1}))
+
+	// The slot is held until the results are closed.
+	body, err := client.RawV2(context.Background(), "db", kql.New("T"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, ConcurrencyStats{InFlight: 1}, client.ConcurrencyStats())
+
+	queued := make(chan error)
+	go func() {
+		_, err := client.Mgmt(context.Background(), "db", kql.New(".show version"))
+		queued <- err
+	}()
+	require.Eventually(t, func() bool { return client.ConcurrencyStats().Queued == 1 }, time.Second, time.Millisecond)
+
+	// The queue is full.
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"))
+	assert.ErrorIs(t, err, ErrConcurrencyLimit)
+
+	require.NoError(t, body.Close())
+	require.NoError(t, <-queued)
+	assert.Equal(t, ConcurrencyStats{}, client.ConcurrencyStats())
+
+	body, err = client.RawV2(context.Background(), "db", kql.New("T"), nil)
+	require.NoError(t, err)
+	defer body.Close()
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"), ConcurrencyWaitTimeout(10*time.Millisecond))
+	assert.ErrorIs(t, err, ErrConcurrencyLimit)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.Mgmt(ctx, "db", kql.New(".show version"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, ConcurrencyStats{InFlight: 1}, client.ConcurrencyStats())
+}
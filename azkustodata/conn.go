@@ -5,20 +5,26 @@ package azkustodata
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	stdErrors "errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 	"unicode"
 
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
 	"github.com/Azure/azure-kusto-go/azkustodata/internal/response"
+	kustoQuery "github.com/Azure/azure-kusto-go/azkustodata/query"
 	truestedEndpoints "github.com/Azure/azure-kusto-go/azkustodata/trusted_endpoints"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/google/uuid"
 )
 
@@ -38,8 +44,34 @@ type Conn struct {
 	client                             *http.Client
 	endpointValidated                  atomic.Bool
 	clientDetails                      *ClientDetails
+	// compressionThreshold is the size, in bytes, from which query and command bodies are gzip compressed. Zero disables compression.
+	compressionThreshold int
+	// retryPolicy retries failed queries and commands. Nil disables retries.
+	retryPolicy *RetryPolicy
+	// throttlingBudget is the total time a request waits for the cluster to stop throttling it. Zero disables waiting.
+	throttlingBudget time.Duration
+	// circuitBreaker fails requests fast while the cluster is unreachable. Nil disables it.
+	circuitBreaker *circuitBreaker
+	// limiter caps the number of queries and commands in progress. Nil disables it.
+	limiter *limiter
+	// tracing creates the spans of queries and commands. Nil disables it.
+	tracing *tracing
+	// metrics receives the retry counts of queries and commands.
+	metrics Metrics
+	// logger receives the logs of the requests' lifecycle.
+	logger Logger
+	// clientRequestIDGenerator generates the client request ids of requests that don't set one. Nil uses a random id.
+	clientRequestIDGenerator func() string
+	// serverSideCancel cancels queries on the cluster when their context is done before their results are read.
+	serverSideCancel bool
+	// inFlight tracks the calls in progress, for Shutdown.
+	inFlight inFlight
 }
 
+// defaultCompressionThreshold is the default size from which query and command bodies are gzip compressed.
+// Smaller bodies are sent as is, as compressing them saves little and costs CPU on both ends.
+const defaultCompressionThreshold = 64 * 1024
+
 // NewConn returns a new Conn object with an injected http.Client
 func NewConn(endpoint string, auth Authorization, client *http.Client, clientDetails *ClientDetails) (*Conn, error) {
 	u, err := url.Parse(endpoint)
@@ -67,6 +99,11 @@ func NewConn(endpoint string, auth Authorization, client *http.Client, clientDet
 		client:          client,
 		clientDetails:   clientDetails,
 		endpoint:        endpoint,
+
+		compressionThreshold: defaultCompressionThreshold,
+		throttlingBudget:     defaultThrottlingBudget,
+		metrics:              NoopMetrics{},
+		logger:               nopLogger{},
 	}
 
 	return c, nil
@@ -82,13 +119,94 @@ type connOptions struct {
 	queryOptions *queryOptions
 }
 
-func (c *Conn) rawQuery(ctx context.Context, callType callType, db string, query Statement, options *queryOptions) (io.ReadCloser, error) {
-	_, _, _, body, e := c.doRequest(ctx, int(callType), db, query, *options.requestProperties)
+func (c *Conn) rawQuery(ctx context.Context, callType callType, db string, query Statement, options *queryOptions) (kustoQuery.RequestIDs, io.ReadCloser, error) {
+	op := errors.OpQuery
+	if callType == mgmtCall {
+		op = errors.OpMgmt
+	}
+	end, err := c.inFlight.begin()
+	if err != nil {
+		return kustoQuery.RequestIDs{}, nil, errors.E(op, errors.KClientArgs, err).SetNoRetry()
+	}
+	parent := ctx
+	ctx, cancelTimeout := withCallTimeout(ctx, options)
+	endCall := end
+	end = func() {
+		cancelTimeout()
+		endCall()
+	}
+	if c.limiter != nil {
+		release, err := c.limiter.acquire(ctx, options.concurrencyWaitTimeout)
+		if err != nil {
+			end()
+			c.logger.Log(ctx, slog.LevelWarn, "call not sent, no concurrency slot available", "db", db, "error", err)
+			kind := errors.KLimitsExceeded
+			if ctx.Err() != nil {
+				kind = errors.KTimeout
+			}
+			return kustoQuery.RequestIDs{}, nil, timeoutError(op, parent, ctx, options, errors.E(op, kind, err).SetNoRetry())
+		}
+		endInFlight := end
+		end = func() {
+			release()
+			endInFlight()
+		}
+	}
+
+	properties := *options.requestProperties
+	stop := end
+	if c.serverSideCancel && callType == queryCall {
+		if properties.ClientRequestID == "" {
+			properties.ClientRequestID = c.newClientRequestID()
+		}
+		stopCancellation := c.watchCancellation(ctx, properties.ClientRequestID)
+		stop = func() {
+			stopCancellation()
+			end()
+		}
+	}
+
+	ctx, span := c.tracing.start(ctx, callType, db, query.String())
+	_, headers, responseHeaders, body, e := c.doRequest(ctx, int(callType), db, query, properties)
+	c.tracing.end(span, headers, responseHeaders, e)
+	ids := requestIDs(headers, responseHeaders, e)
 	if e != nil {
-		return nil, e
+		if ctx.Err() == nil {
+			stop()
+		} else {
+			end()
+		}
+		return ids, nil, timeoutError(op, parent, ctx, options, e)
 	}
 
-	return body, nil
+	res := io.ReadCloser(stopOnDoneReader{ReadCloser: newLimitedReader(body, options.maxResponseBytes), stop: stop})
+	if options.timeout > 0 {
+		res = timeoutReader{ReadCloser: res, op: op, parent: parent, ctx: ctx, options: options}
+	}
+	return ids, res, nil
+}
+
+// requestIDs returns the ids of a request, and sets them on err if it is a Kusto error.
+func requestIDs(headers http.Header, responseHeaders http.Header, err error) kustoQuery.RequestIDs {
+	var httpErr *errors.HttpError
+	if stdErrors.As(err, &httpErr) && responseHeaders == nil {
+		responseHeaders = httpErr.Header
+	}
+	ids := kustoQuery.RequestIDs{
+		ClientRequestID: headers.Get(ClientRequestIdHeader),
+		ActivityID:      responseHeaders.Get(ActivityIdHeader),
+	}
+
+	var kustoErr *errors.Error
+	switch {
+	case httpErr != nil:
+		kustoErr = &httpErr.KustoError
+	case !stdErrors.As(err, &kustoErr):
+		return ids
+	}
+	kustoErr.ClientRequestID = ids.ClientRequestID
+	kustoErr.ActivityID = ids.ActivityID
+	return ids
 }
 
 const (
@@ -99,18 +217,22 @@ const (
 func (c *Conn) doRequest(ctx context.Context, execType int, db string, query Statement, properties requestProperties) (errors.Op, http.Header, http.Header,
 	io.ReadCloser, error) {
 	var op errors.Op
-	err := c.validateEndpoint()
-	if err != nil {
-		op = errors.OpQuery
-		return 0, nil, nil, nil, errors.E(op, errors.KInternal, fmt.Errorf("could not validate endpoint: %w", err))
-	}
-
 	if execType == execQuery {
 		op = errors.OpQuery
 	} else if execType == execMgmt {
 		op = errors.OpMgmt
 	}
 
+	if err := c.validateEndpoint(properties); err != nil {
+		return 0, nil, nil, nil, errors.E(op, errors.KInternal, fmt.Errorf("could not validate endpoint: %w", err))
+	}
+
+	if c.circuitBreaker != nil {
+		if err := c.circuitBreaker.allow(ctx, c.probe); err != nil {
+			return 0, nil, nil, nil, errors.E(op, errors.KIO, err).SetNoRetry()
+		}
+	}
+
 	var endpoint *url.URL
 
 	buff := bufferPool.Get().(*bytes.Buffer)
@@ -146,8 +268,76 @@ func (c *Conn) doRequest(ctx context.Context, execType int, db string, query Sta
 	}
 
 	headers := c.getHeaders(properties)
-	responseHeaders, closer, err := c.doRequestImpl(ctx, op, endpoint, io.NopCloser(buff), headers, fmt.Sprintf("With query: %s", query.String()))
-	return op, headers, responseHeaders, closer, err
+
+	body := buff
+	if c.compressionThreshold > 0 && buff.Len() >= c.compressionThreshold {
+		compressed, err := gzipBody(buff)
+		if err != nil {
+			return 0, nil, nil, nil, errors.E(op, errors.KInternal, fmt.Errorf("could not compress the Query message: %w", err))
+		}
+		defer bufferPool.Put(compressed)
+		body = compressed
+		headers.Set("Content-Encoding", "gzip")
+	}
+
+	if properties.Credential != nil || properties.AuthToken != "" {
+		authorization, err := c.requestAuthorization(ctx, properties)
+		if err != nil {
+			return 0, nil, nil, nil, errors.ES(op, errors.KInternal, "Error while getting token : %s", err)
+		}
+		headers.Set("Authorization", authorization)
+	}
+
+	retryable := c.retryPolicy.retryable(execType, query.String())
+	throttling := throttleState{budget: c.throttlingBudget}
+	operation := callType(execType).operation()
+	sent := 0
+	defer func() {
+		c.metrics.ObserveRetries(ctx, operation, db, sent-1)
+	}()
+	for attempt := 1; ; {
+		sent++
+		// Each attempt gets its own headers, so a retry acquires a fresh token if needed.
+		attemptHeaders := headers.Clone()
+		logArgs := []any{"operation", operation, "db", db, "clientRequestId", attemptHeaders.Get(ClientRequestIdHeader), "attempt", sent}
+		c.logger.Log(ctx, slog.LevelDebug, "sending request", logArgs...)
+		start := time.Now()
+		responseHeaders, closer, err := c.doRequestImpl(ctx, op, endpoint, io.NopCloser(bytes.NewReader(body.Bytes())), attemptHeaders,
+			fmt.Sprintf("With query: %s", query.String()))
+		logArgs = append(logArgs, "duration", time.Since(start))
+		if c.circuitBreaker != nil {
+			c.circuitBreaker.record(err)
+		}
+		if err == nil {
+			c.logger.Log(ctx, slog.LevelDebug, "request succeeded", append(logArgs, "activityId", responseHeaders.Get(ActivityIdHeader))...)
+			return op, attemptHeaders, responseHeaders, closer, nil
+		}
+
+		// Throttled requests were rejected before running, so they are safe to send again, whatever the command.
+		if throttled, ok := newThrottledError(err); ok {
+			wait := throttling.wait(throttled, c.retryPolicy)
+			if wait < 0 {
+				c.logger.Log(ctx, slog.LevelWarn, "request throttled, throttling budget exhausted", append(logArgs, "waited", throttled.Waited)...)
+				return op, attemptHeaders, responseHeaders, closer, throttled
+			}
+			c.logger.Log(ctx, slog.LevelWarn, "request throttled, retrying", append(logArgs, "wait", wait)...)
+			if sleepErr := sleep(ctx, wait); sleepErr != nil {
+				return op, attemptHeaders, responseHeaders, closer, throttled
+			}
+			continue
+		}
+
+		if !retryable || !c.retryPolicy.shouldRetry(ctx, attempt, err) {
+			c.logger.Log(ctx, slog.LevelDebug, "request failed", append(logArgs, "error", err)...)
+			return op, attemptHeaders, responseHeaders, closer, err
+		}
+		delay := c.retryPolicy.delay(attempt)
+		c.logger.Log(ctx, slog.LevelInfo, "request failed, retrying", append(logArgs, "error", err, "delay", delay)...)
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return op, attemptHeaders, responseHeaders, closer, err
+		}
+		attempt++
+	}
 }
 
 func (c *Conn) doRequestImpl(
@@ -160,8 +350,8 @@ func (c *Conn) doRequestImpl(
 
 	// Replace non-ascii chars in headers with '?'
 	for _, values := range headers {
-		var builder strings.Builder
 		for i := range values {
+			var builder strings.Builder
 			for _, char := range values[i] {
 				if char > unicode.MaxASCII {
 					builder.WriteRune('?')
@@ -173,7 +363,8 @@ func (c *Conn) doRequestImpl(
 		}
 	}
 
-	if c.auth.TokenProvider != nil && c.auth.TokenProvider.AuthorizationRequired() {
+	// A per-request credential was already applied by the caller.
+	if headers.Get("Authorization") == "" && c.auth.TokenProvider != nil && c.auth.TokenProvider.AuthorizationRequired() {
 		c.auth.TokenProvider.SetHttp(c.client)
 		token, tokenType, tkerr := c.auth.TokenProvider.AcquireToken(ctx)
 		if tkerr != nil {
@@ -201,24 +392,64 @@ func (c *Conn) doRequestImpl(
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, nil, errors.HTTP(op, resp.Status, resp.StatusCode, body, fmt.Sprintf("error from Kusto endpoint, %v", errorContext))
+		httpErr := errors.HTTP(op, resp.Status, resp.StatusCode, body, fmt.Sprintf("error from Kusto endpoint, %v", errorContext))
+		httpErr.Header = resp.Header
+		return nil, nil, httpErr
 	}
 	return resp.Header, body, nil
 }
 
-func (c *Conn) validateEndpoint() error {
-	if !c.endpointValidated.Load() {
-		var err error
-		if cloud, err := GetMetadata(c.endpoint, c.client); err == nil {
-			err = truestedEndpoints.Instance.ValidateTrustedEndpoint(c.endpoint, cloud.LoginEndpoint)
-			if err == nil {
-				c.endpointValidated.Store(true)
-			}
-		}
+// requestAuthorization returns the Authorization header for a request that overrides the client's token provider.
+func (c *Conn) requestAuthorization(ctx context.Context, properties requestProperties) (string, error) {
+	if c.endQuery.Scheme != "https" {
+		return "", fmt.Errorf("cannot use a credential with http endpoint, as it would send the token in clear text")
+	}
+	if properties.AuthToken != "" {
+		return fmt.Sprintf("%s %s", BEARER_TYPE, properties.AuthToken), nil
+	}
 
+	cloud, err := GetMetadata(c.endpoint, c.client)
+	if err != nil {
+		return "", err
+	}
+	var tokenScope string
+	if c.auth.TokenProvider != nil {
+		tokenScope = c.auth.TokenProvider.tokenScope
+	}
+	token, err := properties.Credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: tokenScopes(&cloud, tokenScope)})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %s", BEARER_TYPE, token.Token), nil
+}
+
+// validateEndpoint checks that the cluster is a trusted endpoint of its cloud before a token is sent to it.
+// Requests that send no token, such as those of clients without authentication, are not validated.
+func (c *Conn) validateEndpoint(properties requestProperties) error {
+	if c.endpointValidated.Load() || c.auth.SkipEndpointValidation {
+		return nil
+	}
+
+	sendsToken := properties.Credential != nil || properties.AuthToken != "" ||
+		(c.auth.TokenProvider != nil && c.auth.TokenProvider.AuthorizationRequired())
+	if !sendsToken {
+		return nil
+	}
+
+	// Without the metadata, the endpoint can't be trusted, so no token is sent to it.
+	cloud, err := GetMetadata(c.endpoint, c.client)
+	if err != nil {
 		return err
 	}
 
+	trusted := c.auth.TrustedEndpoints
+	if trusted == nil {
+		trusted = truestedEndpoints.Instance
+	}
+	if err := trusted.ValidateTrustedEndpoint(c.endpoint, cloud.LoginEndpoint); err != nil {
+		return err
+	}
+	c.endpointValidated.Store(true)
 	return nil
 }
 
@@ -227,6 +458,31 @@ const ApplicationHeader = "x-ms-app"
 const UserHeader = "x-ms-user"
 const ClientVersionHeader = "x-ms-client-version"
 
+// gzipBody returns a pooled buffer holding the gzip compressed contents of body.
+func gzipBody(body *bytes.Buffer) (*bytes.Buffer, error) {
+	compressed := bufferPool.Get().(*bytes.Buffer)
+	compressed.Reset()
+
+	zw := gzip.NewWriter(compressed)
+	if _, err := body.WriteTo(zw); err != nil {
+		bufferPool.Put(compressed)
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		bufferPool.Put(compressed)
+		return nil, err
+	}
+	return compressed, nil
+}
+
+// newClientRequestID returns the client request id of a request that doesn't set one.
+func (c *Conn) newClientRequestID() string {
+	if c.clientRequestIDGenerator != nil {
+		return c.clientRequestIDGenerator()
+	}
+	return "KGC.execute;" + uuid.New().String()
+}
+
 func (c *Conn) getHeaders(properties requestProperties) http.Header {
 	header := http.Header{}
 	header.Add("Accept", "application/json")
@@ -238,7 +494,7 @@ func (c *Conn) getHeaders(properties requestProperties) http.Header {
 	if properties.ClientRequestID != "" {
 		header.Add(ClientRequestIdHeader, properties.ClientRequestID)
 	} else {
-		header.Add(ClientRequestIdHeader, "KGC.execute;"+uuid.New().String())
+		header.Add(ClientRequestIdHeader, c.newClientRequestID())
 	}
 
 	if properties.Application != "" {
@@ -254,6 +510,10 @@ func (c *Conn) getHeaders(properties requestProperties) http.Header {
 	}
 
 	header.Add(ClientVersionHeader, c.clientDetails.ClientVersionForTracing())
+
+	for key, values := range properties.Headers {
+		header[key] = append(header[key], values...)
+	}
 	return header
 }
 
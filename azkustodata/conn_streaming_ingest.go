@@ -68,7 +68,8 @@ func (c *Conn) StreamIngest(ctx context.Context, db, table string, payload io.Re
 	}
 
 	if err != nil {
-		return errors.ES(errors.OpIngestStream, errors.KHTTPError, "streaming ingestion failed: endpoint(%s): %s", streamUrl.String(), err)
+		// The response error is kept in the chain, for the managed client to tell the errors it falls back on.
+		return errors.E(errors.OpIngestStream, errors.KHTTPError, fmt.Errorf("streaming ingestion failed: endpoint(%s): %w", streamUrl.String(), err))
 	}
 
 	return nil
@@ -1,13 +1,23 @@
 package azkustodata
 
 import (
+	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
 	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	trustedEndpoints "github.com/Azure/azure-kusto-go/azkustodata/trusted_endpoints"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestHeaders(t *testing.T) {
@@ -15,6 +25,8 @@ func TestHeaders(t *testing.T) {
 	tests := []struct {
 		name                              string
 		kcsbApplication, kcsbUser         string
+		kcsbAppName, kcsbAppVersion       string
+		kcsbUserName                      string
 		propApplication, propUser         string
 		expectedApplication, expectedUser string
 	}{
@@ -35,6 +47,14 @@ func TestHeaders(t *testing.T) {
 			expectedApplication: "propApplication",
 			expectedUser:        "propUser",
 		},
+		{
+			name:                "TestKcsbBuilder",
+			kcsbAppName:         "my app",
+			kcsbAppVersion:      "1.0",
+			kcsbUserName:        "builderUser",
+			expectedApplication: "App.{my_app}:{1.0}",
+			expectedUser:        "builderUser",
+		},
 		{
 			name:                "TestKcsbProp",
 			kcsbApplication:     "kcsbApplication",
@@ -57,6 +77,12 @@ func TestHeaders(t *testing.T) {
 			if tt.kcsbUser != "" {
 				kcsb.UserForTracing = tt.kcsbUser
 			}
+			if tt.kcsbAppName != "" {
+				kcsb = kcsb.WithApplicationNameAndVersion(tt.kcsbAppName, tt.kcsbAppVersion)
+			}
+			if tt.kcsbUserName != "" {
+				kcsb = kcsb.WithUserNameForTracing(tt.kcsbUserName)
+			}
 
 			queryOptions := make([]QueryOption, 0)
 			queryOptions = append(queryOptions, Application(tt.propApplication))
@@ -89,10 +115,11 @@ func TestSchemas(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name    string
-		url     string
-		hasAuth bool
-		err     error
+		name          string
+		url           string
+		hasAuth       bool
+		allowInsecure bool
+		err           error
 	}{
 		{
 			name:    "TestNoAuthHttps",
@@ -103,6 +130,13 @@ func TestSchemas(t *testing.T) {
 			name:    "TestNoAuthHttp",
 			url:     "http://test.kusto.windows.net",
 			hasAuth: false,
+			err:     errors.ES(errors.OpServConn, errors.KClientArgs, "data source(http://test.kusto.windows.net) uses http, which is only allowed when opting in with WithAllowInsecure").SetNoRetry(),
+		},
+		{
+			name:          "TestNoAuthHttpAllowInsecure",
+			url:           "http://test.kusto.windows.net",
+			hasAuth:       false,
+			allowInsecure: true,
 		},
 		{
 			name:    "TestAuthHttps",
@@ -110,10 +144,11 @@ func TestSchemas(t *testing.T) {
 			hasAuth: true,
 		},
 		{
-			name:    "TestAuthHttp",
-			url:     "http://test.kusto.windows.net",
-			hasAuth: true,
-			err:     errors.ES(errors.OpServConn, errors.KClientArgs, "cannot use token provider with http endpoint, as it would send the token in clear text").SetNoRetry(),
+			name:          "TestAuthHttp",
+			url:           "http://test.kusto.windows.net",
+			hasAuth:       true,
+			allowInsecure: true,
+			err:           errors.ES(errors.OpServConn, errors.KClientArgs, "cannot use token provider with http endpoint, as it would send the token in clear text").SetNoRetry(),
 		},
 		{
 			name: "TestEmptyUrl",
@@ -132,6 +167,9 @@ func TestSchemas(t *testing.T) {
 			if tt.hasAuth {
 				kcsb = kcsb.WithApplicationToken("1", "1")
 			}
+			if tt.allowInsecure {
+				kcsb = kcsb.WithAllowInsecure()
+			}
 			client, err := New(kcsb)
 			if tt.err != nil {
 				assert.Equal(t, tt.err, err)
@@ -199,3 +237,462 @@ func TestSetConnectorDetails(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeDataSource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		dataSource    string
+		allowInsecure bool
+		want          string
+		wantErr       string
+	}{
+		{name: "Canonical", dataSource: "https://test.kusto.windows.net", want: "https://test.kusto.windows.net"},
+		{name: "InferHttps", dataSource: "test.kusto.windows.net", want: "https://test.kusto.windows.net"},
+		{name: "TrailingSlashes", dataSource: " https://test.kusto.windows.net// ", want: "https://test.kusto.windows.net"},
+		{name: "Path", dataSource: "https://test.kusto.windows.net/Samples/", want: "https://test.kusto.windows.net/Samples"},
+		{name: "Emulator", dataSource: "http://localhost:8080", allowInsecure: true, want: "http://localhost:8080"},
+		{name: "HttpNotAllowed", dataSource: "http://localhost:8080", wantErr: "only allowed when opting in"},
+		{name: "QueryString", dataSource: "https://test.kusto.windows.net?a=b", wantErr: "query string"},
+		{name: "Fragment", dataSource: "https://test.kusto.windows.net#a", wantErr: "query string or fragment"},
+		{name: "Scheme", dataSource: "ftp://test.kusto.windows.net", wantErr: "unsupported scheme"},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := normalizeDataSource(tt.dataSource, tt.allowInsecure)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPerRequestCredential(t *testing.T) {
+	var authorization string
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(verifyTestShowVersion))
+	})
+
+	// The client's own credential always fails, so any successful call used the per-request one.
+	client, err := New(NewConnectionStringBuilder(srv.URL).WithTokenCredential(failingCredential{}), WithHttpClient(srv.Client()))
+	require.NoError(t, err)
+	defer client.Close()
+
+	cred := &fakeCredential{}
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"), Credential(cred))
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer fake-token", authorization)
+	assert.Equal(t, []string{"https://kusto.windows.net/.default"}, cred.scopes)
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"), UserToken("user-token"))
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer user-token", authorization)
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"), Credential(cred), UserToken("user-token"))
+	require.ErrorContains(t, err, "Credential and UserToken cannot be used together")
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"))
+	require.ErrorContains(t, err, "invalid client secret")
+}
+
+func TestEndpointValidation(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == metadataPath {
+			if strings.HasPrefix(r.Host, "nometadata.") {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte(testCloudMetadata))
+			return
+		}
+		_, _ = w.Write([]byte(verifyTestShowVersion))
+	}))
+	defer srv.Close()
+
+	// Resolve the custom host name to the test server, as a Private Link DNS zone would.
+	transport := srv.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	httpClient := &http.Client{Transport: transport}
+
+	endpoint := "https://mycluster.privatelink.contoso.com"
+	tests := []struct {
+		name    string
+		kcsb    *ConnectionStringBuilder
+		wantErr string
+	}{
+		{name: "Untrusted", kcsb: NewConnectionStringBuilder(endpoint), wantErr: "hostname is currently not trusted"},
+		{name: "TrustedHosts", kcsb: NewConnectionStringBuilder(endpoint).WithTrustedHosts(trustedEndpoints.NewMatchRule(".privatelink.contoso.com", false))},
+		{name: "SkipValidation", kcsb: NewConnectionStringBuilder(endpoint).WithSkipEndpointValidation()},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(tt.kcsb.WithTokenCredential(&fakeCredential{}), WithHttpClient(httpClient))
+			require.NoError(t, err)
+			defer client.Close()
+
+			_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"))
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tt.wantErr)
+				var kustoErr *errors.Error
+				require.ErrorAs(t, err, &kustoErr)
+				assert.Equal(t, errors.OpMgmt, kustoErr.Op)
+			}
+		})
+	}
+
+	// No token is sent without authentication, so the endpoint isn't validated.
+	client, err := New(NewConnectionStringBuilder(endpoint), WithHttpClient(httpClient))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"))
+	require.NoError(t, err)
+
+	// An endpoint that can't be validated is not sent a token.
+	cred := &fakeCredential{}
+	client, err = New(NewConnectionStringBuilder("https://nometadata.privatelink.contoso.com").
+		WithTrustedHosts(trustedEndpoints.NewMatchRule(".privatelink.contoso.com", false)).WithTokenCredential(cred), WithHttpClient(httpClient))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Query(context.Background(), "db", kql.New("T"))
+	require.ErrorContains(t, err, "could not validate endpoint")
+	assert.Nil(t, cred.scopes)
+}
+
+type countingTransporter struct {
+	client *http.Client
+	calls  int
+}
+
+func (c *countingTransporter) Do(req *http.Request) (*http.Response, error) {
+	c.calls++
+	return c.client.Do(req)
+}
+
+func TestWithTransport(t *testing.T) {
+	srv := newTestKustoServer(t, verifyTestHandler(http.StatusOK))
+
+	transport := &countingTransporter{client: srv.Client()}
+	client, err := New(NewConnectionStringBuilder(srv.URL).WithTokenCredential(&fakeCredential{}), WithTransport(transport))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"))
+	require.NoError(t, err)
+	// The cloud metadata request and the command itself.
+	assert.Equal(t, 2, transport.calls)
+}
+
+func TestRequestCompression(t *testing.T) {
+	var gotEncoding, gotBody string
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotEncoding = r.Header.Get("Content-Encoding")
+		var body io.Reader = r.Body
+		if gotEncoding == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body = zr
+		}
+		b, _ := io.ReadAll(body)
+		gotBody = string(b)
+		_, _ = w.Write([]byte(verifyTestShowVersion))
+	})
+
+	large := ".ingest inline into table T <| " + strings.Repeat("a,b,c\n", 20000)
+	tests := []struct {
+		name         string
+		options      []Option
+		command      string
+		wantEncoding string
+	}{
+		{name: "Small", command: ".show version"},
+		{name: "Large", command: large, wantEncoding: "gzip"},
+		{name: "Disabled", options: []Option{WithoutRequestCompression()}, command: large},
+		{name: "Threshold", options: []Option{WithRequestCompressionThreshold(10)}, command: ".show version", wantEncoding: "gzip"},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestKustoClient(t, srv, tt.options...)
+
+			_, err := client.Mgmt(context.Background(), "db", kql.New("").AddUnsafe(tt.command))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEncoding, gotEncoding)
+			assert.Contains(t, gotBody, `"db":"db"`)
+		})
+	}
+}
+
+func TestRequestIDs(t *testing.T) {
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(ActivityIdHeader, "activity-"+r.Header.Get(ClientRequestIdHeader))
+		if r.URL.Path == "/v2/rest/query" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(verifyTestShowVersion))
+	})
+
+	client := newTestKustoClient(t, srv, WithClientRequestIDGenerator(func() string { return "generated" }))
+
+	ds, err := client.Mgmt(context.Background(), "db", kql.New(".show version"))
+	require.NoError(t, err)
+	assert.Equal(t, "generated", ds.ClientRequestID())
+	assert.Equal(t, "activity-generated", ds.ActivityID())
+
+	ds, err = client.Mgmt(context.Background(), "db", kql.New(".show version"), ClientRequestID("custom"))
+	require.NoError(t, err)
+	assert.Equal(t, "custom", ds.ClientRequestID())
+	assert.Equal(t, "activity-custom", ds.ActivityID())
+
+	_, err = client.Query(context.Background(), "db", kql.New("T"), ClientRequestID("failed"))
+	var httpErr *errors.HttpError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, "failed", httpErr.ClientRequestID)
+	assert.Equal(t, "activity-failed", httpErr.ActivityID)
+}
+
+func TestServerTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     int
+		deadline time.Duration
+		skew     time.Duration
+		options  []QueryOption
+		want     time.Duration
+	}{
+		{name: "QueryDefault", call: queryCall, want: defaultQueryTimeout + clientServerDelta},
+		{name: "MgmtDefault", call: mgmtCall, want: defaultMgmtTimeout + clientServerDelta},
+		{name: "Deadline", call: queryCall, deadline: time.Minute, skew: time.Second, want: time.Minute - time.Second},
+		{name: "DeadlineWithoutSkew", call: mgmtCall, deadline: time.Minute, want: time.Minute},
+		{name: "SkewLargerThanDeadline", call: queryCall, deadline: time.Minute, skew: 2 * time.Minute, want: time.Minute},
+		{name: "ExplicitTimeout", call: queryCall, deadline: time.Minute, options: []QueryOption{ServerTimeout(time.Hour)}, want: time.Hour},
+		{name: "CallTimeout", call: queryCall, skew: time.Second, options: []QueryOption{Timeout(time.Minute)}, want: time.Minute - time.Second},
+		{name: "DeadlineBeforeCallTimeout", call: mgmtCall, deadline: time.Minute, options: []QueryOption{Timeout(time.Hour)}, want: time.Minute},
+		{name: "CallTimeoutBeforeDeadline", call: queryCall, deadline: time.Hour, options: []QueryOption{Timeout(time.Minute)}, want: time.Minute},
+		{name: "CallTimeoutAboveMaximum", call: mgmtCall, options: []QueryOption{Timeout(2 * time.Hour)}, want: maxServerTimeout},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+
+			opts, err := setQueryOptions(ctx, errors.OpQuery, kql.New("test"), tt.call, append([]QueryOption{serverTimeoutSkew(tt.skew)}, tt.options...)...)
+			require.NoError(t, err)
+
+			timeout, err := value.TimespanFromString(opts.requestProperties.Options[ServerTimeoutValue].(string))
+			require.NoError(t, err)
+			assert.InDelta(t, tt.want, *timeout.Ptr(), float64(time.Second))
+		})
+	}
+}
+
+func TestWithHTTPHeader(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+		_, _ = w.Write([]byte(verifyTestShowVersion))
+	})
+
+	client := newTestKustoClient(t, srv)
+
+	_, err := client.Mgmt(context.Background(), "db", kql.New(".show version"),
+		WithHTTPHeader("x-gateway-route", "west"), WithHTTPHeader("x-feature", "a"), WithHTTPHeader("X-Feature", "b"))
+	require.NoError(t, err)
+	got := <-headers
+	assert.Equal(t, "west", got.Get("x-gateway-route"))
+	assert.Equal(t, []string{"a", "b"}, got.Values("x-feature"))
+	assert.Equal(t, "Bearer fake-token", got.Get("Authorization"))
+
+	// The headers are only sent with the call they were set on.
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"))
+	require.NoError(t, err)
+	assert.Empty(t, (<-headers).Get("x-gateway-route"))
+
+	for _, key := range []string{"", "authorization", ClientRequestIdHeader, "Content-Encoding"} {
+		_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"), WithHTTPHeader(key, "value"))
+		assert.Error(t, err, key)
+	}
+}
+
+func TestDefaultDatabase(t *testing.T) {
+	databases := make(chan string, 1)
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			DB string `json:"db"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		databases <- msg.DB
+		_, _ = w.Write([]byte(verifyTestShowVersion))
+	})
+
+	kcsb := NewConnectionStringBuilder(srv.URL).WithTokenCredential(&fakeCredential{})
+	kcsb.InitialCatalog = "catalog"
+	client, err := New(kcsb, WithHttpClient(srv.Client()))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Mgmt(context.Background(), "", kql.New(".show version"))
+	require.NoError(t, err)
+	assert.Equal(t, "catalog", <-databases)
+	_, err = client.Mgmt(context.Background(), "other", kql.New(".show version"))
+	require.NoError(t, err)
+	assert.Equal(t, "other", <-databases)
+
+	client, err = New(kcsb, WithHttpClient(srv.Client()), WithDefaultDatabase("default"))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Mgmt(context.Background(), "", kql.New(".show version"))
+	require.NoError(t, err)
+	assert.Equal(t, "default", <-databases)
+}
+
+func TestQueryConsistency(t *testing.T) {
+	consistencies := make(chan interface{}, 1)
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Properties struct {
+				Options map[string]interface{} `json:"Options"`
+			} `json:"properties"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		consistencies <- msg.Properties.Options[QueryConsistencyValue]
+		_, _ = w.Write([]byte(keepAliveTestResponse))
+	})
+
+	client := newTestKustoClient(t, srv)
+	_, err := client.Query(context.Background(), "db", kql.New("T"))
+	require.NoError(t, err)
+	assert.Nil(t, <-consistencies)
+
+	client = newTestKustoClient(t, srv, WithQueryConsistency(QueryConsistencyWeak))
+	_, err = client.Query(context.Background(), "db", kql.New("T"))
+	require.NoError(t, err)
+	assert.Equal(t, QueryConsistencyWeak, <-consistencies)
+
+	// The option of the query wins.
+	_, err = client.Query(context.Background(), "db", kql.New("T"), QueryConsistency(QueryConsistencyStrong))
+	require.NoError(t, err)
+	assert.Equal(t, QueryConsistencyStrong, <-consistencies)
+}
+
+func TestRequestReadonly(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		stmt    string
+		options []QueryOption
+		err     bool
+	}{
+		{desc: "query", stmt: "T | take 1", options: []QueryOption{RequestReadonly()}},
+		{desc: "command", stmt: ".drop table T", options: []QueryOption{RequestReadonly()}, err: true},
+		{desc: "command after comments", stmt: "\n  // drop it\n\t.drop table T", options: []QueryOption{RequestReadonly()}, err: true},
+		{desc: "comment only", stmt: "// .drop table T", options: []QueryOption{RequestReadonly()}},
+		{desc: "allowed command", stmt: ".show tables", options: []QueryOption{RequestReadonly(), AllowControlCommands()}},
+		{desc: "not read-only", stmt: ".drop table T"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+			_, err := setQueryOptions(context.Background(), errors.OpQuery, kql.New("").AddUnsafe(tt.stmt), queryCall, tt.options...)
+			if tt.err {
+				assert.ErrorContains(t, err, "control commands are not allowed in read-only requests")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWithRequestReadonly(t *testing.T) {
+	readonly := make(chan interface{}, 1)
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Properties struct {
+				Options map[string]interface{} `json:"Options"`
+			} `json:"properties"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		readonly <- msg.Properties.Options[RequestReadonlyValue]
+		if r.URL.Path == "/v2/rest/query" {
+			_, _ = w.Write([]byte(keepAliveTestResponse))
+			return
+		}
+		_, _ = w.Write([]byte(verifyTestShowVersion))
+	})
+
+	client := newTestKustoClient(t, srv, WithRequestReadonly())
+
+	_, err := client.Query(context.Background(), "db", kql.New("T"))
+	require.NoError(t, err)
+	assert.Equal(t, true, <-readonly)
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"))
+	assert.Error(t, err)
+	assert.Empty(t, readonly)
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"), AllowControlCommands())
+	require.NoError(t, err)
+	assert.Equal(t, true, <-readonly)
+}
+
+func TestWithV1Protocol(t *testing.T) {
+	paths := make(chan string, 1)
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		paths <- r.URL.Path
+		_, _ = w.Write([]byte(`{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"x","DataType":"Int64","ColumnType":"long"}],"Rows":[[1],[2]]}]}`))
+	})
+
+	client := newTestKustoClient(t, srv, WithV1Protocol())
+	ctx := context.Background()
+
+	ds, err := client.Query(ctx, "db", kql.New("T"))
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/rest/query", <-paths)
+	require.Len(t, ds.Tables(), 1)
+	assert.True(t, ds.Tables()[0].IsPrimaryResult())
+	assert.Len(t, ds.Tables()[0].Rows(), 2)
+
+	iterative, err := client.IterativeQuery(ctx, "db", kql.New("T"))
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/rest/query", <-paths)
+	var values []int64
+	for tableResult := range iterative.Tables() {
+		require.NoError(t, tableResult.Err())
+		for rowResult := range tableResult.Table().Rows() {
+			require.NoError(t, rowResult.Err())
+			v, err := rowResult.Row().LongByIndex(0)
+			require.NoError(t, err)
+			values = append(values, *v)
+		}
+	}
+	assert.Equal(t, []int64{1, 2}, values)
+	require.NoError(t, iterative.Close())
+
+	_, err = client.Mgmt(ctx, "db", kql.New(".show version"))
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/rest/mgmt", <-paths)
+}
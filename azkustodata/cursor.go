@@ -0,0 +1,167 @@
+package azkustodata
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+)
+
+// CursorColumn is the column added by a CursorSession to the results of its query, holding the database cursor at the
+// time of the query.
+const CursorColumn = "KustoCursor"
+
+// CursorStore persists the cursor of a CursorSession, so that a process reading a table incrementally resumes where it
+// stopped.
+type CursorStore interface {
+	// LoadCursor returns the stored cursor, or "" if none was stored yet.
+	LoadCursor(ctx context.Context) (string, error)
+	// SaveCursor stores the cursor.
+	SaveCursor(ctx context.Context, cursor string) error
+}
+
+// FileCursorStore is a CursorStore keeping the cursor in a file.
+type FileCursorStore string
+
+// LoadCursor reads the cursor from the file, a missing file meaning no cursor was stored yet.
+func (f FileCursorStore) LoadCursor(_ context.Context) (string, error) {
+	data, err := os.ReadFile(string(f))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SaveCursor writes the cursor to a temporary file renamed over the file, so that a crash never leaves a partial cursor.
+func (f FileCursorStore) SaveCursor(_ context.Context, cursor string) error {
+	path := string(f)
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(cursor); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// CursorSession reads the records of a table that were ingested since the previous read, using database cursors.
+// Each read appends cursor_after() with the current cursor to the query, and a CursorColumn column with cursor_current()
+// to its results. Once the records were processed, Commit moves the cursor past them and saves it to the CursorStore, so
+// that records are read at least once even if the process stops in between.
+//
+//	session, err := client.NewCursorSession(ctx, "database", kql.New("Events"), azkustodata.FileCursorStore("events.cursor"))
+//	if err != nil {
+//		return err
+//	}
+//	for {
+//		table, err := session.Next(ctx)
+//		if err != nil {
+//			return err
+//		}
+//		process(table)
+//		if err := session.Commit(ctx); err != nil {
+//			return err
+//		}
+//	}
+//
+// The tables read must have the IngestionTime policy enabled, see
+// https://learn.microsoft.com/kusto/management/ingestion-time-policy. A CursorSession is not safe for concurrent use.
+type CursorSession struct {
+	client  *Client
+	db      string
+	query   Statement
+	store   CursorStore
+	options []QueryOption
+
+	cursor  string
+	pending string
+}
+
+// NewCursorSession returns a CursorSession reading the records returned by a query, which should be a tabular
+// expression, starting after the cursor loaded from store. A nil store keeps the cursor in memory only. The options are
+// used for each read.
+func (c *Client) NewCursorSession(ctx context.Context, db string, kqlQuery Statement, store CursorStore, options ...QueryOption) (*CursorSession, error) {
+	s := &CursorSession{client: c, db: db, query: kqlQuery, store: store, options: options}
+	if store != nil {
+		cursor, err := store.LoadCursor(ctx)
+		if err != nil {
+			return nil, errors.ES(errors.OpQuery, errors.KIO, "could not load the cursor: %s", err).SetNoRetry()
+		}
+		s.cursor = cursor
+	}
+	return s, nil
+}
+
+// Cursor returns the cursor the next read starts after, "" to read all the records.
+func (s *CursorSession) Cursor() string {
+	return s.cursor
+}
+
+// SetCursor sets the cursor the next read starts after, dropping the cursor of the last read if it wasn't committed.
+func (s *CursorSession) SetCursor(cursor string) {
+	s.cursor = cursor
+	s.pending = ""
+}
+
+// Next reads the records ingested after the cursor, returning the primary result table of the query. Reading again
+// before calling Commit returns the same records, along with those ingested since.
+func (s *CursorSession) Next(ctx context.Context) (query.Table, error) {
+	ds, err := s.client.Query(ctx, s.db, s.cursorQuery(), s.options...)
+	if err != nil {
+		return nil, err
+	}
+	tables := ds.PrimaryResults()
+	if len(tables) == 0 {
+		return nil, errors.ES(errors.OpQuery, errors.KInternal, "the response of the cursor query has no primary result")
+	}
+	table := tables[0]
+
+	// cursor_current() is the same for all the rows. Without rows, nothing was ingested after the cursor, so it is kept.
+	s.pending = ""
+	if rows := table.Rows(); len(rows) > 0 {
+		col := table.ColumnByName(CursorColumn)
+		if col == nil {
+			return nil, errors.ES(errors.OpQuery, errors.KInternal, "the results of the cursor query have no %s column", CursorColumn)
+		}
+		s.pending = rows[0].Values()[col.Index()].String()
+	}
+	return table, nil
+}
+
+// Commit moves the cursor after the records returned by the last call to Next, and saves it to the CursorStore.
+func (s *CursorSession) Commit(ctx context.Context) error {
+	if s.pending == "" {
+		return nil
+	}
+	if s.store != nil {
+		if err := s.store.SaveCursor(ctx, s.pending); err != nil {
+			return errors.ES(errors.OpQuery, errors.KIO, "could not save the cursor: %s", err).SetNoRetry()
+		}
+	}
+	s.cursor = s.pending
+	s.pending = ""
+	return nil
+}
+
+// cursorQuery returns the query reading the records after the cursor.
+func (s *CursorSession) cursorQuery() Statement {
+	q := kql.FromBuilder(s.query)
+	if s.cursor != "" {
+		q.AddLiteral("\n| where cursor_after(").AddUnsafe("@'" + strings.ReplaceAll(s.cursor, "'", "''") + "')")
+	}
+	return q.AddLiteral("\n| extend ").AddUnsafe(CursorColumn).AddLiteral(" = cursor_current()")
+}
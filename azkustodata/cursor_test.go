@@ -0,0 +1,96 @@
+package azkustodata
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cursorResponse returns a result of rows read at the given cursor.
+func cursorResponse(rows []string, cursor string) string {
+	var values []string
+	for _, r := range rows {
+		values = append(values, `["`+r+`","`+cursor+`"]`)
+	}
+	return `[{"FrameType":"DataSetHeader","IsProgressive":false,"Version":"v2.0","IsFragmented":true,"ErrorReportingPlacement":"EndOfTable"}
+,{"FrameType":"TableHeader","TableId":1,"TableKind":"PrimaryResult","TableName":"PrimaryResult","Columns":[{"ColumnName":"x","ColumnType":"string"},{"ColumnName":"KustoCursor","ColumnType":"string"}]}
+,{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[` + strings.Join(values, ",") + `]}
+,{"FrameType":"TableCompletion","TableId":1,"RowCount":` + strconv.Itoa(len(rows)) + `}
+,{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}
+]`
+}
+
+func TestCursorSession(t *testing.T) {
+	var lock sync.Mutex
+	var queries []string
+	responses := []string{
+		cursorResponse([]string{"a", "b"}, "100"),
+		cursorResponse([]string{"a", "b", "c"}, "101"),
+		cursorResponse(nil, ""),
+		cursorResponse([]string{"d"}, "102"),
+	}
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			CSL string `json:"csl"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		lock.Lock()
+		queries = append(queries, msg.CSL)
+		response := responses[0]
+		responses = responses[1:]
+		lock.Unlock()
+		_, _ = w.Write([]byte(response))
+	})
+
+	client := newTestKustoClient(t, srv)
+	ctx := context.Background()
+
+	store := FileCursorStore(filepath.Join(t.TempDir(), "cursor"))
+	session, err := client.NewCursorSession(ctx, "db", kql.New("Events"), store)
+	require.NoError(t, err)
+	assert.Equal(t, "", session.Cursor())
+
+	// Without a commit, the next read starts from the same cursor.
+	table, err := session.Next(ctx)
+	require.NoError(t, err)
+	assert.Len(t, table.Rows(), 2)
+	table, err = session.Next(ctx)
+	require.NoError(t, err)
+	assert.Len(t, table.Rows(), 3)
+	require.NoError(t, session.Commit(ctx))
+	assert.Equal(t, "101", session.Cursor())
+
+	// An empty read keeps the cursor.
+	table, err = session.Next(ctx)
+	require.NoError(t, err)
+	assert.Len(t, table.Rows(), 0)
+	require.NoError(t, session.Commit(ctx))
+	assert.Equal(t, "101", session.Cursor())
+
+	// A new session resumes from the stored cursor.
+	session, err = client.NewCursorSession(ctx, "db", kql.New("Events"), store)
+	require.NoError(t, err)
+	assert.Equal(t, "101", session.Cursor())
+	_, err = session.Next(ctx)
+	require.NoError(t, err)
+	require.NoError(t, session.Commit(ctx))
+	stored, err := store.LoadCursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "102", stored)
+
+	assert.Equal(t, []string{
+		"Events\n| extend KustoCursor = cursor_current()",
+		"Events\n| extend KustoCursor = cursor_current()",
+		"Events\n| where cursor_after(@'101')\n| extend KustoCursor = cursor_current()",
+		"Events\n| where cursor_after(@'101')\n| extend KustoCursor = cursor_current()",
+	}, queries)
+}
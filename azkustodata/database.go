@@ -0,0 +1,69 @@
+package azkustodata
+
+import (
+	"context"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+)
+
+// Database is a handle on a database of the cluster, making calls to it without passing its name each time. Handles
+// are lightweight: they share the transport, authentication and settings of their client, so any number of them can be
+// created, and they are safe for concurrent use.
+//
+//	logs := client.Database("Logs")
+//	dataset, err := logs.Query(ctx, kql.New("Events | take 10"))
+type Database struct {
+	client  *Client
+	name    string
+	options []QueryOption
+}
+
+// Database returns a handle on a database. The options are used for each call made with the handle, before the options
+// of the call, which override them.
+func (c *Client) Database(name string, options ...QueryOption) *Database {
+	return &Database{client: c, name: name, options: options}
+}
+
+// Name returns the name of the database.
+func (d *Database) Name() string {
+	return d.name
+}
+
+// Client returns the client the handle makes its calls with.
+func (d *Database) Client() *Client {
+	return d.client
+}
+
+// Query runs a query on the database, see Client.Query.
+func (d *Database) Query(ctx context.Context, kqlQuery Statement, options ...QueryOption) (query.Dataset, error) {
+	return d.client.Query(ctx, d.name, kqlQuery, d.withOptions(options)...)
+}
+
+// IterativeQuery runs a query on the database, streaming its results, see Client.IterativeQuery.
+func (d *Database) IterativeQuery(ctx context.Context, kqlQuery Statement, options ...QueryOption) (query.IterativeDataset, error) {
+	return d.client.IterativeQuery(ctx, d.name, kqlQuery, d.withOptions(options)...)
+}
+
+// QueryRows runs a query on the database, iterating over the rows of its primary result, see Client.QueryRows.
+func (d *Database) QueryRows(ctx context.Context, kqlQuery Statement, options ...QueryOption) (*RowIterator, error) {
+	return d.client.QueryRows(ctx, d.name, kqlQuery, d.withOptions(options)...)
+}
+
+// Mgmt runs a management command on the database, see Client.Mgmt.
+func (d *Database) Mgmt(ctx context.Context, kqlQuery Statement, options ...QueryOption) (v1.Dataset, error) {
+	return d.client.Mgmt(ctx, d.name, kqlQuery, d.withOptions(options)...)
+}
+
+// SubmitAsync runs an async management command on the database, see Client.SubmitAsync.
+func (d *Database) SubmitAsync(ctx context.Context, command Statement, options ...QueryOption) (*Operation, error) {
+	return d.client.SubmitAsync(ctx, d.name, command, d.withOptions(options)...)
+}
+
+// withOptions returns the options of a call, after those of the handle.
+func (d *Database) withOptions(options []QueryOption) []QueryOption {
+	if len(d.options) == 0 {
+		return options
+	}
+	return append(append(make([]QueryOption, 0, len(d.options)+len(options)), d.options...), options...)
+}
@@ -0,0 +1,54 @@
+package azkustodata
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase(t *testing.T) {
+	type call struct {
+		db        string
+		requestID string
+	}
+	calls := make(chan call, 10)
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			DB string `json:"db"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		calls <- call{db: msg.DB, requestID: r.Header.Get(ClientRequestIdHeader)}
+		if r.URL.Path == "/v1/rest/mgmt" {
+			_, _ = w.Write([]byte(verifyTestShowVersion))
+			return
+		}
+		_, _ = w.Write([]byte(keepAliveTestResponse))
+	})
+
+	client := newTestKustoClient(t, srv)
+	ctx := context.Background()
+
+	logs := client.Database("Logs", ClientRequestID("handle"))
+	assert.Equal(t, "Logs", logs.Name())
+	assert.Same(t, client, logs.Client())
+
+	_, err := logs.Query(ctx, kql.New("T"))
+	require.NoError(t, err)
+	assert.Equal(t, call{db: "Logs", requestID: "handle"}, <-calls)
+
+	_, err = logs.Mgmt(ctx, kql.New(".show version"), ClientRequestID("call"))
+	require.NoError(t, err)
+	assert.Equal(t, call{db: "Logs", requestID: "call"}, <-calls, "the options of the call override those of the handle")
+
+	rows, err := client.Database("Other").QueryRows(ctx, kql.New("T"))
+	require.NoError(t, err)
+	for rows.Next() {
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, "Other", (<-calls).db)
+}
@@ -71,6 +71,10 @@ type Error struct {
 	Kind Kind
 	// Err is the error message. This may be of any error type and may also wrap errors.
 	Err error
+	// ClientRequestID is the x-ms-client-request-id of the request that failed, if it was sent.
+	ClientRequestID string
+	// ActivityID is the x-ms-activity-id the cluster returned for the request that failed, if it answered.
+	ActivityID string
 
 	// restErrMsg holds the body of an error messsage that was from a REST endpoint.
 	restErrMsg []byte
@@ -85,6 +89,8 @@ type KustoError = Error
 type HttpError struct {
 	KustoError
 	StatusCode int
+	// Header holds the response headers, such as Retry-After. It is nil if the error wasn't built from a response.
+	Header http.Header
 }
 
 // UnmarshalREST will unmarshal an error message from the server if the message is in
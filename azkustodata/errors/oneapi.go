@@ -0,0 +1,161 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// OneApiError is the error envelope Kusto reports errors in, both in the body of failed responses and inside the
+// frames of v2 results.
+type OneApiError struct {
+	ErrorMessage ErrorMessage `json:"error"`
+}
+
+func (e *OneApiError) Error() string {
+	return e.String()
+}
+
+// ErrorMessage is the content of a OneApiError.
+type ErrorMessage struct {
+	Code        string       `json:"code"`
+	Message     string       `json:"message"`
+	Description string       `json:"@message"`
+	Type        string       `json:"@type"`
+	Context     ErrorContext `json:"@context"`
+	IsPermanent bool         `json:"@permanent"`
+	// IsRetriable is set by the cluster when retrying the request may succeed.
+	IsRetriable bool `json:"@retriable"`
+	// InnerError is the error that caused this one, if any.
+	InnerError *ErrorMessage `json:"innererror"`
+}
+
+// ErrorContext identifies where a OneApiError happened in the cluster.
+type ErrorContext struct {
+	Timestamp        string `json:"timestamp"`
+	ServiceAlias     string `json:"serviceAlias"`
+	MachineName      string `json:"machineName"`
+	ProcessName      string `json:"processName"`
+	ProcessId        int    `json:"processId"`
+	ThreadId         int    `json:"threadId"`
+	ClientRequestId  string `json:"clientRequestId"`
+	ActivityId       string `json:"activityId"`
+	SubActivityId    string `json:"subActivityId"`
+	ActivityType     string `json:"activityType"`
+	ParentActivityId string `json:"parentActivityId"`
+	ActivityStack    string `json:"activityStack"`
+}
+
+func (e *OneApiError) String() string {
+	return fmt.Sprintf("OneApiError(Error=%#v)", e.ErrorMessage)
+}
+
+func (e *ErrorMessage) String() string {
+	return fmt.Sprintf("ErrorMessage(Code=%s, Message=%s, Type=%s, ErrorContext=%v, IsPermanent=%t)", e.Code, e.Message, e.Type, e.Context, e.IsPermanent)
+}
+
+func (e *ErrorContext) String() string {
+	return fmt.Sprintf("ErrorContext(Timestamp=%s, ServiceAlias=%s, MachineName=%s, ProcessName=%s, ProcessId=%d, ThreadId=%d, ClientRequestId=%s, ActivityId=%s, SubActivityId=%s, ActivityType=%s, ParentActivityId=%s, ActivityStack=%s)", e.Timestamp, e.ServiceAlias, e.MachineName, e.ProcessName, e.ProcessId, e.ThreadId, e.ClientRequestId, e.ActivityId, e.SubActivityId, e.ActivityType, e.ParentActivityId, e.ActivityStack)
+}
+
+// Inner returns the chain of errors of the message, starting with itself and ending with the innermost one.
+func (e *ErrorMessage) Inner() []*ErrorMessage {
+	var chain []*ErrorMessage
+	for m := e; m != nil; m = m.InnerError {
+		chain = append(chain, m)
+	}
+	return chain
+}
+
+// OneApiError returns the OneApiError in the body of the response, if the cluster returned one.
+func (e *Error) OneApiError() (*OneApiError, bool) {
+	if len(e.restErrMsg) == 0 {
+		return nil, false
+	}
+	var oneApiErr OneApiError
+	if err := json.Unmarshal(e.restErrMsg, &oneApiErr); err != nil || oneApiErr.ErrorMessage.Code == "" {
+		return nil, false
+	}
+	return &oneApiErr, true
+}
+
+// AsOneApiError returns the first OneApiError in the chain of err, either one reported in the results or in the body
+// of a failed response.
+func AsOneApiError(err error) (*OneApiError, bool) {
+	var oneApiErr *OneApiError
+	if errors.As(err, &oneApiErr) {
+		return oneApiErr, true
+	}
+	var httpErr *HttpError
+	if errors.As(err, &httpErr) {
+		return httpErr.KustoError.OneApiError()
+	}
+	var kustoErr *Error
+	if errors.As(err, &kustoErr) {
+		return kustoErr.OneApiError()
+	}
+	return nil, false
+}
+
+// Code returns the code of the OneApiError of err, such as "LimitsExceeded" or "BadRequest", or "" if it has none.
+func Code(err error) string {
+	if oneApiErr, ok := AsOneApiError(err); ok {
+		return oneApiErr.ErrorMessage.Code
+	}
+	return ""
+}
+
+// IsPermanent reports whether err won't go away by retrying the request, as the cluster marked it permanent, or the
+// client did.
+func IsPermanent(err error) bool {
+	if oneApiErr, ok := AsOneApiError(err); ok {
+		for _, m := range oneApiErr.ErrorMessage.Inner() {
+			if m.IsPermanent {
+				return true
+			}
+		}
+	}
+	var kustoErr *Error
+	if errors.As(err, &kustoErr) {
+		kustoErr.UnmarshalREST()
+		return kustoErr.permanent
+	}
+	return false
+}
+
+// throttledCodes are the codes of the OneApiErrors of throttled requests.
+var throttledCodes = map[string]bool{"TooManyRequests": true, "Throttled": true}
+
+// IsThrottled reports whether err is the cluster throttling the request, which can be retried after a while.
+func IsThrottled(err error) bool {
+	var httpErr *HttpError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if oneApiErr, ok := AsOneApiError(err); ok {
+		for _, m := range oneApiErr.ErrorMessage.Inner() {
+			if throttledCodes[m.Code] || strings.Contains(m.Type, "Throttled") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsServerTimeout reports whether err is the cluster timing the request out, once its servertimeout elapsed.
+func IsServerTimeout(err error) bool {
+	var httpErr *HttpError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusGatewayTimeout {
+		return true
+	}
+	if oneApiErr, ok := AsOneApiError(err); ok {
+		for _, m := range oneApiErr.ErrorMessage.Inner() {
+			if strings.Contains(m.Type, "Timeout") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const throttledBody = `{"error": {
+	"code": "BadRequest",
+	"message": "Request is invalid and cannot be executed.",
+	"@type": "Kusto.DataNode.Exceptions.SomeException",
+	"@message": "outer",
+	"@context": {"clientRequestId": "crid", "activityId": "aid"},
+	"@permanent": false,
+	"innererror": {
+		"code": "TooManyRequests",
+		"message": "throttled",
+		"@type": "Kusto.Data.Exceptions.KustoRequestThrottledException",
+		"@retriable": true,
+		"innererror": {"code": "Inner", "message": "innermost", "@permanent": true}
+	}
+}}`
+
+func TestOneApiError(t *testing.T) {
+	var oneApiErr OneApiError
+	if err := json.Unmarshal([]byte(throttledBody), &oneApiErr); err != nil {
+		t.Fatalf("TestOneApiError: unexpected error: %s", err)
+	}
+
+	chain := oneApiErr.ErrorMessage.Inner()
+	if len(chain) != 3 {
+		t.Fatalf("TestOneApiError: got %d errors in the chain, want 3", len(chain))
+	}
+	if chain[1].Code != "TooManyRequests" || !chain[1].IsRetriable || chain[2].Message != "innermost" || !chain[2].IsPermanent {
+		t.Errorf("TestOneApiError: got chain %v %v %v", chain[0], chain[1], chain[2])
+	}
+	if oneApiErr.ErrorMessage.Context.ClientRequestId != "crid" {
+		t.Errorf("TestOneApiError: got context %v", oneApiErr.ErrorMessage.Context)
+	}
+}
+
+func TestOneApiErrorHelpers(t *testing.T) {
+	inFrame := &OneApiError{ErrorMessage: ErrorMessage{Code: "LimitsExceeded", IsPermanent: true}}
+	tests := []struct {
+		desc          string
+		err           error
+		code          string
+		permanent     bool
+		throttled     bool
+		serverTimeout bool
+	}{
+		{
+			desc: "Error without OneApiError",
+			err:  ES(OpQuery, KIO, "some failure"),
+		},
+		{
+			desc:      "Error set to not retry",
+			err:       ES(OpQuery, KIO, "some failure").SetNoRetry(),
+			permanent: true,
+		},
+		{
+			desc:      "OneApiError from the results, wrapped",
+			err:       fmt.Errorf("reading: %w", inFrame),
+			code:      "LimitsExceeded",
+			permanent: true,
+		},
+		{
+			desc:      "response body with inner errors",
+			err:       HTTP(OpQuery, "400 Bad Request", http.StatusBadRequest, io.NopCloser(strings.NewReader(throttledBody)), "query"),
+			code:      "BadRequest",
+			permanent: true,
+			throttled: true,
+		},
+		{
+			desc:      "throttled response",
+			err:       HTTP(OpQuery, "429 Too Many Requests", http.StatusTooManyRequests, io.NopCloser(strings.NewReader("slow down")), "query"),
+			throttled: true,
+		},
+		{
+			desc:          "gateway timeout response",
+			err:           HTTP(OpQuery, "504 Gateway Timeout", http.StatusGatewayTimeout, io.NopCloser(strings.NewReader("timed out")), "query"),
+			serverTimeout: true,
+		},
+		{
+			desc:          "OneApiError of a timeout",
+			err:           &OneApiError{ErrorMessage: ErrorMessage{Code: "BadRequest", Type: "Kusto.Data.Exceptions.KustoRequestTimeoutException"}},
+			code:          "BadRequest",
+			serverTimeout: true,
+		},
+		{
+			desc: "other error",
+			err:  io.EOF,
+		},
+	}
+
+	for _, test := range tests {
+		if got := Code(test.err); got != test.code {
+			t.Errorf("TestOneApiErrorHelpers(%s): got Code() == %q, want %q", test.desc, got, test.code)
+		}
+		if got := IsPermanent(test.err); got != test.permanent {
+			t.Errorf("TestOneApiErrorHelpers(%s): got IsPermanent() == %t, want %t", test.desc, got, test.permanent)
+		}
+		if got := IsThrottled(test.err); got != test.throttled {
+			t.Errorf("TestOneApiErrorHelpers(%s): got IsThrottled() == %t, want %t", test.desc, got, test.throttled)
+		}
+		if got := IsServerTimeout(test.err); got != test.serverTimeout {
+			t.Errorf("TestOneApiErrorHelpers(%s): got IsServerTimeout() == %t, want %t", test.desc, got, test.serverTimeout)
+		}
+	}
+}
+
+func TestAsOneApiError(t *testing.T) {
+	err := HTTP(OpQuery, "400 Bad Request", http.StatusBadRequest, io.NopCloser(strings.NewReader(throttledBody)), "query")
+	oneApiErr, ok := AsOneApiError(fmt.Errorf("wrapped: %w", err))
+	if !ok {
+		t.Fatalf("TestAsOneApiError: got ok == false, want true")
+	}
+	if got := oneApiErr.ErrorMessage.Context.ActivityId; got != "aid" {
+		t.Errorf("TestAsOneApiError: got ActivityId == %q, want %q", got, "aid")
+	}
+
+	if _, ok := AsOneApiError(io.EOF); ok {
+		t.Errorf("TestAsOneApiError: got ok == true for a non-Kusto error, want false")
+	}
+}
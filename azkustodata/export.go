@@ -0,0 +1,141 @@
+package azkustodata
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+)
+
+// ExportFormat is the format of the files written by Export.
+type ExportFormat string
+
+const (
+	ExportCSV     ExportFormat = "csv"
+	ExportTSV     ExportFormat = "tsv"
+	ExportJSON    ExportFormat = "json"
+	ExportParquet ExportFormat = "parquet"
+)
+
+// ExportDistribution is how the writing of the exported files is split over the cluster.
+type ExportDistribution string
+
+const (
+	// ExportDistributionSingle writes the files from a single node.
+	ExportDistributionSingle ExportDistribution = "single"
+	// ExportDistributionPerNode writes files from each node holding data, in parallel.
+	ExportDistributionPerNode ExportDistribution = "per_node"
+	// ExportDistributionPerShard writes files for each shard of the data, in parallel.
+	ExportDistributionPerShard ExportDistribution = "per_shard"
+)
+
+// ExportOptions configures an export, see Export.
+// See https://learn.microsoft.com/kusto/management/data-export/export-data-to-storage for the details of each option.
+type ExportOptions struct {
+	// Format is the format of the files. Defaults to ExportCSV.
+	Format ExportFormat
+	// Compressed compresses the files, with gzip unless CompressionType is set.
+	Compressed bool
+	// CompressionType is the compression of compressed files, such as "gzip", or "snappy" for Parquet files.
+	CompressionType string
+	// SizeLimit is the size, in bytes, after which a new file is started. Zero uses the cluster's default.
+	SizeLimit int64
+	// NamePrefix is the prefix of the names of the files.
+	NamePrefix string
+	// IncludeHeaders sets which CSV or TSV files start with the names of the columns: "none", "firstFile" or "all".
+	IncludeHeaders string
+	// Distribution splits the writing of the files over the cluster. Empty uses the cluster's default.
+	Distribution ExportDistribution
+}
+
+// ExportedArtifact is a file written by a synchronous export.
+type ExportedArtifact struct {
+	Path        string
+	NumRecords  int64
+	SizeInBytes int64
+}
+
+// Export runs the query, and writes its results to files in the given storage locations, which are connection strings
+// to blob containers or directories, such as "https://account.blob.core.windows.net/container;impersonate". It waits
+// for the export to complete, and returns the files written.
+// The storage connection strings are sent obfuscated, so their secrets don't appear in the cluster's traces.
+func (c *Client) Export(ctx context.Context, db string, storage []string, kqlQuery Statement, options ExportOptions, queryOptions ...QueryOption) ([]ExportedArtifact, error) {
+	cmd, err := exportCommand(false, storage, kqlQuery, options)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := c.Mgmt(ctx, db, cmd, queryOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if tables := ds.Tables(); len(tables) > 0 {
+		return query.ToStructs[ExportedArtifact](tables[0])
+	}
+	return nil, nil
+}
+
+// ExportAsync is Export with the async keyword: it starts the export, and returns a handle on its operation, which can be
+// waited for with Operation.Wait.
+func (c *Client) ExportAsync(ctx context.Context, db string, storage []string, kqlQuery Statement, options ExportOptions, queryOptions ...QueryOption) (*Operation, error) {
+	cmd, err := exportCommand(true, storage, kqlQuery, options)
+	if err != nil {
+		return nil, err
+	}
+	return c.SubmitAsync(ctx, db, cmd, queryOptions...)
+}
+
+// exportCommand builds the `.export` command exporting the results of a query.
+func exportCommand(async bool, storage []string, kqlQuery Statement, options ExportOptions) (Statement, error) {
+	if len(storage) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "at least one storage location is required to export").SetNoRetry()
+	}
+	format := options.Format
+	switch format {
+	case "":
+		format = ExportCSV
+	case ExportCSV, ExportTSV, ExportJSON, ExportParquet:
+	default:
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "unknown export format %q", format).SetNoRetry()
+	}
+
+	cmd := kql.New(".export")
+	if async {
+		cmd.AddLiteral(" async")
+	}
+	if options.Compressed {
+		cmd.AddLiteral(" compressed")
+	}
+	cmd.AddLiteral(" to ").AddUnsafe(string(format)).AddLiteral(" (")
+	for i, s := range storage {
+		if i > 0 {
+			cmd.AddLiteral(", ")
+		}
+		cmd.AddUnsafe(kql.QuoteString(s, true))
+	}
+	cmd.AddLiteral(")")
+
+	var properties []string
+	if options.CompressionType != "" {
+		properties = append(properties, "compressionType="+kql.QuoteString(options.CompressionType, false))
+	}
+	if options.SizeLimit > 0 {
+		properties = append(properties, "sizeLimit="+strconv.FormatInt(options.SizeLimit, 10))
+	}
+	if options.NamePrefix != "" {
+		properties = append(properties, "namePrefix="+kql.QuoteString(options.NamePrefix, false))
+	}
+	if options.IncludeHeaders != "" {
+		properties = append(properties, "includeHeaders="+kql.QuoteString(options.IncludeHeaders, false))
+	}
+	if options.Distribution != "" {
+		properties = append(properties, "distribution="+kql.QuoteString(string(options.Distribution), false))
+	}
+	if len(properties) > 0 {
+		cmd.AddLiteral(" with (").AddUnsafe(strings.Join(properties, ", ")).AddLiteral(")")
+	}
+
+	return cmd.AddLiteral(" <|\n").AddUnsafe(kqlQuery.String()), nil
+}
@@ -0,0 +1,101 @@
+package azkustodata
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		async   bool
+		storage []string
+		options ExportOptions
+		want    string
+		err     bool
+	}{
+		{
+			desc:    "defaults",
+			storage: []string{"https://account.blob.core.windows.net/container;impersonate"},
+			want:    ".export to csv (h\"https://account.blob.core.windows.net/container;impersonate\") <|\nT | take 10",
+		},
+		{
+			desc:    "all options",
+			async:   true,
+			storage: []string{"https://a/c1;key", "https://a/c2;key"},
+			options: ExportOptions{
+				Format:          ExportParquet,
+				Compressed:      true,
+				CompressionType: "snappy",
+				SizeLimit:       1 << 20,
+				NamePrefix:      "export",
+				Distribution:    ExportDistributionPerNode,
+			},
+			want: ".export async compressed to parquet (h\"https://a/c1;key\", h\"https://a/c2;key\")" +
+				" with (compressionType=\"snappy\", sizeLimit=1048576, namePrefix=\"export\", distribution=\"per_node\") <|\nT | take 10",
+		},
+		{
+			desc:    "headers",
+			storage: []string{"https://a/c"},
+			options: ExportOptions{Format: ExportTSV, IncludeHeaders: "all"},
+			want:    ".export to tsv (h\"https://a/c\") with (includeHeaders=\"all\") <|\nT | take 10",
+		},
+		{desc: "no storage", err: true},
+		{desc: "unknown format", storage: []string{"https://a/c"}, options: ExportOptions{Format: "xml"}, err: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+			cmd, err := exportCommand(tt.async, tt.storage, kql.New("T | take 10"), tt.options)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd.String())
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	id := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			CSL string `json:"csl"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		if strings.HasPrefix(msg.CSL, ".export async") {
+			_, _ = w.Write([]byte(`{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"OperationId","ColumnType":"guid"}],"Rows":[["` + id.String() + `"]]}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"Path","ColumnType":"string"},` +
+			`{"ColumnName":"NumRecords","ColumnType":"long"},{"ColumnName":"SizeInBytes","ColumnType":"long"}],` +
+			`"Rows":[["https://a/c/export_1.csv",10,100],["https://a/c/export_2.csv",5,50]]}]}`))
+	})
+
+	client := newTestKustoClient(t, srv)
+	ctx := context.Background()
+
+	artifacts, err := client.Export(ctx, "db", []string{"https://a/c"}, kql.New("T"), ExportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []ExportedArtifact{
+		{Path: "https://a/c/export_1.csv", NumRecords: 10, SizeInBytes: 100},
+		{Path: "https://a/c/export_2.csv", NumRecords: 5, SizeInBytes: 50},
+	}, artifacts)
+
+	op, err := client.ExportAsync(ctx, "db", []string{"https://a/c"}, kql.New("T"), ExportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, id, op.ID())
+}
@@ -0,0 +1,110 @@
+package azkustodata
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/google/uuid"
+)
+
+// IngestFromQueryOptions configures the commands ingesting the results of a query into a table, see SetOrAppend.
+// See https://learn.microsoft.com/kusto/management/data-ingestion/ingest-from-query for the details of each option.
+type IngestFromQueryOptions struct {
+	// Distributed runs the query on all the nodes holding its data, in parallel. It speeds up ingesting large results.
+	Distributed bool
+	// CreationTime overrides the creation time of the extents, for ingesting historical data with the right retention.
+	CreationTime time.Time
+	// Tags are the tags of the extents, such as "drop-by:<value>" tags.
+	Tags []string
+	// ExtendSchema adds the columns of the results that the table doesn't have to its schema.
+	ExtendSchema bool
+}
+
+// IngestedExtent is an extent created by ingesting the results of a query.
+type IngestedExtent struct {
+	ExtentId uuid.UUID
+	// OriginalSize is the size of the ingested data, and ExtentSize the size of the extent, ColumnSize of its data plus
+	// IndexSize of its index, in bytes.
+	OriginalSize float64
+	ExtentSize   float64
+	ColumnSize   float64
+	IndexSize    float64
+	RowCount     int64
+}
+
+// Set creates a table holding the results of the query, failing if the table exists, with `.set`.
+func (c *Client) Set(ctx context.Context, db string, table string, kqlQuery Statement, options IngestFromQueryOptions, queryOptions ...QueryOption) ([]IngestedExtent, error) {
+	return c.ingestFromQuery(ctx, ".set", db, table, kqlQuery, options, queryOptions)
+}
+
+// Append appends the results of the query to an existing table, with `.append`.
+func (c *Client) Append(ctx context.Context, db string, table string, kqlQuery Statement, options IngestFromQueryOptions, queryOptions ...QueryOption) ([]IngestedExtent, error) {
+	return c.ingestFromQuery(ctx, ".append", db, table, kqlQuery, options, queryOptions)
+}
+
+// SetOrAppend appends the results of the query to a table, creating it if it doesn't exist, with `.set-or-append`.
+//
+//	extents, err := client.SetOrAppend(ctx, "database", "DailyStats",
+//		kql.New("Events | where Timestamp > ago(1d) | summarize count() by Source"),
+//		azkustodata.IngestFromQueryOptions{Tags: []string{"drop-by:2024-01-01"}})
+func (c *Client) SetOrAppend(ctx context.Context, db string, table string, kqlQuery Statement, options IngestFromQueryOptions, queryOptions ...QueryOption) ([]IngestedExtent, error) {
+	return c.ingestFromQuery(ctx, ".set-or-append", db, table, kqlQuery, options, queryOptions)
+}
+
+// SetOrReplace replaces the data of a table with the results of the query, creating it if it doesn't exist, with
+// `.set-or-replace`.
+func (c *Client) SetOrReplace(ctx context.Context, db string, table string, kqlQuery Statement, options IngestFromQueryOptions, queryOptions ...QueryOption) ([]IngestedExtent, error) {
+	return c.ingestFromQuery(ctx, ".set-or-replace", db, table, kqlQuery, options, queryOptions)
+}
+
+// ingestFromQuery runs an ingest from query command, and decodes the extents it created.
+func (c *Client) ingestFromQuery(ctx context.Context, command string, db string, table string, kqlQuery Statement, options IngestFromQueryOptions, queryOptions []QueryOption) ([]IngestedExtent, error) {
+	cmd, err := ingestFromQueryCommand(command, table, kqlQuery, options)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := c.Mgmt(ctx, db, cmd, queryOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if tables := ds.Tables(); len(tables) > 0 {
+		return query.ToStructs[IngestedExtent](tables[0])
+	}
+	return nil, nil
+}
+
+// ingestFromQueryCommand builds a `<command> <table> [with (...)] <| <query>` command.
+func ingestFromQueryCommand(command string, table string, kqlQuery Statement, options IngestFromQueryOptions) (Statement, error) {
+	if table == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "a table is required to ingest the results of a query").SetNoRetry()
+	}
+
+	var properties []string
+	if options.Distributed {
+		properties = append(properties, "distributed=true")
+	}
+	if !options.CreationTime.IsZero() {
+		properties = append(properties, "creationTime="+kql.QuoteString(options.CreationTime.UTC().Format(time.RFC3339Nano), false))
+	}
+	if len(options.Tags) > 0 {
+		tags, err := json.Marshal(options.Tags)
+		if err != nil {
+			return nil, errors.ES(errors.OpMgmt, errors.KClientArgs, "could not marshal the tags: %s", err).SetNoRetry()
+		}
+		properties = append(properties, "tags="+kql.QuoteString(string(tags), false))
+	}
+	if options.ExtendSchema {
+		properties = append(properties, "extend_schema=true")
+	}
+
+	cmd := kql.New("").AddUnsafe(command).AddLiteral(" ").AddUnsafe(kql.NormalizeName(table))
+	if len(properties) > 0 {
+		cmd.AddLiteral(" with (").AddUnsafe(strings.Join(properties, ", ")).AddLiteral(")")
+	}
+	return cmd.AddLiteral(" <|\n").AddUnsafe(kqlQuery.String()), nil
+}
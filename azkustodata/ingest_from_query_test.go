@@ -0,0 +1,91 @@
+package azkustodata
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestFromQueryCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		command string
+		table   string
+		options IngestFromQueryOptions
+		want    string
+		err     bool
+	}{
+		{
+			desc:    "no options",
+			command: ".append",
+			table:   "Stats",
+			want:    ".append Stats <|\nT | count",
+		},
+		{
+			desc:    "all options",
+			command: ".set-or-append",
+			table:   "my table",
+			options: IngestFromQueryOptions{
+				Distributed:  true,
+				CreationTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Tags:         []string{"drop-by:a", "ingest-by:b"},
+				ExtendSchema: true,
+			},
+			want: `.set-or-append ["my table"] with (distributed=true, creationTime="2024-01-02T03:04:05Z", ` +
+				`tags="[\"drop-by:a\",\"ingest-by:b\"]", extend_schema=true) <|` + "\nT | count",
+		},
+		{desc: "no table", command: ".set", err: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+			cmd, err := ingestFromQueryCommand(tt.command, tt.table, kql.New("T | count"), tt.options)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd.String())
+		})
+	}
+}
+
+func TestIngestFromQuery(t *testing.T) {
+	id := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	var csl []string
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			CSL string `json:"csl"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		csl = append(csl, msg.CSL)
+		_, _ = w.Write([]byte(`{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"ExtentId","ColumnType":"guid"},` +
+			`{"ColumnName":"OriginalSize","ColumnType":"real"},{"ColumnName":"ExtentSize","ColumnType":"real"},` +
+			`{"ColumnName":"ColumnSize","ColumnType":"real"},{"ColumnName":"IndexSize","ColumnType":"real"},` +
+			`{"ColumnName":"RowCount","ColumnType":"long"}],"Rows":[["` + id.String() + `",100,40,30,10,5]]}]}`))
+	})
+
+	client := newTestKustoClient(t, srv)
+	ctx := context.Background()
+
+	want := []IngestedExtent{{ExtentId: id, OriginalSize: 100, ExtentSize: 40, ColumnSize: 30, IndexSize: 10, RowCount: 5}}
+	for _, run := range []func(context.Context, string, string, Statement, IngestFromQueryOptions, ...QueryOption) ([]IngestedExtent, error){
+		client.Set, client.Append, client.SetOrAppend, client.SetOrReplace,
+	} {
+		extents, err := run(ctx, "db", "Stats", kql.New("T"), IngestFromQueryOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, want, extents)
+	}
+	assert.Equal(t, []string{".set Stats <|\nT", ".append Stats <|\nT", ".set-or-append Stats <|\nT", ".set-or-replace Stats <|\nT"}, csl)
+}
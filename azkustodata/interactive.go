@@ -0,0 +1,149 @@
+package azkustodata
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/public"
+	"github.com/pkg/browser"
+)
+
+// defaultInteractiveTenant is the tenant used for interactive login when no authority is given, which accepts any work
+// or school account.
+const defaultInteractiveTenant = "organizations"
+
+// InteractiveLoginOptions customizes the browser login of WithInteractiveLoginOptions.
+type InteractiveLoginOptions struct {
+	// RedirectURL overrides the redirect URL of the cluster's client application, for example to use a fixed port
+	// that is allowed through a proxy or firewall. It must be of the form http://localhost[:port].
+	RedirectURL string
+	// LoginHint pre-fills the username in the login page.
+	LoginHint string
+	// DomainHint skips the account type selection, and sends the user directly to the sign-in page of the given domain.
+	DomainHint string
+	// SelectAccount always shows the account picker, even when the user is already signed in with a single account.
+	SelectAccount bool
+}
+
+// interactiveBrowserCredential authenticates a user through the system browser.
+// It is only used for logins with a domain hint or the account picker, which azidentity.InteractiveBrowserCredential
+// doesn't support.
+type interactiveBrowserCredential struct {
+	client                     public.Client
+	options                    InteractiveLoginOptions
+	tenantID                   string
+	additionallyAllowedTenants []string
+
+	lock    sync.Mutex
+	account public.Account
+}
+
+func newInteractiveBrowserCredential(authorityHost string, tenantID string, clientID string, transport policy.Transporter,
+	options InteractiveLoginOptions) (*interactiveBrowserCredential, error) {
+	if isEmpty(tenantID) {
+		tenantID = defaultInteractiveTenant
+	}
+	authority := strings.TrimRight(authorityHost, "/") + "/" + tenantID
+
+	client, err := public.New(clientID, public.WithAuthority(authority), public.WithHTTPClient(msalHTTPClient{transport: transport}))
+	if err != nil {
+		return nil, err
+	}
+	return &interactiveBrowserCredential{client: client, options: options, tenantID: tenantID}, nil
+}
+
+// tenantOption returns the option acquiring a token for the tenant of the request, if it isn't the credential's own
+// tenant. Other tenants must be allowed with WithAdditionallyAllowedTenants.
+func (c *interactiveBrowserCredential) tenantOption(tenantID string) (public.AcquireSilentOption, public.AcquireInteractiveOption, error) {
+	if isEmpty(tenantID) || strings.EqualFold(tenantID, c.tenantID) {
+		return nil, nil, nil
+	}
+	for _, allowed := range c.additionallyAllowedTenants {
+		if allowed == "*" || strings.EqualFold(allowed, tenantID) {
+			return public.WithTenantID(tenantID), public.WithTenantID(tenantID), nil
+		}
+	}
+	return nil, nil, fmt.Errorf("the interactive login can't acquire tokens for tenant %q, add it with WithAdditionallyAllowedTenants", tenantID)
+}
+
+// GetToken implements azcore.TokenCredential.
+// A token is first acquired silently for the account that last logged in, and the browser is only opened when that fails.
+func (c *interactiveBrowserCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	silentTenant, interactiveTenant, err := c.tenantOption(opts.TenantID)
+	if err != nil {
+		return azcore.AccessToken{}, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !isEmpty(c.account.HomeAccountID) {
+		silentOpts := []public.AcquireSilentOption{public.WithSilentAccount(c.account)}
+		if silentTenant != nil {
+			silentOpts = append(silentOpts, silentTenant)
+		}
+		res, err := c.client.AcquireTokenSilent(ctx, opts.Scopes, silentOpts...)
+		if err == nil {
+			return azcore.AccessToken{Token: res.AccessToken, ExpiresOn: res.ExpiresOn}, nil
+		}
+	}
+
+	interactiveOpts := c.interactiveOptions()
+	if interactiveTenant != nil {
+		interactiveOpts = append(interactiveOpts, interactiveTenant)
+	}
+	res, err := c.client.AcquireTokenInteractive(ctx, opts.Scopes, interactiveOpts...)
+	if err != nil {
+		return azcore.AccessToken{}, err
+	}
+	c.account = res.Account
+	return azcore.AccessToken{Token: res.AccessToken, ExpiresOn: res.ExpiresOn}, nil
+}
+
+func (c *interactiveBrowserCredential) interactiveOptions() []public.AcquireInteractiveOption {
+	var opts []public.AcquireInteractiveOption
+	if !isEmpty(c.options.RedirectURL) {
+		opts = append(opts, public.WithRedirectURI(c.options.RedirectURL))
+	}
+	if !isEmpty(c.options.LoginHint) {
+		opts = append(opts, public.WithLoginHint(c.options.LoginHint))
+	}
+	if !isEmpty(c.options.DomainHint) {
+		opts = append(opts, public.WithDomainHint(c.options.DomainHint))
+	}
+	if c.options.SelectAccount {
+		opts = append(opts, public.WithOpenURL(func(u string) error {
+			return browser.OpenURL(withPrompt(u, "select_account"))
+		}))
+	}
+	return opts
+}
+
+// withPrompt sets the prompt parameter of an authorization URL, which MSAL doesn't expose an option for.
+func withPrompt(authURL string, prompt string) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return authURL
+	}
+	q := u.Query()
+	q.Set("prompt", prompt)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// msalHTTPClient adapts the transport of the client options to the HTTP client interface MSAL expects.
+type msalHTTPClient struct {
+	transport policy.Transporter
+}
+
+func (c msalHTTPClient) Do(r *http.Request) (*http.Response, error) {
+	return c.transport.Do(r)
+}
+
+func (c msalHTTPClient) CloseIdleConnections() {}
@@ -0,0 +1,135 @@
+package azkustodata
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/stretchr/testify/require"
+	"github.com/tj/assert"
+)
+
+func TestWithInteractiveLoginOptions(t *testing.T) {
+	want := ConnectionStringBuilder{
+		DataSource:          "endpoint",
+		AuthorityId:         "authorityID",
+		InteractiveLogin:    true,
+		RedirectURL:         "http://localhost:8400",
+		LoginHint:           "user@contoso.com",
+		DomainHint:          "contoso.com",
+		PromptSelectAccount: true,
+	}
+
+	actual := NewConnectionStringBuilder("endpoint").WithInteractiveLoginOptions("authorityID", InteractiveLoginOptions{
+		RedirectURL:   "http://localhost:8400",
+		LoginHint:     "user@contoso.com",
+		DomainHint:    "contoso.com",
+		SelectAccount: true,
+	})
+
+	assert.EqualValues(t, want, *actual)
+}
+
+func TestInteractiveLoginProvider(t *testing.T) {
+	s := newTestServ()
+	defer s.close()
+	s.code = 200
+	s.payload = []byte(testCloudMetadata)
+
+	tests := []struct {
+		name string
+		kcsb *ConnectionStringBuilder
+		// want is nil when azidentity's credential is used.
+		want *InteractiveLoginOptions
+	}{
+		{
+			name: "Defaults",
+			kcsb: NewConnectionStringBuilder(s.urlStr()).WithInteractiveLogin(""),
+		},
+		{
+			name: "LoginHint",
+			kcsb: NewConnectionStringBuilder(s.urlStr()).WithInteractiveLoginOptions("tenantID", InteractiveLoginOptions{
+				RedirectURL: "http://localhost:8400",
+				LoginHint:   "user@contoso.com",
+			}),
+		},
+		{
+			name: "Options",
+			kcsb: NewConnectionStringBuilder(s.urlStr()).WithInteractiveLoginOptions("tenantID", InteractiveLoginOptions{
+				RedirectURL:   "http://localhost:8400",
+				LoginHint:     "user@contoso.com",
+				SelectAccount: true,
+			}),
+			want: &InteractiveLoginOptions{RedirectURL: "http://localhost:8400", LoginHint: "user@contoso.com", SelectAccount: true},
+		},
+		{
+			name: "DomainHintFromConnectionString",
+			kcsb: NewConnectionStringBuilder(s.urlStr() + ";Interactive Login=true;Domain Hint=contoso.com"),
+			want: &InteractiveLoginOptions{RedirectURL: "https://microsoft/dummykustoclient", DomainHint: "contoso.com"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			tkp, err := tt.kcsb.newTokenProvider()
+			require.NoError(t, err)
+			tkp.SetHttp(s.http.Client())
+			_, err = tkp.initOnce.DoWithInit()
+			require.NoError(t, err)
+
+			if tt.want == nil {
+				assert.IsType(t, &azidentity.InteractiveBrowserCredential{}, tkp.tokenCred)
+				return
+			}
+			cred, ok := tkp.tokenCred.(*interactiveBrowserCredential)
+			require.True(t, ok)
+			assert.Equal(t, *tt.want, cred.options)
+			assert.Len(t, cred.interactiveOptions(), countSet(*tt.want))
+		})
+	}
+}
+
+func TestInteractiveLoginTenants(t *testing.T) {
+	cred, err := newInteractiveBrowserCredential("https://login.microsofdummy.com", "tenantID", "clientID", nil, InteractiveLoginOptions{SelectAccount: true})
+	require.NoError(t, err)
+
+	silent, interactive, err := cred.tenantOption("")
+	require.NoError(t, err)
+	assert.Nil(t, silent)
+	assert.Nil(t, interactive)
+	_, _, err = cred.tenantOption("TENANTID")
+	require.NoError(t, err)
+
+	_, _, err = cred.tenantOption("otherTenant")
+	require.ErrorContains(t, err, "WithAdditionallyAllowedTenants")
+
+	cred.additionallyAllowedTenants = []string{"otherTenant"}
+	silent, interactive, err = cred.tenantOption("otherTenant")
+	require.NoError(t, err)
+	assert.NotNil(t, silent)
+	assert.NotNil(t, interactive)
+
+	cred.additionallyAllowedTenants = []string{"*"}
+	_, _, err = cred.tenantOption("anyTenant")
+	require.NoError(t, err)
+}
+
+func countSet(o InteractiveLoginOptions) int {
+	n := 0
+	for _, set := range []bool{o.RedirectURL != "", o.LoginHint != "", o.DomainHint != "", o.SelectAccount} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+func TestWithPrompt(t *testing.T) {
+	got := withPrompt("https://login.microsoftonline.com/organizations/oauth2/v2.0/authorize?client_id=id&prompt=login", "select_account")
+
+	u, err := url.Parse(got)
+	require.NoError(t, err)
+	assert.Equal(t, "select_account", u.Query().Get("prompt"))
+	assert.Equal(t, "id", u.Query().Get("client_id"))
+	assert.Equal(t, "/organizations/oauth2/v2.0/authorize", u.Path)
+}
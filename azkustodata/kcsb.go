@@ -1,93 +1,238 @@
 package azkustodata
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 
 	kustoErrors "github.com/Azure/azure-kusto-go/azkustodata/errors"
+	trustedEndpoints "github.com/Azure/azure-kusto-go/azkustodata/trusted_endpoints"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 )
 
+// ConnectionStringBuilder holds the settings used to connect and authenticate to a Kusto cluster.
+// The With* methods return a modified copy and leave the builder they are called on unchanged, so a builder can safely
+// be used as a template for several clusters or identities.
 type ConnectionStringBuilder struct {
 	DataSource                     string
+	InitialCatalog                 string
+	FederatedSecurity              bool
 	AadUserID                      string
 	Password                       string
 	UserToken                      string
 	ApplicationClientId            string
 	ApplicationKey                 string
+	OnBehalfOfUserAssertion        string
 	AuthorityId                    string
 	ApplicationCertificatePath     string
 	ApplicationCertificateBytes    []byte
 	ApplicationCertificatePassword []byte
 	SendCertificateChain           bool
+	KeyVaultURL                    string
+	KeyVaultCertificateName        string
 	ApplicationToken               string
 	AzCli                          bool
+	AzCliSubscription              string
+	AzdCli                         bool
 	MsiAuthentication              bool
 	WorkloadAuthentication         bool
 	FederationTokenFilePath        string
+	ClientAssertionCallback        func(context.Context) (string, error)
 	ManagedServiceIdentity         string
+	ManagedIdentityObjectID        string
+	ManagedIdentityResourceID      string
 	InteractiveLogin               bool
 	RedirectURL                    string
+	LoginHint                      string
+	DomainHint                     string
+	PromptSelectAccount            bool
+	DeviceCodeLogin                bool
+	DeviceCodeCallback             func(DeviceCodeMessage)
 	DefaultAuth                    bool
+	EnvironmentAuth                bool
 	ClientOptions                  *azcore.ClientOptions
+	AllowInsecure                  bool
+	TokenScope                     string
+	TokenEvents                    *TokenProviderEvents
+	AdditionallyAllowedTenants     []string
+	TokenCachePersistenceName      string
+	SkipEndpointValidation         bool
+	TrustedHosts                   []trustedEndpoints.MatchRule
 	ApplicationForTracing          string
 	UserForTracing                 string
 	TokenCredential                azcore.TokenCredential
+	// MutateInPlace restores the legacy behavior of the With* methods, which modify the builder they are called on instead
+	// of returning a modified copy. It is meant as a migration aid, and will be removed in a future version.
+	MutateInPlace bool
 }
 
 const (
-	dataSource                       string = "DataSource"
-	aadUserId                        string = "AADUserID"
-	password                         string = "Password"
-	applicationClientId              string = "ApplicationClientId"
-	applicationKey                   string = "ApplicationKey"
-	applicationCertificate           string = "ApplicationCertificate"
-	authorityId                      string = "AuthorityId"
-	applicationToken                 string = "ApplicationToken"
-	userToken                        string = "UserToken"
-	applicationCertificateThumbprint string = "ApplicationCertificateThumbprint"
-	sendCertificateChain             string = "SendCertificateChain"
-	interactiveLogin                 string = "InteractiveLogin"
-	domainHint                       string = "RedirectURL"
+	dataSource                     string = "DataSource"
+	aadUserId                      string = "AADUserID"
+	password                       string = "Password"
+	applicationClientId            string = "ApplicationClientId"
+	applicationKey                 string = "ApplicationKey"
+	applicationCertificate         string = "ApplicationCertificate"
+	applicationCertificatePassword string = "ApplicationCertificatePassword"
+	authorityId                    string = "AuthorityId"
+	applicationToken               string = "ApplicationToken"
+	userToken                      string = "UserToken"
+	sendCertificateChain           string = "SendCertificateChain"
+	interactiveLogin               string = "InteractiveLogin"
+	domainHint                     string = "DomainHint"
+	defaultAuth                    string = "DefaultAuth"
+	keyVaultURL                    string = "KeyVaultURL"
+	onBehalfOfUserAssertion        string = "OnBehalfOfUserAssertion"
+	keyVaultCertificateName        string = "KeyVaultCertificateName"
+	initialCatalog                 string = "InitialCatalog"
+	federatedSecurity              string = "FederatedSecurity"
+	applicationNameForTracing      string = "ApplicationNameForTracing"
+	managedIdentityObjectID        string = "ManagedIdentityObjectID"
+	managedIdentityResourceID      string = "ManagedIdentityResourceID"
+	tokenScope                     string = "TokenScope"
+	tokenCachePersistenceName      string = "TokenCachePersistenceName"
+	userNameForTracing             string = "UserNameForTracing"
 )
 
 const (
 	BEARER_TYPE = "Bearer"
 )
 
-var csMapping = map[string]string{"datasource": dataSource, "data source": dataSource, "addr": dataSource, "address": dataSource, "network address": dataSource, "server": dataSource,
-	"aad user id": aadUserId, "aaduserid": aadUserId,
-	"password": password, "pwd": password,
-	"application client id": applicationClientId, "applicationclientid": applicationClientId, "appclientid": applicationClientId,
-	"application key": applicationKey, "applicationkey": applicationKey, "appkey": applicationKey,
-	"application certificate": applicationCertificate, "applicationcertificate": applicationCertificate,
-	"application certificate thumbprint": applicationCertificateThumbprint, "applicationcertificatethumbprint": applicationCertificateThumbprint,
-	"sendcertificatechain": sendCertificateChain, "send certificate chain": sendCertificateChain,
-	"authority id": authorityId, "authorityid": authorityId, "authority": authorityId, "tenantid": authorityId, "tenant": authorityId, "tid": authorityId,
-	"application token": applicationToken, "applicationtoken": applicationToken, "apptoken": applicationToken,
-	"user token": userToken, "usertoken": userToken, "usrtoken": userToken,
-	"interactive login": interactiveLogin, "interactivelogin": interactiveLogin,
-	"domain hint": domainHint, "domainhint": domainHint,
-}
-
-func requireNonEmpty(key string, value string) {
+// DeviceCodeMessage contains the user code and verification URL a user needs to complete the device code flow.
+type DeviceCodeMessage = azidentity.DeviceCodeMessage
+
+// csMapping maps the normalized keywords of the Kusto connection string spec, and their aliases, to the builder properties.
+// Keywords are normalized by lower-casing them and removing all whitespace, see normalizeKeyword.
+// https://learn.microsoft.com/azure/data-explorer/kusto/api/connection-strings/kusto
+var csMapping = map[string]string{
+	"datasource": dataSource, "addr": dataSource, "address": dataSource, "networkaddress": dataSource, "server": dataSource,
+	"initialcatalog": initialCatalog, "database": initialCatalog,
+	"aadfederatedsecurity": federatedSecurity, "federatedsecurity": federatedSecurity, "federated": federatedSecurity, "fed": federatedSecurity, "aadfed": federatedSecurity,
+	"aaduserid": aadUserId,
+	"password":  password, "pwd": password,
+	"applicationclientid": applicationClientId, "appclientid": applicationClientId,
+	"applicationkey": applicationKey, "appkey": applicationKey,
+	"applicationcertificate": applicationCertificate,
+	"sendcertificatechain":   sendCertificateChain, "applicationcertificatesendpubliccertificate": sendCertificateChain,
+	"applicationcertificatesendx5c": sendCertificateChain, "sendx5c": sendCertificateChain,
+	"authorityid": authorityId, "authority": authorityId, "tenantid": authorityId, "tenant": authorityId, "tid": authorityId,
+	"applicationtoken": applicationToken, "apptoken": applicationToken,
+	"usertoken": userToken, "usrtoken": userToken,
+	"interactivelogin":          interactiveLogin,
+	"domainhint":                domainHint,
+	"defaultauth":               defaultAuth,
+	"applicationnamefortracing": applicationNameForTracing, "traceappname": applicationNameForTracing,
+	"usernamefortracing": userNameForTracing, "traceusername": userNameForTracing,
+}
+
+// unsupportedKeywords are keywords of the Kusto connection string spec that have no equivalent in this SDK.
+// They are reported as errors rather than ignored, so the connection isn't silently made with different settings than intended.
+var unsupportedKeywords = map[string]bool{
+	"applicationcertificatesubjectdistinguishedname": true, "applicationcertificatesubject": true,
+	"applicationcertificateissuerdistinguishedname": true, "applicationcertificateissuer": true,
+	"dstsfederatedsecurity": true, "dstsfed": true,
+	"userid": true, "uid": true, "user": true,
+	"streaming": true, "uncompressed": true,
+}
+
+// ignoredKeywords are keywords of the Kusto connection string spec that are accepted, but have no effect in this SDK.
+// The certificate thumbprint is only used to look certificates up in a certificate store, which the SDK loads from
+// files instead, and the trusted issuers are only used by other SDKs to validate the issuer of federated tokens.
+var ignoredKeywords = map[string]bool{
+	"applicationcertificatethumbprint": true, "appcert": true,
+	"trustedissuers": true,
+}
+
+// KeywordsError is returned when a connection string contains keywords that are unknown, or known but not supported by this SDK.
+type KeywordsError struct {
+	// Unknown holds the keywords that are not part of the Kusto connection string spec.
+	Unknown []string
+	// Unsupported holds the keywords that are part of the spec, but have no equivalent in this SDK.
+	Unsupported []string
+}
+
+func (e *KeywordsError) Error() string {
+	var parts []string
+	if len(e.Unknown) > 0 {
+		parts = append(parts, fmt.Sprintf("unknown keywords %q", e.Unknown))
+	}
+	if len(e.Unsupported) > 0 {
+		parts = append(parts, fmt.Sprintf("unsupported keywords %q", e.Unsupported))
+	}
+	return "Error: connection string contains " + strings.Join(parts, " and ")
+}
+
+func normalizeKeyword(rawKey string) string {
+	return strings.ToLower(strings.Join(strings.Fields(rawKey), ""))
+}
+
+// connectionStringKeywords lists the keywords that are serialized by ToConnectionString, in order, with their canonical names.
+var connectionStringKeywords = []struct {
+	key    string
+	name   string
+	secret bool
+}{
+	{dataSource, "Data Source", false},
+	{initialCatalog, "Initial Catalog", false},
+	{federatedSecurity, "AAD Federated Security", false},
+	{aadUserId, "AAD User ID", false},
+	{password, "Password", true},
+	{applicationClientId, "Application Client Id", false},
+	{applicationKey, "Application Key", true},
+	{applicationCertificate, "Application Certificate", false},
+	{sendCertificateChain, "Send Certificate Chain", false},
+	{authorityId, "Authority Id", false},
+	{applicationToken, "Application Token", true},
+	{userToken, "User Token", true},
+	{interactiveLogin, "Interactive Login", false},
+	{domainHint, "Domain Hint", false},
+	{defaultAuth, "Default Auth", false},
+	{applicationNameForTracing, "Application Name for Tracing", false},
+	{userNameForTracing, "User Name for Tracing", false},
+}
+
+const redactedValue = "****"
+
+func requireNonEmpty(key string, value string) error {
 	if isEmpty(value) {
-		panic(fmt.Sprintf("Error: %s cannot be null", key))
+		return fmt.Errorf("Error: %s cannot be null", key)
 	}
+	return nil
 }
 
-func assignValue(kcsb *ConnectionStringBuilder, rawKey string, value string) error {
-	rawKey = strings.ToLower(strings.Trim(rawKey, " "))
-	parsedKey, ok := csMapping[rawKey]
-	if !ok {
-		return fmt.Errorf("Error: unsupported key %q in connection string ", rawKey)
+// firstError returns the first non-nil error, so a builder method can check all its arguments in one statement.
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// mustBuild is used by the panicking With* methods, which wrap their error-returning TryWith* variants.
+// The panic value is the error the TryWith* variant returns.
+func mustBuild(kcsb *ConnectionStringBuilder, err error) *ConnectionStringBuilder {
+	if err != nil {
+		panic(err)
+	}
+	return kcsb
+}
+
+func assignValue(kcsb *ConnectionStringBuilder, parsedKey string, value string) {
 	switch parsedKey {
 	case dataSource:
 		kcsb.DataSource = value
+	case initialCatalog:
+		kcsb.InitialCatalog = value
+	case federatedSecurity:
+		bval, _ := strconv.ParseBool(value)
+		kcsb.FederatedSecurity = bval
 	case aadUserId:
 		kcsb.AadUserID = value
 	case password:
@@ -111,9 +256,102 @@ func assignValue(kcsb *ConnectionStringBuilder, rawKey string, value string) err
 		bval, _ := strconv.ParseBool(value)
 		kcsb.InteractiveLogin = bval
 	case domainHint:
-		kcsb.RedirectURL = value
+		kcsb.DomainHint = value
+	case defaultAuth:
+		bval, _ := strconv.ParseBool(value)
+		kcsb.DefaultAuth = bval
+	case applicationNameForTracing:
+		kcsb.ApplicationForTracing = value
+	case userNameForTracing:
+		kcsb.UserForTracing = value
 	}
-	return nil
+}
+
+func getValue(kcsb *ConnectionStringBuilder, key string) string {
+	formatBool := func(b bool) string {
+		if !b {
+			return ""
+		}
+		return strconv.FormatBool(b)
+	}
+
+	switch key {
+	case dataSource:
+		return kcsb.DataSource
+	case initialCatalog:
+		return kcsb.InitialCatalog
+	case federatedSecurity:
+		return formatBool(kcsb.FederatedSecurity)
+	case aadUserId:
+		return kcsb.AadUserID
+	case password:
+		return kcsb.Password
+	case applicationClientId:
+		return kcsb.ApplicationClientId
+	case applicationKey:
+		return kcsb.ApplicationKey
+	case applicationCertificate:
+		return kcsb.ApplicationCertificatePath
+	case sendCertificateChain:
+		return formatBool(kcsb.SendCertificateChain)
+	case authorityId:
+		return kcsb.AuthorityId
+	case applicationToken:
+		return kcsb.ApplicationToken
+	case userToken:
+		return kcsb.UserToken
+	case interactiveLogin:
+		return formatBool(kcsb.InteractiveLogin)
+	case domainHint:
+		return kcsb.DomainHint
+	case defaultAuth:
+		return formatBool(kcsb.DefaultAuth)
+	case applicationNameForTracing:
+		return kcsb.ApplicationForTracing
+	case userNameForTracing:
+		return kcsb.UserForTracing
+	}
+	return ""
+}
+
+// splitConnectionString splits a connection string into its key=value pairs, ignoring separators inside quoted values.
+func splitConnectionString(connStr string) []string {
+	var parts []string
+	var current strings.Builder
+	var quote rune
+	for _, c := range connStr {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ';':
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(c)
+	}
+	return append(parts, current.String())
+}
+
+// unquoteValue removes the quotes around a connection string value, if any. Quotes inside the value are escaped by doubling them.
+func unquoteValue(val string) string {
+	if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+		q := string(val[0])
+		return strings.ReplaceAll(val[1:len(val)-1], q+q, q)
+	}
+	return val
+}
+
+// quoteValue quotes a connection string value if it can't be written as is.
+func quoteValue(val string) string {
+	if !strings.ContainsAny(val, ";\"'") && strings.TrimSpace(val) == val {
+		return val
+	}
+	return `"` + strings.ReplaceAll(val, `"`, `""`) + `"`
 }
 
 // NewConnectionStringBuilder Creates new Kusto ConnectionStringBuilder.
@@ -121,31 +359,112 @@ func assignValue(kcsb *ConnectionStringBuilder, rawKey string, value string) err
 // https://<clusterName>.<location>.kusto.windows.net;AAD User ID="user@microsoft.com";Password=P@ssWord
 // For more information please look at:
 // https://docs.microsoft.com/azure/data-explorer/kusto/api/connection-strings/kusto
+// Keywords are case-insensitive and whitespace-insensitive. It panics with the error of ParseConnectionString, which is a
+// *KeywordsError listing them if the connection string contains unknown or unsupported keywords. Use
+// ParseConnectionString for user supplied connection strings.
 func NewConnectionStringBuilder(connStr string) *ConnectionStringBuilder {
+	kcsb, err := ParseConnectionString(connStr)
+	if err != nil {
+		panic(err)
+	}
+	return kcsb
+}
+
+// ParseConnectionString is NewConnectionStringBuilder, returning an error instead of panicking when the connection string
+// is empty or contains unknown or unsupported keywords (as a *KeywordsError).
+func ParseConnectionString(connStr string) (*ConnectionStringBuilder, error) {
 	kcsb := ConnectionStringBuilder{}
 	if isEmpty(connStr) {
-		panic("error: Connection string cannot be empty")
+		return nil, errors.New("error: Connection string cannot be empty")
 	}
-	connStrArr := strings.Split(connStr, ";")
+	connStrArr := splitConnectionString(connStr)
 	if !strings.Contains(connStrArr[0], "=") {
 		connStrArr[0] = "Data Source=" + connStrArr[0]
 	}
 
+	keywordsErr := &KeywordsError{}
 	for _, kvp := range connStrArr {
 		if isEmpty(strings.Trim(kvp, " ")) {
 			continue
 		}
-		kvparr := strings.Split(kvp, "=")
-		val := strings.Trim(kvparr[1], " ")
+		kvparr := strings.SplitN(kvp, "=", 2)
+		key := normalizeKeyword(kvparr[0])
+		if ignoredKeywords[key] {
+			continue
+		}
+		parsedKey, ok := csMapping[key]
+		if !ok {
+			if unsupportedKeywords[key] {
+				keywordsErr.Unsupported = append(keywordsErr.Unsupported, strings.TrimSpace(kvparr[0]))
+			} else {
+				keywordsErr.Unknown = append(keywordsErr.Unknown, strings.TrimSpace(kvparr[0]))
+			}
+			continue
+		}
+		if len(kvparr) < 2 {
+			continue
+		}
+		val := unquoteValue(strings.Trim(kvparr[1], " "))
 		if isEmpty(val) {
 			continue
 		}
-		if err := assignValue(&kcsb, kvparr[0], val); err != nil {
-			panic(err)
+		assignValue(&kcsb, parsedKey, val)
+	}
+
+	if len(keywordsErr.Unknown) > 0 || len(keywordsErr.Unsupported) > 0 {
+		return nil, keywordsErr
+	}
+
+	return &kcsb, nil
+}
+
+// ToConnectionString serializes the builder back into a Kusto connection string, that can be parsed by NewConnectionStringBuilder.
+// Only the settings that have a connection string keyword are serialized - credentials, callbacks and client options are not.
+// When redactSecrets is true, passwords, keys and tokens are masked.
+func (kcsb *ConnectionStringBuilder) ToConnectionString(redactSecrets bool) string {
+	var pairs []string
+	for _, keyword := range connectionStringKeywords {
+		val := getValue(kcsb, keyword.key)
+		if isEmpty(val) {
+			continue
 		}
+		if keyword.secret && redactSecrets {
+			val = redactedValue
+		}
+		pairs = append(pairs, keyword.name+"="+quoteValue(val))
+	}
+	return strings.Join(pairs, ";")
+}
+
+// String returns the connection string of the builder, with secrets redacted. See ToConnectionString.
+func (kcsb *ConnectionStringBuilder) String() string {
+	return kcsb.ToConnectionString(true)
+}
+
+// Clone returns a copy of the builder, that can be modified without affecting the original.
+// Credentials, callbacks and client options are shared, as they are references to user supplied values.
+func (kcsb *ConnectionStringBuilder) Clone() *ConnectionStringBuilder {
+	clone := *kcsb
+	clone.ApplicationCertificateBytes = cloneBytes(kcsb.ApplicationCertificateBytes)
+	clone.ApplicationCertificatePassword = cloneBytes(kcsb.ApplicationCertificatePassword)
+	clone.AdditionallyAllowedTenants = append([]string(nil), kcsb.AdditionallyAllowedTenants...)
+	clone.TrustedHosts = append([]trustedEndpoints.MatchRule(nil), kcsb.TrustedHosts...)
+	return &clone
+}
+
+// modifiable returns the builder the With* methods should modify: a copy, unless MutateInPlace is set.
+func (kcsb *ConnectionStringBuilder) modifiable() *ConnectionStringBuilder {
+	if kcsb.MutateInPlace {
+		return kcsb
 	}
+	return kcsb.Clone()
+}
 
-	return &kcsb
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
 }
 
 func (kcsb *ConnectionStringBuilder) resetConnectionString() {
@@ -154,62 +473,125 @@ func (kcsb *ConnectionStringBuilder) resetConnectionString() {
 	kcsb.UserToken = ""
 	kcsb.ApplicationClientId = ""
 	kcsb.ApplicationKey = ""
+	kcsb.OnBehalfOfUserAssertion = ""
 	kcsb.AuthorityId = ""
 	kcsb.ApplicationCertificatePath = ""
 	kcsb.ApplicationCertificateBytes = nil
 	kcsb.ApplicationCertificatePassword = nil
 	kcsb.SendCertificateChain = false
+	kcsb.KeyVaultURL = ""
+	kcsb.KeyVaultCertificateName = ""
 	kcsb.ApplicationToken = ""
 	kcsb.AzCli = false
+	kcsb.AzCliSubscription = ""
+	kcsb.AzdCli = false
 	kcsb.MsiAuthentication = false
 	kcsb.WorkloadAuthentication = false
+	kcsb.FederationTokenFilePath = ""
+	kcsb.ClientAssertionCallback = nil
 	kcsb.ManagedServiceIdentity = ""
+	kcsb.ManagedIdentityObjectID = ""
+	kcsb.ManagedIdentityResourceID = ""
 	kcsb.InteractiveLogin = false
 	kcsb.RedirectURL = ""
+	kcsb.LoginHint = ""
+	kcsb.DomainHint = ""
+	kcsb.PromptSelectAccount = false
+	kcsb.DeviceCodeLogin = false
+	kcsb.DeviceCodeCallback = nil
 	kcsb.ClientOptions = nil
 	kcsb.DefaultAuth = false
+	kcsb.EnvironmentAuth = false
 	kcsb.TokenCredential = nil
 }
 
 // WithAadUserPassAuth Creates a Kusto Connection string builder that will authenticate with AAD user name and password.
 func (kcsb *ConnectionStringBuilder) WithAadUserPassAuth(uname string, pswrd string, authorityID string) *ConnectionStringBuilder {
-	requireNonEmpty(dataSource, kcsb.DataSource)
-	requireNonEmpty(aadUserId, uname)
-	requireNonEmpty(password, pswrd)
+	return mustBuild(kcsb.TryWithAadUserPassAuth(uname, pswrd, authorityID))
+}
+
+// TryWithAadUserPassAuth is WithAadUserPassAuth, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithAadUserPassAuth(uname string, pswrd string, authorityID string) (*ConnectionStringBuilder, error) {
+	if err := firstError(requireNonEmpty(dataSource, kcsb.DataSource), requireNonEmpty(aadUserId, uname), requireNonEmpty(password, pswrd)); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
 	kcsb.resetConnectionString()
 	kcsb.AadUserID = uname
 	kcsb.Password = pswrd
 	kcsb.AuthorityId = authorityID
-	return kcsb
+	return kcsb, nil
 }
 
 // WitAadUserToken Creates a Kusto Connection string builder that will authenticate with AAD user token
 func (kcsb *ConnectionStringBuilder) WitAadUserToken(usertoken string) *ConnectionStringBuilder {
-	requireNonEmpty(dataSource, kcsb.DataSource)
-	requireNonEmpty(userToken, usertoken)
+	return mustBuild(kcsb.TryWithAadUserToken(usertoken))
+}
+
+// TryWithAadUserToken is WitAadUserToken, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithAadUserToken(usertoken string) (*ConnectionStringBuilder, error) {
+	if err := firstError(requireNonEmpty(dataSource, kcsb.DataSource), requireNonEmpty(userToken, usertoken)); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
 	kcsb.resetConnectionString()
 	kcsb.UserToken = usertoken
-	return kcsb
+	return kcsb, nil
 }
 
 // WithAadAppKey Creates a Kusto Connection string builder that will authenticate with AAD application and key.
 func (kcsb *ConnectionStringBuilder) WithAadAppKey(appId string, appKey string, authorityID string) *ConnectionStringBuilder {
-	requireNonEmpty(dataSource, kcsb.DataSource)
-	requireNonEmpty(applicationClientId, appId)
-	requireNonEmpty(applicationKey, appKey)
-	requireNonEmpty(authorityId, authorityID)
+	return mustBuild(kcsb.TryWithAadAppKey(appId, appKey, authorityID))
+}
+
+// TryWithAadAppKey is WithAadAppKey, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithAadAppKey(appId string, appKey string, authorityID string) (*ConnectionStringBuilder, error) {
+	if err := firstError(requireNonEmpty(dataSource, kcsb.DataSource), requireNonEmpty(applicationClientId, appId),
+		requireNonEmpty(applicationKey, appKey), requireNonEmpty(authorityId, authorityID)); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
 	kcsb.resetConnectionString()
 	kcsb.ApplicationClientId = appId
 	kcsb.ApplicationKey = appKey
 	kcsb.AuthorityId = authorityID
-	return kcsb
+	return kcsb, nil
+}
+
+// WithOnBehalfOf Creates a Kusto Connection string builder that will exchange a user's token for a Kusto token using the
+// AAD on-behalf-of flow. userAssertion is the access token the calling service received from the user, and the exchange
+// is done with the service's own application id and secret.
+func (kcsb *ConnectionStringBuilder) WithOnBehalfOf(authorityID string, appId string, appKey string, userAssertion string) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithOnBehalfOf(authorityID, appId, appKey, userAssertion))
+}
+
+// TryWithOnBehalfOf is WithOnBehalfOf, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithOnBehalfOf(authorityID string, appId string, appKey string, userAssertion string) (*ConnectionStringBuilder, error) {
+	if err := firstError(requireNonEmpty(dataSource, kcsb.DataSource), requireNonEmpty(authorityId, authorityID), requireNonEmpty(applicationClientId, appId),
+		requireNonEmpty(applicationKey, appKey), requireNonEmpty(onBehalfOfUserAssertion, userAssertion)); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.resetConnectionString()
+	kcsb.AuthorityId = authorityID
+	kcsb.ApplicationClientId = appId
+	kcsb.ApplicationKey = appKey
+	kcsb.OnBehalfOfUserAssertion = userAssertion
+	return kcsb, nil
 }
 
 // WithAppCertificatePath Creates a Kusto Connection string builder that will authenticate with AAD application using a certificate.
 func (kcsb *ConnectionStringBuilder) WithAppCertificatePath(appId string, certificatePath string, password []byte, sendCertChain bool, authorityID string) *ConnectionStringBuilder {
-	requireNonEmpty(dataSource, kcsb.DataSource)
-	requireNonEmpty(applicationCertificate, certificatePath)
-	requireNonEmpty(authorityId, authorityID)
+	return mustBuild(kcsb.TryWithAppCertificatePath(appId, certificatePath, password, sendCertChain, authorityID))
+}
+
+// TryWithAppCertificatePath is WithAppCertificatePath, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithAppCertificatePath(appId string, certificatePath string, password []byte, sendCertChain bool, authorityID string) (*ConnectionStringBuilder, error) {
+	if err := firstError(requireNonEmpty(dataSource, kcsb.DataSource), requireNonEmpty(applicationCertificate, certificatePath),
+		requireNonEmpty(authorityId, authorityID)); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
 	kcsb.resetConnectionString()
 	kcsb.ApplicationClientId = appId
 	kcsb.AuthorityId = authorityID
@@ -217,16 +599,37 @@ func (kcsb *ConnectionStringBuilder) WithAppCertificatePath(appId string, certif
 	kcsb.ApplicationCertificatePath = certificatePath
 	kcsb.ApplicationCertificatePassword = password
 	kcsb.SendCertificateChain = sendCertChain
-	return kcsb
+	return kcsb, nil
+}
+
+// WithAppCertificatePassword Creates a Kusto Connection string builder that will authenticate with AAD application using a
+// password protected PKCS#12 (PFX) certificate, such as one exported from Key Vault or a Windows certificate store.
+func (kcsb *ConnectionStringBuilder) WithAppCertificatePassword(appId string, certificatePath string, certificatePassword string, sendCertChain bool, authorityID string) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithAppCertificatePassword(appId, certificatePath, certificatePassword, sendCertChain, authorityID))
+}
+
+// TryWithAppCertificatePassword is WithAppCertificatePassword, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithAppCertificatePassword(appId string, certificatePath string, certificatePassword string, sendCertChain bool, authorityID string) (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(applicationCertificatePassword, certificatePassword); err != nil {
+		return nil, err
+	}
+	return kcsb.TryWithAppCertificatePath(appId, certificatePath, []byte(certificatePassword), sendCertChain, authorityID)
 }
 
 // WithAppCertificateBytes Creates a Kusto Connection string builder that will authenticate with AAD application using a certificate.
 func (kcsb *ConnectionStringBuilder) WithAppCertificateBytes(appId string, certificateBytes []byte, password []byte, sendCertChain bool, authorityID string) *ConnectionStringBuilder {
-	requireNonEmpty(dataSource, kcsb.DataSource)
-	requireNonEmpty(authorityId, authorityID)
+	return mustBuild(kcsb.TryWithAppCertificateBytes(appId, certificateBytes, password, sendCertChain, authorityID))
+}
+
+// TryWithAppCertificateBytes is WithAppCertificateBytes, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithAppCertificateBytes(appId string, certificateBytes []byte, password []byte, sendCertChain bool, authorityID string) (*ConnectionStringBuilder, error) {
+	if err := firstError(requireNonEmpty(dataSource, kcsb.DataSource), requireNonEmpty(authorityId, authorityID)); err != nil {
+		return nil, err
+	}
 	if len(certificateBytes) == 0 {
-		panic("error: Certificate cannot be null")
+		return nil, errors.New("error: Certificate cannot be null")
 	}
+	kcsb = kcsb.modifiable()
 	kcsb.resetConnectionString()
 	kcsb.ApplicationClientId = appId
 	kcsb.AuthorityId = authorityID
@@ -234,108 +637,546 @@ func (kcsb *ConnectionStringBuilder) WithAppCertificateBytes(appId string, certi
 	kcsb.ApplicationCertificateBytes = certificateBytes
 	kcsb.ApplicationCertificatePassword = password
 	kcsb.SendCertificateChain = sendCertChain
-	return kcsb
+	return kcsb, nil
+}
+
+// WithKeyVaultCertificate Creates a Kusto Connection string builder that will authenticate with AAD application using a certificate
+// stored in Azure Key Vault. The certificate is fetched using the ambient DefaultAzureCredential, and fetched again when it nears its expiry.
+func (kcsb *ConnectionStringBuilder) WithKeyVaultCertificate(appId string, vaultURL string, certName string, sendCertChain bool, authorityID string) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithKeyVaultCertificate(appId, vaultURL, certName, sendCertChain, authorityID))
+}
+
+// TryWithKeyVaultCertificate is WithKeyVaultCertificate, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithKeyVaultCertificate(appId string, vaultURL string, certName string, sendCertChain bool, authorityID string) (*ConnectionStringBuilder, error) {
+	if err := firstError(requireNonEmpty(dataSource, kcsb.DataSource), requireNonEmpty(applicationClientId, appId), requireNonEmpty(keyVaultURL, vaultURL),
+		requireNonEmpty(keyVaultCertificateName, certName), requireNonEmpty(authorityId, authorityID)); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.resetConnectionString()
+	kcsb.ApplicationClientId = appId
+	kcsb.AuthorityId = authorityID
+
+	kcsb.KeyVaultURL = vaultURL
+	kcsb.KeyVaultCertificateName = certName
+	kcsb.SendCertificateChain = sendCertChain
+	return kcsb, nil
 }
 
 // WithApplicationToken Creates a Kusto Connection string builder that will authenticate with AAD application and an application token.
 func (kcsb *ConnectionStringBuilder) WithApplicationToken(appId string, appToken string) *ConnectionStringBuilder {
-	requireNonEmpty(dataSource, kcsb.DataSource)
-	requireNonEmpty(applicationToken, appToken)
+	return mustBuild(kcsb.TryWithApplicationToken(appId, appToken))
+}
+
+// TryWithApplicationToken is WithApplicationToken, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithApplicationToken(appId string, appToken string) (*ConnectionStringBuilder, error) {
+	if err := firstError(requireNonEmpty(dataSource, kcsb.DataSource), requireNonEmpty(applicationToken, appToken)); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
 	kcsb.resetConnectionString()
 	kcsb.ApplicationToken = appToken
-	return kcsb
+	return kcsb, nil
 }
 
 // WithAzCli Creates a Kusto Connection string builder that will use existing authenticated az cli profile password.
 func (kcsb *ConnectionStringBuilder) WithAzCli() *ConnectionStringBuilder {
-	requireNonEmpty(dataSource, kcsb.DataSource)
+	return mustBuild(kcsb.TryWithAzCli())
+}
+
+// TryWithAzCli is WithAzCli, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithAzCli() (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(dataSource, kcsb.DataSource); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
 	kcsb.resetConnectionString()
 	kcsb.AzCli = true
-	return kcsb
+	return kcsb, nil
+}
+
+// WithAzCliAuth Creates a Kusto Connection string builder that will use existing authenticated az cli profile, in the
+// given tenant and subscription, for users logged into more than one.
+// authorityID and subscription are optional, and default to the az cli's current tenant and subscription.
+func (kcsb *ConnectionStringBuilder) WithAzCliAuth(authorityID string, subscription string) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithAzCliAuth(authorityID, subscription))
+}
+
+// TryWithAzCliAuth is WithAzCliAuth, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithAzCliAuth(authorityID string, subscription string) (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(dataSource, kcsb.DataSource); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.resetConnectionString()
+	if !isEmpty(authorityID) {
+		kcsb.AuthorityId = authorityID
+	}
+	kcsb.AzCliSubscription = subscription
+	kcsb.AzCli = true
+	return kcsb, nil
+}
+
+// WithAzdCliAuth Creates a Kusto Connection string builder that will use the existing authenticated Azure Developer CLI (azd) profile.
+// authorityID is optional, and defaults to the tenant of the azd environment.
+func (kcsb *ConnectionStringBuilder) WithAzdCliAuth(authorityID string) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithAzdCliAuth(authorityID))
+}
+
+// TryWithAzdCliAuth is WithAzdCliAuth, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithAzdCliAuth(authorityID string) (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(dataSource, kcsb.DataSource); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.resetConnectionString()
+	if !isEmpty(authorityID) {
+		kcsb.AuthorityId = authorityID
+	}
+	kcsb.AzdCli = true
+	return kcsb, nil
 }
 
 // WithUserManagedIdentity Creates a Kusto Connection string builder that will authenticate with AAD application, using
 // an application token obtained from a Microsoft Service Identity endpoint using user assigned id.
 func (kcsb *ConnectionStringBuilder) WithUserManagedIdentity(clientID string) *ConnectionStringBuilder {
-	requireNonEmpty(dataSource, kcsb.DataSource)
+	return mustBuild(kcsb.TryWithUserManagedIdentity(clientID))
+}
+
+// TryWithUserManagedIdentity is WithUserManagedIdentity, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithUserManagedIdentity(clientID string) (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(dataSource, kcsb.DataSource); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
 	kcsb.resetConnectionString()
 	kcsb.MsiAuthentication = true
 	kcsb.ManagedServiceIdentity = clientID
-	return kcsb
+	return kcsb, nil
+}
+
+// WithUserAssignedIdentityObjectId Creates a Kusto Connection string builder that will authenticate with AAD application, using
+// an application token obtained from a Microsoft Service Identity endpoint using the object (principal) id of a user assigned identity.
+// Not all hosting environments support selecting an identity by object id, see azidentity.ObjectID.
+func (kcsb *ConnectionStringBuilder) WithUserAssignedIdentityObjectId(objectID string) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithUserAssignedIdentityObjectId(objectID))
+}
+
+// TryWithUserAssignedIdentityObjectId is WithUserAssignedIdentityObjectId, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithUserAssignedIdentityObjectId(objectID string) (*ConnectionStringBuilder, error) {
+	if err := firstError(requireNonEmpty(dataSource, kcsb.DataSource), requireNonEmpty(managedIdentityObjectID, objectID)); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.resetConnectionString()
+	kcsb.MsiAuthentication = true
+	kcsb.ManagedIdentityObjectID = objectID
+	return kcsb, nil
+}
+
+// WithUserAssignedIdentityResourceId Creates a Kusto Connection string builder that will authenticate with AAD application, using
+// an application token obtained from a Microsoft Service Identity endpoint using the ARM resource id of a user assigned identity,
+// of the form /subscriptions/<sub>/resourceGroups/<group>/providers/Microsoft.ManagedIdentity/userAssignedIdentities/<name>.
+func (kcsb *ConnectionStringBuilder) WithUserAssignedIdentityResourceId(resourceID string) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithUserAssignedIdentityResourceId(resourceID))
+}
+
+// TryWithUserAssignedIdentityResourceId is WithUserAssignedIdentityResourceId, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithUserAssignedIdentityResourceId(resourceID string) (*ConnectionStringBuilder, error) {
+	if err := firstError(requireNonEmpty(dataSource, kcsb.DataSource), requireNonEmpty(managedIdentityResourceID, resourceID)); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.resetConnectionString()
+	kcsb.MsiAuthentication = true
+	kcsb.ManagedIdentityResourceID = resourceID
+	return kcsb, nil
 }
 
 // WithSystemManagedIdentity Creates a Kusto Connection string builder that will authenticate with AAD application, using
 // an application token obtained from a Microsoft Service Identity endpoint using system assigned id.
 func (kcsb *ConnectionStringBuilder) WithSystemManagedIdentity() *ConnectionStringBuilder {
-	requireNonEmpty(dataSource, kcsb.DataSource)
+	return mustBuild(kcsb.TryWithSystemManagedIdentity())
+}
+
+// TryWithSystemManagedIdentity is WithSystemManagedIdentity, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithSystemManagedIdentity() (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(dataSource, kcsb.DataSource); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
 	kcsb.resetConnectionString()
 	kcsb.MsiAuthentication = true
-	return kcsb
+	return kcsb, nil
 }
 
 // WithKubernetesWorkloadIdentity Creates a Kusto Connection string builder that will authenticate with AAD application, using
 // an application token obtained from a Microsoft Service Identity endpoint using Kubernetes workload identity.
 func (kcsb *ConnectionStringBuilder) WithKubernetesWorkloadIdentity(appId, tokenFilePath, authorityID string) *ConnectionStringBuilder {
-	requireNonEmpty(dataSource, kcsb.DataSource)
+	return mustBuild(kcsb.TryWithKubernetesWorkloadIdentity(appId, tokenFilePath, authorityID))
+}
+
+// TryWithKubernetesWorkloadIdentity is WithKubernetesWorkloadIdentity, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithKubernetesWorkloadIdentity(appId, tokenFilePath, authorityID string) (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(dataSource, kcsb.DataSource); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
 	kcsb.resetConnectionString()
 	kcsb.ApplicationClientId = appId
 	kcsb.AuthorityId = authorityID
 	kcsb.FederationTokenFilePath = tokenFilePath
 	kcsb.WorkloadAuthentication = true
-	return kcsb
+	return kcsb, nil
+}
+
+// WithClientAssertion Creates a Kusto Connection string builder that will authenticate with AAD application, using
+// a client assertion (such as a federated OIDC token) returned by getAssertion. The callback is invoked whenever a new
+// token is needed, so it should return a fresh assertion each time.
+func (kcsb *ConnectionStringBuilder) WithClientAssertion(authorityID string, appId string, getAssertion func(context.Context) (string, error)) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithClientAssertion(authorityID, appId, getAssertion))
+}
+
+// TryWithClientAssertion is WithClientAssertion, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithClientAssertion(authorityID string, appId string, getAssertion func(context.Context) (string, error)) (*ConnectionStringBuilder, error) {
+	if err := firstError(requireNonEmpty(dataSource, kcsb.DataSource), requireNonEmpty(authorityId, authorityID), requireNonEmpty(applicationClientId, appId)); err != nil {
+		return nil, err
+	}
+	if getAssertion == nil {
+		return nil, errors.New("error: ClientAssertionCallback cannot be null")
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.resetConnectionString()
+	kcsb.AuthorityId = authorityID
+	kcsb.ApplicationClientId = appId
+	kcsb.ClientAssertionCallback = getAssertion
+	return kcsb, nil
 }
 
 // WithInteractiveLogin Creates a Kusto Connection string builder that will authenticate by launching the system default browser
 // to interactively authenticate a user, and obtain an access token
 func (kcsb *ConnectionStringBuilder) WithInteractiveLogin(authorityID string) *ConnectionStringBuilder {
-	requireNonEmpty(dataSource, kcsb.DataSource)
+	return mustBuild(kcsb.TryWithInteractiveLogin(authorityID))
+}
+
+// TryWithInteractiveLogin is WithInteractiveLogin, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithInteractiveLogin(authorityID string) (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(dataSource, kcsb.DataSource); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
 	kcsb.resetConnectionString()
 	if !isEmpty(authorityID) {
 		kcsb.AuthorityId = authorityID
 	}
 	kcsb.InteractiveLogin = true
+	return kcsb, nil
+}
+
+// WithInteractiveLoginOptions Creates a Kusto Connection string builder that will authenticate a user through the system
+// default browser, like WithInteractiveLogin, with a customized login: a fixed redirect port, a pre-filled username,
+// a domain hint, or always showing the account picker.
+func (kcsb *ConnectionStringBuilder) WithInteractiveLoginOptions(authorityID string, options InteractiveLoginOptions) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithInteractiveLoginOptions(authorityID, options))
+}
+
+// TryWithInteractiveLoginOptions is WithInteractiveLoginOptions, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithInteractiveLoginOptions(authorityID string, options InteractiveLoginOptions) (*ConnectionStringBuilder, error) {
+	kcsb, err := kcsb.TryWithInteractiveLogin(authorityID)
+	if err != nil {
+		return nil, err
+	}
+	kcsb.RedirectURL = options.RedirectURL
+	kcsb.LoginHint = options.LoginHint
+	kcsb.DomainHint = options.DomainHint
+	kcsb.PromptSelectAccount = options.SelectAccount
+	return kcsb, nil
+}
+
+// WithDeviceCodeAuth Creates a Kusto Connection string builder that will authenticate a user with the AAD device code flow.
+// The callback receives the user code and verification URL, and is responsible for surfacing them to the user.
+// If callback is nil, the message is printed to stdout.
+func (kcsb *ConnectionStringBuilder) WithDeviceCodeAuth(authorityID string, callback func(DeviceCodeMessage)) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithDeviceCodeAuth(authorityID, callback))
+}
+
+// TryWithDeviceCodeAuth is WithDeviceCodeAuth, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithDeviceCodeAuth(authorityID string, callback func(DeviceCodeMessage)) (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(dataSource, kcsb.DataSource); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.resetConnectionString()
+	if !isEmpty(authorityID) {
+		kcsb.AuthorityId = authorityID
+	}
+	kcsb.DeviceCodeLogin = true
+	kcsb.DeviceCodeCallback = callback
+	return kcsb, nil
+}
+
+// WithAllowInsecure Allows connecting to a DataSource over plain http, such as a local Kusto emulator (Kustainer).
+// Authentication over http is still rejected, as it would send the token in clear text.
+func (kcsb *ConnectionStringBuilder) WithAllowInsecure() *ConnectionStringBuilder {
+	kcsb = kcsb.modifiable()
+	kcsb.AllowInsecure = true
+	return kcsb
+}
+
+// WithTrustedHosts Trusts the given hosts for this client, in addition to the well-known Kusto endpoints, so tokens can be
+// sent to custom DNS names such as Private Link endpoints or self-hosted proxies.
+// To trust hosts for all the clients in the process, use trustedEndpoints.Instance.AddTrustedHosts instead.
+func (kcsb *ConnectionStringBuilder) WithTrustedHosts(rules ...trustedEndpoints.MatchRule) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithTrustedHosts(rules...))
+}
+
+// TryWithTrustedHosts is WithTrustedHosts, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithTrustedHosts(rules ...trustedEndpoints.MatchRule) (*ConnectionStringBuilder, error) {
+	if len(rules) == 0 {
+		return nil, errors.New("error: TrustedHosts cannot be empty")
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.TrustedHosts = append([]trustedEndpoints.MatchRule(nil), rules...)
+	return kcsb, nil
+}
+
+// WithSkipEndpointValidation Disables the validation that the DataSource is a trusted Kusto endpoint before sending it a token.
+// Only use it when the endpoint can't be described with WithTrustedHosts, as tokens may then be sent to any host.
+func (kcsb *ConnectionStringBuilder) WithSkipEndpointValidation() *ConnectionStringBuilder {
+	kcsb = kcsb.modifiable()
+	kcsb.SkipEndpointValidation = true
 	return kcsb
 }
 
+// WithTokenScope Overrides the scope tokens are requested for, which is otherwise derived from the cluster's cloud metadata
+// as `<resource>/.default`. This is needed for private clusters or proxies that require a different AAD resource.
+// The scope is used as is, so it should usually end with `/.default`. It applies to all the authentication methods.
+func (kcsb *ConnectionStringBuilder) WithTokenScope(scope string) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithTokenScope(scope))
+}
+
+// TryWithTokenScope is WithTokenScope, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithTokenScope(scope string) (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(tokenScope, scope); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.TokenScope = scope
+	return kcsb, nil
+}
+
+// WithTokenProviderEvents Sets callbacks that are invoked whenever a token is acquired from the credential, for
+// example to emit metrics on auth latency and refresh failures. It applies to all the authentication methods.
+func (kcsb *ConnectionStringBuilder) WithTokenProviderEvents(events *TokenProviderEvents) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithTokenProviderEvents(events))
+}
+
+// TryWithTokenProviderEvents is WithTokenProviderEvents, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithTokenProviderEvents(events *TokenProviderEvents) (*ConnectionStringBuilder, error) {
+	if events == nil {
+		return nil, errors.New("error: TokenProviderEvents cannot be null")
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.TokenEvents = events
+	return kcsb, nil
+}
+
+// WithAdditionallyAllowedTenants Allows the credential to acquire tokens for tenants other than its own, so a single
+// service principal can query clusters homed in multiple tenants. Use "*" to allow any tenant.
+// It applies to all the authentication methods that support it; managed identity and interactive login don't, and
+// environment authentication reads the AZURE_ADDITIONALLY_ALLOWED_TENANTS variable instead.
+func (kcsb *ConnectionStringBuilder) WithAdditionallyAllowedTenants(tenants ...string) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithAdditionallyAllowedTenants(tenants...))
+}
+
+// TryWithAdditionallyAllowedTenants is WithAdditionallyAllowedTenants, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithAdditionallyAllowedTenants(tenants ...string) (*ConnectionStringBuilder, error) {
+	if len(tenants) == 0 {
+		return nil, errors.New("error: AdditionallyAllowedTenants cannot be empty")
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.AdditionallyAllowedTenants = append([]string(nil), tenants...)
+	return kcsb, nil
+}
+
+// WithTokenCachePersistence Stores the tokens of interactive and device code logins in a persistent cache with the
+// given name, encrypted with the platform's secret storage, so users stay logged in across runs of the process.
+// Different applications should use different names. It has no effect on the other authentication methods.
+// With a persistent cache, interactive login doesn't support a domain hint or always showing the account picker.
+func (kcsb *ConnectionStringBuilder) WithTokenCachePersistence(name string) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithTokenCachePersistence(name))
+}
+
+// TryWithTokenCachePersistence is WithTokenCachePersistence, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithTokenCachePersistence(name string) (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(tokenCachePersistenceName, name); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.TokenCachePersistenceName = name
+	return kcsb, nil
+}
+
 // AttachPolicyClientOptions Assigns ClientOptions to string builder that contains configuration settings like Logging and Retry configs for a client's pipeline.
 // Read more at https://pkg.go.dev/github.com/Azure/azure-sdk-for-go/sdk/azcore@v1.2.0/policy#ClientOptions
 func (kcsb *ConnectionStringBuilder) AttachPolicyClientOptions(options *azcore.ClientOptions) *ConnectionStringBuilder {
-	requireNonEmpty(dataSource, kcsb.DataSource)
+	return mustBuild(kcsb.TryAttachPolicyClientOptions(options))
+}
+
+// TryAttachPolicyClientOptions is AttachPolicyClientOptions, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryAttachPolicyClientOptions(options *azcore.ClientOptions) (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(dataSource, kcsb.DataSource); err != nil {
+		return nil, err
+	}
 	if options != nil {
+		kcsb = kcsb.modifiable()
 		kcsb.ClientOptions = options
 	}
-	return kcsb
+	return kcsb, nil
 }
 
-// WithDefaultAzureCredential Create Kusto Conntection String that will be used for default auth mode. The order of auth will be via environment variables, managed identity and Azure CLI .
+// WithDefaultAzureCredential Create Kusto Conntection String that will be used for default auth mode. The order of auth will be via environment variables, workload identity, managed identity and Azure CLI .
+// The same mode can be selected from a connection string with `DefaultAuth=true`.
 // Read more at https://learn.microsoft.com/azure/developer/go/azure-sdk-authentication?tabs=bash#2-authenticate-with-azure
 func (kcsb *ConnectionStringBuilder) WithDefaultAzureCredential() *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithDefaultAzureCredential())
+}
+
+// TryWithDefaultAzureCredential is WithDefaultAzureCredential, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithDefaultAzureCredential() (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(dataSource, kcsb.DataSource); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
 	kcsb.resetConnectionString()
 	kcsb.DefaultAuth = true
-	return kcsb
+	return kcsb, nil
 }
 
+// WithEnvironmentAuth Creates a Kusto Connection string builder that will authenticate with the credentials configured in
+// environment variables: AZURE_TENANT_ID and AZURE_CLIENT_ID, together with either AZURE_CLIENT_SECRET,
+// AZURE_CLIENT_CERTIFICATE_PATH (and optionally AZURE_CLIENT_CERTIFICATE_PASSWORD), or AZURE_USERNAME and AZURE_PASSWORD.
+// The variables are read when the first token is acquired.
+func (kcsb *ConnectionStringBuilder) WithEnvironmentAuth() *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithEnvironmentAuth())
+}
+
+// TryWithEnvironmentAuth is WithEnvironmentAuth, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithEnvironmentAuth() (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(dataSource, kcsb.DataSource); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.resetConnectionString()
+	kcsb.EnvironmentAuth = true
+	return kcsb, nil
+}
+
+// WithTokenCredential Creates a Kusto Connection string builder that will use the given azcore.TokenCredential for token acquisition.
+// The SDK does not construct any credential of its own in this mode; the token scope is still resolved per cluster from its cloud metadata.
 func (kcsb *ConnectionStringBuilder) WithTokenCredential(tokenCredential azcore.TokenCredential) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithTokenCredential(tokenCredential))
+}
+
+// TryWithTokenCredential is WithTokenCredential, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithTokenCredential(tokenCredential azcore.TokenCredential) (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(dataSource, kcsb.DataSource); err != nil {
+		return nil, err
+	}
+	if tokenCredential == nil {
+		return nil, errors.New("error: TokenCredential cannot be null")
+	}
+	kcsb = kcsb.modifiable()
 	kcsb.resetConnectionString()
 	kcsb.TokenCredential = tokenCredential
-	return kcsb
+	return kcsb, nil
 }
 
 // Method to be used for generating TokenCredential
 func (kcsb *ConnectionStringBuilder) newTokenProvider() (*TokenProvider, error) {
 	tkp := &TokenProvider{}
 	tkp.tokenScheme = BEARER_TYPE
+	tkp.identity = kcsb.credentialIdentity()
+	tkp.events = kcsb.TokenEvents
+	tkp.tokenScope = kcsb.TokenScope
+	if err := kcsb.Validate(); err != nil {
+		tkp.configErr = kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KClientArgs, err).SetNoRetry()
+	}
 
 	var init func(*CloudInfo, *azcore.ClientOptions, string) (azcore.TokenCredential, error)
 
 	switch {
+	case kcsb.TokenCredential != nil:
+		// A user supplied credential always takes precedence over the ones we construct ourselves.
+		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
+			return kcsb.TokenCredential, nil
+		}
+	case kcsb.InteractiveLogin && !isEmpty(kcsb.TokenCachePersistenceName):
+		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
+			// Only azidentity's credential can use its persistent cache, so the domain hint and account picker aren't available.
+			persistent, err := openPersistentCache(kcsb.TokenCachePersistenceName)
+			if err != nil {
+				return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther,
+					fmt.Errorf("error: Couldn't open the persistent token cache: %s", err))
+			}
+
+			inOpts := &azidentity.InteractiveBrowserCredentialOptions{}
+			inOpts.ClientID = ci.KustoClientAppID
+			inOpts.TenantID = kcsb.AuthorityId
+			inOpts.RedirectURL = kcsb.RedirectURL
+			if isEmpty(inOpts.RedirectURL) {
+				inOpts.RedirectURL = ci.KustoClientRedirectURI
+			}
+			inOpts.LoginHint = kcsb.LoginHint
+			inOpts.ClientOptions = *cliOpts
+			inOpts.AdditionallyAllowedTenants = kcsb.AdditionallyAllowedTenants
+			inOpts.Cache = persistent.cache
+			inOpts.AuthenticationRecord = persistent.record
+
+			cred, err := azidentity.NewInteractiveBrowserCredential(inOpts)
+			if err != nil {
+				return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther,
+					fmt.Errorf("error: Couldn't retrieve client credentials using Interactive Login. "+
+						"Error: %s", err))
+			}
+
+			return persistent.wrap(cred), nil
+		}
+	case kcsb.InteractiveLogin && (!isEmpty(kcsb.DomainHint) || kcsb.PromptSelectAccount):
+		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
+			// azidentity's credential doesn't support domain hints or the account picker, so only these logins use our own.
+			inOpts := InteractiveLoginOptions{
+				RedirectURL:   kcsb.RedirectURL,
+				LoginHint:     kcsb.LoginHint,
+				DomainHint:    kcsb.DomainHint,
+				SelectAccount: kcsb.PromptSelectAccount,
+			}
+			if isEmpty(inOpts.RedirectURL) {
+				inOpts.RedirectURL = ci.KustoClientRedirectURI
+			}
+
+			cred, err := newInteractiveBrowserCredential(cliOpts.Cloud.ActiveDirectoryAuthorityHost, kcsb.AuthorityId, ci.KustoClientAppID, cliOpts.Transport, inOpts)
+			if err != nil {
+				return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther,
+					fmt.Errorf("error: Couldn't retrieve client credentials using Interactive Login. "+
+						"Error: %s", err))
+			}
+			cred.additionallyAllowedTenants = kcsb.AdditionallyAllowedTenants
+
+			return cred, nil
+		}
 	case kcsb.InteractiveLogin:
 		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
 			inOpts := &azidentity.InteractiveBrowserCredentialOptions{}
 			inOpts.ClientID = ci.KustoClientAppID
 			inOpts.TenantID = kcsb.AuthorityId
-			inOpts.RedirectURL = ci.KustoClientRedirectURI
+			inOpts.RedirectURL = kcsb.RedirectURL
+			if isEmpty(inOpts.RedirectURL) {
+				inOpts.RedirectURL = ci.KustoClientRedirectURI
+			}
+			inOpts.LoginHint = kcsb.LoginHint
 			inOpts.ClientOptions = *cliOpts
+			inOpts.AdditionallyAllowedTenants = kcsb.AdditionallyAllowedTenants
 
 			cred, err := azidentity.NewInteractiveBrowserCredential(inOpts)
 			if err != nil {
@@ -344,11 +1185,51 @@ func (kcsb *ConnectionStringBuilder) newTokenProvider() (*TokenProvider, error)
 						"Error: %s", err))
 			}
 
+			return cred, nil
+		}
+	case kcsb.DeviceCodeLogin:
+		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
+			dcOpts := &azidentity.DeviceCodeCredentialOptions{}
+			dcOpts.ClientID = ci.KustoClientAppID
+			dcOpts.TenantID = kcsb.AuthorityId
+			dcOpts.ClientOptions = *cliOpts
+			dcOpts.AdditionallyAllowedTenants = kcsb.AdditionallyAllowedTenants
+			if kcsb.DeviceCodeCallback != nil {
+				callback := kcsb.DeviceCodeCallback
+				dcOpts.UserPrompt = func(_ context.Context, msg DeviceCodeMessage) error {
+					callback(msg)
+					return nil
+				}
+			}
+
+			var persistent *persistentCache
+			if !isEmpty(kcsb.TokenCachePersistenceName) {
+				var err error
+				persistent, err = openPersistentCache(kcsb.TokenCachePersistenceName)
+				if err != nil {
+					return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther,
+						fmt.Errorf("error: Couldn't open the persistent token cache: %s", err))
+				}
+				dcOpts.Cache = persistent.cache
+				dcOpts.AuthenticationRecord = persistent.record
+			}
+
+			cred, err := azidentity.NewDeviceCodeCredential(dcOpts)
+			if err != nil {
+				return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther,
+					fmt.Errorf("error: Couldn't retrieve client credentials using Device Code. "+
+						"Error: %s", err))
+			}
+
+			if persistent != nil {
+				return persistent.wrap(cred), nil
+			}
 			return cred, nil
 		}
 	case !isEmpty(kcsb.AadUserID) && !isEmpty(kcsb.Password):
 		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
 			opts := &azidentity.UsernamePasswordCredentialOptions{ClientOptions: *cliOpts}
+			opts.AdditionallyAllowedTenants = kcsb.AdditionallyAllowedTenants
 
 			cred, err := azidentity.NewUsernamePasswordCredential(kcsb.AuthorityId, appClientId, kcsb.AadUserID, kcsb.Password, opts)
 
@@ -357,6 +1238,19 @@ func (kcsb *ConnectionStringBuilder) newTokenProvider() (*TokenProvider, error)
 					fmt.Errorf("error: Couldn't retrieve client credentials using Username Password. Error: %s", err))
 			}
 
+			return cred, nil
+		}
+	case !isEmpty(kcsb.OnBehalfOfUserAssertion):
+		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
+			opts := &azidentity.OnBehalfOfCredentialOptions{ClientOptions: *cliOpts}
+			opts.AdditionallyAllowedTenants = kcsb.AdditionallyAllowedTenants
+
+			cred, err := azidentity.NewOnBehalfOfCredentialWithSecret(kcsb.AuthorityId, appClientId, kcsb.OnBehalfOfUserAssertion, kcsb.ApplicationKey, opts)
+			if err != nil {
+				return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther,
+					fmt.Errorf("error: Couldn't retrieve client credentials using On Behalf Of: %s", err))
+			}
+
 			return cred, nil
 		}
 	case !isEmpty(kcsb.ApplicationClientId) && !isEmpty(kcsb.ApplicationKey):
@@ -364,10 +1258,11 @@ func (kcsb *ConnectionStringBuilder) newTokenProvider() (*TokenProvider, error)
 			authorityId := kcsb.AuthorityId
 
 			if isEmpty(authorityId) {
-				authorityId = ci.FirstPartyAuthorityURL
+				authorityId = ci.firstPartyTenant()
 			}
 
 			opts := &azidentity.ClientSecretCredentialOptions{ClientOptions: *cliOpts}
+			opts.AdditionallyAllowedTenants = kcsb.AdditionallyAllowedTenants
 
 			cred, err := azidentity.NewClientSecretCredential(authorityId, appClientId, kcsb.ApplicationKey, opts)
 
@@ -376,12 +1271,34 @@ func (kcsb *ConnectionStringBuilder) newTokenProvider() (*TokenProvider, error)
 					fmt.Errorf("error: Couldn't retrieve client credentials using Client Secret. Error: %s", err))
 			}
 
+			return cred, nil
+		}
+	case !isEmpty(kcsb.KeyVaultURL) && !isEmpty(kcsb.KeyVaultCertificateName):
+		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
+			ambient, err := azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+				ClientOptions:              *cliOpts,
+				AdditionallyAllowedTenants: kcsb.AdditionallyAllowedTenants,
+			})
+			if err != nil {
+				return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther,
+					fmt.Errorf("error: Couldn't retrieve credentials for Key Vault: %s", err))
+			}
+
+			fetch, err := keyVaultSecretFetcher(kcsb.KeyVaultURL, kcsb.KeyVaultCertificateName, ambient, *cliOpts)
+			if err != nil {
+				return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther,
+					fmt.Errorf("error: Couldn't create Key Vault client: %s", err))
+			}
+
+			cred := newKeyVaultCertificateCredential(kcsb.AuthorityId, appClientId, kcsb.SendCertificateChain, *cliOpts, fetch)
+			cred.additionallyAllowedTenants = kcsb.AdditionallyAllowedTenants
 			return cred, nil
 		}
 	case !isEmpty(kcsb.ApplicationCertificatePath) || len(kcsb.ApplicationCertificateBytes) != 0:
 		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
 			opts := &azidentity.ClientCertificateCredentialOptions{ClientOptions: *cliOpts}
 			opts.SendCertificateChain = kcsb.SendCertificateChain
+			opts.AdditionallyAllowedTenants = kcsb.AdditionallyAllowedTenants
 
 			bytes := kcsb.ApplicationCertificateBytes
 			if !isEmpty(kcsb.ApplicationCertificatePath) {
@@ -394,7 +1311,7 @@ func (kcsb *ConnectionStringBuilder) newTokenProvider() (*TokenProvider, error)
 				bytes = fileBytes
 			}
 
-			cert, thumprintKey, err := azidentity.ParseCertificates(bytes, kcsb.ApplicationCertificatePassword)
+			cert, thumprintKey, err := parseCertificates(bytes, kcsb.ApplicationCertificatePassword)
 			if err != nil {
 				return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther, err)
 			}
@@ -410,9 +1327,7 @@ func (kcsb *ConnectionStringBuilder) newTokenProvider() (*TokenProvider, error)
 	case kcsb.MsiAuthentication:
 		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
 			opts := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: *cliOpts}
-			if !isEmpty(kcsb.ManagedServiceIdentity) {
-				opts.ID = azidentity.ClientID(kcsb.ManagedServiceIdentity)
-			}
+			opts.ID = kcsb.managedIdentityID()
 
 			cred, err := azidentity.NewManagedIdentityCredential(opts)
 
@@ -421,11 +1336,25 @@ func (kcsb *ConnectionStringBuilder) newTokenProvider() (*TokenProvider, error)
 					fmt.Errorf("error: Couldn't retrieve client credentials using Managed Identity: %s", err))
 			}
 
+			return cred, nil
+		}
+	case kcsb.ClientAssertionCallback != nil:
+		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
+			opts := &azidentity.ClientAssertionCredentialOptions{ClientOptions: *cliOpts}
+			opts.AdditionallyAllowedTenants = kcsb.AdditionallyAllowedTenants
+
+			cred, err := azidentity.NewClientAssertionCredential(kcsb.AuthorityId, appClientId, kcsb.ClientAssertionCallback, opts)
+			if err != nil {
+				return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther,
+					fmt.Errorf("error: Couldn't retrieve client credentials using Client Assertion: %s", err))
+			}
+
 			return cred, nil
 		}
 	case kcsb.WorkloadAuthentication:
 		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
 			opts := &azidentity.WorkloadIdentityCredentialOptions{ClientOptions: *cliOpts}
+			opts.AdditionallyAllowedTenants = kcsb.AdditionallyAllowedTenants
 			if !isEmpty(kcsb.ApplicationClientId) {
 				opts.ClientID = kcsb.ApplicationClientId
 			}
@@ -456,14 +1385,10 @@ func (kcsb *ConnectionStringBuilder) newTokenProvider() (*TokenProvider, error)
 		}
 	case kcsb.AzCli:
 		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
-			authorityId := kcsb.AuthorityId
-
-			if isEmpty(authorityId) {
-				authorityId = ci.FirstPartyAuthorityURL
-			}
-
 			opts := &azidentity.AzureCLICredentialOptions{}
 			opts.TenantID = kcsb.AuthorityId
+			opts.Subscription = kcsb.AzCliSubscription
+			opts.AdditionallyAllowedTenants = kcsb.AdditionallyAllowedTenants
 			cred, err := azidentity.NewAzureCLICredential(opts)
 
 			if err != nil {
@@ -471,6 +1396,32 @@ func (kcsb *ConnectionStringBuilder) newTokenProvider() (*TokenProvider, error)
 					fmt.Errorf("error: Couldn't retrieve client credentials using Azure CLI: %s", err))
 			}
 
+			return cred, nil
+		}
+	case kcsb.EnvironmentAuth:
+		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
+			opts := &azidentity.EnvironmentCredentialOptions{ClientOptions: *cliOpts}
+
+			cred, err := azidentity.NewEnvironmentCredential(opts)
+			if err != nil {
+				return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther,
+					fmt.Errorf("error: Couldn't retrieve client credentials from the environment: %s", err))
+			}
+
+			return cred, nil
+		}
+	case kcsb.AzdCli:
+		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
+			opts := &azidentity.AzureDeveloperCLICredentialOptions{}
+			opts.TenantID = kcsb.AuthorityId
+			opts.AdditionallyAllowedTenants = kcsb.AdditionallyAllowedTenants
+			cred, err := azidentity.NewAzureDeveloperCLICredential(opts)
+
+			if err != nil {
+				return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther,
+					fmt.Errorf("error: Couldn't retrieve client credentials using Azure Developer CLI: %s", err))
+			}
+
 			return cred, nil
 		}
 	case kcsb.DefaultAuth:
@@ -478,9 +1429,7 @@ func (kcsb *ConnectionStringBuilder) newTokenProvider() (*TokenProvider, error)
 			//Default Azure authentication
 			opts := &azidentity.DefaultAzureCredentialOptions{}
 			opts.ClientOptions = *cliOpts
-			if kcsb.ClientOptions != nil {
-				opts.ClientOptions = *kcsb.ClientOptions
-			}
+			opts.AdditionallyAllowedTenants = kcsb.AdditionallyAllowedTenants
 			if !isEmpty(kcsb.AuthorityId) {
 				opts.TenantID = kcsb.AuthorityId
 			}
@@ -494,11 +1443,6 @@ func (kcsb *ConnectionStringBuilder) newTokenProvider() (*TokenProvider, error)
 
 			return cred, nil
 		}
-	case kcsb.TokenCredential != nil:
-		init = func(ci *CloudInfo, cliOpts *azcore.ClientOptions, appClientId string) (azcore.TokenCredential, error) {
-			return kcsb.TokenCredential, nil
-		}
-
 	}
 
 	if init != nil {
@@ -508,10 +1452,208 @@ func (kcsb *ConnectionStringBuilder) newTokenProvider() (*TokenProvider, error)
 	return tkp, nil
 }
 
+// AuthConfigError is returned by Validate when the authentication settings of a builder conflict or are incomplete.
+type AuthConfigError struct {
+	// Methods lists the authentication methods that are configured together, when they conflict.
+	Methods []string
+	// Reason describes the problem.
+	Reason string
+}
+
+func (e *AuthConfigError) Error() string {
+	return "invalid authentication settings: " + e.Reason
+}
+
+// authMethods returns the names of the authentication methods that have settings on the builder.
+func (kcsb *ConnectionStringBuilder) authMethods() []string {
+	var methods []string
+	add := func(configured bool, name string) {
+		if configured {
+			methods = append(methods, name)
+		}
+	}
+
+	add(kcsb.TokenCredential != nil, "TokenCredential")
+	add(kcsb.InteractiveLogin, "InteractiveLogin")
+	add(kcsb.DeviceCodeLogin, "DeviceCode")
+	add(!isEmpty(kcsb.AadUserID) || !isEmpty(kcsb.Password), "UserPassword")
+	add(!isEmpty(kcsb.OnBehalfOfUserAssertion), "OnBehalfOf")
+	// The on-behalf-of flow authenticates the service with its application key.
+	add(!isEmpty(kcsb.ApplicationKey) && isEmpty(kcsb.OnBehalfOfUserAssertion), "ApplicationKey")
+	add(!isEmpty(kcsb.KeyVaultURL) || !isEmpty(kcsb.KeyVaultCertificateName), "KeyVaultCertificate")
+	add(!isEmpty(kcsb.ApplicationCertificatePath) || len(kcsb.ApplicationCertificateBytes) != 0, "ApplicationCertificate")
+	add(kcsb.MsiAuthentication, "ManagedIdentity")
+	add(kcsb.ClientAssertionCallback != nil, "ClientAssertion")
+	add(kcsb.WorkloadAuthentication, "WorkloadIdentity")
+	add(!isEmpty(kcsb.UserToken), "UserToken")
+	add(!isEmpty(kcsb.ApplicationToken), "ApplicationToken")
+	add(kcsb.AzCli, "AzCli")
+	add(kcsb.EnvironmentAuth, "EnvironmentAuth")
+	add(kcsb.AzdCli, "AzdCli")
+	add(kcsb.DefaultAuth, "DefaultAuth")
+	return methods
+}
+
+// Validate checks that the builder configures at most one authentication method, and that the method has all the
+// settings it needs, returning an *AuthConfigError otherwise. The With* methods always produce valid settings, but
+// connection strings and fields set directly may not, and would otherwise be resolved by an undocumented precedence.
+// It is invoked automatically before the first token is acquired.
+func (kcsb *ConnectionStringBuilder) Validate() error {
+	methods := kcsb.authMethods()
+	if len(methods) > 1 {
+		return &AuthConfigError{Methods: methods, Reason: fmt.Sprintf("conflicting authentication methods %q, only one can be used", methods)}
+	}
+	if len(methods) == 0 {
+		return nil
+	}
+
+	missing := func(setting string) error {
+		return &AuthConfigError{Methods: methods, Reason: fmt.Sprintf("%s authentication requires %s", methods[0], setting)}
+	}
+
+	switch methods[0] {
+	case "UserPassword":
+		if isEmpty(kcsb.AadUserID) {
+			return missing(aadUserId)
+		}
+		if isEmpty(kcsb.Password) {
+			return missing(password)
+		}
+	case "OnBehalfOf", "ApplicationKey":
+		if isEmpty(kcsb.ApplicationClientId) {
+			return missing(applicationClientId)
+		}
+		if isEmpty(kcsb.ApplicationKey) {
+			return missing(applicationKey)
+		}
+		// Application keys default to the first party tenant of the cluster, but the on-behalf-of flow needs the tenant
+		// of the user.
+		if methods[0] == "OnBehalfOf" && isEmpty(kcsb.AuthorityId) {
+			return missing(authorityId)
+		}
+	case "KeyVaultCertificate":
+		if isEmpty(kcsb.KeyVaultURL) {
+			return missing(keyVaultURL)
+		}
+		if isEmpty(kcsb.KeyVaultCertificateName) {
+			return missing(keyVaultCertificateName)
+		}
+		if isEmpty(kcsb.AuthorityId) {
+			return missing(authorityId)
+		}
+	case "ApplicationCertificate", "ClientAssertion":
+		if isEmpty(kcsb.AuthorityId) {
+			return missing(authorityId)
+		}
+	case "InteractiveLogin":
+		if !isEmpty(kcsb.TokenCachePersistenceName) && (!isEmpty(kcsb.DomainHint) || kcsb.PromptSelectAccount) {
+			return &AuthConfigError{Methods: methods, Reason: "a domain hint and the account picker can't be used with a persistent token cache"}
+		}
+	case "ManagedIdentity":
+		var ids int
+		for _, id := range []string{kcsb.ManagedServiceIdentity, kcsb.ManagedIdentityObjectID, kcsb.ManagedIdentityResourceID} {
+			if !isEmpty(id) {
+				ids++
+			}
+		}
+		if ids > 1 {
+			return &AuthConfigError{Methods: methods, Reason: "a managed identity can be selected by only one of client id, object id or resource id"}
+		}
+	case "WorkloadIdentity":
+		// azidentity falls back to the variable set by the workload identity webhook.
+		if isEmpty(kcsb.FederationTokenFilePath) && isEmpty(os.Getenv("AZURE_FEDERATED_TOKEN_FILE")) {
+			return missing("a federation token file path")
+		}
+	}
+	return nil
+}
+
+// managedIdentityID returns the user assigned identity to authenticate as, or nil for the system assigned identity.
+func (kcsb *ConnectionStringBuilder) managedIdentityID() azidentity.ManagedIDKind {
+	switch {
+	case !isEmpty(kcsb.ManagedServiceIdentity):
+		return azidentity.ClientID(kcsb.ManagedServiceIdentity)
+	case !isEmpty(kcsb.ManagedIdentityObjectID):
+		return azidentity.ObjectID(kcsb.ManagedIdentityObjectID)
+	case !isEmpty(kcsb.ManagedIdentityResourceID):
+		return azidentity.ResourceID(kcsb.ManagedIdentityResourceID)
+	}
+	return nil
+}
+
+// normalizeDataSource validates the DataSource and returns it in its canonical form: https is inferred when no scheme is
+// given, and trailing slashes are removed. Query strings and fragments are rejected, as they can't be part of a cluster URI.
+func normalizeDataSource(ds string, allowInsecure bool) (string, error) {
+	ds = strings.TrimSpace(ds)
+	if !strings.Contains(ds, "://") {
+		ds = "https://" + ds
+	}
+
+	u, err := url.Parse(ds)
+	if err != nil {
+		return "", kustoErrors.ES(kustoErrors.OpServConn, kustoErrors.KClientArgs, "could not parse the data source(%s): %s", ds, err).SetNoRetry()
+	}
+
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !allowInsecure {
+			return "", kustoErrors.ES(kustoErrors.OpServConn, kustoErrors.KClientArgs,
+				"data source(%s) uses http, which is only allowed when opting in with WithAllowInsecure", ds).SetNoRetry()
+		}
+	default:
+		return "", kustoErrors.ES(kustoErrors.OpServConn, kustoErrors.KClientArgs, "data source(%s) has an unsupported scheme %q", ds, u.Scheme).SetNoRetry()
+	}
+
+	if u.Host == "" {
+		return "", kustoErrors.ES(kustoErrors.OpServConn, kustoErrors.KClientArgs, "data source(%s) has no host", ds).SetNoRetry()
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", kustoErrors.ES(kustoErrors.OpServConn, kustoErrors.KClientArgs, "data source(%s) cannot contain a query string or fragment", ds).SetNoRetry()
+	}
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u.String(), nil
+}
+
 func isEmpty(str string) bool {
 	return strings.TrimSpace(str) == ""
 }
 
+// WithApplicationNameAndVersion Sets the application reported to the service in the x-ms-app header, formatted per the
+// Kusto convention as `App.{name}:{version}`. It is used by both the query and the ingestion clients.
+// If version is empty, it is reported as [none].
+func (kcsb *ConnectionStringBuilder) WithApplicationNameAndVersion(name string, version string) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithApplicationNameAndVersion(name, version))
+}
+
+// TryWithApplicationNameAndVersion is WithApplicationNameAndVersion, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithApplicationNameAndVersion(name string, version string) (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(applicationNameForTracing, name); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.ApplicationForTracing = formatApplicationForTracing(name, version)
+	return kcsb, nil
+}
+
+// WithUserNameForTracing Sets the user reported to the service in the x-ms-user header, instead of the OS user.
+// It is used by both the query and the ingestion clients.
+func (kcsb *ConnectionStringBuilder) WithUserNameForTracing(user string) *ConnectionStringBuilder {
+	return mustBuild(kcsb.TryWithUserNameForTracing(user))
+}
+
+// TryWithUserNameForTracing is WithUserNameForTracing, returning an error instead of panicking on invalid input.
+func (kcsb *ConnectionStringBuilder) TryWithUserNameForTracing(user string) (*ConnectionStringBuilder, error) {
+	if err := requireNonEmpty(userNameForTracing, user); err != nil {
+		return nil, err
+	}
+	kcsb = kcsb.modifiable()
+	kcsb.UserForTracing = user
+	return kcsb, nil
+}
+
 func (kcsb *ConnectionStringBuilder) SetConnectorDetails(name, version, appName, appVersion string, sendUser bool, overrideUser string, additionalFields ...StringPair) {
 	app, user := setConnectorDetails(name, version, appName, appVersion, sendUser, overrideUser, additionalFields...)
 	kcsb.ApplicationForTracing = app
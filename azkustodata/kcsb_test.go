@@ -1,10 +1,23 @@
 package azkustodata
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/stretchr/testify/require"
 	"github.com/tj/assert"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 func TestGetConnectionStringBuilder(t *testing.T) {
@@ -45,7 +58,15 @@ func TestGetConnectionStringBuilder(t *testing.T) {
 				MsiAuthentication:          false,
 				ManagedServiceIdentity:     "",
 				InteractiveLogin:           false,
-				RedirectURL:                "www.google.com",
+				DomainHint:                 "www.google.com",
+			},
+		},
+		{
+			name:             "test_conn_string_defaultauth",
+			connectionString: "https://help.kusto.windows.net;Default Auth=true",
+			want: ConnectionStringBuilder{
+				DataSource:  "https://help.kusto.windows.net",
+				DefaultAuth: true,
 			},
 		},
 	}
@@ -86,8 +107,8 @@ func TestWithAadUserPassAuthErr(t *testing.T) {
 	defer func() {
 		if res := recover(); res == nil {
 			t.Errorf("Should have panic")
-		} else if res != "Error: Password cannot be null" {
-			t.Errorf("Wrong panic message: %s", res)
+		} else if err, ok := res.(error); !ok || err.Error() != "Error: Password cannot be null" {
+			t.Errorf("Wrong panic value: %v", res)
 		}
 	}()
 	NewConnectionStringBuilder("endpoint").WithAadUserPassAuth("userid", "", "authorityID")
@@ -120,12 +141,175 @@ func TestWithWorkloadIdentity(t *testing.T) {
 	assert.EqualValues(t, want, *actual)
 }
 
+func TestWithDeviceCodeAuth(t *testing.T) {
+	want := ConnectionStringBuilder{
+		DataSource:      "endpoint",
+		AuthorityId:     "authorityID",
+		DeviceCodeLogin: true,
+	}
+
+	actual := NewConnectionStringBuilder("endpoint").WithDeviceCodeAuth("authorityID", nil)
+
+	assert.EqualValues(t, want, *actual)
+}
+
+func TestWithClientAssertion(t *testing.T) {
+	actual := NewConnectionStringBuilder("endpoint").WithClientAssertion("authorityID", "clientID", func(context.Context) (string, error) {
+		return "assertion", nil
+	})
+
+	assert.Equal(t, "authorityID", actual.AuthorityId)
+	assert.Equal(t, "clientID", actual.ApplicationClientId)
+	assertion, err := actual.ClientAssertionCallback(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "assertion", assertion)
+
+	assert.Panics(t, func() { NewConnectionStringBuilder("endpoint").WithClientAssertion("authorityID", "clientID", nil) })
+}
+
+func TestWithOnBehalfOf(t *testing.T) {
+	want := ConnectionStringBuilder{
+		DataSource:              "endpoint",
+		AuthorityId:             "authorityID",
+		ApplicationClientId:     "clientID",
+		ApplicationKey:          "secret",
+		OnBehalfOfUserAssertion: "userToken",
+	}
+
+	actual := NewConnectionStringBuilder("endpoint").WithOnBehalfOf("authorityID", "clientID", "secret", "userToken")
+
+	assert.EqualValues(t, want, *actual)
+}
+
+func TestWithAzdCliAuth(t *testing.T) {
+	want := ConnectionStringBuilder{
+		DataSource:  "endpoint",
+		AuthorityId: "authorityID",
+		AzdCli:      true,
+	}
+
+	actual := NewConnectionStringBuilder("endpoint").WithAzdCliAuth("authorityID")
+
+	assert.EqualValues(t, want, *actual)
+}
+
+func TestWithAzCliAuth(t *testing.T) {
+	want := ConnectionStringBuilder{
+		DataSource:        "endpoint",
+		AuthorityId:       "authorityID",
+		AzCliSubscription: "subscription",
+		AzCli:             true,
+	}
+
+	actual := NewConnectionStringBuilder("endpoint").WithAzCliAuth("authorityID", "subscription")
+	assert.EqualValues(t, want, *actual)
+
+	// Switching to another auth method drops the subscription.
+	assert.Equal(t, "", actual.WithAzCli().AzCliSubscription)
+}
+
+func TestWithMethodsReturnCopy(t *testing.T) {
+	template := NewConnectionStringBuilder("https://endpoint").WithAadAppKey("clientID", "secret", "tenantID")
+
+	first := template.WithAzCli()
+	second := template.WithAadAppKey("otherClientID", "otherSecret", "tenantID")
+
+	assert.Equal(t, "clientID", template.ApplicationClientId)
+	assert.False(t, template.AzCli)
+	assert.True(t, first.AzCli)
+	assert.Equal(t, "", first.ApplicationClientId)
+	assert.Equal(t, "otherClientID", second.ApplicationClientId)
+}
+
+func TestWithMethodsMutateInPlace(t *testing.T) {
+	kcsb := NewConnectionStringBuilder("https://endpoint")
+	kcsb.MutateInPlace = true
+
+	actual := kcsb.WithAzCli()
+
+	assert.Same(t, kcsb, actual)
+	assert.True(t, kcsb.AzCli)
+}
+
+func TestClone(t *testing.T) {
+	kcsb := NewConnectionStringBuilder("https://endpoint").WithAppCertificateBytes("clientID", []byte("cert"), []byte("pass"), true, "tenantID")
+
+	clone := kcsb.Clone()
+	assert.EqualValues(t, *kcsb, *clone)
+
+	clone.ApplicationCertificateBytes[0] = 'x'
+	clone.DataSource = "https://other"
+	assert.Equal(t, []byte("cert"), kcsb.ApplicationCertificateBytes)
+	assert.Equal(t, "https://endpoint", kcsb.DataSource)
+}
+
+func TestManagedIdentityID(t *testing.T) {
+	resourceID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/name"
+
+	tests := []struct {
+		name string
+		kcsb *ConnectionStringBuilder
+		want azidentity.ManagedIDKind
+	}{
+		{name: "System", kcsb: NewConnectionStringBuilder("endpoint").WithSystemManagedIdentity(), want: nil},
+		{name: "ClientID", kcsb: NewConnectionStringBuilder("endpoint").WithUserManagedIdentity("clientID"), want: azidentity.ClientID("clientID")},
+		{name: "ObjectID", kcsb: NewConnectionStringBuilder("endpoint").WithUserAssignedIdentityObjectId("objectID"), want: azidentity.ObjectID("objectID")},
+		{name: "ResourceID", kcsb: NewConnectionStringBuilder("endpoint").WithUserAssignedIdentityResourceId(resourceID), want: azidentity.ResourceID(resourceID)},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.kcsb.MsiAuthentication)
+			assert.Equal(t, tt.want, tt.kcsb.managedIdentityID())
+		})
+	}
+
+	assert.Panics(t, func() { NewConnectionStringBuilder("endpoint").WithUserAssignedIdentityObjectId("") })
+	assert.Panics(t, func() { NewConnectionStringBuilder("endpoint").WithUserAssignedIdentityResourceId("") })
+}
+
+func TestWithAdditionallyAllowedTenants(t *testing.T) {
+	s := newTestServ()
+	defer s.close()
+	s.code = 200
+	s.payload = []byte(testCloudMetadata)
+
+	kcsb := NewConnectionStringBuilder(s.urlStr()).WithAadAppKey("clientID", "secret", "tenantID")
+	tenants := []string{"otherTenant", "thirdTenant"}
+
+	actual := kcsb.WithAdditionallyAllowedTenants(tenants...)
+	tenants[0] = "changed"
+
+	assert.Equal(t, []string{"otherTenant", "thirdTenant"}, actual.AdditionallyAllowedTenants)
+	assert.Nil(t, kcsb.AdditionallyAllowedTenants)
+	assert.NotEqual(t, kcsb.credentialIdentity(), actual.credentialIdentity())
+	assert.Panics(t, func() { kcsb.WithAdditionallyAllowedTenants() })
+
+	tkp, err := actual.newTokenProvider()
+	require.NoError(t, err)
+	tkp.SetHttp(s.http.Client())
+	_, err = tkp.initOnce.DoWithInit()
+	require.NoError(t, err)
+	assert.NotNil(t, tkp.tokenCred)
+}
+
+func TestWithApplicationNameAndVersion(t *testing.T) {
+	kcsb := NewConnectionStringBuilder("https://endpoint")
+
+	assert.Equal(t, "App.{my_app}:{2.1.0}", kcsb.WithApplicationNameAndVersion("my app", "2.1.0").ApplicationForTracing)
+	assert.Equal(t, "App.{a_b_c_}:{[none]}", kcsb.WithApplicationNameAndVersion("a|b{c}", "").ApplicationForTracing)
+	assert.Equal(t, "user", kcsb.WithUserNameForTracing("user").UserForTracing)
+	assert.Equal(t, "", kcsb.ApplicationForTracing)
+	assert.Panics(t, func() { kcsb.WithApplicationNameAndVersion("", "1.0") })
+	assert.Panics(t, func() { kcsb.WithUserNameForTracing("") })
+}
+
 func TestWitAadUserTokenErr(t *testing.T) {
 	defer func() {
 		if res := recover(); res == nil {
 			t.Errorf("Should have panic")
-		} else if res != "Error: UserToken cannot be null" {
-			t.Errorf("Wrong panic message: %s", res)
+		} else if err, ok := res.(error); !ok || err.Error() != "Error: UserToken cannot be null" {
+			t.Errorf("Wrong panic value: %v", res)
 		}
 	}()
 	NewConnectionStringBuilder("endpoint").WitAadUserToken("")
@@ -173,6 +357,20 @@ func TestGetTokenProviderHappy(t *testing.T) {
 				MsiAuthentication:      true,
 				ClientOptions:          &azcore.ClientOptions{},
 			},
+		}, {
+			name: "test_tokenprovider_managedidobjectid",
+			kcsb: ConnectionStringBuilder{
+				DataSource:              "https://endpoint/test_tokenprovider_managedidobjectid",
+				ManagedIdentityObjectID: "objectid",
+				MsiAuthentication:       true,
+			},
+		}, {
+			name: "test_tokenprovider_managedidresourceid",
+			kcsb: ConnectionStringBuilder{
+				DataSource:                "https://endpoint/test_tokenprovider_managedidresourceid",
+				ManagedIdentityResourceID: "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/name",
+				MsiAuthentication:         true,
+			},
 		}, {
 			name: "test_tokenprovider_managedidauth2",
 			kcsb: ConnectionStringBuilder{
@@ -188,6 +386,45 @@ func TestGetTokenProviderHappy(t *testing.T) {
 				FederationTokenFilePath: "tokenfilepath",
 				WorkloadAuthentication:  true,
 			},
+		}, {
+			name: "test_tokenprovider_devicecode",
+			kcsb: ConnectionStringBuilder{
+				DataSource:         "https://endpoint/test_tokenprovider_devicecode",
+				DeviceCodeLogin:    true,
+				AuthorityId:        "tenantID",
+				DeviceCodeCallback: func(DeviceCodeMessage) {},
+			},
+		}, {
+			name: "test_tokenprovider_defaultauth",
+			kcsb: ConnectionStringBuilder{
+				DataSource:  "https://endpoint/test_tokenprovider_defaultauth",
+				DefaultAuth: true,
+			},
+		}, {
+			name: "test_tokenprovider_clientassertion",
+			kcsb: ConnectionStringBuilder{
+				DataSource:          "https://endpoint/test_tokenprovider_clientassertion",
+				ApplicationClientId: "clientID",
+				AuthorityId:         "tenantID",
+				ClientAssertionCallback: func(context.Context) (string, error) {
+					return "assertion", nil
+				},
+			},
+		}, {
+			name: "test_tokenprovider_onbehalfof",
+			kcsb: ConnectionStringBuilder{
+				DataSource:              "https://endpoint/test_tokenprovider_onbehalfof",
+				AuthorityId:             "tenantID",
+				ApplicationClientId:     "clientID",
+				ApplicationKey:          "secret",
+				OnBehalfOfUserAssertion: "userToken",
+			},
+		}, {
+			name: "test_tokenprovider_azdcli",
+			kcsb: ConnectionStringBuilder{
+				DataSource: "https://endpoint/test_tokenprovider_azdcli",
+				AzdCli:     true,
+			},
 		}, {
 			name: "test_tokenprovider_usertoken",
 			kcsb: ConnectionStringBuilder{
@@ -214,3 +451,317 @@ func TestGetTokenProviderHappy(t *testing.T) {
 	}
 
 }
+
+func newTestCertificate(t *testing.T, notAfter time.Time) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func newTestCertificatePEM(t *testing.T, notAfter time.Time) []byte {
+	cert, key := newTestCertificate(t, notAfter)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return append(certPEM, keyPEM...)
+}
+
+func TestWithAppCertificatePassword(t *testing.T) {
+	s := newTestServ()
+	defer s.close()
+	s.code = 200
+	s.payload = []byte(testCloudMetadata)
+
+	// Modern uses AES-256 encryption, which is what OpenSSL 3 and Key Vault produce by default.
+	cert, key := newTestCertificate(t, time.Now().Add(time.Hour))
+	pfx, err := pkcs12.Modern.Encode(key, cert, nil, "pfxPassword")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "cert.pfx")
+	require.NoError(t, os.WriteFile(path, pfx, 0600))
+
+	kcsb := NewConnectionStringBuilder(s.urlStr()).WithAppCertificatePassword("clientID", path, "pfxPassword", false, "tenantID")
+	assert.Equal(t, path, kcsb.ApplicationCertificatePath)
+	assert.Equal(t, []byte("pfxPassword"), kcsb.ApplicationCertificatePassword)
+
+	tkp, err := kcsb.newTokenProvider()
+	require.NoError(t, err)
+	tkp.SetHttp(s.http.Client())
+	_, err = tkp.initOnce.DoWithInit()
+	require.NoError(t, err)
+	assert.NotNil(t, tkp.tokenCred)
+
+	tkp, err = NewConnectionStringBuilder(s.urlStr()).WithAppCertificatePassword("clientID", path, "wrongPassword", false, "tenantID").newTokenProvider()
+	require.NoError(t, err)
+	tkp.SetHttp(s.http.Client())
+	_, err = tkp.initOnce.DoWithInit()
+	require.ErrorContains(t, err, "password")
+
+	assert.Panics(t, func() {
+		NewConnectionStringBuilder(s.urlStr()).WithAppCertificatePassword("clientID", path, "", false, "tenantID")
+	})
+}
+
+func TestWithAppCertificateBytes(t *testing.T) {
+	s := newTestServ()
+	defer s.close()
+	s.code = 200
+	s.payload = []byte(testCloudMetadata)
+
+	certBytes := newTestCertificatePEM(t, time.Now().Add(time.Hour))
+	kcsb := NewConnectionStringBuilder(s.urlStr()).WithAppCertificateBytes("clientID", certBytes, nil, true, "tenantID")
+	assert.Equal(t, certBytes, kcsb.ApplicationCertificateBytes)
+	assert.Equal(t, "", kcsb.ApplicationCertificatePath)
+	assert.True(t, kcsb.SendCertificateChain)
+
+	tkp, err := kcsb.newTokenProvider()
+	require.NoError(t, err)
+	tkp.SetHttp(s.http.Client())
+
+	_, err = tkp.initOnce.DoWithInit()
+	require.NoError(t, err)
+	assert.NotNil(t, tkp.tokenCred)
+}
+
+func TestWithAppCertificateBytesInvalid(t *testing.T) {
+	s := newTestServ()
+	defer s.close()
+	s.code = 200
+	s.payload = []byte(testCloudMetadata)
+
+	tkp, err := NewConnectionStringBuilder(s.urlStr()).WithAppCertificateBytes("clientID", []byte("not a certificate"), nil, false, "tenantID").newTokenProvider()
+	require.NoError(t, err)
+	tkp.SetHttp(s.http.Client())
+
+	_, err = tkp.initOnce.DoWithInit()
+	assert.Error(t, err)
+}
+
+func TestToConnectionString(t *testing.T) {
+	kcsb := NewConnectionStringBuilder("https://help.kusto.windows.net").WithAadAppKey("clientID", "se;cr=et", "tenantID")
+
+	assert.Equal(t, "Data Source=https://help.kusto.windows.net;Application Client Id=clientID;Application Key=****;Authority Id=tenantID", kcsb.String())
+	assert.Equal(t, `Data Source=https://help.kusto.windows.net;Application Client Id=clientID;Application Key="se;cr=et";Authority Id=tenantID`,
+		kcsb.ToConnectionString(false))
+}
+
+func TestToConnectionStringRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		kcsb *ConnectionStringBuilder
+	}{
+		{
+			name: "userpass",
+			kcsb: NewConnectionStringBuilder("https://help.kusto.windows.net/Samples").WithAadUserPassAuth("user", `pa"ss=word`, "tenantID"),
+		},
+		{
+			name: "usertoken",
+			kcsb: NewConnectionStringBuilder("https://help.kusto.windows.net").WitAadUserToken("dG9rZW4="),
+		},
+		{
+			name: "interactive",
+			kcsb: NewConnectionStringBuilder("https://help.kusto.windows.net").WithInteractiveLogin("tenantID"),
+		},
+		{
+			name: "defaultauth",
+			kcsb: NewConnectionStringBuilder("https://help.kusto.windows.net").WithDefaultAzureCredential(),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			parsed := NewConnectionStringBuilder(test.kcsb.ToConnectionString(false))
+			parsed.ApplicationForTracing = test.kcsb.ApplicationForTracing
+			parsed.UserForTracing = test.kcsb.UserForTracing
+			assert.EqualValues(t, *test.kcsb, *parsed)
+		})
+	}
+}
+
+func TestConnectionStringAliases(t *testing.T) {
+	actual := NewConnectionStringBuilder("Server=https://help.kusto.windows.net;Database=Samples;Fed=True;AppClientId=clientID;AppKey=key;TenantId=tenant;SendX5c=true;TraceAppName=app;Trace User Name=user")
+
+	assert.EqualValues(t, ConnectionStringBuilder{
+		DataSource:            "https://help.kusto.windows.net",
+		InitialCatalog:        "Samples",
+		FederatedSecurity:     true,
+		ApplicationClientId:   "clientID",
+		ApplicationKey:        "key",
+		AuthorityId:           "tenant",
+		SendCertificateChain:  true,
+		ApplicationForTracing: "app",
+		UserForTracing:        "user",
+	}, *actual)
+
+	for _, key := range []string{"Application Client Id", "applicationclientid", "APPLICATION  CLIENTID", "appClientId"} {
+		assert.Equal(t, "clientID", NewConnectionStringBuilder("https://help.kusto.windows.net;"+key+"=clientID").ApplicationClientId, key)
+	}
+
+	// Keywords without an equivalent in this SDK, but harmless to ignore, are accepted.
+	for _, key := range []string{"Application Certificate Thumbprint", "AppCert", "TrustedIssuers", "Trusted Issuers"} {
+		actual, err := ParseConnectionString("https://help.kusto.windows.net;Fed=true;" + key + "=x")
+		require.NoError(t, err, key)
+		assert.Equal(t, ConnectionStringBuilder{DataSource: "https://help.kusto.windows.net", FederatedSecurity: true}, *actual, key)
+	}
+}
+
+func TestConnectionStringUnknownKeywords(t *testing.T) {
+	defer func() {
+		res := recover()
+		keywordsErr, ok := res.(*KeywordsError)
+		if !ok {
+			t.Fatalf("Wrong panic value: %v", res)
+		}
+		assert.Equal(t, []string{"Bogus", "Other Bogus"}, keywordsErr.Unknown)
+		assert.Equal(t, []string{"dSTS Federated Security"}, keywordsErr.Unsupported)
+	}()
+	NewConnectionStringBuilder("https://help.kusto.windows.net;Bogus=1;dSTS Federated Security=true;Application Certificate Thumbprint=abc;Other Bogus=")
+}
+
+func TestParseConnectionString(t *testing.T) {
+	kcsb, err := ParseConnectionString("https://help.kusto.windows.net;Initial Catalog=Samples")
+	require.NoError(t, err)
+	assert.Equal(t, "https://help.kusto.windows.net", kcsb.DataSource)
+	assert.Equal(t, "Samples", kcsb.InitialCatalog)
+
+	_, err = ParseConnectionString(" ")
+	assert.EqualError(t, err, "error: Connection string cannot be empty")
+	assert.PanicsWithError(t, "error: Connection string cannot be empty", func() { NewConnectionStringBuilder(" ") })
+
+	_, err = ParseConnectionString("https://help.kusto.windows.net;Bogus=1")
+	var keywordsErr *KeywordsError
+	require.ErrorAs(t, err, &keywordsErr)
+	assert.Equal(t, []string{"Bogus"}, keywordsErr.Unknown)
+}
+
+func TestTryWithMethods(t *testing.T) {
+	kcsb := NewConnectionStringBuilder("https://endpoint")
+	noDataSource := &ConnectionStringBuilder{}
+
+	tests := []struct {
+		name string
+		call func() (*ConnectionStringBuilder, error)
+		want string
+	}{
+		{name: "UserPass", call: func() (*ConnectionStringBuilder, error) { return kcsb.TryWithAadUserPassAuth("user", "", "") }, want: "Error: Password cannot be null"},
+		{name: "AppKey", call: func() (*ConnectionStringBuilder, error) { return kcsb.TryWithAadAppKey("clientID", "key", "") }, want: "Error: AuthorityId cannot be null"},
+		{name: "NoDataSource", call: func() (*ConnectionStringBuilder, error) { return noDataSource.TryWithAzCli() }, want: "Error: DataSource cannot be null"},
+		{name: "CertificateBytes", call: func() (*ConnectionStringBuilder, error) {
+			return kcsb.TryWithAppCertificateBytes("clientID", nil, nil, false, "tenantID")
+		}, want: "error: Certificate cannot be null"},
+		{name: "TokenCredential", call: func() (*ConnectionStringBuilder, error) { return kcsb.TryWithTokenCredential(nil) }, want: "error: TokenCredential cannot be null"},
+		{name: "TokenScope", call: func() (*ConnectionStringBuilder, error) { return kcsb.TryWithTokenScope("") }, want: "Error: TokenScope cannot be null"},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := tt.call()
+			assert.Nil(t, actual)
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+
+	actual, err := kcsb.TryWithAadAppKey("clientID", "key", "tenantID")
+	require.NoError(t, err)
+	assert.Equal(t, "clientID", actual.ApplicationClientId)
+	assert.Equal(t, "", kcsb.ApplicationClientId)
+}
+
+func TestValidate(t *testing.T) {
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", "")
+
+	tests := []struct {
+		name    string
+		kcsb    *ConnectionStringBuilder
+		reason  string
+		methods []string
+	}{
+		{name: "None", kcsb: NewConnectionStringBuilder("https://endpoint")},
+		{name: "AppKey", kcsb: NewConnectionStringBuilder("https://endpoint").WithAadAppKey("clientID", "key", "tenantID")},
+		{name: "OnBehalfOf", kcsb: NewConnectionStringBuilder("https://endpoint").WithOnBehalfOf("tenantID", "clientID", "key", "assertion")},
+		{
+			name:    "Conflict",
+			kcsb:    NewConnectionStringBuilder("https://endpoint;Application Client Id=clientID;Application Key=key;Authority Id=tenantID;Default Auth=true"),
+			reason:  `conflicting authentication methods ["ApplicationKey" "DefaultAuth"], only one can be used`,
+			methods: []string{"ApplicationKey", "DefaultAuth"},
+		},
+		// Application keys without a tenant use the first party tenant of the cluster.
+		{name: "AppKeyWithoutAuthority", kcsb: NewConnectionStringBuilder("https://endpoint;Application Client Id=clientID;Application Key=key")},
+		{name: "AppKeyAliasesWithoutAuthority", kcsb: NewConnectionStringBuilder("https://endpoint;AppClientId=clientID;AppKey=key")},
+		{
+			name: "OnBehalfOfMissingAuthority",
+			kcsb: &ConnectionStringBuilder{
+				DataSource:              "https://endpoint",
+				ApplicationClientId:     "clientID",
+				ApplicationKey:          "key",
+				OnBehalfOfUserAssertion: "assertion",
+			},
+			reason:  "OnBehalfOf authentication requires AuthorityId",
+			methods: []string{"OnBehalfOf"},
+		},
+		{
+			name:    "MissingPassword",
+			kcsb:    NewConnectionStringBuilder("https://endpoint;AAD User ID=user"),
+			reason:  "UserPassword authentication requires Password",
+			methods: []string{"UserPassword"},
+		},
+		{
+			name:    "WorkloadWithoutTokenFile",
+			kcsb:    NewConnectionStringBuilder("https://endpoint").WithKubernetesWorkloadIdentity("clientID", "", "tenantID"),
+			reason:  "WorkloadIdentity authentication requires a federation token file path",
+			methods: []string{"WorkloadIdentity"},
+		},
+		{
+			name: "ManagedIdentityTwoIDs",
+			kcsb: func() *ConnectionStringBuilder {
+				kcsb := NewConnectionStringBuilder("https://endpoint").WithUserManagedIdentity("clientID")
+				kcsb.ManagedIdentityObjectID = "objectID"
+				return kcsb
+			}(),
+			reason:  "a managed identity can be selected by only one of client id, object id or resource id",
+			methods: []string{"ManagedIdentity"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.kcsb.Validate()
+			if tt.reason == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var configErr *AuthConfigError
+			require.ErrorAs(t, err, &configErr)
+			assert.Equal(t, tt.reason, configErr.Reason)
+			assert.Equal(t, tt.methods, configErr.Methods)
+		})
+	}
+}
+
+func TestAppKeyWithoutAuthorityTokenProvider(t *testing.T) {
+	kcsb := NewConnectionStringBuilder("https://endpoint;AppClientId=clientID;AppKey=key")
+	tkp, err := kcsb.newTokenProvider()
+	require.NoError(t, err)
+	assert.True(t, tkp.AuthorizationRequired())
+	assert.NoError(t, kcsb.Validate())
+}
+
+func TestValidateBeforeAcquireToken(t *testing.T) {
+	tkp, err := NewConnectionStringBuilder("https://endpoint;AAD User ID=user").newTokenProvider()
+	require.NoError(t, err)
+	assert.True(t, tkp.AuthorizationRequired())
+
+	_, _, err = tkp.AcquireToken(context.Background())
+	var configErr *AuthConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "UserPassword authentication requires Password", configErr.Reason)
+}
@@ -0,0 +1,96 @@
+package azkustodata
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const keepAliveTestResponse = `[{"FrameType":"DataSetHeader","IsProgressive":true,"Version":"v2.0","IsFragmented":true,"ErrorReportingPlacement":"EndOfTable"}
+,{"FrameType":"TableProgress","TableId":1,"TableProgress":12.5}
+,{"FrameType":"TableHeader","TableId":1,"TableKind":"PrimaryResult","TableName":"T","Columns":[{"ColumnName":"x","ColumnType":"long"}]}
+,{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[[1],[2]]}
+,{"FrameType":"TableProgress","TableId":1,"TableProgress":100}
+,{"FrameType":"TableCompletion","TableId":1,"RowCount":2}
+,{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}
+]`
+
+func TestKeepAlive(t *testing.T) {
+	var properties map[string]interface{}
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Properties struct {
+				Options map[string]interface{} `json:"Options"`
+			} `json:"properties"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		properties = msg.Properties.Options
+		_, _ = w.Write([]byte(keepAliveTestResponse))
+	})
+
+	client := newTestKustoClient(t, srv)
+
+	var lock sync.Mutex
+	var heartbeats []query.Heartbeat
+	start := time.Now()
+	ds, err := client.Query(context.Background(), "db", kql.New("T"), KeepAlive(5*time.Second, func(h query.Heartbeat) {
+		lock.Lock()
+		defer lock.Unlock()
+		heartbeats = append(heartbeats, h)
+	}))
+	require.NoError(t, err)
+	require.Len(t, ds.Tables(), 1)
+	assert.Len(t, ds.Tables()[0].Rows(), 2)
+
+	assert.Equal(t, true, properties[ResultsProgressiveEnabledValue])
+	assert.Equal(t, "00:00:05", properties[QueryResultsProgressiveUpdatePeriodValue])
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Len(t, heartbeats, 7)
+	assert.Equal(t, "DataSetHeader", heartbeats[0].FrameType)
+	assert.Equal(t, "TableProgress", heartbeats[1].FrameType)
+	assert.Equal(t, 12.5, heartbeats[1].Progress)
+	assert.Equal(t, 100.0, heartbeats[4].Progress)
+	assert.False(t, heartbeats[6].Received.Before(start))
+
+	_, err = client.Query(context.Background(), "db", kql.New("T"), KeepAlive(0, nil))
+	assert.Error(t, err)
+}
+
+func TestProgressiveResults(t *testing.T) {
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(keepAliveTestResponse))
+	})
+
+	client := newTestKustoClient(t, srv)
+
+	var lock sync.Mutex
+	var progress []query.Progress
+	heartbeats := 0
+	_, err := client.Query(context.Background(), "db", kql.New("T"),
+		ProgressiveResults(func(p query.Progress) {
+			lock.Lock()
+			defer lock.Unlock()
+			progress = append(progress, p)
+		}),
+		KeepAlive(time.Second, func(query.Heartbeat) {
+			lock.Lock()
+			defer lock.Unlock()
+			heartbeats++
+		}))
+	require.NoError(t, err)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []query.Progress{{TableID: 1, Percent: 12.5}, {TableID: 1, Percent: 100}}, progress)
+	assert.Equal(t, 7, heartbeats)
+}
@@ -0,0 +1,125 @@
+package azkustodata
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	kustoErrors "github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+// keyVaultCertificateRefreshWindow is how long before the certificate expires it is fetched again from Key Vault.
+const keyVaultCertificateRefreshWindow = 24 * time.Hour
+
+const pkcs12ContentType = "application/x-pkcs12"
+
+// certificateFetcher returns the raw bytes of a certificate and its private key, in PEM or PKCS#12 format.
+type certificateFetcher func(ctx context.Context) ([]byte, error)
+
+// keyVaultCertificateCredential authenticates an application with a client certificate stored in Azure Key Vault.
+// The certificate is fetched lazily, and fetched again when it nears its expiry, so rotations in Key Vault are picked up
+// without restarting the process.
+type keyVaultCertificateCredential struct {
+	tenantID      string
+	clientID      string
+	sendCertChain bool
+	clientOptions azcore.ClientOptions
+	fetch         certificateFetcher
+	refreshWindow time.Duration
+	now           func() time.Time
+
+	additionallyAllowedTenants []string
+
+	lock     sync.Mutex
+	cred     azcore.TokenCredential
+	notAfter time.Time
+}
+
+func newKeyVaultCertificateCredential(tenantID, clientID string, sendCertChain bool, clientOptions azcore.ClientOptions, fetch certificateFetcher) *keyVaultCertificateCredential {
+	return &keyVaultCertificateCredential{
+		tenantID:      tenantID,
+		clientID:      clientID,
+		sendCertChain: sendCertChain,
+		clientOptions: clientOptions,
+		fetch:         fetch,
+		refreshWindow: keyVaultCertificateRefreshWindow,
+		now:           time.Now,
+	}
+}
+
+// GetToken implements azcore.TokenCredential.
+func (k *keyVaultCertificateCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	cred, err := k.credential(ctx)
+	if err != nil {
+		return azcore.AccessToken{}, err
+	}
+	return cred.GetToken(ctx, options)
+}
+
+func (k *keyVaultCertificateCredential) credential(ctx context.Context) (azcore.TokenCredential, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	now := k.now()
+	if k.cred != nil && now.Before(k.notAfter.Add(-k.refreshWindow)) {
+		return k.cred, nil
+	}
+
+	bytes, err := k.fetch(ctx)
+	if err != nil {
+		// Keep using the current certificate until it actually expires, Key Vault may just be temporarily unavailable.
+		if k.cred != nil && now.Before(k.notAfter) {
+			return k.cred, nil
+		}
+		return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther,
+			fmt.Errorf("error: Couldn't fetch certificate from Key Vault: %s", err))
+	}
+
+	certs, key, err := parseCertificates(bytes, nil)
+	if err != nil {
+		return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther, err)
+	}
+
+	opts := &azidentity.ClientCertificateCredentialOptions{ClientOptions: k.clientOptions}
+	opts.SendCertificateChain = k.sendCertChain
+	opts.AdditionallyAllowedTenants = k.additionallyAllowedTenants
+	cred, err := azidentity.NewClientCertificateCredential(k.tenantID, k.clientID, certs, key, opts)
+	if err != nil {
+		return nil, kustoErrors.E(kustoErrors.OpTokenProvider, kustoErrors.KOther,
+			fmt.Errorf("error: Couldn't retrieve client credentials using Key Vault Certificate: %s", err))
+	}
+
+	k.cred = cred
+	k.notAfter = certs[0].NotAfter
+	return k.cred, nil
+}
+
+// keyVaultSecretFetcher returns a certificateFetcher that reads the secret backing a Key Vault certificate, which holds
+// both the certificate and its private key.
+func keyVaultSecretFetcher(vaultURL string, certName string, credential azcore.TokenCredential, clientOptions azcore.ClientOptions) (certificateFetcher, error) {
+	client, err := azsecrets.NewClient(vaultURL, credential, &azsecrets.ClientOptions{ClientOptions: clientOptions})
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) ([]byte, error) {
+		resp, err := client.GetSecret(ctx, certName, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Value == nil {
+			return nil, fmt.Errorf("secret for certificate %q has no value", certName)
+		}
+
+		if resp.ContentType != nil && *resp.ContentType == pkcs12ContentType {
+			return base64.StdEncoding.DecodeString(*resp.Value)
+		}
+		return []byte(*resp.Value), nil
+	}, nil
+}
@@ -0,0 +1,76 @@
+package azkustodata
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyVaultCertificateCredentialRotation(t *testing.T) {
+	now := time.Now()
+	notAfter := now.Add(48 * time.Hour)
+	fetches := 0
+	var fetchErr error
+
+	k := newKeyVaultCertificateCredential("tenantID", "clientID", false, azcore.ClientOptions{}, func(ctx context.Context) ([]byte, error) {
+		fetches++
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return newTestCertificatePEM(t, notAfter), nil
+	})
+	k.now = func() time.Time { return now }
+
+	first, err := k.credential(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetches)
+
+	// Far from expiry - the certificate is reused.
+	second, err := k.credential(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetches)
+	assert.Same(t, first, second)
+
+	// Inside the refresh window - the certificate is fetched again.
+	now = notAfter.Add(-time.Hour)
+	third, err := k.credential(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, fetches)
+	assert.NotSame(t, first, third)
+
+	// Key Vault is unavailable, but the current certificate is still valid.
+	fetchErr = errors.New("unavailable")
+	fourth, err := k.credential(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, fetches)
+	assert.Same(t, third, fourth)
+
+	// Key Vault is unavailable, and the certificate expired.
+	now = notAfter.Add(time.Minute)
+	_, err = k.credential(context.Background())
+	assert.ErrorContains(t, err, "unavailable")
+}
+
+func TestWithKeyVaultCertificate(t *testing.T) {
+	want := ConnectionStringBuilder{
+		DataSource:              "endpoint",
+		ApplicationClientId:     "clientID",
+		AuthorityId:             "authorityID",
+		KeyVaultURL:             "https://vault.vault.azure.net",
+		KeyVaultCertificateName: "cert",
+		SendCertificateChain:    true,
+	}
+
+	actual := NewConnectionStringBuilder("endpoint").WithKeyVaultCertificate("clientID", "https://vault.vault.azure.net", "cert", true, "authorityID")
+
+	assert.EqualValues(t, want, *actual)
+
+	tkp, err := actual.newTokenProvider()
+	require.NoError(t, err)
+	assert.True(t, tkp.AuthorizationRequired())
+}
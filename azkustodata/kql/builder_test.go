@@ -129,6 +129,13 @@ func TestBuilder(t *testing.T) {
 				AddColumn("b\na\nz").AddLiteral(" == ").
 				AddFunction("f_u_n\u1234c").AddLiteral("()"),
 			`database("f\"\"o").["b\\a\\r"] | where ["b\na\nz"] == ["f_u_n\u1234c"]()`},
+		{
+			"Test add cluster",
+			New("").
+				AddCluster("https://help.kusto.windows.net").AddLiteral(".").
+				AddDatabase("Samples").AddLiteral(".").
+				AddTable("StormEvents"),
+			`cluster("https://help.kusto.windows.net").database("Samples").StormEvents`},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
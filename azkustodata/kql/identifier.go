@@ -1,6 +1,15 @@
 package kql
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// AddCluster adds a reference to another cluster, by name or URL, such as cluster("help"), to be followed by a database.
+func (b *Builder) AddCluster(cluster string) *Builder {
+	return b.addBase(stringConstant(fmt.Sprintf("%s(%s)", "cluster", QuoteString(cluster, false))))
+}
 
 func (b *Builder) AddDatabase(database string) *Builder {
 	return b.addBase(stringConstant(fmt.Sprintf("%s(%s)", "database", QuoteString(database, false))))
@@ -37,3 +46,16 @@ func NormalizeName(name string) string {
 
 	return "[" + QuoteString(name, false) + "]"
 }
+
+// AddFunctionCall adds a call to a function, such as a stored function, with its arguments rendered as typed literals,
+// such as MyFunction("text", long(5)). Go values can be converted to arguments with Value.
+func (b *Builder) AddFunctionCall(function string, args ...value.Kusto) *Builder {
+	b.AddFunction(function).AddLiteral("(")
+	for i, arg := range args {
+		if i > 0 {
+			b.AddLiteral(", ")
+		}
+		b.AddValue(arg)
+	}
+	return b.AddLiteral(")")
+}
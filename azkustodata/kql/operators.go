@@ -0,0 +1,172 @@
+package kql
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// This file holds typed helpers adding common tabular operators to a Builder. Column names are quoted as needed, and
+// values are added as literals, so they are safe to use with user input:
+//
+//	query := kql.New("StormEvents").
+//		Where("State", kql.Equal, value.NewString(state)).
+//		Summarize([]kql.Aggregation{kql.Count().As("Events")}, "EventType").
+//		Top(10, "Events", kql.Desc)
+
+// Comparison is a comparison operator of a Where operator.
+type Comparison struct {
+	op string
+}
+
+var (
+	Equal          = Comparison{"=="}
+	NotEqual       = Comparison{"!="}
+	Less           = Comparison{"<"}
+	LessOrEqual    = Comparison{"<="}
+	Greater        = Comparison{">"}
+	GreaterOrEqual = Comparison{">="}
+	Has            = Comparison{"has"}
+	NotHas         = Comparison{"!has"}
+	Contains       = Comparison{"contains"}
+	NotContains    = Comparison{"!contains"}
+	StartsWith     = Comparison{"startswith"}
+	EndsWith       = Comparison{"endswith"}
+	// EqualCaseInsensitive compares strings ignoring their case.
+	EqualCaseInsensitive = Comparison{"=~"}
+)
+
+// SortOrder is the order of a Top operator.
+type SortOrder struct {
+	order string
+}
+
+var (
+	Asc  = SortOrder{"asc"}
+	Desc = SortOrder{"desc"}
+)
+
+// JoinKind is the flavor of a Join operator.
+type JoinKind struct {
+	kind string
+}
+
+var (
+	JoinInnerUnique = JoinKind{"innerunique"}
+	JoinInner       = JoinKind{"inner"}
+	JoinLeftOuter   = JoinKind{"leftouter"}
+	JoinRightOuter  = JoinKind{"rightouter"}
+	JoinFullOuter   = JoinKind{"fullouter"}
+	JoinLeftAnti    = JoinKind{"leftanti"}
+	JoinRightAnti   = JoinKind{"rightanti"}
+	JoinLeftSemi    = JoinKind{"leftsemi"}
+	JoinRightSemi   = JoinKind{"rightsemi"}
+)
+
+// Aggregation is an aggregation function of a Summarize operator, such as Count() or Sum("Column").
+type Aggregation struct {
+	name     string
+	function string
+	column   string
+}
+
+func newAggregation(function string, column string) Aggregation {
+	return Aggregation{function: function, column: column}
+}
+
+// Count counts the rows of each group.
+func Count() Aggregation { return newAggregation("count", "") }
+
+// Sum sums a column.
+func Sum(column string) Aggregation { return newAggregation("sum", column) }
+
+// Avg averages a column.
+func Avg(column string) Aggregation { return newAggregation("avg", column) }
+
+// Min returns the minimum of a column.
+func Min(column string) Aggregation { return newAggregation("min", column) }
+
+// Max returns the maximum of a column.
+func Max(column string) Aggregation { return newAggregation("max", column) }
+
+// DCount counts the distinct values of a column.
+func DCount(column string) Aggregation { return newAggregation("dcount", column) }
+
+// As names the column of the aggregation.
+func (a Aggregation) As(name string) Aggregation {
+	a.name = name
+	return a
+}
+
+func (a Aggregation) String() string {
+	var b strings.Builder
+	if a.name != "" {
+		b.WriteString(NormalizeName(a.name))
+		b.WriteString(" = ")
+	}
+	b.WriteString(a.function)
+	b.WriteString("(")
+	b.WriteString(NormalizeName(a.column))
+	b.WriteString(")")
+	return b.String()
+}
+
+// addOperator starts a new tabular operator.
+func (b *Builder) addOperator(operator stringConstant) *Builder {
+	return b.AddLiteral("\n| ").AddLiteral(operator)
+}
+
+func (b *Builder) addColumns(columns []string) *Builder {
+	for i, column := range columns {
+		if i > 0 {
+			b.AddLiteral(", ")
+		}
+		b.AddColumn(column)
+	}
+	return b
+}
+
+// Where adds a where operator, keeping the rows whose column compares to v.
+func (b *Builder) Where(column string, comparison Comparison, v value.Kusto) *Builder {
+	return b.addOperator("where ").AddColumn(column).
+		AddLiteral(" ").addBase(stringConstant(comparison.op)).AddLiteral(" ").
+		AddValue(v)
+}
+
+// Project adds a project operator, keeping only the given columns.
+func (b *Builder) Project(columns ...string) *Builder {
+	return b.addOperator("project ").addColumns(columns)
+}
+
+// Extend adds an extend operator, adding a column computed by an expression, itself built with a Builder.
+func (b *Builder) Extend(column string, expression *Builder) *Builder {
+	return b.addOperator("extend ").AddColumn(column).AddLiteral(" = ").addBase(expression)
+}
+
+// Summarize adds a summarize operator, aggregating the rows by the given columns, or all the rows if there are none.
+func (b *Builder) Summarize(aggregations []Aggregation, by ...string) *Builder {
+	b.addOperator("summarize ")
+	for i, aggregation := range aggregations {
+		if i > 0 {
+			b.AddLiteral(", ")
+		}
+		b.addBase(aggregation)
+	}
+	if len(by) > 0 {
+		b.AddLiteral(" by ").addColumns(by)
+	}
+	return b
+}
+
+// Top adds a top operator, keeping the first n rows sorted by a column.
+func (b *Builder) Top(n int64, by string, order SortOrder) *Builder {
+	return b.addOperator("top ").addBase(stringConstant(strconv.FormatInt(n, 10))).
+		AddLiteral(" by ").AddColumn(by).AddLiteral(" ").addBase(stringConstant(order.order))
+}
+
+// Join adds a join operator, joining the rows with the results of another query on the given columns.
+func (b *Builder) Join(kind JoinKind, right *Builder, on ...string) *Builder {
+	return b.addOperator("join kind=").addBase(stringConstant(kind.kind)).
+		AddLiteral(" (").addBase(right).AddLiteral(") on ").addColumns(on)
+}
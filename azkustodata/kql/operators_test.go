@@ -0,0 +1,67 @@
+package kql
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperators(t *testing.T) {
+	tests := []struct {
+		name     string
+		b        *Builder
+		expected string
+	}{
+		{
+			"Where",
+			New("T").Where("State", Equal, value.NewString("TEXAS\" | take 1")).Where("Injuries", GreaterOrEqual, value.NewLong(2)),
+			"T\n| where State == \"TEXAS\\\" | take 1\"\n| where Injuries >= long(2)",
+		},
+		{
+			"Where quotes columns",
+			New("T").Where("a b", Has, value.NewString("x")),
+			"T\n| where [\"a b\"] has \"x\"",
+		},
+		{
+			"FunctionCall",
+			New("").AddFunctionCall("Events by state", value.NewString("TEXAS\""), value.NewLong(5)).Project("a"),
+			"[\"Events by state\"](\"TEXAS\\\"\", long(5))\n| project a",
+		},
+		{
+			"Project",
+			New("T").Project("a", "b c"),
+			"T\n| project a, [\"b c\"]",
+		},
+		{
+			"Extend",
+			New("T").Extend("Total", New("").AddColumn("a").AddLiteral(" + ").AddLong(1)),
+			"T\n| extend Total = a + long(1)",
+		},
+		{
+			"Summarize",
+			New("T").Summarize([]Aggregation{Count().As("Events"), Sum("Damage"), DCount("x y").As("n")}, "State", "EventType"),
+			"T\n| summarize Events = count(), sum(Damage), n = dcount([\"x y\"]) by State, EventType",
+		},
+		{
+			"Summarize without grouping",
+			New("T").Summarize([]Aggregation{Max("a")}),
+			"T\n| summarize max(a)",
+		},
+		{
+			"Top",
+			New("T").Top(10, "Damage", Desc),
+			"T\n| top 10 by Damage desc",
+		},
+		{
+			"Join",
+			New("T").Join(JoinLeftOuter, New("U").Project("Id", "Name"), "Id"),
+			"T\n| join kind=leftouter (U\n| project Id, Name) on Id",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.b.String())
+		})
+	}
+}
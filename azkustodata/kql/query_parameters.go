@@ -8,8 +8,12 @@ import (
 	"time"
 )
 
+// Parameters are the typed values of the parameters of a query. They are sent in the request's properties, and declared
+// in a `declare query_parameters(...)` statement prepended to the query, so user input never becomes part of the query
+// text. Parameters are declared in the order they were first added.
 type Parameters struct {
 	parameters map[string]value.Kusto
+	keys       []string
 }
 
 func NewParameters() *Parameters {
@@ -23,6 +27,9 @@ func (q *Parameters) AddValue(key string, v value.Kusto) *Parameters {
 	if RequiresQuoting(key) {
 		panic("Invalid parameter values. make sure to adhere to KQL entity name conventions and escaping rules.")
 	}
+	if _, ok := q.parameters[key]; !ok {
+		q.keys = append(q.keys, key)
+	}
 	q.parameters[key] = v
 	return q
 }
@@ -84,15 +91,13 @@ func (q *Parameters) ToDeclarationString() string {
 
 	build.WriteString(declare)
 
-	comma := len(q.parameters)
-	for key, paramVals := range q.parameters {
-		build.WriteString(key)
-		build.WriteString(":")
-		build.WriteString(string(paramVals.GetType()))
-		if comma > 1 {
+	for i, key := range q.keys {
+		if i > 0 {
 			build.WriteString(", ")
 		}
-		comma--
+		build.WriteString(key)
+		build.WriteString(":")
+		build.WriteString(string(q.parameters[key].GetType()))
 	}
 	build.WriteString(closeStmt)
 	return build.String()
@@ -108,4 +113,5 @@ func (q *Parameters) ToParameterCollection() map[string]string {
 // Reset resets the parameters map
 func (q *Parameters) Reset() {
 	q.parameters = make(map[string]value.Kusto)
+	q.keys = nil
 }
@@ -1,10 +1,12 @@
 package kql
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
+	"math"
 	"strings"
 	"testing"
 	"time"
@@ -129,3 +131,69 @@ func TestQueryParameters(t *testing.T) {
 		})
 	}
 }
+
+func TestQueryParametersDeclarationOrder(t *testing.T) {
+	qp := NewParameters().
+		AddString("foo", "bar").
+		AddInt("num", 1).
+		AddTimespan("span", time.Minute).
+		AddString("foo", "baz")
+
+	require.Equal(t, "declare query_parameters(foo:string, num:int, span:timespan);", qp.ToDeclarationString())
+	require.Equal(t, map[string]string{"foo": `"baz"`, "num": "int(1)", "span": "timespan(00:01:00.0000000)"}, qp.ToParameterCollection())
+
+	qp.Reset()
+	require.Equal(t, "", qp.ToDeclarationString())
+	require.Equal(t, "declare query_parameters(num:long);", qp.AddLong("num", 1).ToDeclarationString())
+}
+
+func TestValue(t *testing.T) {
+	type tag struct {
+		Name  string `json:"name"`
+		Score float64
+	}
+	nested := struct {
+		Tags  []tag
+		Attrs map[string]interface{}
+	}{
+		Tags:  []tag{{Name: "<a & b>", Score: 1.5}, {Name: "it's \"quoted\"\n"}},
+		Attrs: map[string]interface{}{"ids": []int{1, 2}},
+	}
+	const nestedJSON = `{"Tags":[{"name":"<a & b>","Score":1.5},{"name":"it's \"quoted\"\n","Score":0}],"Attrs":{"ids":[1,2]}}`
+
+	s := "str"
+	tests := []struct {
+		in       interface{}
+		expected string
+	}{
+		{nil, "dynamic(null)"},
+		{true, "bool(true)"},
+		{int16(3), "int(3)"},
+		{3, "long(3)"},
+		{uint64(3), "long(3)"},
+		{2.5, "real(2.5)"},
+		{&s, `"str"`},
+		{time.Minute, "timespan(00:01:00.0000000)"},
+		{[]string{"a", "b"}, `dynamic(["a","b"])`},
+		{map[string]int{"a": 1}, `dynamic({"a":1})`},
+		{nested, "dynamic(" + nestedJSON + ")"},
+		{json.RawMessage(`{"a": [1]}`), `dynamic({"a": [1]})`},
+	}
+	for _, test := range tests {
+		v, err := Value(test.in)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, QuoteValue(v))
+	}
+
+	v, err := Value(nested)
+	require.NoError(t, err)
+	qp := NewParameters().AddValue("obj", v)
+	require.Equal(t, "declare query_parameters(obj:dynamic);", qp.ToDeclarationString())
+	require.Equal(t, map[string]string{"obj": "dynamic(" + nestedJSON + ")"}, qp.ToParameterCollection())
+	require.Equal(t, "T | where x in (dynamic("+nestedJSON+"))", New("T | where x in (").AddValue(v).AddLiteral(")").String())
+
+	for _, in := range []interface{}{uint64(math.MaxUint64), make(chan int), json.RawMessage(`{`), map[string]interface{}{"f": func() {}}} {
+		_, err := Value(in)
+		require.Error(t, err, "%T", in)
+	}
+}
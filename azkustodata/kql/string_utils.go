@@ -5,6 +5,8 @@ import (
 	"strings"
 	"time"
 	"unicode"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
 )
 
 // RequiresQuoting checks whether a given string is an identifier
@@ -93,27 +95,12 @@ func ShouldBeEscaped(c int32) bool {
 	return true
 }
 
+// FormatTimespan formats a duration as the value of a timespan literal, see value.FormatTimespan.
 func FormatTimespan(duration time.Duration) string {
-	// Calculate the number of days in the duration
-	days := duration / (24 * time.Hour)
-
-	// Calculate the remaining time after subtracting the days
-	remaining := duration - days*24*time.Hour
-
-	daysStr := ""
-	if days > 0 {
-		daysStr = fmt.Sprintf("%d.", days)
-	}
-
-	// Use the `fmt.Sprintf()` function to format the duration as a string
-	return fmt.Sprintf("%s%02d:%02d:%02d.%07d",
-		daysStr,
-		int(remaining.Hours()),
-		int(remaining.Minutes())%60,
-		int(remaining.Seconds())%60,
-		int((remaining.Nanoseconds())%1000000000/100))
+	return value.FormatTimespan(duration)
 }
 
+// FormatDatetime formats a time as the value of a datetime literal, see value.FormatDatetime.
 func FormatDatetime(datetime time.Time) string {
-	return datetime.Format("2006-01-02T15:04:05.9999999Z07:00")
+	return value.FormatDatetime(datetime)
 }
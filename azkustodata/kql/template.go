@@ -0,0 +1,174 @@
+package kql
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"regexp"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// placeholderRe matches the {{name:type}} placeholders of a template.
+	placeholderRe = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*:\s*([A-Za-z0-9_]+)\s*\}\}`)
+	// untypedPlaceholderRe matches the placeholders missing their type, which are reported rather than left in the query.
+	untypedPlaceholderRe = regexp.MustCompile(`\{\{\s*[A-Za-z_][A-Za-z0-9_]*\s*\}\}`)
+)
+
+// TemplateParameter is a parameter declared by a placeholder of a Template.
+type TemplateParameter struct {
+	Name string
+	Type types.Column
+}
+
+// Template is a query kept in a file rather than in the code, with {{name:type}} placeholders for its parameters, such as:
+//
+//	StormEvents
+//	| where State == {{state:string}} and StartTime > ago({{window:timespan}})
+//	| take {{limit:long}}
+//
+// A placeholder may appear several times, with the same type. Build validates the arguments against the declared types,
+// and returns the query with the placeholders replaced by query parameters, so the arguments never become part of the
+// query text:
+//
+//	tmpl, err := kql.LoadTemplate("queries/storms.kql")
+//	...
+//	query, params, err := tmpl.Build(map[string]interface{}{"state": "TEXAS", "window": 24 * time.Hour, "limit": 10})
+//	...
+//	dataset, err := client.Query(ctx, "database", query, azkustodata.QueryParameters(params))
+//
+// Placeholders are replaced wherever they appear, including in string literals and comments. A Template is safe for
+// concurrent use.
+type Template struct {
+	text       string
+	parameters []TemplateParameter
+}
+
+// LoadTemplate reads a Template from a file.
+func LoadTemplate(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTemplate(string(data))
+}
+
+// LoadTemplateFS reads a Template from a file of a file system, such as an embed.FS holding the queries of a program.
+func LoadTemplateFS(fsys fs.FS, path string) (*Template, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTemplate(string(data))
+}
+
+// ParseTemplate parses the text of a Template.
+func ParseTemplate(text string) (*Template, error) {
+	if loc := untypedPlaceholderRe.FindStringIndex(text); loc != nil {
+		return nil, fmt.Errorf("placeholder %s has no type, placeholders are written {{name:type}}", text[loc[0]:loc[1]])
+	}
+
+	t := &Template{}
+	declared := map[string]types.Column{}
+	for _, m := range placeholderRe.FindAllStringSubmatch(text, -1) {
+		name := m[1]
+		typ := types.NormalizeColumn(m[2])
+		if typ == "" {
+			return nil, fmt.Errorf("placeholder %s has unknown type %q", m[0], m[2])
+		}
+		if prev, ok := declared[name]; ok {
+			if prev != typ {
+				return nil, fmt.Errorf("placeholder %s is declared as both %s and %s", name, prev, typ)
+			}
+			continue
+		}
+		declared[name] = typ
+		t.parameters = append(t.parameters, TemplateParameter{Name: name, Type: typ})
+	}
+	t.text = placeholderRe.ReplaceAllString(text, "$1")
+	return t, nil
+}
+
+// Parameters returns the parameters declared by the placeholders of the template, in the order they first appear.
+func (t *Template) Parameters() []TemplateParameter {
+	return append([]TemplateParameter(nil), t.parameters...)
+}
+
+// Build returns the query of the template, and the parameters to run it with, set to the arguments, keyed by name.
+// Arguments are converted as with Value, and must match the declared type, except for integers which may be passed for
+// int, long, real or decimal parameters if their value fits. A nil argument is a null value of the declared type.
+// Missing and unknown arguments are errors.
+func (t *Template) Build(args map[string]interface{}) (*Builder, *Parameters, error) {
+	params := NewParameters()
+	for _, p := range t.parameters {
+		arg, ok := args[p.Name]
+		if !ok {
+			return nil, nil, fmt.Errorf("missing argument for parameter %s of type %s", p.Name, p.Type)
+		}
+		v, err := templateValue(p, arg)
+		if err != nil {
+			return nil, nil, err
+		}
+		params.AddValue(p.Name, v)
+	}
+	if len(args) > len(t.parameters) {
+		for name := range args {
+			if !t.declares(name) {
+				return nil, nil, fmt.Errorf("argument %s is not a parameter of the template", name)
+			}
+		}
+	}
+	return New(stringConstant(t.text)), params, nil
+}
+
+func (t *Template) declares(name string) bool {
+	for _, p := range t.parameters {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// templateValue converts the argument of a parameter to a value of its declared type.
+func templateValue(p TemplateParameter, arg interface{}) (value.Kusto, error) {
+	if arg == nil {
+		return value.Default(p.Type), nil
+	}
+	v, err := Value(arg)
+	if err != nil {
+		return nil, fmt.Errorf("argument for parameter %s: %w", p.Name, err)
+	}
+	if v.GetType() == p.Type {
+		return v, nil
+	}
+
+	// Integers are widened or narrowed to the declared numeric type, as Go has more integer types than Kusto.
+	var i int64
+	switch n := v.GetValue().(type) {
+	case *int32:
+		i = int64(*n)
+	case *int64:
+		i = *n
+	default:
+		return nil, fmt.Errorf("parameter %s is declared as %s, but the argument is a %s", p.Name, p.Type, v.GetType())
+	}
+	switch p.Type {
+	case types.Int:
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return nil, fmt.Errorf("argument %d for parameter %s overflows an int", i, p.Name)
+		}
+		return value.NewInt(int32(i)), nil
+	case types.Long:
+		return value.NewLong(i), nil
+	case types.Real:
+		return value.NewReal(float64(i)), nil
+	case types.Decimal:
+		return value.NewDecimal(decimal.NewFromInt(i)), nil
+	}
+	return nil, fmt.Errorf("parameter %s is declared as %s, but the argument is a %s", p.Name, p.Type, v.GetType())
+}
@@ -0,0 +1,115 @@
+package kql
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/stretchr/testify/require"
+)
+
+const stormsTemplate = `StormEvents
+| where State == {{state:string}} and StartTime > ago({{ window : timespan }})
+| where isempty({{state:string}}) or DamageProperty > {{minDamage:real}}
+| take {{limit:int}}`
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate(stormsTemplate)
+	require.NoError(t, err)
+	require.Equal(t, []TemplateParameter{
+		{Name: "state", Type: types.String},
+		{Name: "window", Type: types.Timespan},
+		{Name: "minDamage", Type: types.Real},
+		{Name: "limit", Type: types.Int},
+	}, tmpl.Parameters())
+
+	query, params, err := tmpl.Build(map[string]interface{}{"state": "TEXAS", "window": time.Hour, "minDamage": 1000, "limit": 10})
+	require.NoError(t, err)
+	require.Equal(t, `StormEvents
+| where State == state and StartTime > ago(window)
+| where isempty(state) or DamageProperty > minDamage
+| take limit`, query.String())
+	require.Equal(t, "declare query_parameters(state:string, window:timespan, minDamage:real, limit:int);", params.ToDeclarationString())
+	require.Equal(t, map[string]string{
+		"state":     `"TEXAS"`,
+		"window":    "timespan(01:00:00.0000000)",
+		"minDamage": "real(1000)",
+		"limit":     "int(10)",
+	}, params.ToParameterCollection())
+
+	_, params, err = tmpl.Build(map[string]interface{}{"state": "TEXAS", "window": nil, "minDamage": 2.5, "limit": int32(1)})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"state":     `"TEXAS"`,
+		"window":    "timespan(null)",
+		"minDamage": "real(2.5)",
+		"limit":     "int(1)",
+	}, params.ToParameterCollection())
+}
+
+func TestTemplateBuildErrors(t *testing.T) {
+	tmpl, err := ParseTemplate(stormsTemplate)
+	require.NoError(t, err)
+	valid := map[string]interface{}{"state": "TEXAS", "window": time.Hour, "minDamage": 1.5, "limit": 10}
+
+	tests := []struct {
+		desc    string
+		changes map[string]interface{}
+		remove  string
+	}{
+		{desc: "missing argument", remove: "limit"},
+		{desc: "unknown argument", changes: map[string]interface{}{"other": 1}},
+		{desc: "wrong type", changes: map[string]interface{}{"state": 1}},
+		{desc: "real for an int", changes: map[string]interface{}{"limit": 1.5}},
+		{desc: "int overflow", changes: map[string]interface{}{"limit": int64(1) << 40}},
+		{desc: "unsupported value", changes: map[string]interface{}{"state": make(chan int)}},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			args := map[string]interface{}{}
+			for k, v := range valid {
+				args[k] = v
+			}
+			for k, v := range test.changes {
+				args[k] = v
+			}
+			delete(args, test.remove)
+			_, _, err := tmpl.Build(args)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	for _, text := range []string{
+		"T | take {{limit}}",
+		"T | take {{limit:integer32}}",
+		"T | where x == {{x:string}} or y == {{x:long}}",
+	} {
+		_, err := ParseTemplate(text)
+		require.Error(t, err, text)
+	}
+
+	tmpl, err := ParseTemplate("print dynamic({})")
+	require.NoError(t, err)
+	require.Empty(t, tmpl.Parameters())
+}
+
+func TestLoadTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storms.kql")
+	require.NoError(t, os.WriteFile(path, []byte(stormsTemplate), 0o600))
+	tmpl, err := LoadTemplate(path)
+	require.NoError(t, err)
+	require.Len(t, tmpl.Parameters(), 4)
+
+	_, err = LoadTemplate(filepath.Join(t.TempDir(), "missing.kql"))
+	require.Error(t, err)
+
+	fsys := fstest.MapFS{"queries/count.kql": {Data: []byte("T | where x > {{min:long}} | count")}}
+	tmpl, err = LoadTemplateFS(fsys, "queries/count.kql")
+	require.NoError(t, err)
+	require.Equal(t, []TemplateParameter{{Name: "min", Type: types.Long}}, tmpl.Parameters())
+}
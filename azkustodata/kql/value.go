@@ -1,7 +1,11 @@
 package kql
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"reflect"
+
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"github.com/Azure/azure-kusto-go/azkustodata/value"
 	"github.com/google/uuid"
@@ -12,7 +16,7 @@ import (
 func QuoteValue(v value.Kusto) string {
 	val := v.GetValue()
 	t := v.GetType()
-	if val == nil {
+	if val == nil || (t != types.String && v.IsNull()) {
 		return fmt.Sprintf("%v(null)", t)
 	}
 
@@ -24,6 +28,9 @@ func QuoteValue(v value.Kusto) string {
 	case types.Timespan:
 		val = FormatTimespan(*val.(*time.Duration))
 	case types.Dynamic:
+		if len(val.([]byte)) == 0 {
+			return "dynamic(null)"
+		}
 		val = string(val.([]byte))
 	case types.Bool:
 		val = *val.(*bool)
@@ -41,3 +48,80 @@ func QuoteValue(v value.Kusto) string {
 
 	return fmt.Sprintf("%v(%v)", t, val)
 }
+
+// Value converts a Go value to the Kusto value it is sent as, for use with AddValue on a Builder or Parameters.
+// Booleans, integers, floats, strings, time.Time, time.Duration, uuid.UUID and decimal.Decimal become the matching
+// scalars, and maps, slices, arrays and structs become dynamic values, nested at any depth, encoded as with json.Marshal.
+// A json.RawMessage is used as the JSON of a dynamic value as is, and nil is a null dynamic.
+func Value(v interface{}) (value.Kusto, error) {
+	switch v := v.(type) {
+	case nil:
+		return value.NewNullDynamic(), nil
+	case value.Kusto:
+		return v, nil
+	case bool:
+		return value.NewBool(v), nil
+	case int8:
+		return value.NewInt(int32(v)), nil
+	case int16:
+		return value.NewInt(int32(v)), nil
+	case int32:
+		return value.NewInt(v), nil
+	case uint8:
+		return value.NewInt(int32(v)), nil
+	case uint16:
+		return value.NewInt(int32(v)), nil
+	case int:
+		return value.NewLong(int64(v)), nil
+	case int64:
+		return value.NewLong(v), nil
+	case uint32:
+		return value.NewLong(int64(v)), nil
+	case uint:
+		return uintValue(uint64(v))
+	case uint64:
+		return uintValue(v)
+	case float32:
+		return value.NewReal(float64(v)), nil
+	case float64:
+		return value.NewReal(v), nil
+	case string:
+		return value.NewString(v), nil
+	case time.Time:
+		return value.NewDateTime(v), nil
+	case time.Duration:
+		return value.NewTimespan(v), nil
+	case uuid.UUID:
+		return value.NewGUID(v), nil
+	case decimal.Decimal:
+		return value.NewDecimal(v), nil
+	case json.RawMessage:
+		if !json.Valid(v) {
+			return nil, fmt.Errorf("the json.RawMessage is not valid JSON")
+		}
+		return value.NewDynamic(v), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return value.NewNullDynamic(), nil
+		}
+		return Value(rv.Elem().Interface())
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		marshal, err := value.MarshalDynamic(v)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert %T to a dynamic value: %w", v, err)
+		}
+		return value.NewDynamic(marshal), nil
+	}
+	return nil, fmt.Errorf("type %T has no matching Kusto type", v)
+}
+
+func uintValue(v uint64) (value.Kusto, error) {
+	if v > math.MaxInt64 {
+		return nil, fmt.Errorf("%d overflows a long", v)
+	}
+	return value.NewLong(int64(v)), nil
+}
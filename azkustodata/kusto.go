@@ -11,6 +11,9 @@ import (
 	"time"
 
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	trustedEndpoints "github.com/Azure/azure-kusto-go/azkustodata/trusted_endpoints"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 )
 
 type Statement = *kql.Builder
@@ -18,19 +21,26 @@ type Statement = *kql.Builder
 // queryer provides for getting a stream of Kusto frames. Exists to allow fake Kusto streams in tests.
 type queryer interface {
 	io.Closer
-	rawQuery(ctx context.Context, callType callType, db string, query Statement, options *queryOptions) (io.ReadCloser, error)
+	rawQuery(ctx context.Context, callType callType, db string, query Statement, options *queryOptions) (query.RequestIDs, io.ReadCloser, error)
+	shutdown(ctx context.Context) error
 }
 
 // Authorization provides the TokenProvider needed to acquire the auth token.
 type Authorization struct {
 	// Token provider that can be used to get the access token.
 	TokenProvider *TokenProvider
+	// TrustedEndpoints validates the endpoints tokens are sent to. If nil, the process-wide trustedEndpoints.Instance is used.
+	TrustedEndpoints *trustedEndpoints.TrustedEndpoints
+	// SkipEndpointValidation disables the validation of the endpoints tokens are sent to.
+	SkipEndpointValidation bool
 }
 
 const (
 	defaultMgmtTimeout  = time.Hour
 	defaultQueryTimeout = 4 * time.Minute
 	clientServerDelta   = 30 * time.Second
+	// defaultServerTimeoutSkew is how much earlier than the caller's context deadline the server times out calls by default.
+	defaultServerTimeoutSkew = time.Second
 )
 
 // Client is a client to a Kusto instance.
@@ -39,7 +49,29 @@ type Client struct {
 	endpoint      string
 	auth          Authorization
 	http          *http.Client
+	transport     transportOptions
 	clientDetails *ClientDetails
+
+	compressionThreshold int
+	retryPolicy          *RetryPolicy
+	throttlingBudget     time.Duration
+	circuitBreaker       *circuitBreaker
+	limiter              *limiter
+	middleware           []Middleware
+	tracing              *tracing
+	metrics              Metrics
+	logger               Logger
+
+	clientRequestIDGenerator func() string
+	serverTimeoutSkew        time.Duration
+	timeout                  time.Duration
+	serverSideCancel         bool
+	maxResponseBytes         int64
+	defaultDatabase          string
+	queryConsistency         string
+	readonly                 bool
+	resultCache              *resultCache
+	v1Protocol               bool
 }
 
 // Option is an optional argument type for New().
@@ -47,25 +79,65 @@ type Option func(c *Client)
 
 // New returns a new Client.
 func New(kcsb *ConnectionStringBuilder, options ...Option) (*Client, error) {
+	if !isEmpty(kcsb.DataSource) {
+		ds, err := normalizeDataSource(kcsb.DataSource, kcsb.AllowInsecure)
+		if err != nil {
+			return nil, err
+		}
+		normalized := *kcsb
+		normalized.DataSource = ds
+		kcsb = &normalized
+	}
+
 	tkp, err := kcsb.newTokenProvider()
 	if err != nil {
 		return nil, err
 	}
 	auth := &Authorization{
-		TokenProvider: tkp,
+		TokenProvider:          tkp,
+		SkipEndpointValidation: kcsb.SkipEndpointValidation,
+	}
+	if len(kcsb.TrustedHosts) > 0 {
+		auth.TrustedEndpoints, err = trustedEndpoints.Instance.WithTrustedHosts(kcsb.TrustedHosts)
+		if err != nil {
+			return nil, err
+		}
 	}
 	endpoint := kcsb.DataSource
 
-	client := &Client{auth: *auth, endpoint: endpoint, clientDetails: NewClientDetails(kcsb.ApplicationForTracing, kcsb.UserForTracing)}
+	client := &Client{
+		auth:                 *auth,
+		endpoint:             endpoint,
+		clientDetails:        NewClientDetails(kcsb.ApplicationForTracing, kcsb.UserForTracing),
+		compressionThreshold: defaultCompressionThreshold,
+		throttlingBudget:     defaultThrottlingBudget,
+		metrics:              NoopMetrics{},
+		serverTimeoutSkew:    defaultServerTimeoutSkew,
+		defaultDatabase:      kcsb.InitialCatalog,
+	}
 	for _, o := range options {
 		o(client)
 	}
+	if client.logger == nil {
+		client.logger = nopLogger{}
+	} else if client.circuitBreaker != nil {
+		client.circuitBreaker.onStateChange = logStateChanges(client.logger, client.circuitBreaker.onStateChange)
+	}
 
 	if client.http == nil {
+		transport, err := client.transport.newTransport()
+		if err != nil {
+			return nil, err
+		}
 		client.http = &http.Client{
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				return http.ErrUseLastResponse
-			},
+			Transport:     transport,
+			CheckRedirect: doNotFollowRedirects,
+		}
+	}
+	if len(client.middleware) > 0 {
+		client.http, err = withMiddleware(client.http, endpoint, client.middleware)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -73,17 +145,152 @@ func New(kcsb *ConnectionStringBuilder, options ...Option) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	conn.compressionThreshold = client.compressionThreshold
+	conn.retryPolicy = client.retryPolicy
+	conn.throttlingBudget = client.throttlingBudget
+	conn.circuitBreaker = client.circuitBreaker
+	conn.limiter = client.limiter
+	conn.tracing = client.tracing
+	conn.metrics = client.metrics
+	conn.logger = client.logger
+	conn.clientRequestIDGenerator = client.clientRequestIDGenerator
+	conn.serverSideCancel = client.serverSideCancel
+	if client.v1Protocol {
+		conn.endQuery = conn.endMgmt.JoinPath("../query")
+	}
 	client.conn = conn
+	if tkp != nil {
+		tkp.metrics = client.metrics
+		tkp.logger = client.logger
+	}
 
 	return client, nil
 }
 
+// WithHttpClient sets the http client the client sends its requests with, including the cloud metadata and token requests.
 func WithHttpClient(client *http.Client) Option {
 	return func(c *Client) {
 		c.http = client
 	}
 }
 
+// WithTransport sets the azcore transport the client sends its requests with, such as an instrumented or mocked transport.
+// Like WithHttpClient, it is used for the cloud metadata and token requests too.
+func WithTransport(transport policy.Transporter) Option {
+	return func(c *Client) {
+		if client, ok := transport.(*http.Client); ok {
+			c.http = client
+			return
+		}
+		c.http = &http.Client{
+			Transport:     transporterRoundTripper{transport: transport},
+			CheckRedirect: doNotFollowRedirects,
+		}
+	}
+}
+
+// transporterRoundTripper adapts a policy.Transporter to an http.RoundTripper.
+type transporterRoundTripper struct {
+	transport policy.Transporter
+}
+
+func (t transporterRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.transport.Do(req)
+}
+
+func doNotFollowRedirects(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// WithRequestCompressionThreshold sets the size, in bytes, from which query and command bodies are gzip compressed, such
+// as large `.ingest inline` commands. Defaults to 64 KiB.
+func WithRequestCompressionThreshold(threshold int) Option {
+	return func(c *Client) {
+		c.compressionThreshold = threshold
+	}
+}
+
+// WithoutRequestCompression disables the gzip compression of large query and command bodies.
+func WithoutRequestCompression() Option {
+	return WithRequestCompressionThreshold(0)
+}
+
+// WithClientRequestIDGenerator sets the function generating the x-ms-client-request-id of calls that don't set one with
+// the ClientRequestID option. By default, a random id prefixed with "KGC.execute;" is used.
+func WithClientRequestIDGenerator(generator func() string) Option {
+	return func(c *Client) {
+		c.clientRequestIDGenerator = generator
+	}
+}
+
+// WithServerTimeoutSkew sets how much earlier than the deadline of a call's context the server times the call out.
+// When a call's context has a deadline, and neither the ServerTimeout nor the NoRequestTimeout option is used, the
+// servertimeout request property is set to the time left until the deadline, minus the skew, so the server cancels the
+// call instead of running it after the caller gave up. Defaults to one second. Calls without a deadline use the default
+// server timeouts.
+func WithServerTimeoutSkew(skew time.Duration) Option {
+	return func(c *Client) {
+		c.serverTimeoutSkew = skew
+	}
+}
+
+// WithDefaultDatabase sets the database of calls made with an empty database name. It defaults to the connection
+// string's Initial Catalog. Each call can target any database of the cluster, so a single client serves all of them.
+func WithDefaultDatabase(db string) Option {
+	return func(c *Client) {
+		c.defaultDatabase = db
+	}
+}
+
+// WithV1Protocol sends queries to the v1 query endpoint, /v1/rest/query, instead of the v2 one, for older proxies and
+// emulators that only support v1. The results are read at once, and returned through the same interfaces as v2 results.
+// The features of v2 results, such as progressive results, partial failures and the QueryCompletionInformation table,
+// aren't available.
+func WithV1Protocol() Option {
+	return func(c *Client) {
+		c.v1Protocol = true
+	}
+}
+
+// WithRequestReadonly makes all the requests of the client read-only, as with the RequestReadonly option, so the client
+// refuses to send control commands unless a call sets the AllowControlCommands option. It protects dashboards and
+// reporting services from running commands by accident.
+func WithRequestReadonly() Option {
+	return func(c *Client) {
+		c.readonly = true
+	}
+}
+
+// WithQueryConsistency sets the consistency of the queries of the client, one of the QueryConsistency constants, unless
+// a query sets its own with the QueryConsistency option. Management commands are not affected.
+func WithQueryConsistency(consistency string) Option {
+	return func(c *Client) {
+		c.queryConsistency = consistency
+	}
+}
+
+// database returns the database a call targets, db if it isn't empty, or the client's default database.
+func (c *Client) database(db string) string {
+	if db == "" {
+		return c.defaultDatabase
+	}
+	return db
+}
+
+// WithoutTokenCache disables the process-wide token cache for this client, so it acquires tokens with its own credential only.
+func WithoutTokenCache() Option {
+	return func(c *Client) {
+		c.auth.TokenProvider.cacheDisabled = true
+	}
+}
+
+// WithTokenRefreshWindow sets how long before a cached token expires it is refreshed in the background. Defaults to 5 minutes.
+func WithTokenRefreshWindow(d time.Duration) Option {
+	return func(c *Client) {
+		c.auth.TokenProvider.refreshWindow = d
+	}
+}
+
 // QueryOption is an option type for a call to Query().
 type QueryOption func(q *queryOptions) error
 
@@ -105,11 +312,18 @@ const (
 )
 
 func (c *Client) Mgmt(ctx context.Context, db string, kqlQuery Statement, options ...QueryOption) (v1.Dataset, error) {
+	start := time.Now()
+	ds, err := c.mgmt(ctx, db, kqlQuery, options)
+	c.observeCall(ctx, mgmtCall, db, start, ds, err)
+	return ds, err
+}
+
+func (c *Client) mgmt(ctx context.Context, db string, kqlQuery Statement, options []QueryOption) (v1.Dataset, error) {
 	ctx, cancel := contextSetup(ctx)
 
 	opQuery := errors.OpMgmt
 	call := mgmtCall
-	opts, err := setQueryOptions(ctx, opQuery, kqlQuery, call, options...)
+	opts, err := setQueryOptions(ctx, opQuery, kqlQuery, call, append(c.defaultQueryOptions(call), options...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -119,18 +333,33 @@ func (c *Client) Mgmt(ctx context.Context, db string, kqlQuery Statement, option
 		return nil, err
 	}
 
-	res, err := conn.rawQuery(ctx, callType(call), db, kqlQuery, opts)
+	ids, res, err := conn.rawQuery(ctx, callType(call), c.database(db), kqlQuery, opts)
 
 	if err != nil {
 		cancel()
 		return nil, err
 	}
 
-	return v1.NewDatasetFromReader(ctx, opQuery, res)
+	return v1.NewDatasetFromReader(query.ContextWithRequestIDs(ctx, ids), opQuery, res)
 }
 
 func (c *Client) Query(ctx context.Context, db string, kqlQuery Statement, options ...QueryOption) (query.Dataset, error) {
-	ds, err := c.IterativeQuery(ctx, db, kqlQuery, options...)
+	start := time.Now()
+	var ds query.Dataset
+	var err error
+	if c.resultCache != nil {
+		ds, err = c.cachedQuery(ctx, db, kqlQuery, append(c.defaultQueryOptions(queryCall), options...), func() (query.Dataset, error) {
+			return c.query(ctx, db, kqlQuery, options)
+		})
+	} else {
+		ds, err = c.query(ctx, db, kqlQuery, options)
+	}
+	c.observeCall(ctx, queryCall, db, start, ds, err)
+	return ds, err
+}
+
+func (c *Client) query(ctx context.Context, db string, kqlQuery Statement, options []QueryOption) (query.Dataset, error) {
+	ds, err := c.iterativeQuery(ctx, db, kqlQuery, options)
 	if err != nil {
 		return nil, err
 	}
@@ -139,11 +368,26 @@ func (c *Client) Query(ctx context.Context, db string, kqlQuery Statement, optio
 }
 
 func (c *Client) IterativeQuery(ctx context.Context, db string, kqlQuery Statement, options ...QueryOption) (query.IterativeDataset, error) {
+	start := time.Now()
+	ds, err := c.iterativeQuery(ctx, db, kqlQuery, options)
+	c.metrics.ObserveQueryDuration(ctx, callType(queryCall).operation(), db, time.Since(start), err)
+	return ds, err
+}
+
+func (c *Client) iterativeQuery(ctx context.Context, db string, kqlQuery Statement, options []QueryOption) (query.IterativeDataset, error) {
+	if c.v1Protocol {
+		_, ids, res, err := c.rawV2(ctx, db, kqlQuery, options)
+		if err != nil {
+			return nil, err
+		}
+		return v1.NewIterativeDatasetFromReader(query.ContextWithRequestIDs(ctx, ids), errors.OpQuery, res)
+	}
+
 	options = append(options, V2NewlinesBetweenFrames())
 	options = append(options, V2FragmentPrimaryTables())
 	options = append(options, ResultsErrorReportingPlacement(ResultsErrorReportingPlacementEndOfTable))
 
-	opts, res, err := c.rawV2(ctx, db, kqlQuery, options)
+	opts, ids, res, err := c.rawV2(ctx, db, kqlQuery, options)
 	if err != nil {
 		return nil, err
 	}
@@ -163,41 +407,51 @@ func (c *Client) IterativeQuery(ctx context.Context, db string, kqlQuery Stateme
 		fragmentCapacity = opts.v2FragmentCapacity
 	}
 
+	ctx = query.ContextWithRequestIDs(ctx, ids)
+	if opts.onHeartbeat != nil {
+		ctx = query.ContextWithHeartbeat(ctx, opts.onHeartbeat)
+	}
+	if opts.frameTimeout > 0 {
+		ctx = query.ContextWithStallDetection(ctx, opts.frameTimeout, opts.onStall)
+	}
+	if opts.spill != nil {
+		ctx = query.ContextWithSpill(ctx, query.NewSpill(*opts.spill))
+	}
 	return queryv2.NewIterativeDataset(ctx, res, frameCapacity, rowCapacity, fragmentCapacity)
 }
 
 func (c *Client) RawV2(ctx context.Context, db string, kqlQuery Statement, options []QueryOption) (io.ReadCloser, error) {
 
-	_, res, err := c.rawV2(ctx, db, kqlQuery, options)
+	_, _, res, err := c.rawV2(ctx, db, kqlQuery, options)
 
 	return res, err
 
 }
 
-func (c *Client) rawV2(ctx context.Context, db string, kqlQuery Statement, options []QueryOption) (*queryOptions, io.ReadCloser, error) {
+func (c *Client) rawV2(ctx context.Context, db string, kqlQuery Statement, options []QueryOption) (*queryOptions, query.RequestIDs, io.ReadCloser, error) {
 	ctx, cancel := contextSetup(ctx)
 	opQuery := errors.OpQuery
-	opts, err := setQueryOptions(ctx, opQuery, kqlQuery, queryCall, options...)
+	opts, err := setQueryOptions(ctx, opQuery, kqlQuery, queryCall, append(c.defaultQueryOptions(queryCall), options...)...)
 	if err != nil {
-		return nil, nil, err
+		return nil, query.RequestIDs{}, nil, err
 	}
 
 	conn, err := c.getConn(queryCall, connOptions{queryOptions: opts})
 	if err != nil {
-		return nil, nil, err
+		return nil, query.RequestIDs{}, nil, err
 	}
 
-	res, err := conn.rawQuery(ctx, queryCall, db, kqlQuery, opts)
+	ids, res, err := conn.rawQuery(ctx, queryCall, c.database(db), kqlQuery, opts)
 
 	if err != nil {
 		cancel()
-		return nil, nil, err
+		return nil, ids, nil, err
 	}
-	return opts, res, nil
+	return opts, ids, res, nil
 }
 
 func (c *Client) QueryToJson(ctx context.Context, db string, query Statement, options ...QueryOption) (string, error) {
-	_, res, err := c.rawV2(ctx, db, query, options)
+	_, _, res, err := c.rawV2(ctx, db, query, options)
 	if err != nil {
 		return "", err
 	}
@@ -210,6 +464,24 @@ func (c *Client) QueryToJson(ctx context.Context, db string, query Statement, op
 	return string(all), nil
 }
 
+// defaultQueryOptions returns the options set on the client for a type of call, which the options of each call override.
+func (c *Client) defaultQueryOptions(call int) []QueryOption {
+	defaults := []QueryOption{serverTimeoutSkew(c.serverTimeoutSkew), MaxResponseBytes(c.maxResponseBytes)}
+	if c.timeout > 0 {
+		defaults = append(defaults, Timeout(c.timeout))
+	}
+	if call == queryCall && c.queryConsistency != "" {
+		defaults = append(defaults, QueryConsistency(c.queryConsistency))
+	}
+	if c.readonly {
+		defaults = append(defaults, RequestReadonly())
+	}
+	if call == queryCall && c.resultCache != nil && c.resultCache.options.ServerMaxAge > 0 {
+		defaults = append(defaults, QueryResultsCacheMaxAge(c.resultCache.options.ServerMaxAge))
+	}
+	return defaults
+}
+
 func setQueryOptions(ctx context.Context, op errors.Op, query Statement, queryType int, options ...QueryOption) (*queryOptions, error) {
 	opt := &queryOptions{
 		requestProperties: &requestProperties{
@@ -226,6 +498,10 @@ func setQueryOptions(ctx context.Context, op errors.Op, query Statement, queryTy
 		}
 	}
 
+	if readonly, _ := opt.requestProperties.Options[RequestReadonlyValue].(bool); readonly && !opt.allowControlCommands && isControlCommand(query.String()) {
+		return nil, errors.ES(op, errors.KClientArgs, "control commands are not allowed in read-only requests, set the AllowControlCommands option to send them").SetNoRetry()
+	}
+
 	CalculateTimeout(ctx, opt, queryType)
 
 	if query.SupportsInlineParameters() {
@@ -251,9 +527,25 @@ func CalculateTimeout(ctx context.Context, opt *queryOptions, queryType int) {
 		return
 	}
 
-	// Otherwise use the context deadline, if it exists. If it doesn't, use the default timeout.
-	if deadline, ok := ctx.Deadline(); ok {
-		opt.requestProperties.Options[ServerTimeoutValue] = deadline.Sub(time.Now())
+	// Otherwise use the context deadline or the call's timeout, whichever comes first, so the server cancels the call
+	// once the caller gave up on it. The skew makes the server time out first, so the caller gets the server's timeout
+	// error. If there is neither, use the default timeout.
+	remaining, ok := time.Duration(0), false
+	if deadline, hasDeadline := ctx.Deadline(); hasDeadline {
+		remaining, ok = time.Until(deadline), true
+	}
+	if opt.timeout > 0 && (!ok || opt.timeout < remaining) {
+		remaining, ok = opt.timeout, true
+	}
+	if ok {
+		timeout := remaining - opt.serverTimeoutSkew
+		if timeout <= 0 {
+			timeout = remaining
+		}
+		if timeout > maxServerTimeout {
+			timeout = maxServerTimeout
+		}
+		opt.requestProperties.Options[ServerTimeoutValue] = value.TimespanString(timeout)
 		return
 	}
 
@@ -264,7 +556,7 @@ func CalculateTimeout(ctx context.Context, opt *queryOptions, queryType int) {
 	case mgmtCall:
 		timeout = defaultMgmtTimeout
 	}
-	opt.requestProperties.Options[ServerTimeoutValue] = timeout + clientServerDelta
+	opt.requestProperties.Options[ServerTimeoutValue] = value.TimespanString(timeout + clientServerDelta)
 }
 
 func (c *Client) getConn(callType callType, options connOptions) (queryer, error) {
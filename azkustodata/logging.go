@@ -0,0 +1,42 @@
+package azkustodata
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Logger receives the client's structured logs. It is implemented by *slog.Logger; to use another logging library,
+// such as zap or zerolog, implement Log by forwarding to it, with args holding alternating keys and values as with slog.
+// Log may be called concurrently.
+type Logger interface {
+	Log(ctx context.Context, level slog.Level, msg string, args ...any)
+}
+
+// WithLogger sets the logger the client writes its logs to. By default, the client doesn't log.
+// The client logs the lifecycle of requests at debug level, retries and token acquisitions at info level, and throttling,
+// failed token acquisitions and the circuit breaker opening at warn level. Tokens are never logged, but errors may
+// include the query text.
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// nopLogger is the default Logger, which discards all logs.
+type nopLogger struct{}
+
+func (nopLogger) Log(context.Context, slog.Level, string, ...any) {}
+
+// logStateChanges logs the state changes of a circuit breaker, before calling onStateChange if it is set.
+func logStateChanges(logger Logger, onStateChange func(from, to CircuitState)) func(from, to CircuitState) {
+	return func(from, to CircuitState) {
+		level := slog.LevelWarn
+		if to == CircuitClosed {
+			level = slog.LevelInfo
+		}
+		logger.Log(context.Background(), level, "circuit breaker state changed", "from", from.String(), "to", to.String())
+		if onStateChange != nil {
+			onStateChange(from, to)
+		}
+	}
+}
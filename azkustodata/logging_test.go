@@ -0,0 +1,39 @@
+package azkustodata
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLogger(t *testing.T) {
+	var attempts atomic.Int32
+	srv := newFlakyTestServer(1, http.StatusServiceUnavailable, &attempts)
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client, err := New(NewConnectionStringBuilder(srv.URL).WithTokenCredential(&fakeCredential{}), WithHttpClient(srv.Client()),
+		WithoutTokenCache(), WithLogger(logger),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"), ClientRequestID("request-1"))
+	require.NoError(t, err)
+
+	logs := buf.String()
+	assert.Contains(t, logs, `level=DEBUG msg="sending request" operation=mgmt db=db clientRequestId=request-1 attempt=1`)
+	assert.Contains(t, logs, `level=INFO msg="request failed, retrying" operation=mgmt db=db clientRequestId=request-1 attempt=1`)
+	assert.Contains(t, logs, `level=DEBUG msg="request succeeded" operation=mgmt db=db clientRequestId=request-1 attempt=2`)
+	assert.Contains(t, logs, `level=INFO msg="acquired token"`)
+	assert.NotContains(t, logs, "fake-token")
+}
@@ -0,0 +1,133 @@
+package management
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+)
+
+// FollowerDatabase is a database followed from a leader cluster, as reported by `.show follower databases`, run on the
+// follower cluster. Databases are attached as followers through Azure Resource Manager, these commands only change how
+// they follow their leader.
+type FollowerDatabase struct {
+	DatabaseName string
+	// OriginalDatabaseName is the name of the database on the leader cluster.
+	OriginalDatabaseName      string
+	LeaderClusterMetadataPath string
+	// CachingPolicyOverride is the caching policy overriding the leader's, as JSON, and CachingPoliciesModificationKind
+	// how it combines with the leader's policies.
+	CachingPolicyOverride           string
+	CachingPoliciesModificationKind string
+	// AuthorizedPrincipalsOverride are the principals overriding the leader's, as JSON, and
+	// AuthorizedPrincipalsModificationKind how they combine with the leader's principals.
+	AuthorizedPrincipalsOverride         string
+	AuthorizedPrincipalsModificationKind string
+	IsAutoPrefetchEnabled                bool
+	TableMetadataOverrides               string
+}
+
+// FollowerRole is a role the principals of a follower database can be given.
+type FollowerRole string
+
+const (
+	FollowerAdmins   FollowerRole = "admins"
+	FollowerUsers    FollowerRole = "users"
+	FollowerViewers  FollowerRole = "viewers"
+	FollowerMonitors FollowerRole = "monitors"
+)
+
+// ModificationKind is how the overrides of a follower database combine with the settings of its leader.
+type ModificationKind string
+
+const (
+	// ModificationKindNone ignores the overrides, keeping the leader's settings.
+	ModificationKindNone ModificationKind = "none"
+	// ModificationKindUnion combines the overrides with the leader's settings.
+	ModificationKindUnion ModificationKind = "union"
+	// ModificationKindReplace replaces the leader's settings with the overrides.
+	ModificationKindReplace ModificationKind = "replace"
+)
+
+// ShowFollowerDatabases returns the databases the cluster follows.
+func ShowFollowerDatabases(ctx context.Context, client Client, options ...azkustodata.QueryOption) ([]FollowerDatabase, error) {
+	return show[FollowerDatabase](ctx, client, "", kql.New(".show follower databases"), options)
+}
+
+// ShowFollowerDatabase returns a database the cluster follows.
+func ShowFollowerDatabase(ctx context.Context, client Client, name string, options ...azkustodata.QueryOption) (*FollowerDatabase, error) {
+	cmd := kql.New(".show follower database ").AddUnsafe(kql.NormalizeName(name))
+	return showOne[FollowerDatabase](ctx, client, "", cmd, options)
+}
+
+// AlterFollowerDatabaseCaching overrides the hot cache period of a follower database.
+func AlterFollowerDatabaseCaching(ctx context.Context, client Client, name string, hot time.Duration, options ...azkustodata.QueryOption) error {
+	cmd := followerCommand(".alter", name).AddLiteral(" policy caching hot = ").AddTimespan(hot)
+	return runFollower(ctx, client, cmd, options)
+}
+
+// DeleteFollowerDatabaseCaching deletes the override of the caching policy of a follower database.
+func DeleteFollowerDatabaseCaching(ctx context.Context, client Client, name string, options ...azkustodata.QueryOption) error {
+	return runFollower(ctx, client, followerCommand(".delete", name).AddLiteral(" policy caching"), options)
+}
+
+// AddFollowerDatabasePrincipals gives principals, such as "aaduser=user@contoso.com" or "aadapp=<app id>;<tenant id>", a
+// role on a follower database, with optional notes.
+func AddFollowerDatabasePrincipals(ctx context.Context, client Client, name string, role FollowerRole, principals []string, notes string, options ...azkustodata.QueryOption) error {
+	cmd := followerPrincipalsCommand(".add", name, role, principals)
+	if notes != "" {
+		cmd.AddLiteral(" ").AddString(notes)
+	}
+	return runFollower(ctx, client, cmd, options)
+}
+
+// DropFollowerDatabasePrincipals removes a role from principals on a follower database.
+func DropFollowerDatabasePrincipals(ctx context.Context, client Client, name string, role FollowerRole, principals []string, options ...azkustodata.QueryOption) error {
+	return runFollower(ctx, client, followerPrincipalsCommand(".drop", name, role, principals), options)
+}
+
+// SetFollowerDatabasePrincipalsModificationKind sets how the principals of a follower database combine with its
+// leader's.
+func SetFollowerDatabasePrincipalsModificationKind(ctx context.Context, client Client, name string, kind ModificationKind, options ...azkustodata.QueryOption) error {
+	cmd := followerCommand(".alter", name).AddLiteral(" principals-modification-kind = ").AddUnsafe(string(kind))
+	return runFollower(ctx, client, cmd, options)
+}
+
+// SetFollowerDatabaseCachingPoliciesModificationKind sets how the caching policies of a follower database combine with
+// its leader's.
+func SetFollowerDatabaseCachingPoliciesModificationKind(ctx context.Context, client Client, name string, kind ModificationKind, options ...azkustodata.QueryOption) error {
+	cmd := followerCommand(".alter", name).AddLiteral(" caching-policies-modification-kind = ").AddUnsafe(string(kind))
+	return runFollower(ctx, client, cmd, options)
+}
+
+// SetFollowerDatabasePrefetchExtents sets whether a follower database loads the new extents of its leader into its hot
+// cache before they are queryable.
+func SetFollowerDatabasePrefetchExtents(ctx context.Context, client Client, name string, enabled bool, options ...azkustodata.QueryOption) error {
+	cmd := followerCommand(".alter", name).AddLiteral(" prefetch-extents = ").AddUnsafe(strconv.FormatBool(enabled))
+	return runFollower(ctx, client, cmd, options)
+}
+
+// followerCommand starts a `<verb> follower database <name>` command.
+func followerCommand(verb string, name string) *kql.Builder {
+	return kql.New("").AddUnsafe(verb).AddLiteral(" follower database ").AddUnsafe(kql.NormalizeName(name))
+}
+
+// followerPrincipalsCommand builds a `<verb> follower database <name> <role> (<principals>)` command.
+func followerPrincipalsCommand(verb string, name string, role FollowerRole, principals []string) *kql.Builder {
+	cmd := followerCommand(verb, name).AddLiteral(" ").AddUnsafe(string(role)).AddLiteral(" (")
+	for i, p := range principals {
+		if i > 0 {
+			cmd.AddLiteral(", ")
+		}
+		cmd.AddString(p)
+	}
+	return cmd.AddLiteral(")")
+}
+
+// runFollower runs a follower command, which isn't scoped to a database.
+func runFollower(ctx context.Context, client Client, cmd azkustodata.Statement, options []azkustodata.QueryOption) error {
+	_, err := client.Mgmt(ctx, "", cmd, options...)
+	return err
+}
@@ -0,0 +1,55 @@
+package management
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowerDatabases(t *testing.T) {
+	empty := `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"DatabaseName","ColumnType":"string"}],"Rows":[]}]}`
+	client := &fakeClient{responses: map[string]string{
+		".show follower databases": `{"Tables":[{"TableName":"Table_0","Columns":[` +
+			`{"ColumnName":"DatabaseName","ColumnType":"string"},{"ColumnName":"LeaderClusterMetadataPath","ColumnType":"string"},` +
+			`{"ColumnName":"CachingPolicyOverride","ColumnType":"string"},{"ColumnName":"AuthorizedPrincipalsOverride","ColumnType":"string"},` +
+			`{"ColumnName":"AuthorizedPrincipalsModificationKind","ColumnType":"string"},{"ColumnName":"IsAutoPrefetchEnabled","ColumnType":"bool"},` +
+			`{"ColumnName":"TableMetadataOverrides","ColumnType":"string"},{"ColumnName":"CachingPoliciesModificationKind","ColumnType":"string"},` +
+			`{"ColumnName":"OriginalDatabaseName","ColumnType":"string"}],` +
+			`"Rows":[["db","https://leader/metadata","null","[]","None",true,"","Union","leaderdb"]]}]}`,
+		".alter follower database db policy caching hot = timespan(7.00:00:00.0000000)":                        empty,
+		".delete follower database db policy caching":                                                          empty,
+		".add follower database db viewers (\"aaduser=a@contoso.com\", \"aadapp=app;tenant\") \"the readers\"": empty,
+		".drop follower database db viewers (\"aaduser=a@contoso.com\")":                                       empty,
+		".alter follower database db principals-modification-kind = replace":                                   empty,
+		".alter follower database db caching-policies-modification-kind = union":                               empty,
+		".alter follower database db prefetch-extents = false":                                                 empty,
+	}}
+	ctx := context.Background()
+
+	dbs, err := ShowFollowerDatabases(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, []FollowerDatabase{{
+		DatabaseName:                         "db",
+		OriginalDatabaseName:                 "leaderdb",
+		LeaderClusterMetadataPath:            "https://leader/metadata",
+		CachingPolicyOverride:                "null",
+		CachingPoliciesModificationKind:      "Union",
+		AuthorizedPrincipalsOverride:         "[]",
+		AuthorizedPrincipalsModificationKind: "None",
+		IsAutoPrefetchEnabled:                true,
+	}}, dbs)
+
+	require.NoError(t, AlterFollowerDatabaseCaching(ctx, client, "db", 7*24*time.Hour))
+	require.NoError(t, DeleteFollowerDatabaseCaching(ctx, client, "db"))
+	require.NoError(t, AddFollowerDatabasePrincipals(ctx, client, "db", FollowerViewers, []string{"aaduser=a@contoso.com", "aadapp=app;tenant"}, "the readers"))
+	require.NoError(t, DropFollowerDatabasePrincipals(ctx, client, "db", FollowerViewers, []string{"aaduser=a@contoso.com"}))
+	require.NoError(t, SetFollowerDatabasePrincipalsModificationKind(ctx, client, "db", ModificationKindReplace))
+	require.NoError(t, SetFollowerDatabaseCachingPoliciesModificationKind(ctx, client, "db", ModificationKindUnion))
+	require.NoError(t, SetFollowerDatabasePrefetchExtents(ctx, client, "db", false))
+	for _, c := range client.commands {
+		assert.Equal(t, ": ", c[:2], "follower commands aren't scoped to a database")
+	}
+}
@@ -0,0 +1,102 @@
+package management
+
+import (
+	"context"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// Function is a stored function, as reported by `.show functions`.
+type Function struct {
+	Name string
+	// Parameters are the parameters of the function, as declared, such as "(State:string, MinCount:long)".
+	Parameters string
+	Body       string
+	Folder     string
+	DocString  string
+}
+
+// ShowFunctions returns the stored functions of a database.
+func ShowFunctions(ctx context.Context, client Client, db string, options ...azkustodata.QueryOption) ([]Function, error) {
+	return show[Function](ctx, client, db, kql.New(".show functions"), options)
+}
+
+// ShowFunction returns a stored function.
+func ShowFunction(ctx context.Context, client Client, db string, name string, options ...azkustodata.QueryOption) (*Function, error) {
+	cmd := kql.New(".show function ").AddUnsafe(kql.NormalizeName(name))
+	return showOne[Function](ctx, client, db, cmd, options)
+}
+
+// FunctionParameter is a scalar parameter of a function definition.
+type FunctionParameter struct {
+	Name string
+	Type types.Column
+	// Default is the value of the parameter when a call omits it, making it optional, or nil.
+	Default value.Kusto
+}
+
+// FunctionDefinition is the definition of a stored function, see CreateOrAlterFunction.
+type FunctionDefinition struct {
+	Name       string
+	Parameters []FunctionParameter
+	// Body is the body of the function, without its braces.
+	Body      string
+	Folder    string
+	DocString string
+	// SkipValidation creates the function even if its body doesn't compile, such as when it references tables that
+	// don't exist yet.
+	SkipValidation bool
+}
+
+// CreateOrAlterFunction creates a stored function, or replaces it if it exists, so that functions kept as code can be
+// deployed repeatedly. The function can then be called with kql.Builder.AddFunctionCall.
+func CreateOrAlterFunction(ctx context.Context, client Client, db string, function FunctionDefinition, options ...azkustodata.QueryOption) error {
+	cmd := kql.New(".create-or-alter function")
+	first := true
+	property := func(key string, v string) {
+		if first {
+			cmd.AddLiteral(" with (")
+			first = false
+		} else {
+			cmd.AddLiteral(", ")
+		}
+		cmd.AddUnsafe(key).AddLiteral("=").AddString(v)
+	}
+	if function.Folder != "" {
+		property("folder", function.Folder)
+	}
+	if function.DocString != "" {
+		property("docstring", function.DocString)
+	}
+	if function.SkipValidation {
+		property("skipvalidation", "true")
+	}
+	if !first {
+		cmd.AddLiteral(")")
+	}
+
+	cmd.AddLiteral(" ").AddFunction(function.Name).AddLiteral("(")
+	for i, p := range function.Parameters {
+		if i > 0 {
+			cmd.AddLiteral(", ")
+		}
+		cmd.AddColumn(p.Name).AddLiteral(":").AddUnsafe(string(p.Type))
+		if p.Default != nil {
+			cmd.AddLiteral(" = ").AddValue(p.Default)
+		}
+	}
+	cmd.AddLiteral(")\n{\n").AddUnsafe(function.Body).AddLiteral("\n}")
+
+	_, err := client.Mgmt(ctx, db, cmd, options...)
+	return err
+}
+
+// DropFunction drops a stored function, if it exists.
+func DropFunction(ctx context.Context, client Client, db string, name string, options ...azkustodata.QueryOption) error {
+	cmd := kql.New(".drop function ").AddUnsafe(kql.NormalizeName(name)).AddLiteral(" ifexists")
+	_, err := client.Mgmt(ctx, db, cmd, options...)
+	return err
+}
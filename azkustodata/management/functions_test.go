@@ -0,0 +1,46 @@
+package management
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunctions(t *testing.T) {
+	empty := `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"Name","ColumnType":"string"}],"Rows":[]}]}`
+	client := &fakeClient{responses: map[string]string{
+		".show functions": `{"Tables":[{"TableName":"Table_0","Columns":[` +
+			`{"ColumnName":"Name","ColumnType":"string"},{"ColumnName":"Parameters","ColumnType":"string"},` +
+			`{"ColumnName":"Body","ColumnType":"string"},{"ColumnName":"Folder","ColumnType":"string"},{"ColumnName":"DocString","ColumnType":"string"}],` +
+			`"Rows":[["EventsByState","(State:string, MinCount:long)","{ Events | where State == State }","reports","events of a state"]]}]}`,
+		".create-or-alter function with (folder=\"reports\", skipvalidation=\"true\") EventsByState(State:string, [\"Min Count\"]:long = long(1))\n{\nEvents | where State == State\n}": empty,
+		".drop function EventsByState ifexists": empty,
+	}}
+	ctx := context.Background()
+
+	functions, err := ShowFunctions(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Equal(t, []Function{{
+		Name:       "EventsByState",
+		Parameters: "(State:string, MinCount:long)",
+		Body:       "{ Events | where State == State }",
+		Folder:     "reports",
+		DocString:  "events of a state",
+	}}, functions)
+
+	require.NoError(t, CreateOrAlterFunction(ctx, client, "db", FunctionDefinition{
+		Name: "EventsByState",
+		Parameters: []FunctionParameter{
+			{Name: "State", Type: types.String},
+			{Name: "Min Count", Type: types.Long, Default: value.NewLong(1)},
+		},
+		Body:           "Events | where State == State",
+		Folder:         "reports",
+		SkipValidation: true,
+	}))
+	require.NoError(t, DropFunction(ctx, client, "db", "EventsByState"))
+}
@@ -0,0 +1,187 @@
+/*
+Package management provides typed wrappers for common `.show` management commands, decoding their results into structs.
+
+	tables, err := management.ShowTables(ctx, client, "database")
+	if err != nil {
+		return err
+	}
+	for _, t := range tables {
+		fmt.Println(t.TableName, t.Folder)
+	}
+
+The functions take any Client, which *azkustodata.Client implements. The options are passed on to Mgmt.
+*/
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/google/uuid"
+)
+
+// Client runs management commands. It is implemented by *azkustodata.Client.
+type Client interface {
+	Mgmt(ctx context.Context, db string, kqlQuery azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error)
+}
+
+// Table is a table of a database, as reported by `.show tables`.
+type Table struct {
+	TableName    string
+	DatabaseName string
+	Folder       string
+	DocString    string
+}
+
+// ShowTables returns the tables of a database.
+func ShowTables(ctx context.Context, client Client, db string, options ...azkustodata.QueryOption) ([]Table, error) {
+	return show[Table](ctx, client, db, kql.New(".show tables"), options)
+}
+
+// Column is a column of a table schema.
+type Column struct {
+	Name string
+	// Type is the Kusto type of the column.
+	Type      types.Column
+	DocString string
+}
+
+// TableSchema is the schema of a table, as reported by `.show table schema as json`.
+type TableSchema struct {
+	TableName    string
+	DatabaseName string
+	Folder       string
+	DocString    string
+	// Columns are the columns of the table, in order.
+	Columns []Column
+}
+
+// tableSchemaRow is a row of the results of `.show table schema as json`.
+type tableSchemaRow struct {
+	TableName    string
+	Schema       string
+	DatabaseName string
+	Folder       string
+	DocString    string
+}
+
+// ShowTableSchema returns the schema of a table.
+func ShowTableSchema(ctx context.Context, client Client, db string, table string, options ...azkustodata.QueryOption) (*TableSchema, error) {
+	cmd := kql.New(".show table ").AddUnsafe(kql.NormalizeName(table)).AddLiteral(" schema as json")
+	row, err := showOne[tableSchemaRow](ctx, client, db, cmd, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var schema struct {
+		OrderedColumns []struct {
+			Name      string
+			CslType   string
+			DocString string
+		}
+	}
+	if err := json.Unmarshal([]byte(row.Schema), &schema); err != nil {
+		return nil, errors.ES(errors.OpMgmt, errors.KFailedToParse, "could not parse the schema of table %s: %s", table, err)
+	}
+	result := &TableSchema{
+		TableName:    row.TableName,
+		DatabaseName: row.DatabaseName,
+		Folder:       row.Folder,
+		DocString:    row.DocString,
+		Columns:      make([]Column, 0, len(schema.OrderedColumns)),
+	}
+	for _, c := range schema.OrderedColumns {
+		result.Columns = append(result.Columns, Column{Name: c.Name, Type: types.Column(c.CslType), DocString: c.DocString})
+	}
+	return result, nil
+}
+
+// Database is a database of the cluster, as reported by `.show databases`.
+type Database struct {
+	DatabaseName       string
+	PersistentStorage  string
+	Version            string
+	IsCurrent          bool
+	DatabaseAccessMode string
+	PrettyName         string
+	DatabaseId         uuid.UUID
+}
+
+// ShowDatabases returns the databases of the cluster the caller has access to.
+func ShowDatabases(ctx context.Context, client Client, options ...azkustodata.QueryOption) ([]Database, error) {
+	return show[Database](ctx, client, "", kql.New(".show databases"), options)
+}
+
+// Version is the version of the cluster, as reported by `.show version`.
+type Version struct {
+	BuildVersion    string
+	BuildTime       time.Time
+	ServiceType     string
+	ProductVersion  string
+	ServiceOffering string
+}
+
+// ShowVersion returns the version of the cluster.
+func ShowVersion(ctx context.Context, client Client, options ...azkustodata.QueryOption) (*Version, error) {
+	return showOne[Version](ctx, client, "", kql.New(".show version"), options)
+}
+
+// Extent is a data shard of a table, as reported by `.show extents`.
+type Extent struct {
+	ExtentId     uuid.UUID
+	DatabaseName string
+	TableName    string
+	// OriginalSize is the size of the ingested data, and ExtentSize the size of the extent, CompressedSize of its data
+	// plus IndexSize of its index, in bytes.
+	OriginalSize   float64
+	ExtentSize     float64
+	CompressedSize float64
+	IndexSize      float64
+	RowCount       int64
+	MinCreatedOn   time.Time
+	MaxCreatedOn   time.Time
+	// Tags are the tags of the extent, separated by new lines.
+	Tags string
+}
+
+// ShowExtents returns the extents of a table, or of the whole database if table is empty.
+func ShowExtents(ctx context.Context, client Client, db string, table string, options ...azkustodata.QueryOption) ([]Extent, error) {
+	cmd := kql.New(".show database extents")
+	if table != "" {
+		cmd = kql.New(".show table ").AddUnsafe(kql.NormalizeName(table)).AddLiteral(" extents")
+	}
+	return show[Extent](ctx, client, db, cmd, options)
+}
+
+// show runs a `.show` command, and decodes the rows of its first table. The command only reads, so it can run on
+// read-only clients.
+func show[T any](ctx context.Context, client Client, db string, cmd azkustodata.Statement, options []azkustodata.QueryOption) ([]T, error) {
+	ds, err := client.Mgmt(ctx, db, cmd, append([]azkustodata.QueryOption{azkustodata.AllowControlCommands()}, options...)...)
+	if err != nil {
+		return nil, err
+	}
+	tables := ds.Tables()
+	if len(tables) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KInternal, "the command %q returned no table", cmd.String())
+	}
+	return query.ToStructs[T](tables[0])
+}
+
+// showOne runs a command returning a single row, and decodes it.
+func showOne[T any](ctx context.Context, client Client, db string, cmd azkustodata.Statement, options []azkustodata.QueryOption) (*T, error) {
+	rows, err := show[T](ctx, client, db, cmd, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KInternal, "the command %q returned no row", cmd.String())
+	}
+	return &rows[0], nil
+}
@@ -0,0 +1,130 @@
+package management
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient returns the v1 response of each command, recording the commands.
+type fakeClient struct {
+	responses map[string]string
+	commands  []string
+}
+
+func (f *fakeClient) Mgmt(ctx context.Context, db string, kqlQuery azkustodata.Statement, _ ...azkustodata.QueryOption) (v1.Dataset, error) {
+	f.commands = append(f.commands, db+": "+kqlQuery.String())
+	response, ok := f.responses[kqlQuery.String()]
+	if !ok {
+		return nil, errors.ES(errors.OpMgmt, errors.KHTTPError, "unexpected command %s", kqlQuery.String())
+	}
+	return v1.NewDatasetFromReader(ctx, errors.OpMgmt, io.NopCloser(strings.NewReader(response)))
+}
+
+func TestShow(t *testing.T) {
+	client := &fakeClient{responses: map[string]string{
+		".show tables": `{"Tables":[{"TableName":"Table_0","Columns":[` +
+			`{"ColumnName":"TableName","ColumnType":"string"},{"ColumnName":"DatabaseName","ColumnType":"string"},` +
+			`{"ColumnName":"Folder","ColumnType":"string"},{"ColumnName":"DocString","ColumnType":"string"}],` +
+			`"Rows":[["Events","db","logs","the events"],["Users","db","",""]]}]}`,
+		".show table [\"my table\"] schema as json": `{"Tables":[{"TableName":"Table_0","Columns":[` +
+			`{"ColumnName":"TableName","ColumnType":"string"},{"ColumnName":"Schema","ColumnType":"string"},` +
+			`{"ColumnName":"DatabaseName","ColumnType":"string"},{"ColumnName":"Folder","ColumnType":"string"},{"ColumnName":"DocString","ColumnType":"string"}],` +
+			`"Rows":[["my table","{\"Name\":\"my table\",\"OrderedColumns\":[{\"Name\":\"Timestamp\",\"Type\":\"System.DateTime\",\"CslType\":\"datetime\"},` +
+			`{\"Name\":\"Count\",\"Type\":\"System.Int64\",\"CslType\":\"long\",\"DocString\":\"how many\"}]}","db","",""]]}]}`,
+		".show databases": `{"Tables":[{"TableName":"Table_0","Columns":[` +
+			`{"ColumnName":"DatabaseName","ColumnType":"string"},{"ColumnName":"PersistentStorage","ColumnType":"string"},` +
+			`{"ColumnName":"Version","ColumnType":"string"},{"ColumnName":"IsCurrent","ColumnType":"bool"},` +
+			`{"ColumnName":"DatabaseAccessMode","ColumnType":"string"},{"ColumnName":"PrettyName","ColumnType":"string"},` +
+			`{"ColumnName":"ReservedSlot1","ColumnType":"bool"},{"ColumnName":"DatabaseId","ColumnType":"guid"}],` +
+			`"Rows":[["db","https://storage","v7.1",true,"ReadWrite","Pretty",false,"74be27de-1e4e-49d9-b579-fe0b331d3642"]]}]}`,
+		".show version": `{"Tables":[{"TableName":"Table_0","Columns":[` +
+			`{"ColumnName":"BuildVersion","ColumnType":"string"},{"ColumnName":"BuildTime","ColumnType":"datetime"},` +
+			`{"ColumnName":"ServiceType","ColumnType":"string"},{"ColumnName":"ProductVersion","ColumnType":"string"},` +
+			`{"ColumnName":"ServiceOffering","ColumnType":"string"}],` +
+			`"Rows":[["1.0.9","2024-01-02T03:04:05Z","Engine","KustoMain_2024.01.01","{}"]]}]}`,
+		".show table Events extents": `{"Tables":[{"TableName":"Table_0","Columns":[` +
+			`{"ColumnName":"ExtentId","ColumnType":"guid"},{"ColumnName":"DatabaseName","ColumnType":"string"},` +
+			`{"ColumnName":"TableName","ColumnType":"string"},{"ColumnName":"MaxCreatedOn","ColumnType":"datetime"},` +
+			`{"ColumnName":"OriginalSize","ColumnType":"real"},{"ColumnName":"ExtentSize","ColumnType":"real"},` +
+			`{"ColumnName":"RowCount","ColumnType":"long"},{"ColumnName":"Tags","ColumnType":"string"}],` +
+			`"Rows":[["74be27de-1e4e-49d9-b579-fe0b331d3642","db","Events","2024-01-02T03:04:05Z",1024.0,256.0,10,"drop-by:a"]]}]}`,
+		".show database extents": `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"ExtentId","ColumnType":"guid"}],"Rows":[]}]}`,
+	}}
+	ctx := context.Background()
+	id := uuid.MustParse("74be27de-1e4e-49d9-b579-fe0b331d3642")
+	built := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tables, err := ShowTables(ctx, client, "db")
+	require.NoError(t, err)
+	assert.Equal(t, []Table{
+		{TableName: "Events", DatabaseName: "db", Folder: "logs", DocString: "the events"},
+		{TableName: "Users", DatabaseName: "db"},
+	}, tables)
+
+	schema, err := ShowTableSchema(ctx, client, "db", "my table")
+	require.NoError(t, err)
+	assert.Equal(t, &TableSchema{
+		TableName:    "my table",
+		DatabaseName: "db",
+		Columns: []Column{
+			{Name: "Timestamp", Type: types.DateTime},
+			{Name: "Count", Type: types.Long, DocString: "how many"},
+		},
+	}, schema)
+
+	databases, err := ShowDatabases(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, []Database{{
+		DatabaseName:       "db",
+		PersistentStorage:  "https://storage",
+		Version:            "v7.1",
+		IsCurrent:          true,
+		DatabaseAccessMode: "ReadWrite",
+		PrettyName:         "Pretty",
+		DatabaseId:         id,
+	}}, databases)
+
+	version, err := ShowVersion(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, &Version{BuildVersion: "1.0.9", BuildTime: built, ServiceType: "Engine", ProductVersion: "KustoMain_2024.01.01", ServiceOffering: "{}"}, version)
+
+	extents, err := ShowExtents(ctx, client, "db", "Events")
+	require.NoError(t, err)
+	assert.Equal(t, []Extent{{
+		ExtentId:     id,
+		DatabaseName: "db",
+		TableName:    "Events",
+		MaxCreatedOn: built,
+		OriginalSize: 1024,
+		ExtentSize:   256,
+		RowCount:     10,
+		Tags:         "drop-by:a",
+	}}, extents)
+
+	extents, err = ShowExtents(ctx, client, "db", "")
+	require.NoError(t, err)
+	assert.Empty(t, extents)
+
+	assert.Equal(t, []string{
+		"db: .show tables",
+		"db: .show table [\"my table\"] schema as json",
+		": .show databases",
+		": .show version",
+		"db: .show table Events extents",
+		"db: .show database extents",
+	}, client.commands)
+
+	_, err = ShowTableSchema(ctx, client, "db", "missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,174 @@
+package management
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+)
+
+// MaterializedView is a materialized view, as reported by `.show materialized-view`.
+type MaterializedView struct {
+	Name        string
+	SourceTable string
+	Query       string
+	// MaterializedTo is the ingestion time of the source records the view has materialized up to, and LastRun when
+	// the view's materialization last ran, with LastRunResult its result.
+	MaterializedTo    time.Time
+	LastRun           time.Time
+	LastRunResult     string
+	IsHealthy         bool
+	IsEnabled         bool
+	Folder            string
+	DocString         string
+	AutoUpdateSchema  bool
+	EffectiveDateTime time.Time
+	Lookback          time.Duration
+}
+
+// ShowMaterializedViews returns the materialized views of a database.
+func ShowMaterializedViews(ctx context.Context, client Client, db string, options ...azkustodata.QueryOption) ([]MaterializedView, error) {
+	return show[MaterializedView](ctx, client, db, kql.New(".show materialized-views"), options)
+}
+
+// ShowMaterializedView returns a materialized view.
+func ShowMaterializedView(ctx context.Context, client Client, db string, name string, options ...azkustodata.QueryOption) (*MaterializedView, error) {
+	cmd := kql.New(".show materialized-view ").AddUnsafe(kql.NormalizeName(name))
+	return showOne[MaterializedView](ctx, client, db, cmd, options)
+}
+
+// MaterializedViewDetails are the details of a materialized view, as reported by `.show materialized-view details`.
+type MaterializedViewDetails struct {
+	MaterializedViewName string
+	DatabaseName         string
+	SourceTable          string
+	// Health is "Healthy" or "Unhealthy".
+	Health         string
+	ExtentsCount   int64
+	ExtentsSize    float64
+	CompressedSize float64
+	IndexSize      float64
+	TotalRowCount  int64
+	// MaterializedTo is the ingestion time of the source records the view has materialized up to, and LastRun when
+	// the view's materialization last ran.
+	MaterializedTo time.Time
+	LastRun        time.Time
+}
+
+// Lag returns how far behind its source table the materialized part of the view was at the given time. Queries on the
+// view still return the records it hasn't materialized yet, aggregating them at query time, which gets slower as the
+// lag grows. A reader accepting stale data can query only the materialized part with MaterializedViewQuery and a max
+// age greater than the lag.
+func (d *MaterializedViewDetails) Lag(now time.Time) time.Duration {
+	if d.MaterializedTo.IsZero() {
+		return 0
+	}
+	return now.Sub(d.MaterializedTo)
+}
+
+// ShowMaterializedViewDetails returns the details of a materialized view.
+func ShowMaterializedViewDetails(ctx context.Context, client Client, db string, name string, options ...azkustodata.QueryOption) (*MaterializedViewDetails, error) {
+	cmd := kql.New(".show materialized-view ").AddUnsafe(kql.NormalizeName(name)).AddLiteral(" details")
+	return showOne[MaterializedViewDetails](ctx, client, db, cmd, options)
+}
+
+// MaterializedViewOptions are the properties of a materialized view, see
+// https://learn.microsoft.com/kusto/management/materialized-views/materialized-view-create for the details of each.
+// Zero values aren't sent, leaving the cluster's defaults.
+type MaterializedViewOptions struct {
+	// IfNotExists doesn't fail the creation of a view that already exists. It is ignored by AlterMaterializedView.
+	IfNotExists bool
+	// Backfill materializes the records already in the source table when the view is created, rather than only those
+	// ingested after. Backfilling large tables may take hours.
+	Backfill bool
+	// EffectiveDateTime limits the backfill to the records ingested after it.
+	EffectiveDateTime time.Time
+	// UpdateExtentsCreationTime sets the creation time of the backfilled extents from the records' ingestion time.
+	UpdateExtentsCreationTime bool
+	// AutoUpdateSchema alters the view when columns are added to the source table.
+	AutoUpdateSchema bool
+	// Lookback limits the deduplication of arg_max, arg_min and take_any views to the records ingested in this period.
+	Lookback  time.Duration
+	Folder    string
+	DocString string
+}
+
+// CreateMaterializedView creates a materialized view of a source table, with an aggregation query such as
+// `Events | summarize arg_max(Timestamp, *) by Id`.
+func CreateMaterializedView(ctx context.Context, client Client, db string, name string, sourceTable string, viewQuery azkustodata.Statement, options MaterializedViewOptions, queryOptions ...azkustodata.QueryOption) error {
+	cmd := kql.New(".create ")
+	if options.IfNotExists {
+		cmd.AddLiteral("ifnotexists ")
+	}
+	cmd.AddLiteral("materialized-view")
+	materializedViewDefinition(cmd, name, sourceTable, viewQuery, options)
+	_, err := client.Mgmt(ctx, db, cmd, queryOptions...)
+	return err
+}
+
+// AlterMaterializedView changes the aggregation query of a materialized view. Only some properties can be altered, such
+// as Lookback, see https://learn.microsoft.com/kusto/management/materialized-views/materialized-view-alter.
+func AlterMaterializedView(ctx context.Context, client Client, db string, name string, sourceTable string, viewQuery azkustodata.Statement, options MaterializedViewOptions, queryOptions ...azkustodata.QueryOption) error {
+	options.IfNotExists = false
+	cmd := kql.New(".alter materialized-view")
+	materializedViewDefinition(cmd, name, sourceTable, viewQuery, options)
+	_, err := client.Mgmt(ctx, db, cmd, queryOptions...)
+	return err
+}
+
+// materializedViewDefinition adds ` [with (...)] <name> on table <source>\n{\n<query>\n}` to a command.
+func materializedViewDefinition(cmd *kql.Builder, name string, sourceTable string, viewQuery azkustodata.Statement, options MaterializedViewOptions) {
+	first := true
+	property := func(key string) *kql.Builder {
+		if first {
+			cmd.AddLiteral(" with (")
+			first = false
+		} else {
+			cmd.AddLiteral(", ")
+		}
+		return cmd.AddUnsafe(key).AddLiteral("=")
+	}
+	if options.Backfill {
+		property("backfill").AddLiteral("true")
+	}
+	if !options.EffectiveDateTime.IsZero() {
+		property("effectiveDateTime").AddDateTime(options.EffectiveDateTime)
+	}
+	if options.UpdateExtentsCreationTime {
+		property("updateExtentsCreationTime").AddLiteral("true")
+	}
+	if options.AutoUpdateSchema {
+		property("autoUpdateSchema").AddLiteral("true")
+	}
+	if options.Lookback > 0 {
+		property("lookback").AddTimespan(options.Lookback)
+	}
+	if options.Folder != "" {
+		property("folder").AddString(options.Folder)
+	}
+	if options.DocString != "" {
+		property("docString").AddString(options.DocString)
+	}
+	if !first {
+		cmd.AddLiteral(")")
+	}
+
+	cmd.AddLiteral(" ").AddUnsafe(kql.NormalizeName(name)).
+		AddLiteral(" on table ").AddUnsafe(kql.NormalizeName(sourceTable)).
+		AddLiteral("\n{\n").AddUnsafe(viewQuery.String()).AddLiteral("\n}")
+}
+
+// MaterializedViewQuery returns a query of a materialized view with materialized_view(), to which operators can be
+// added. Unlike querying the view by its name, it only returns the materialized part of the view, which is faster but
+// may miss the latest records. With a positive maxAge, the query returns the whole view if the lag of its materialized
+// part is greater than maxAge, so the results are never staler than maxAge.
+//
+//	q := management.MaterializedViewQuery("LatestEvents", 5*time.Minute).AddLiteral("\n| count")
+func MaterializedViewQuery(name string, maxAge time.Duration) *kql.Builder {
+	q := kql.New("materialized_view(").AddString(name)
+	if maxAge > 0 {
+		q.AddLiteral(", ").AddTimespan(maxAge)
+	}
+	return q.AddLiteral(")")
+}
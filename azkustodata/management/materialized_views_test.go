@@ -0,0 +1,66 @@
+package management
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaterializedViews(t *testing.T) {
+	client := &fakeClient{responses: map[string]string{
+		".show materialized-view LatestEvents": `{"Tables":[{"TableName":"Table_0","Columns":[` +
+			`{"ColumnName":"Name","ColumnType":"string"},{"ColumnName":"SourceTable","ColumnType":"string"},` +
+			`{"ColumnName":"Query","ColumnType":"string"},{"ColumnName":"MaterializedTo","ColumnType":"datetime"},` +
+			`{"ColumnName":"IsHealthy","ColumnType":"bool"},{"ColumnName":"IsEnabled","ColumnType":"bool"},` +
+			`{"ColumnName":"Lookback","ColumnType":"timespan"}],` +
+			`"Rows":[["LatestEvents","Events","Events | summarize arg_max(Timestamp, *) by Id","2024-01-02T03:00:00Z",true,true,"06:00:00"]]}]}`,
+		".show materialized-view LatestEvents details": `{"Tables":[{"TableName":"Table_0","Columns":[` +
+			`{"ColumnName":"MaterializedViewName","ColumnType":"string"},{"ColumnName":"DatabaseName","ColumnType":"string"},` +
+			`{"ColumnName":"SourceTable","ColumnType":"string"},{"ColumnName":"Health","ColumnType":"string"},` +
+			`{"ColumnName":"TotalRowCount","ColumnType":"long"},{"ColumnName":"MaterializedTo","ColumnType":"datetime"}],` +
+			`"Rows":[["LatestEvents","db","Events","Healthy",1000,"2024-01-02T03:00:00Z"]]}]}`,
+		".create ifnotexists materialized-view with (backfill=true, effectiveDateTime=datetime(2024-01-01T00:00:00.0000000Z), lookback=timespan(06:00:00.0000000), folder=\"views\") LatestEvents on table Events\n{\nEvents | summarize arg_max(Timestamp, *) by Id\n}": `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"Name","ColumnType":"string"}],"Rows":[]}]}`,
+		".alter materialized-view LatestEvents on table Events\n{\nEvents | summarize arg_max(Timestamp, *) by Id\n}":                                                                                                                                                    `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"Name","ColumnType":"string"}],"Rows":[]}]}`,
+	}}
+	ctx := context.Background()
+	materializedTo := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	view, err := ShowMaterializedView(ctx, client, "db", "LatestEvents")
+	require.NoError(t, err)
+	assert.Equal(t, &MaterializedView{
+		Name:           "LatestEvents",
+		SourceTable:    "Events",
+		Query:          "Events | summarize arg_max(Timestamp, *) by Id",
+		MaterializedTo: materializedTo,
+		IsHealthy:      true,
+		IsEnabled:      true,
+		Lookback:       6 * time.Hour,
+	}, view)
+
+	details, err := ShowMaterializedViewDetails(ctx, client, "db", "LatestEvents")
+	require.NoError(t, err)
+	assert.Equal(t, "Healthy", details.Health)
+	assert.Equal(t, int64(1000), details.TotalRowCount)
+	assert.Equal(t, 5*time.Minute, details.Lag(materializedTo.Add(5*time.Minute)))
+	assert.Zero(t, (&MaterializedViewDetails{}).Lag(materializedTo))
+
+	viewQuery := kql.New("Events | summarize arg_max(Timestamp, *) by Id")
+	require.NoError(t, CreateMaterializedView(ctx, client, "db", "LatestEvents", "Events", viewQuery, MaterializedViewOptions{
+		IfNotExists:       true,
+		Backfill:          true,
+		EffectiveDateTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Lookback:          6 * time.Hour,
+		Folder:            "views",
+	}))
+	require.NoError(t, AlterMaterializedView(ctx, client, "db", "LatestEvents", "Events", viewQuery, MaterializedViewOptions{IfNotExists: true}))
+}
+
+func TestMaterializedViewQuery(t *testing.T) {
+	assert.Equal(t, `materialized_view("LatestEvents")`, MaterializedViewQuery("LatestEvents", 0).String())
+	assert.Equal(t, "materialized_view(\"LatestEvents\", timespan(00:05:00.0000000))\n| count",
+		MaterializedViewQuery("LatestEvents", 5*time.Minute).AddLiteral("\n| count").String())
+}
@@ -0,0 +1,67 @@
+package azkustodata
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+)
+
+// Metrics receives measurements of the client's operations, to be recorded in a metrics system such as Prometheus or
+// OpenTelemetry. The operation argument is "query" or "mgmt".
+// Methods may be called concurrently and should return quickly. Embed NoopMetrics to only implement some of them.
+type Metrics interface {
+	// ObserveQueryDuration is called when a Query or Mgmt call ends, with the time it took to receive and parse the
+	// results. For IterativeQuery, it is the time it took to receive the response headers.
+	ObserveQueryDuration(ctx context.Context, operation string, db string, duration time.Duration, err error)
+	// ObserveRowsRead is called when a Query or Mgmt call succeeds, with the number of rows of its result tables.
+	ObserveRowsRead(ctx context.Context, operation string, db string, rows int)
+	// ObserveIngestBytes is called when an ingestion succeeds, with the number of bytes read from the local file or
+	// io.Reader. It isn't called for blobs, as the client doesn't read them.
+	ObserveIngestBytes(ctx context.Context, db string, table string, bytes int64)
+	// ObserveRetries is called for each request sent to the cluster, with the number of times it was retried.
+	ObserveRetries(ctx context.Context, operation string, db string, retries int)
+	// ObserveAuthLatency is called each time a token is acquired from the credential, with the time it took.
+	// It isn't called when a cached token is used.
+	ObserveAuthLatency(ctx context.Context, duration time.Duration, err error)
+}
+
+// NoopMetrics is the default Metrics, which discards all measurements.
+type NoopMetrics struct{}
+
+func (NoopMetrics) ObserveQueryDuration(context.Context, string, string, time.Duration, error) {}
+func (NoopMetrics) ObserveRowsRead(context.Context, string, string, int)                       {}
+func (NoopMetrics) ObserveIngestBytes(context.Context, string, string, int64)                  {}
+func (NoopMetrics) ObserveRetries(context.Context, string, string, int)                        {}
+func (NoopMetrics) ObserveAuthLatency(context.Context, time.Duration, error)                   {}
+
+// WithMetrics sets the Metrics the client reports its measurements to.
+func WithMetrics(metrics Metrics) Option {
+	return func(c *Client) {
+		if metrics == nil {
+			metrics = NoopMetrics{}
+		}
+		c.metrics = metrics
+	}
+}
+
+// operation returns the name of the call type, as reported in metrics and spans.
+func (t callType) operation() string {
+	if t == mgmtCall {
+		return "mgmt"
+	}
+	return "query"
+}
+
+// observeCall reports the duration of a call that started at start, and the rows of its result if it succeeded.
+func (c *Client) observeCall(ctx context.Context, callType callType, db string, start time.Time, ds query.Dataset, err error) {
+	c.metrics.ObserveQueryDuration(ctx, callType.operation(), db, time.Since(start), err)
+	if err != nil {
+		return
+	}
+	rows := 0
+	for _, table := range ds.Tables() {
+		rows += len(table.Rows())
+	}
+	c.metrics.ObserveRowsRead(ctx, callType.operation(), db, rows)
+}
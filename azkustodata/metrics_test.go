@@ -0,0 +1,89 @@
+package azkustodata
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingMetrics struct {
+	NoopMetrics
+	mu        sync.Mutex
+	durations []string
+	rows      []int
+	retries   []int
+	auths     int
+}
+
+func (m *recordingMetrics) ObserveQueryDuration(_ context.Context, operation string, db string, _ time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.durations = append(m.durations, operation+"/"+db+"/"+result)
+}
+
+func (m *recordingMetrics) ObserveRowsRead(_ context.Context, _ string, _ string, rows int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rows = append(m.rows, rows)
+}
+
+func (m *recordingMetrics) ObserveRetries(_ context.Context, _ string, _ string, retries int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries = append(m.retries, retries)
+}
+
+func (m *recordingMetrics) ObserveAuthLatency(_ context.Context, _ time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.auths++
+}
+
+func TestMetrics(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int32
+		wantDurations []string
+		wantRows      []int
+		wantRetries   []int
+	}{
+		{name: "Success", wantDurations: []string{"mgmt/db/ok"}, wantRows: []int{1}, wantRetries: []int{0}},
+		{name: "Retried", failures: 1, wantDurations: []string{"mgmt/db/ok"}, wantRows: []int{1}, wantRetries: []int{1}},
+		{name: "Failure", failures: 3, wantDurations: []string{"mgmt/db/error"}, wantRetries: []int{2}},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := newFlakyTestServer(tt.failures, http.StatusServiceUnavailable, &attempts)
+			defer srv.Close()
+
+			metrics := &recordingMetrics{}
+			client, err := New(NewConnectionStringBuilder(srv.URL).WithTokenCredential(&fakeCredential{}), WithHttpClient(srv.Client()),
+				WithoutTokenCache(), WithMetrics(metrics),
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}))
+			require.NoError(t, err)
+			defer client.Close()
+
+			_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"))
+			assert.Equal(t, tt.failures >= 3, err != nil)
+
+			assert.Equal(t, tt.wantDurations, metrics.durations)
+			assert.Equal(t, tt.wantRows, metrics.rows)
+			assert.Equal(t, tt.wantRetries, metrics.retries)
+			// Without the token cache, each attempt acquires a token.
+			assert.Equal(t, int(attempts.Load()), metrics.auths)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package azkustodata
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Middleware observes or mutates a request sent to the cluster, and its response. It sends the request by calling next,
+// and may change the request before, or the response after. Middleware can be used for auditing, adding headers required
+// by a gateway, or capturing requests.
+//
+// Only requests to the cluster go through middleware, not the ones to the identity provider or to storage.
+// Request and response bodies are streams: middleware that reads them must replace them, see DumpMiddleware.
+type Middleware func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error)
+
+// WithMiddleware adds middleware to the client's requests to the cluster. The first middleware is the outermost one: it
+// sees the request first, and the response last.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// middlewareTransport runs the middleware of requests to host, before sending them with next.
+type middlewareTransport struct {
+	host       string
+	middleware []Middleware
+	next       http.RoundTripper
+}
+
+func (t *middlewareTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.EqualFold(req.URL.Host, t.host) {
+		return t.next.RoundTrip(req)
+	}
+	return t.send(0, req)
+}
+
+func (t *middlewareTransport) send(i int, req *http.Request) (*http.Response, error) {
+	if i == len(t.middleware) {
+		return t.next.RoundTrip(req)
+	}
+	return t.middleware[i](req, func(req *http.Request) (*http.Response, error) {
+		return t.send(i+1, req)
+	})
+}
+
+// withMiddleware returns a copy of client that runs middleware on the requests to endpoint.
+func withMiddleware(client *http.Client, endpoint string, middleware []Middleware) (*http.Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	wrapped := *client
+	wrapped.Transport = &middlewareTransport{host: u.Host, middleware: middleware, next: next}
+	return &wrapped, nil
+}
+
+// DumpMiddleware returns middleware writing every request and response to w, with their headers. The Authorization
+// header is redacted.
+// If bodies is set, the bodies are written too. This is meant for debugging only: the bodies are held in memory, so
+// large results are no longer streamed, and they can contain sensitive data.
+func DumpMiddleware(w io.Writer, bodies bool) Middleware {
+	var lock sync.Mutex
+	write := func(dump []byte) {
+		lock.Lock()
+		defer lock.Unlock()
+		_, _ = fmt.Fprintf(w, "%s\n", dump)
+	}
+
+	return func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+		redacted := *req
+		redacted.Header = req.Header.Clone()
+		if redacted.Header.Get("Authorization") != "" {
+			redacted.Header.Set("Authorization", "REDACTED")
+		}
+		dump, err := httputil.DumpRequestOut(&redacted, bodies)
+		if err != nil {
+			return nil, err
+		}
+		// Dumping the body replaces it with a copy, the original was consumed.
+		req.Body = redacted.Body
+		write(dump)
+
+		resp, err := next(req)
+		if err != nil {
+			return nil, err
+		}
+		dump, err = httputil.DumpResponse(resp, bodies)
+		if err != nil {
+			return nil, err
+		}
+		write(dump)
+		return resp, nil
+	}
+}
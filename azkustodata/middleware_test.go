@@ -0,0 +1,70 @@
+package azkustodata
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	var gotGatewayKey string
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotGatewayKey = r.Header.Get("x-gateway-key")
+		_, _ = w.Write([]byte(verifyTestShowVersion))
+	})
+
+	var order, paths []string
+	audit := func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+		order = append(order, "audit")
+		paths = append(paths, req.URL.Path)
+		return next(req)
+	}
+	gateway := func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+		order = append(order, "gateway")
+		req.Header.Set("x-gateway-key", "secret")
+		return next(req)
+	}
+	var dump bytes.Buffer
+
+	client := newTestKustoClient(t, srv, WithMiddleware(audit, gateway), WithMiddleware(DumpMiddleware(&dump, true)))
+
+	ds, err := client.Mgmt(context.Background(), "db", kql.New(".show version"))
+	require.NoError(t, err)
+	assert.Len(t, ds.Tables(), 1)
+
+	assert.Equal(t, "secret", gotGatewayKey)
+	assert.Equal(t, []string{"audit", "gateway", "audit", "gateway"}, order)
+	assert.Equal(t, []string{metadataPath, "/v1/rest/mgmt"}, paths)
+
+	assert.Contains(t, dump.String(), "POST /v1/rest/mgmt")
+	assert.Contains(t, dump.String(), `"csl":".show version"`)
+	assert.Contains(t, dump.String(), "BuildVersion")
+	assert.Contains(t, dump.String(), "Authorization: REDACTED")
+	assert.NotContains(t, dump.String(), "fake-token")
+}
+
+func TestMiddlewareSkipsOtherHosts(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer other.Close()
+
+	called := false
+	client, err := New(NewConnectionStringBuilder("https://help.kusto.windows.net").WithTokenCredential(&fakeCredential{}),
+		WithMiddleware(func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+			called = true
+			return next(req)
+		}))
+	require.NoError(t, err)
+	defer client.Close()
+
+	// Requests to storage or the identity provider share the http client, but don't go through middleware.
+	resp, err := client.HttpClient().Get(other.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.False(t, called)
+}
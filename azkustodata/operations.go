@@ -0,0 +1,261 @@
+package azkustodata
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/google/uuid"
+)
+
+// DefaultOperationPollInterval is the interval Operation.Wait polls at when it is given a non-positive interval.
+const DefaultOperationPollInterval = 5 * time.Second
+
+// OperationState is the state of an async management command, as reported by `.show operations`.
+type OperationState string
+
+const (
+	OperationInProgress         OperationState = "InProgress"
+	OperationScheduled          OperationState = "Scheduled"
+	OperationThrottled          OperationState = "Throttled"
+	OperationCompleted          OperationState = "Completed"
+	OperationPartiallySucceeded OperationState = "PartiallySucceeded"
+	OperationFailed             OperationState = "Failed"
+	OperationAbandoned          OperationState = "Abandoned"
+	OperationBadInput           OperationState = "BadInput"
+	OperationCanceled           OperationState = "Canceled"
+	OperationSkipped            OperationState = "Skipped"
+)
+
+// IsTerminal returns whether the operation has ended, in which case its state won't change anymore.
+func (s OperationState) IsTerminal() bool {
+	switch s {
+	case OperationInProgress, OperationScheduled, OperationThrottled:
+		return false
+	}
+	return true
+}
+
+// OperationStatus is the status of an async management command, as reported by `.show operations`.
+type OperationStatus struct {
+	// ID is the id of the operation.
+	ID uuid.UUID
+	// Operation is the kind of the operation, such as "DataExportToFile".
+	Operation string
+	// State is the state of the operation.
+	State OperationState
+	// Status holds details about the state, such as the error of a failed operation.
+	Status string
+	// StartedOn and LastUpdatedOn are when the operation started, and when its state last changed.
+	StartedOn     time.Time
+	LastUpdatedOn time.Time
+	// Duration is how long the operation ran.
+	Duration time.Duration
+	// ShouldRetry is whether the operation failed with a transient error, so running the command again may succeed.
+	ShouldRetry bool
+	// Database is the database the command ran in.
+	Database string
+	// RootActivityID is the activity id of the command, for troubleshooting with the cluster's diagnostics.
+	RootActivityID uuid.UUID
+}
+
+// OperationError is returned by Operation.Wait when the operation ended in a state other than OperationCompleted.
+type OperationError struct {
+	// Status is the final status of the operation.
+	Status OperationStatus
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("operation %s ended in state %s: %s", e.Status.ID, e.Status.State, e.Status.Status)
+}
+
+// operationRow is a row of the results of `.show operations`.
+type operationRow struct {
+	OperationId    uuid.UUID
+	Operation      string
+	State          string
+	Status         string
+	StartedOn      time.Time
+	LastUpdatedOn  time.Time
+	Duration       time.Duration
+	ShouldRetry    bool
+	Database       string
+	RootActivityId uuid.UUID
+}
+
+// toStatus returns the status of an operation reported by a row.
+func (r operationRow) toStatus() OperationStatus {
+	return OperationStatus{
+		ID:             r.OperationId,
+		Operation:      r.Operation,
+		State:          OperationState(r.State),
+		Status:         r.Status,
+		StartedOn:      r.StartedOn,
+		LastUpdatedOn:  r.LastUpdatedOn,
+		Duration:       r.Duration,
+		ShouldRetry:    r.ShouldRetry,
+		Database:       r.Database,
+		RootActivityID: r.RootActivityId,
+	}
+}
+
+// Operation is a handle on an async management command, such as `.export async` or `.set-or-append async`, which runs
+// on the cluster after the call that started it returned.
+type Operation struct {
+	client  *Client
+	db      string
+	id      uuid.UUID
+	options []QueryOption
+}
+
+// SubmitAsync runs an async management command, and returns a handle on the operation it started. The command must
+// return an OperationId column, as commands run with the async keyword do.
+// The options are used for the command, and for the `.show operations` commands polling the operation.
+func (c *Client) SubmitAsync(ctx context.Context, db string, command Statement, options ...QueryOption) (*Operation, error) {
+	ds, err := c.Mgmt(ctx, db, command, options...)
+	if err != nil {
+		return nil, err
+	}
+	tables := ds.Tables()
+	if len(tables) == 0 || len(tables[0].Rows()) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KInternal, "the command didn't return an operation id, make sure it runs with the async keyword")
+	}
+	id, err := tables[0].Rows()[0].GuidByName("OperationId")
+	if err != nil {
+		return nil, errors.ES(errors.OpMgmt, errors.KInternal, "the command didn't return an operation id, make sure it runs with the async keyword: %s", err)
+	}
+	if id == nil {
+		return nil, errors.ES(errors.OpMgmt, errors.KInternal, "the command returned an empty operation id")
+	}
+	return c.Operation(db, *id, options...), nil
+}
+
+// Operation returns a handle on an operation started earlier, by its id.
+// The options are used for the `.show operations` commands polling the operation.
+func (c *Client) Operation(db string, id uuid.UUID, options ...QueryOption) *Operation {
+	return &Operation{client: c, db: db, id: id, options: options}
+}
+
+// ID returns the id of the operation.
+func (o *Operation) ID() uuid.UUID {
+	return o.id
+}
+
+// Poll returns the current status of the operation.
+func (o *Operation) Poll(ctx context.Context) (OperationStatus, error) {
+	return o.client.Operations(o.db, o.options...).Get(ctx, o.id)
+}
+
+// Wait polls the operation at the given interval until it ends, and returns its final status. If the operation didn't
+// complete successfully, the status is returned along with an *OperationError. A non-positive interval polls every
+// DefaultOperationPollInterval.
+func (o *Operation) Wait(ctx context.Context, interval time.Duration) (OperationStatus, error) {
+	if interval <= 0 {
+		interval = DefaultOperationPollInterval
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return OperationStatus{}, errors.E(errors.OpMgmt, errors.KTimeout, ctx.Err()).SetNoRetry()
+		case <-timer.C:
+		}
+
+		status, err := o.Poll(ctx)
+		if err != nil {
+			return OperationStatus{}, err
+		}
+		if status.State.IsTerminal() {
+			if status.State != OperationCompleted {
+				return status, &OperationError{Status: status}
+			}
+			return status, nil
+		}
+		timer.Reset(interval)
+	}
+}
+
+// OperationsClient reads the status of the async operations of a cluster with `.show operations`.
+type OperationsClient struct {
+	client  *Client
+	db      string
+	options []QueryOption
+}
+
+// Operations returns an OperationsClient. The options are used for its `.show operations` commands.
+func (c *Client) Operations(db string, options ...QueryOption) *OperationsClient {
+	return &OperationsClient{client: c, db: db, options: options}
+}
+
+// List returns the current status of the operations started since the given time, in the order they started. A zero
+// since lists all the operations the cluster keeps, and an empty state the operations in any state.
+func (o *OperationsClient) List(ctx context.Context, since time.Time, state OperationState) ([]OperationStatus, error) {
+	cmd := kql.New(".show operations")
+	if !since.IsZero() {
+		cmd.AddLiteral("\n| where StartedOn >= ").AddDateTime(since)
+	}
+	statuses, err := o.show(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	if state == "" {
+		return statuses, nil
+	}
+	var filtered []OperationStatus
+	for _, s := range statuses {
+		if s.State == state {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered, nil
+}
+
+// Get returns the current status of an operation.
+func (o *OperationsClient) Get(ctx context.Context, id uuid.UUID) (OperationStatus, error) {
+	statuses, err := o.show(ctx, kql.New(".show operations ").AddUnsafe(id.String()))
+	if err != nil {
+		return OperationStatus{}, err
+	}
+	if len(statuses) == 0 {
+		return OperationStatus{}, errors.ES(errors.OpMgmt, errors.KOther, "operation %s was not found", id).SetNoRetry()
+	}
+	return statuses[0], nil
+}
+
+// show runs a `.show operations` command, and returns the current status of each operation.
+func (o *OperationsClient) show(ctx context.Context, cmd Statement) ([]OperationStatus, error) {
+	// The command only reads, so it can run on read-only clients.
+	ds, err := o.client.Mgmt(ctx, o.db, cmd, append([]QueryOption{AllowControlCommands()}, o.options...)...)
+	if err != nil {
+		return nil, err
+	}
+	var rows []operationRow
+	if tables := ds.Tables(); len(tables) > 0 {
+		rows, err = query.ToStructs[operationRow](tables[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// An operation may be reported once per state it went through, the latest is the current one.
+	latest := make(map[uuid.UUID]int)
+	var statuses []OperationStatus
+	for _, row := range rows {
+		i, ok := latest[row.OperationId]
+		if !ok {
+			latest[row.OperationId] = len(statuses)
+			statuses = append(statuses, row.toStatus())
+		} else if row.LastUpdatedOn.After(statuses[i].LastUpdatedOn) {
+			statuses[i] = row.toStatus()
+		}
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].StartedOn.Before(statuses[j].StartedOn)
+	})
+	return statuses, nil
+}
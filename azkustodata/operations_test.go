@@ -0,0 +1,135 @@
+package azkustodata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const operationsTestColumns = `[{"ColumnName":"OperationId","DataType":"Guid","ColumnType":"guid"},{"ColumnName":"Operation","DataType":"String","ColumnType":"string"},{"ColumnName":"StartedOn","DataType":"DateTime","ColumnType":"datetime"},{"ColumnName":"LastUpdatedOn","DataType":"DateTime","ColumnType":"datetime"},{"ColumnName":"Duration","DataType":"TimeSpan","ColumnType":"timespan"},{"ColumnName":"State","DataType":"String","ColumnType":"string"},{"ColumnName":"Status","DataType":"String","ColumnType":"string"},{"ColumnName":"ShouldRetry","DataType":"Boolean","ColumnType":"bool"},{"ColumnName":"Database","DataType":"String","ColumnType":"string"}]`
+
+func operationsTestRow(id uuid.UUID, updated string, state string, status string) string {
+	return fmt.Sprintf(`["%s","DataExportToFile","2024-01-01T00:00:00Z","%s","00:01:00","%s","%s",false,"db"]`, id, updated, state, status)
+}
+
+func TestOperations(t *testing.T) {
+	completed := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	failed := uuid.MustParse("22222222-2222-2222-2222-222222222222")
+	var polls atomic.Int32
+
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			CSL string `json:"csl"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+
+		var rows []string
+		columns := operationsTestColumns
+		switch {
+		case strings.HasPrefix(msg.CSL, ".export async"):
+			columns = `[{"ColumnName":"OperationId","DataType":"Guid","ColumnType":"guid"}]`
+			rows = []string{fmt.Sprintf(`["%s"]`, completed)}
+		case msg.CSL == ".show operations "+completed.String():
+			rows = []string{operationsTestRow(completed, "2024-01-01T00:00:10Z", "InProgress", "")}
+			if polls.Add(1) > 2 {
+				rows = append(rows, operationsTestRow(completed, "2024-01-01T00:01:00Z", "Completed", ""))
+			}
+		case msg.CSL == ".show operations "+failed.String():
+			rows = []string{operationsTestRow(failed, "2024-01-01T00:01:00Z", "Failed", "export failed")}
+		}
+		_, _ = fmt.Fprintf(w, `{"Tables":[{"TableName":"Table_0","Columns":%s,"Rows":[%s]}]}`, columns, strings.Join(rows, ","))
+	})
+
+	client := newTestKustoClient(t, srv)
+	ctx := context.Background()
+
+	op, err := client.SubmitAsync(ctx, "db", kql.New(".export async to csv (h@'https://storage/container') <| T"))
+	require.NoError(t, err)
+	assert.Equal(t, completed, op.ID())
+
+	status, err := op.Poll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, OperationInProgress, status.State)
+	assert.False(t, status.State.IsTerminal())
+
+	status, err = op.Wait(ctx, time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, OperationStatus{
+		ID:            completed,
+		Operation:     "DataExportToFile",
+		State:         OperationCompleted,
+		StartedOn:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		LastUpdatedOn: time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC),
+		Duration:      time.Minute,
+		Database:      "db",
+	}, status)
+
+	status, err = client.Operation("db", failed).Wait(ctx, time.Millisecond)
+	var opErr *OperationError
+	require.ErrorAs(t, err, &opErr)
+	assert.Equal(t, OperationFailed, status.State)
+	assert.Equal(t, "export failed", opErr.Status.Status)
+
+	_, err = client.Operation("db", uuid.New()).Poll(ctx)
+	assert.Error(t, err)
+
+	_, err = client.SubmitAsync(ctx, "db", kql.New(".show version"))
+	assert.Error(t, err)
+}
+
+func TestOperationsClient(t *testing.T) {
+	first := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	second := uuid.MustParse("22222222-2222-2222-2222-222222222222")
+	commands := make(chan string, 1)
+
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			CSL string `json:"csl"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		commands <- msg.CSL
+
+		rows := []string{
+			strings.Replace(operationsTestRow(second, "2024-01-01T00:02:00Z", "InProgress", ""), "2024-01-01T00:00:00Z", "2024-01-01T00:01:00Z", 1),
+			operationsTestRow(first, "2024-01-01T00:00:10Z", "InProgress", ""),
+			operationsTestRow(first, "2024-01-01T00:01:00Z", "Completed", ""),
+		}
+		if strings.HasPrefix(msg.CSL, ".show operations "+second.String()) {
+			rows = rows[:1]
+		}
+		_, _ = fmt.Fprintf(w, `{"Tables":[{"TableName":"Table_0","Columns":%s,"Rows":[%s]}]}`, operationsTestColumns, strings.Join(rows, ","))
+	})
+
+	client := newTestKustoClient(t, srv)
+	ctx := context.Background()
+	operations := client.Operations("db")
+
+	all, err := operations.List(ctx, time.Time{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, ".show operations", <-commands)
+	require.Len(t, all, 2)
+	assert.Equal(t, first, all[0].ID)
+	assert.Equal(t, OperationCompleted, all[0].State)
+	assert.Equal(t, second, all[1].ID)
+
+	inProgress, err := operations.List(ctx, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), OperationInProgress)
+	require.NoError(t, err)
+	assert.Equal(t, ".show operations\n| where StartedOn >= datetime(2024-01-01T00:00:00.0000000Z)", <-commands)
+	require.Len(t, inProgress, 1)
+	assert.Equal(t, second, inProgress[0].ID)
+
+	status, err := operations.Get(ctx, second)
+	require.NoError(t, err)
+	assert.Equal(t, ".show operations "+second.String(), <-commands)
+	assert.Equal(t, OperationInProgress, status.State)
+}
@@ -0,0 +1,112 @@
+package azkustodata
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// authRecordDir is the directory, under the user's cache directory, that holds the authentication records of persistent
+// token caches.
+const authRecordDir = "azure-kusto-go"
+
+// authRecordPath returns the file the authentication record of the named cache is stored in.
+func authRecordPath(name string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, authRecordDir, name+".authrecord.json"), nil
+}
+
+// loadAuthRecord reads a previously stored authentication record. A missing or unreadable record is not an error, the
+// user is just asked to log in again.
+func loadAuthRecord(path string) (azidentity.AuthenticationRecord, bool) {
+	var record azidentity.AuthenticationRecord
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return record, false
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, false
+	}
+	return record, true
+}
+
+func saveAuthRecord(path string, record azidentity.AuthenticationRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// persistentCache is a persistent token cache, together with the record of the account that last logged in with it.
+type persistentCache struct {
+	cache      azidentity.Cache
+	record     azidentity.AuthenticationRecord
+	recordPath string
+	hasRecord  bool
+}
+
+func openPersistentCache(name string) (*persistentCache, error) {
+	c, err := newPersistentCache(name)
+	if err != nil {
+		return nil, err
+	}
+	path, err := authRecordPath(name)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := loadAuthRecord(path)
+	return &persistentCache{cache: c, record: record, recordPath: path, hasRecord: ok}, nil
+}
+
+// wrap returns a credential that stores the authentication record of cred's first login, unless one was already stored.
+func (p *persistentCache) wrap(cred userAuthenticator) azcore.TokenCredential {
+	return &persistentUserCredential{cred: cred, recordPath: p.recordPath, authenticated: p.hasRecord}
+}
+
+// userAuthenticator is implemented by the azidentity credentials that log in a user, and can return a record of the login.
+type userAuthenticator interface {
+	azcore.TokenCredential
+	Authenticate(ctx context.Context, opts *policy.TokenRequestOptions) (azidentity.AuthenticationRecord, error)
+}
+
+// persistentUserCredential stores the authentication record of the first login, so later runs find the user's tokens in
+// the persistent cache instead of asking them to log in again.
+// The record only identifies the account, the tokens themselves are stored in the persistent cache.
+type persistentUserCredential struct {
+	cred       userAuthenticator
+	recordPath string
+
+	lock          sync.Mutex
+	authenticated bool
+}
+
+// GetToken implements azcore.TokenCredential.
+func (p *persistentUserCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	p.lock.Lock()
+	if !p.authenticated {
+		record, err := p.cred.Authenticate(ctx, &opts)
+		if err != nil {
+			p.lock.Unlock()
+			return azcore.AccessToken{}, err
+		}
+		p.authenticated = true
+		// Failing to store the record only means the user logs in again on the next run.
+		_ = saveAuthRecord(p.recordPath, record)
+	}
+	p.lock.Unlock()
+
+	return p.cred.GetToken(ctx, opts)
+}
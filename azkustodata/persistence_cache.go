@@ -0,0 +1,14 @@
+//go:build darwin || linux || windows
+
+package azkustodata
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity/cache"
+)
+
+// newPersistentCache returns the persistent token cache with the given name. It is a variable so tests can avoid
+// depending on the platform's secret storage.
+var newPersistentCache = func(name string) (azidentity.Cache, error) {
+	return cache.New(&cache.Options{Name: name})
+}
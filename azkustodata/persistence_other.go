@@ -0,0 +1,16 @@
+//go:build !(darwin || linux || windows)
+
+package azkustodata
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// newPersistentCache fails, as azidentity only implements persistent caches on top of the secret storage of Linux,
+// macOS and Windows.
+var newPersistentCache = func(name string) (azidentity.Cache, error) {
+	return azidentity.Cache{}, fmt.Errorf("persistent token caches aren't supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,84 @@
+package azkustodata
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuthenticator struct {
+	authenticateCalls int
+}
+
+func (f *fakeAuthenticator) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "user-token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func (f *fakeAuthenticator) Authenticate(context.Context, *policy.TokenRequestOptions) (azidentity.AuthenticationRecord, error) {
+	f.authenticateCalls++
+	return azidentity.AuthenticationRecord{HomeAccountID: "account", Username: "user@contoso.com", Version: "1.0"}, nil
+}
+
+func TestPersistentUserCredential(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "test.authrecord.json")
+	auth := &fakeAuthenticator{}
+	first := (&persistentCache{recordPath: path}).wrap(auth)
+
+	for i := 0; i < 2; i++ {
+		token, err := first.GetToken(context.Background(), policy.TokenRequestOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "user-token", token.Token)
+	}
+	assert.Equal(t, 1, auth.authenticateCalls)
+
+	record, ok := loadAuthRecord(path)
+	require.True(t, ok)
+	assert.Equal(t, "user@contoso.com", record.Username)
+
+	// A later run finds the stored record, and doesn't need to log in again.
+	second := (&persistentCache{recordPath: path, record: record, hasRecord: true}).wrap(auth)
+	_, err := second.GetToken(context.Background(), policy.TokenRequestOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, auth.authenticateCalls)
+}
+
+func TestWithTokenCachePersistence(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	original := newPersistentCache
+	defer func() { newPersistentCache = original }()
+	var opened string
+	newPersistentCache = func(name string) (azidentity.Cache, error) {
+		opened = name
+		return azidentity.Cache{}, nil
+	}
+
+	s := newTestServ()
+	defer s.close()
+	s.code = 200
+	s.payload = []byte(testCloudMetadata)
+
+	kcsb := NewConnectionStringBuilder(s.urlStr()).WithDeviceCodeAuth("tenantID", nil).WithTokenCachePersistence("my-cli")
+	assert.Equal(t, "my-cli", kcsb.TokenCachePersistenceName)
+	assert.Panics(t, func() { kcsb.WithTokenCachePersistence("") })
+
+	tkp, err := kcsb.newTokenProvider()
+	require.NoError(t, err)
+	tkp.SetHttp(s.http.Client())
+	_, err = tkp.initOnce.DoWithInit()
+	require.NoError(t, err)
+	assert.Equal(t, "my-cli", opened)
+	assert.IsType(t, &persistentUserCredential{}, tkp.tokenCred)
+
+	err = NewConnectionStringBuilder(s.urlStr()).
+		WithInteractiveLoginOptions("", InteractiveLoginOptions{SelectAccount: true}).
+		WithTokenCachePersistence("my-cli").Validate()
+	var configErr *AuthConfigError
+	require.ErrorAs(t, err, &configErr)
+}
@@ -0,0 +1,313 @@
+/*
+Package policies reads and changes the policies of tables and databases, such as their retention or caching policies,
+marshaling the JSON policy documents into structs.
+
+	retention, err := policies.GetRetention(ctx, client, "database", policies.Table("Events"))
+	if err != nil {
+		return err
+	}
+	if retention == nil || retention.SoftDeletePeriod > 30*24*time.Hour {
+		err = policies.SetRetention(ctx, client, "database", policies.Table("Events"), policies.Retention{SoftDeletePeriod: 30 * 24 * time.Hour})
+	}
+
+Get functions return nil when the entity has no policy of their kind, in which case the policy of its database, or the
+cluster's default, applies. The functions take any management.Client, which *azkustodata.Client implements, and pass the
+options on to Mgmt.
+*/
+package policies
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// Entity is a table or a database that policies are attached to.
+type Entity struct {
+	kind string
+	name string
+}
+
+// Table returns the Entity of a table.
+func Table(name string) Entity {
+	return Entity{kind: "table", name: name}
+}
+
+// Database returns the Entity of a database.
+func Database(name string) Entity {
+	return Entity{kind: "database", name: name}
+}
+
+// String returns the entity as it appears in commands, such as `table Events`.
+func (e Entity) String() string {
+	return e.kind + " " + kql.NormalizeName(e.name)
+}
+
+// Retention is the retention policy, which sets how long data is kept.
+type Retention struct {
+	// SoftDeletePeriod is how long data is queryable after it was ingested.
+	SoftDeletePeriod time.Duration
+	// Recoverability is "Enabled" if deleted data can be recovered for 14 days, or "Disabled". Empty uses the default,
+	// "Enabled".
+	Recoverability string
+}
+
+type retentionJSON struct {
+	SoftDeletePeriod timespan
+	Recoverability   string `json:",omitempty"`
+}
+
+func (r Retention) MarshalJSON() ([]byte, error) {
+	return json.Marshal(retentionJSON{SoftDeletePeriod: timespan(r.SoftDeletePeriod), Recoverability: r.Recoverability})
+}
+
+func (r *Retention) UnmarshalJSON(data []byte) error {
+	var aux retentionJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*r = Retention{SoftDeletePeriod: time.Duration(aux.SoftDeletePeriod), Recoverability: aux.Recoverability}
+	return nil
+}
+
+// GetRetention returns the retention policy of an entity, or nil if it has none.
+func GetRetention(ctx context.Context, client management.Client, db string, entity Entity, options ...azkustodata.QueryOption) (*Retention, error) {
+	return get[Retention](ctx, client, db, entity, "retention", options)
+}
+
+// SetRetention sets the retention policy of an entity.
+func SetRetention(ctx context.Context, client management.Client, db string, entity Entity, policy Retention, options ...azkustodata.QueryOption) error {
+	return set(ctx, client, db, entity, "retention", policy, options)
+}
+
+// DeleteRetention deletes the retention policy of an entity.
+func DeleteRetention(ctx context.Context, client management.Client, db string, entity Entity, options ...azkustodata.QueryOption) error {
+	return remove(ctx, client, db, entity, "retention", options)
+}
+
+// Caching is the caching policy, which sets how long data is kept in the hot cache, on the local disks of the cluster.
+type Caching struct {
+	// Hot is how long data is kept in the hot cache after it was ingested.
+	Hot time.Duration
+}
+
+// cachingJSON is the caching policy, as reported by `.show policy caching`.
+type cachingJSON struct {
+	DataHotSpan struct {
+		Value timespan
+	}
+}
+
+// GetCaching returns the caching policy of an entity, or nil if it has none.
+func GetCaching(ctx context.Context, client management.Client, db string, entity Entity, options ...azkustodata.QueryOption) (*Caching, error) {
+	policy, err := get[cachingJSON](ctx, client, db, entity, "caching", options)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+	return &Caching{Hot: time.Duration(policy.DataHotSpan.Value)}, nil
+}
+
+// SetCaching sets the caching policy of an entity.
+func SetCaching(ctx context.Context, client management.Client, db string, entity Entity, policy Caching, options ...azkustodata.QueryOption) error {
+	cmd := kql.New(".alter ").AddUnsafe(entity.String()).AddLiteral(" policy caching hot = ").AddTimespan(policy.Hot)
+	return run(ctx, client, db, cmd, options)
+}
+
+// DeleteCaching deletes the caching policy of an entity.
+func DeleteCaching(ctx context.Context, client management.Client, db string, entity Entity, options ...azkustodata.QueryOption) error {
+	return remove(ctx, client, db, entity, "caching", options)
+}
+
+// IngestionBatching is the ingestion batching policy, which sets when the data queued for ingestion is ingested. A batch
+// is ingested once any of the limits is reached. Zero limits use the defaults.
+type IngestionBatching struct {
+	MaximumBatchingTimeSpan time.Duration
+	MaximumNumberOfItems    int
+	MaximumRawDataSizeMB    int
+}
+
+type ingestionBatchingJSON struct {
+	MaximumBatchingTimeSpan timespan `json:",omitempty"`
+	MaximumNumberOfItems    int      `json:",omitempty"`
+	MaximumRawDataSizeMB    int      `json:",omitempty"`
+}
+
+func (b IngestionBatching) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ingestionBatchingJSON{
+		MaximumBatchingTimeSpan: timespan(b.MaximumBatchingTimeSpan),
+		MaximumNumberOfItems:    b.MaximumNumberOfItems,
+		MaximumRawDataSizeMB:    b.MaximumRawDataSizeMB,
+	})
+}
+
+func (b *IngestionBatching) UnmarshalJSON(data []byte) error {
+	var aux ingestionBatchingJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*b = IngestionBatching{
+		MaximumBatchingTimeSpan: time.Duration(aux.MaximumBatchingTimeSpan),
+		MaximumNumberOfItems:    aux.MaximumNumberOfItems,
+		MaximumRawDataSizeMB:    aux.MaximumRawDataSizeMB,
+	}
+	return nil
+}
+
+// GetIngestionBatching returns the ingestion batching policy of an entity, or nil if it has none.
+func GetIngestionBatching(ctx context.Context, client management.Client, db string, entity Entity, options ...azkustodata.QueryOption) (*IngestionBatching, error) {
+	return get[IngestionBatching](ctx, client, db, entity, "ingestionbatching", options)
+}
+
+// SetIngestionBatching sets the ingestion batching policy of an entity.
+func SetIngestionBatching(ctx context.Context, client management.Client, db string, entity Entity, policy IngestionBatching, options ...azkustodata.QueryOption) error {
+	return set(ctx, client, db, entity, "ingestionbatching", policy, options)
+}
+
+// DeleteIngestionBatching deletes the ingestion batching policy of an entity.
+func DeleteIngestionBatching(ctx context.Context, client management.Client, db string, entity Entity, options ...azkustodata.QueryOption) error {
+	return remove(ctx, client, db, entity, "ingestionbatching", options)
+}
+
+// StreamingIngestion is the streaming ingestion policy, which allows streaming ingestion into an entity.
+type StreamingIngestion struct {
+	IsEnabled bool
+	// HintAllocatedRate is the expected rate of the streaming ingestion, in GB per hour, or nil if unknown.
+	HintAllocatedRate *float64
+}
+
+// GetStreamingIngestion returns the streaming ingestion policy of an entity, or nil if it has none.
+func GetStreamingIngestion(ctx context.Context, client management.Client, db string, entity Entity, options ...azkustodata.QueryOption) (*StreamingIngestion, error) {
+	return get[StreamingIngestion](ctx, client, db, entity, "streamingingestion", options)
+}
+
+// SetStreamingIngestion sets the streaming ingestion policy of an entity.
+func SetStreamingIngestion(ctx context.Context, client management.Client, db string, entity Entity, policy StreamingIngestion, options ...azkustodata.QueryOption) error {
+	return set(ctx, client, db, entity, "streamingingestion", policy, options)
+}
+
+// DeleteStreamingIngestion deletes the streaming ingestion policy of an entity.
+func DeleteStreamingIngestion(ctx context.Context, client management.Client, db string, entity Entity, options ...azkustodata.QueryOption) error {
+	return remove(ctx, client, db, entity, "streamingingestion", options)
+}
+
+// Update is an update policy of a table, which runs a query on the data ingested into a source table, and ingests its
+// results into the table.
+type Update struct {
+	IsEnabled bool
+	// Source is the table the query runs on the ingested data of.
+	Source string
+	Query  string
+	// IsTransactional fails the ingestion into the source table if the query fails.
+	IsTransactional bool
+	// PropagateIngestionProperties propagates the properties of the ingestion into the source table, such as its tags.
+	PropagateIngestionProperties bool
+	// ManagedIdentity is the managed identity the query runs as, if it reads external data.
+	ManagedIdentity string `json:",omitempty"`
+}
+
+// GetUpdate returns the update policies of a table, or nil if it has none.
+func GetUpdate(ctx context.Context, client management.Client, db string, table string, options ...azkustodata.QueryOption) ([]Update, error) {
+	policy, err := get[[]Update](ctx, client, db, Table(table), "update", options)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+	return *policy, nil
+}
+
+// SetUpdate replaces the update policies of a table.
+func SetUpdate(ctx context.Context, client management.Client, db string, table string, policy []Update, options ...azkustodata.QueryOption) error {
+	if policy == nil {
+		policy = []Update{}
+	}
+	return set(ctx, client, db, Table(table), "update", policy, options)
+}
+
+// DeleteUpdate deletes the update policies of a table.
+func DeleteUpdate(ctx context.Context, client management.Client, db string, table string, options ...azkustodata.QueryOption) error {
+	return remove(ctx, client, db, Table(table), "update", options)
+}
+
+// policyRow is a row of the results of `.show policy`.
+type policyRow struct {
+	PolicyName string
+	EntityName string
+	Policy     string
+}
+
+// get returns the policy of an entity, or nil if it has none.
+func get[T any](ctx context.Context, client management.Client, db string, entity Entity, kind string, options []azkustodata.QueryOption) (*T, error) {
+	cmd := kql.New(".show ").AddUnsafe(entity.String()).AddLiteral(" policy ").AddUnsafe(kind)
+	// The command only reads, so it can run on read-only clients.
+	ds, err := client.Mgmt(ctx, db, cmd, append([]azkustodata.QueryOption{azkustodata.AllowControlCommands()}, options...)...)
+	if err != nil {
+		return nil, err
+	}
+	var rows []policyRow
+	if tables := ds.Tables(); len(tables) > 0 {
+		if rows, err = query.ToStructs[policyRow](tables[0]); err != nil {
+			return nil, err
+		}
+	}
+	if len(rows) == 0 || rows[0].Policy == "" || rows[0].Policy == "null" {
+		return nil, nil
+	}
+
+	policy := new(T)
+	if err := json.Unmarshal([]byte(rows[0].Policy), policy); err != nil {
+		return nil, errors.ES(errors.OpMgmt, errors.KFailedToParse, "could not parse the %s policy of %s: %s", kind, entity, err)
+	}
+	return policy, nil
+}
+
+// set sets the policy of an entity, with its JSON document.
+func set(ctx context.Context, client management.Client, db string, entity Entity, kind string, policy interface{}, options []azkustodata.QueryOption) error {
+	doc, err := json.Marshal(policy)
+	if err != nil {
+		return errors.ES(errors.OpMgmt, errors.KClientArgs, "could not marshal the %s policy: %s", kind, err).SetNoRetry()
+	}
+	cmd := kql.New(".alter ").AddUnsafe(entity.String()).AddLiteral(" policy ").AddUnsafe(kind).
+		AddLiteral(" ").AddUnsafe(kql.QuoteString(string(doc), false))
+	return run(ctx, client, db, cmd, options)
+}
+
+// remove deletes the policy of an entity.
+func remove(ctx context.Context, client management.Client, db string, entity Entity, kind string, options []azkustodata.QueryOption) error {
+	cmd := kql.New(".delete ").AddUnsafe(entity.String()).AddLiteral(" policy ").AddUnsafe(kind)
+	return run(ctx, client, db, cmd, options)
+}
+
+func run(ctx context.Context, client management.Client, db string, cmd azkustodata.Statement, options []azkustodata.QueryOption) error {
+	_, err := client.Mgmt(ctx, db, cmd, options...)
+	return err
+}
+
+// timespan is a duration, marshaled to JSON in the format of Kusto's timespans, such as "365.00:00:00".
+type timespan time.Duration
+
+func (t timespan) MarshalJSON() ([]byte, error) {
+	return json.Marshal(value.NewTimespan(time.Duration(t)).Marshal())
+}
+
+func (t *timespan) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == nil {
+		*t = 0
+		return nil
+	}
+	d, err := value.ParseTimespan(*s)
+	if err != nil {
+		return err
+	}
+	*t = timespan(d)
+	return nil
+}
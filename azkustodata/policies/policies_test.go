@@ -0,0 +1,103 @@
+package policies
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient returns the v1 response of each command, or an empty one, recording the commands.
+type fakeClient struct {
+	responses map[string]string
+	commands  []string
+}
+
+func (f *fakeClient) Mgmt(ctx context.Context, db string, kqlQuery azkustodata.Statement, _ ...azkustodata.QueryOption) (v1.Dataset, error) {
+	f.commands = append(f.commands, db+": "+kqlQuery.String())
+	response, ok := f.responses[kqlQuery.String()]
+	if !ok {
+		response = `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"Policy","ColumnType":"string"}],"Rows":[]}]}`
+	}
+	return v1.NewDatasetFromReader(ctx, errors.OpMgmt, io.NopCloser(strings.NewReader(response)))
+}
+
+// policyResponse returns the response of `.show policy` with a policy document.
+func policyResponse(policy string) string {
+	return `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"PolicyName","ColumnType":"string"},` +
+		`{"ColumnName":"EntityName","ColumnType":"string"},{"ColumnName":"Policy","ColumnType":"string"},` +
+		`{"ColumnName":"ChildEntities","ColumnType":"string"},{"ColumnName":"EntityType","ColumnType":"string"}],` +
+		`"Rows":[["Policy","[db].[Events]",` + strconv.Quote(policy) + `,"",""]]}]}`
+}
+
+func TestGet(t *testing.T) {
+	rate := 2.5
+	client := &fakeClient{responses: map[string]string{
+		".show table Events policy retention":          policyResponse(`{"SoftDeletePeriod":"365.00:00:00","Recoverability":"Enabled"}`),
+		".show database db policy caching":             policyResponse(`{"DataHotSpan":{"Value":"7.00:00:00"},"IndexHotSpan":{"Value":"7.00:00:00"}}`),
+		".show table Events policy ingestionbatching":  policyResponse(`{"MaximumBatchingTimeSpan":"00:00:30","MaximumNumberOfItems":500,"MaximumRawDataSizeMB":1024}`),
+		".show table Events policy streamingingestion": policyResponse(`{"IsEnabled":true,"HintAllocatedRate":2.5}`),
+		".show table Events policy update":             policyResponse(`[{"IsEnabled":true,"Source":"Raw","Query":"Parse()","IsTransactional":true,"PropagateIngestionProperties":false,"ManagedIdentity":null}]`),
+		".show table Other policy retention":           policyResponse("null"),
+	}}
+	ctx := context.Background()
+
+	retention, err := GetRetention(ctx, client, "db", Table("Events"))
+	require.NoError(t, err)
+	assert.Equal(t, &Retention{SoftDeletePeriod: 365 * 24 * time.Hour, Recoverability: "Enabled"}, retention)
+
+	caching, err := GetCaching(ctx, client, "db", Database("db"))
+	require.NoError(t, err)
+	assert.Equal(t, &Caching{Hot: 7 * 24 * time.Hour}, caching)
+
+	batching, err := GetIngestionBatching(ctx, client, "db", Table("Events"))
+	require.NoError(t, err)
+	assert.Equal(t, &IngestionBatching{MaximumBatchingTimeSpan: 30 * time.Second, MaximumNumberOfItems: 500, MaximumRawDataSizeMB: 1024}, batching)
+
+	streaming, err := GetStreamingIngestion(ctx, client, "db", Table("Events"))
+	require.NoError(t, err)
+	assert.Equal(t, &StreamingIngestion{IsEnabled: true, HintAllocatedRate: &rate}, streaming)
+
+	update, err := GetUpdate(ctx, client, "db", "Events")
+	require.NoError(t, err)
+	assert.Equal(t, []Update{{IsEnabled: true, Source: "Raw", Query: "Parse()", IsTransactional: true}}, update)
+
+	retention, err = GetRetention(ctx, client, "db", Table("Other"))
+	require.NoError(t, err)
+	assert.Nil(t, retention)
+
+	caching, err = GetCaching(ctx, client, "db", Table("Other"))
+	require.NoError(t, err)
+	assert.Nil(t, caching)
+}
+
+func TestSetAndDelete(t *testing.T) {
+	client := &fakeClient{}
+	ctx := context.Background()
+
+	require.NoError(t, SetRetention(ctx, client, "db", Table("my table"), Retention{SoftDeletePeriod: 30 * 24 * time.Hour}))
+	require.NoError(t, SetCaching(ctx, client, "db", Database("db"), Caching{Hot: 7 * 24 * time.Hour}))
+	require.NoError(t, SetIngestionBatching(ctx, client, "db", Table("Events"), IngestionBatching{MaximumBatchingTimeSpan: 30 * time.Second}))
+	require.NoError(t, SetStreamingIngestion(ctx, client, "db", Database("db"), StreamingIngestion{IsEnabled: true}))
+	require.NoError(t, SetUpdate(ctx, client, "db", "Events", []Update{{IsEnabled: true, Source: "Raw", Query: "Parse()"}}))
+	require.NoError(t, DeleteRetention(ctx, client, "db", Table("Events")))
+	require.NoError(t, DeleteUpdate(ctx, client, "db", "Events"))
+
+	assert.Equal(t, []string{
+		`db: .alter table ["my table"] policy retention "{\"SoftDeletePeriod\":\"30.00:00:00\"}"`,
+		`db: .alter database db policy caching hot = timespan(7.00:00:00.0000000)`,
+		`db: .alter table Events policy ingestionbatching "{\"MaximumBatchingTimeSpan\":\"00:00:30\"}"`,
+		`db: .alter database db policy streamingingestion "{\"IsEnabled\":true,\"HintAllocatedRate\":null}"`,
+		`db: .alter table Events policy update "[{\"IsEnabled\":true,\"Source\":\"Raw\",\"Query\":\"Parse()\",\"IsTransactional\":false,\"PropagateIngestionProperties\":false}]"`,
+		`db: .delete table Events policy retention`,
+		`db: .delete table Events policy update`,
+	}, client.commands)
+}
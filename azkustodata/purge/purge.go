@@ -0,0 +1,280 @@
+/*
+Package purge deletes records from tables for compliance, such as GDPR deletion requests, with `.purge table records`.
+
+Purging is irreversible, and expensive for the cluster. A Purger runs it in two phases: the purge command is first run
+without a verification token, which returns how many records would be purged, and a token, then run again with the token,
+which schedules the purge. With WithDryRun, only the first phase runs, so a pipeline can report what it would purge.
+
+	purger := purge.New(client, "database")
+	result, err := purger.Purge(ctx, "Users", kql.New("where UserId == ").AddString(userID))
+	if err != nil {
+		return err
+	}
+	fmt.Println(result.Verification.NumRecordsToPurge, "records will be purged")
+	status, err := purger.Wait(ctx, result.Status.OperationId)
+
+Purge commands must be sent to the data management endpoint of the cluster, "https://ingest-<cluster>...", so the client
+given to New must connect to it. See https://learn.microsoft.com/azure/data-explorer/kusto/concepts/data-purge.
+*/
+package purge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/google/uuid"
+)
+
+// DefaultPollInterval is the interval Wait polls at, unless WithPollInterval is used.
+const DefaultPollInterval = 30 * time.Second
+
+// State is the state of a purge operation, as reported by `.show purges`.
+type State string
+
+const (
+	StateScheduled  State = "Scheduled"
+	StateInProgress State = "InProgress"
+	StateCompleted  State = "Completed"
+	StateBadInput   State = "BadInput"
+	StateFailed     State = "Failed"
+	StateAborted    State = "Aborted"
+)
+
+// IsTerminal returns whether the purge has ended, in which case its state won't change anymore.
+func (s State) IsTerminal() bool {
+	return s != StateScheduled && s != StateInProgress
+}
+
+// Verification is the result of the first phase of a purge, which doesn't delete anything.
+type Verification struct {
+	// NumRecordsToPurge is how many records match the predicate.
+	NumRecordsToPurge int64
+	// EstimatedPurgeExecutionTime is how long the purge is expected to run.
+	EstimatedPurgeExecutionTime time.Duration
+	// VerificationToken is the token the second phase is run with.
+	VerificationToken string
+}
+
+// Status is the status of a purge operation, as reported by `.show purges`.
+type Status struct {
+	OperationId  uuid.UUID
+	DatabaseName string
+	TableName    string
+	// ScheduledTime is when the purge was scheduled, and LastUpdatedOn when its state last changed.
+	ScheduledTime time.Time
+	LastUpdatedOn time.Time
+	// Duration is how long the purge ran, including the time it was waiting to run.
+	Duration time.Duration
+	State    State
+	// StateDetails holds details about the state, such as the error of a failed purge.
+	StateDetails string
+	// EngineOperationId, EngineStartTime and EngineDuration identify the purge in the engine, and how long it ran there.
+	EngineOperationId string
+	EngineStartTime   time.Time
+	EngineDuration    time.Duration
+	Retries           int64
+	ClientRequestId   string
+	Principal         string
+}
+
+// statusRow is a row of the results of a purge command, or of `.show purges`.
+type statusRow struct {
+	OperationId       uuid.UUID
+	DatabaseName      string
+	TableName         string
+	ScheduledTime     time.Time
+	LastUpdatedOn     time.Time
+	Duration          time.Duration
+	State             string
+	StateDetails      string
+	EngineOperationId string
+	EngineStartTime   time.Time
+	EngineDuration    time.Duration
+	Retries           int64
+	ClientRequestId   string
+	Principal         string
+}
+
+func (r statusRow) toStatus() *Status {
+	return &Status{
+		OperationId:       r.OperationId,
+		DatabaseName:      r.DatabaseName,
+		TableName:         r.TableName,
+		ScheduledTime:     r.ScheduledTime,
+		LastUpdatedOn:     r.LastUpdatedOn,
+		Duration:          r.Duration,
+		State:             State(r.State),
+		StateDetails:      r.StateDetails,
+		EngineOperationId: r.EngineOperationId,
+		EngineStartTime:   r.EngineStartTime,
+		EngineDuration:    r.EngineDuration,
+		Retries:           r.Retries,
+		ClientRequestId:   r.ClientRequestId,
+		Principal:         r.Principal,
+	}
+}
+
+// Error is returned by Purger.Wait when the purge ended in a state other than StateCompleted.
+type Error struct {
+	// Status is the final status of the purge.
+	Status Status
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("purge %s of table %s ended in state %s: %s", e.Status.OperationId, e.Status.TableName, e.Status.State, e.Status.StateDetails)
+}
+
+// Result is the result of Purger.Purge.
+type Result struct {
+	// Verification is the result of the first phase.
+	Verification Verification
+	// Status is the status of the scheduled purge, or nil for a dry run.
+	Status *Status
+}
+
+// Purger purges records from the tables of a database.
+type Purger struct {
+	client       management.Client
+	db           string
+	dryRun       bool
+	pollInterval time.Duration
+	options      []azkustodata.QueryOption
+}
+
+// Option is an optional argument to New.
+type Option func(p *Purger)
+
+// WithDryRun only runs the verification phase of purges, so nothing is deleted.
+func WithDryRun() Option {
+	return func(p *Purger) {
+		p.dryRun = true
+	}
+}
+
+// WithPollInterval sets the interval Wait polls the status of a purge at. Defaults to DefaultPollInterval.
+func WithPollInterval(interval time.Duration) Option {
+	return func(p *Purger) {
+		p.pollInterval = interval
+	}
+}
+
+// WithQueryOptions sets the options the purge and `.show purges` commands are run with.
+func WithQueryOptions(options ...azkustodata.QueryOption) Option {
+	return func(p *Purger) {
+		p.options = options
+	}
+}
+
+// New returns a Purger for a database. The client must connect to the data management endpoint of the cluster.
+func New(client management.Client, db string, options ...Option) *Purger {
+	p := &Purger{client: client, db: db, pollInterval: DefaultPollInterval}
+	for _, o := range options {
+		o(p)
+	}
+	return p
+}
+
+// Verify runs the first phase of the purge of the records of a table matching a predicate, such as
+// `where UserId == "id"`, which returns how many records would be purged, without deleting them.
+func (p *Purger) Verify(ctx context.Context, table string, predicate azkustodata.Statement) (*Verification, error) {
+	rows, err := run[Verification](ctx, p, purgeCommand(p.db, table, "", predicate))
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || rows[0].VerificationToken == "" {
+		return nil, errors.ES(errors.OpMgmt, errors.KInternal, "the purge of table %s didn't return a verification token", table)
+	}
+	return &rows[0], nil
+}
+
+// Purge purges the records of a table matching a predicate, such as `where UserId == "id"`, in two phases: it runs Verify,
+// then schedules the purge with the verification token. With WithDryRun, it only runs Verify.
+// Purge returns once the purge is scheduled, Wait waits for it to complete.
+func (p *Purger) Purge(ctx context.Context, table string, predicate azkustodata.Statement) (*Result, error) {
+	verification, err := p.Verify(ctx, table, predicate)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Verification: *verification}
+	if p.dryRun {
+		return result, nil
+	}
+
+	rows, err := run[statusRow](ctx, p, purgeCommand(p.db, table, verification.VerificationToken, predicate))
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KInternal, "the purge of table %s didn't return an operation id", table)
+	}
+	result.Status = rows[0].toStatus()
+	return result, nil
+}
+
+// Status returns the current status of a purge.
+func (p *Purger) Status(ctx context.Context, id uuid.UUID) (*Status, error) {
+	cmd := kql.New(".show purges ").AddUnsafe(id.String()).AddLiteral(" in database ").AddUnsafe(kql.NormalizeName(p.db))
+	rows, err := run[statusRow](ctx, p, cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KOther, "purge %s was not found", id).SetNoRetry()
+	}
+	return rows[0].toStatus(), nil
+}
+
+// Wait polls a purge until it ends, and returns its final status. If the purge didn't complete successfully, the status is
+// returned along with an *Error.
+func (p *Purger) Wait(ctx context.Context, id uuid.UUID) (*Status, error) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, errors.E(errors.OpMgmt, errors.KTimeout, ctx.Err()).SetNoRetry()
+		case <-timer.C:
+		}
+
+		status, err := p.Status(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if status.State.IsTerminal() {
+			if status.State != StateCompleted {
+				return status, &Error{Status: *status}
+			}
+			return status, nil
+		}
+		timer.Reset(p.pollInterval)
+	}
+}
+
+// purgeCommand builds the `.purge table records` command, with the verification token of the second phase if it is set.
+func purgeCommand(db string, table string, token string, predicate azkustodata.Statement) azkustodata.Statement {
+	cmd := kql.New(".purge table ").AddUnsafe(kql.NormalizeName(table)).
+		AddLiteral(" records in database ").AddUnsafe(kql.NormalizeName(db))
+	if token != "" {
+		cmd.AddLiteral(" with (verificationtoken=").AddUnsafe(kql.QuoteString(token, true)).AddLiteral(")")
+	}
+	return cmd.AddLiteral(" <|\n").AddUnsafe(predicate.String())
+}
+
+// run runs a command, and decodes the rows of its first table.
+func run[T any](ctx context.Context, p *Purger, cmd azkustodata.Statement) ([]T, error) {
+	ds, err := p.client.Mgmt(ctx, p.db, cmd, p.options...)
+	if err != nil {
+		return nil, err
+	}
+	tables := ds.Tables()
+	if len(tables) == 0 {
+		return nil, nil
+	}
+	return query.ToStructs[T](tables[0])
+}
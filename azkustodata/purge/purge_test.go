@@ -0,0 +1,98 @@
+package purge
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient returns the v1 responses of each command in turn, recording the commands.
+type fakeClient struct {
+	responses map[string][]string
+	commands  []string
+}
+
+func (f *fakeClient) Mgmt(ctx context.Context, db string, kqlQuery azkustodata.Statement, _ ...azkustodata.QueryOption) (v1.Dataset, error) {
+	f.commands = append(f.commands, db+": "+kqlQuery.String())
+	responses := f.responses[kqlQuery.String()]
+	if len(responses) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KHTTPError, "unexpected command %s", kqlQuery.String())
+	}
+	f.responses[kqlQuery.String()] = responses[1:]
+	return v1.NewDatasetFromReader(ctx, errors.OpMgmt, io.NopCloser(strings.NewReader(responses[0])))
+}
+
+const (
+	verifyCommand  = ".purge table Users records in database db <|\nwhere UserId == \"u1\""
+	executeCommand = ".purge table Users records in database db with (verificationtoken=h\"token\") <|\nwhere UserId == \"u1\""
+	statusCommand  = ".show purges 11111111-1111-1111-1111-111111111111 in database db"
+
+	verifyResponse = `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"NumRecordsToPurge","ColumnType":"long"},` +
+		`{"ColumnName":"EstimatedPurgeExecutionTime","ColumnType":"timespan"},{"ColumnName":"VerificationToken","ColumnType":"string"}],` +
+		`"Rows":[[42,"00:10:00","token"]]}]}`
+)
+
+func statusResponse(state string) string {
+	return `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"OperationId","ColumnType":"guid"},` +
+		`{"ColumnName":"DatabaseName","ColumnType":"string"},{"ColumnName":"TableName","ColumnType":"string"},` +
+		`{"ColumnName":"ScheduledTime","ColumnType":"datetime"},{"ColumnName":"State","ColumnType":"string"},` +
+		`{"ColumnName":"StateDetails","ColumnType":"string"}],` +
+		`"Rows":[["11111111-1111-1111-1111-111111111111","db","Users","2024-01-02T03:04:05Z","` + state + `","details"]]}]}`
+}
+
+func predicate() azkustodata.Statement {
+	return kql.New("where UserId == ").AddString("u1")
+}
+
+func TestPurge(t *testing.T) {
+	id := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	client := &fakeClient{responses: map[string][]string{
+		verifyCommand:  {verifyResponse},
+		executeCommand: {statusResponse("Scheduled")},
+		statusCommand:  {statusResponse("InProgress"), statusResponse("Completed")},
+	}}
+	purger := New(client, "db", WithPollInterval(time.Millisecond))
+
+	result, err := purger.Purge(context.Background(), "Users", predicate())
+	require.NoError(t, err)
+	assert.Equal(t, Verification{NumRecordsToPurge: 42, EstimatedPurgeExecutionTime: 10 * time.Minute, VerificationToken: "token"}, result.Verification)
+	require.NotNil(t, result.Status)
+	assert.Equal(t, id, result.Status.OperationId)
+	assert.Equal(t, StateScheduled, result.Status.State)
+
+	status, err := purger.Wait(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, StateCompleted, status.State)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), status.ScheduledTime)
+	assert.Equal(t, []string{"db: " + verifyCommand, "db: " + executeCommand, "db: " + statusCommand, "db: " + statusCommand}, client.commands)
+}
+
+func TestPurgeDryRun(t *testing.T) {
+	client := &fakeClient{responses: map[string][]string{verifyCommand: {verifyResponse}}}
+
+	result, err := New(client, "db", WithDryRun()).Purge(context.Background(), "Users", predicate())
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), result.Verification.NumRecordsToPurge)
+	assert.Nil(t, result.Status)
+	assert.Equal(t, []string{"db: " + verifyCommand}, client.commands)
+}
+
+func TestWaitFailed(t *testing.T) {
+	client := &fakeClient{responses: map[string][]string{statusCommand: {statusResponse("Failed")}}}
+
+	status, err := New(client, "db").Wait(context.Background(), uuid.MustParse("11111111-1111-1111-1111-111111111111"))
+	var purgeErr *Error
+	require.ErrorAs(t, err, &purgeErr)
+	assert.Equal(t, StateFailed, purgeErr.Status.State)
+	assert.Equal(t, "details", status.StateDetails)
+}
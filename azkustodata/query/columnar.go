@@ -0,0 +1,193 @@
+package query
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ColumnView is a column-oriented view of the values of a column of a table, returned by Table.Column.
+// Its concrete type is a *ColumnData of the Go type of the column's Kusto type:
+//
+//	bool     -> *ColumnData[bool]
+//	int      -> *ColumnData[int32]
+//	long     -> *ColumnData[int64]
+//	real     -> *ColumnData[float64]
+//	decimal  -> *ColumnData[decimal.Decimal]
+//	string   -> *ColumnData[string]
+//	datetime -> *ColumnData[time.Time]
+//	timespan -> *ColumnData[time.Duration]
+//	guid     -> *ColumnData[uuid.UUID]
+//	dynamic  -> *ColumnData[[]byte]
+//
+// Use ColumnOf to get it with the right type.
+type ColumnView interface {
+	// Column returns the column the view holds the values of.
+	Column() Column
+	// Len returns the number of values, which is the number of rows of the table.
+	Len() int
+	// IsNull reports whether the value of row i is null.
+	IsNull(i int) bool
+}
+
+// ColumnData holds the values of a column of a table in a slice of their Go type, with a validity bitmap for the nulls,
+// so that consumers reading only a few columns avoid going through a value.Kusto per cell.
+type ColumnData[T any] struct {
+	column Column
+	values []T
+	// valid has bit i set if the value of row i is not null.
+	valid []uint64
+}
+
+func (c *ColumnData[T]) Column() Column {
+	return c.column
+}
+
+func (c *ColumnData[T]) Len() int {
+	return len(c.values)
+}
+
+// Values returns the values of the column, indexed by row. Null values are the zero value of T, use IsNull to tell them
+// apart. The slice is shared by all the callers and must not be modified.
+func (c *ColumnData[T]) Values() []T {
+	return c.values
+}
+
+func (c *ColumnData[T]) IsNull(i int) bool {
+	return c.valid[i/64]&(1<<(uint(i)%64)) == 0
+}
+
+// Value returns the value of row i, and false if it is null.
+func (c *ColumnData[T]) Value(i int) (T, bool) {
+	return c.values[i], !c.IsNull(i)
+}
+
+// ColumnOf returns the typed view of a column of a table, see ColumnView for the type matching each Kusto type.
+func ColumnOf[T any](t Table, name string) (*ColumnData[T], error) {
+	view, err := t.Column(name)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := view.(*ColumnData[T])
+	if !ok {
+		var zero T
+		return nil, errors.ES(t.Op(), errors.KWrongColumnType, "column %s of type %s can't be viewed as %T", name, view.Column().Type(), zero)
+	}
+	return data, nil
+}
+
+// columnViews builds and caches the column views of a table.
+type columnViews struct {
+	lock  sync.Mutex
+	views map[string]ColumnView
+}
+
+// get returns the view of a column, building it from the rows on first use.
+func (c *columnViews) get(t Table, name string) (ColumnView, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if view, ok := c.views[name]; ok {
+		return view, nil
+	}
+
+	col := t.ColumnByName(name)
+	if col == nil {
+		return nil, errors.ES(t.Op(), errors.KClientArgs, "column %s is not in table %s", name, t.Name())
+	}
+
+	view, err := newColumnView(t, col)
+	if err != nil {
+		return nil, err
+	}
+	if c.views == nil {
+		c.views = make(map[string]ColumnView)
+	}
+	c.views[name] = view
+	return view, nil
+}
+
+func newColumnView(t Table, col Column) (ColumnView, error) {
+	switch col.Type() {
+	case types.Bool:
+		return newPointerColumn[bool](t, col)
+	case types.Int:
+		return newPointerColumn[int32](t, col)
+	case types.Long:
+		return newPointerColumn[int64](t, col)
+	case types.Real:
+		return newPointerColumn[float64](t, col)
+	case types.Decimal:
+		return newPointerColumn[decimal.Decimal](t, col)
+	case types.DateTime:
+		return newPointerColumn[time.Time](t, col)
+	case types.Timespan:
+		return newPointerColumn[time.Duration](t, col)
+	case types.GUID:
+		return newPointerColumn[uuid.UUID](t, col)
+	case types.String:
+		// Strings are never null.
+		return newColumnData(t, col, func(v value.Kusto) (string, bool, bool) {
+			s, ok := v.(*value.String)
+			if !ok {
+				return "", false, false
+			}
+			return s.Value, true, true
+		})
+	case types.Dynamic:
+		return newColumnData(t, col, func(v value.Kusto) ([]byte, bool, bool) {
+			d, ok := v.(*value.Dynamic)
+			if !ok {
+				return nil, false, false
+			}
+			return d.Value, d.Value != nil, true
+		})
+	}
+	return nil, errors.ES(t.Op(), errors.KWrongColumnType, "column %s has unsupported type %s", col.Name(), col.Type())
+}
+
+// newPointerColumn builds the view of a column whose values hold a pointer to their Go value, nil for null.
+func newPointerColumn[T any](t Table, col Column) (ColumnView, error) {
+	return newColumnData(t, col, func(v value.Kusto) (T, bool, bool) {
+		var zero T
+		p, ok := v.(interface{ Ptr() *T })
+		if !ok {
+			return zero, false, false
+		}
+		if ptr := p.Ptr(); ptr != nil {
+			return *ptr, true, true
+		}
+		return zero, false, true
+	})
+}
+
+// newColumnData builds the view of a column, get returning the Go value of a cell, whether it isn't null, and whether
+// it has the expected type.
+func newColumnData[T any](t Table, col Column, get func(value.Kusto) (T, bool, bool)) (*ColumnData[T], error) {
+	rows := t.Rows()
+	c := &ColumnData[T]{
+		column: col,
+		values: make([]T, len(rows)),
+		valid:  make([]uint64, (len(rows)+63)/64),
+	}
+	for i, row := range rows {
+		v := row.Values()[col.Index()]
+		if v == nil {
+			continue
+		}
+		got, valid, ok := get(v)
+		if !ok {
+			return nil, errors.ES(t.Op(), errors.KWrongColumnType, "column %s of type %s has a value of type %s in row %d", col.Name(), col.Type(), v.GetType(), i)
+		}
+		c.values[i] = got
+		if valid {
+			c.valid[i/64] |= 1 << (uint(i) % 64)
+		}
+	}
+	return c, nil
+}
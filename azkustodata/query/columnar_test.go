@@ -0,0 +1,85 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumn(t *testing.T) {
+	ds := NewBaseDataset(context.Background(), errors.OpQuery, "PrimaryResult")
+	columns := Columns{
+		NewColumn(0, "long", types.Long),
+		NewColumn(1, "str", types.String),
+		NewColumn(2, "dt", types.DateTime),
+		NewColumn(3, "dyn", types.Dynamic),
+	}
+	base := NewBaseTable(ds, 0, "0", "T", "PrimaryResult", columns)
+	now := time.Date(2020, 3, 4, 14, 5, 1, 0, time.UTC)
+	var rows []Row
+	for i := 0; i < 70; i++ {
+		long := value.NewLong(int64(i))
+		if i%3 == 0 {
+			long = value.NewNullLong()
+		}
+		rows = append(rows, NewRow(base, i, value.Values{
+			long,
+			value.NewString("s"),
+			value.NewDateTime(now),
+			value.NewNullDynamic(),
+		}))
+	}
+	table := NewTable(base, rows)
+
+	longs, err := ColumnOf[int64](table, "long")
+	require.NoError(t, err)
+	require.Equal(t, 70, longs.Len())
+	assert.Equal(t, "long", longs.Column().Name())
+	for i, v := range longs.Values() {
+		if i%3 == 0 {
+			assert.True(t, longs.IsNull(i), "row %d", i)
+			assert.Zero(t, v)
+		} else {
+			assert.False(t, longs.IsNull(i), "row %d", i)
+			assert.Equal(t, int64(i), v)
+		}
+	}
+	v, ok := longs.Value(68)
+	assert.True(t, ok)
+	assert.Equal(t, int64(68), v)
+
+	// Views are cached.
+	again, err := table.Column("long")
+	require.NoError(t, err)
+	assert.Same(t, longs, again)
+
+	strs, err := ColumnOf[string](table, "str")
+	require.NoError(t, err)
+	assert.Equal(t, "s", strs.Values()[69])
+	assert.False(t, strs.IsNull(69))
+
+	dts, err := ColumnOf[time.Time](table, "dt")
+	require.NoError(t, err)
+	assert.Equal(t, now, dts.Values()[0])
+
+	dyns, err := ColumnOf[[]byte](table, "dyn")
+	require.NoError(t, err)
+	assert.True(t, dyns.IsNull(0))
+
+	_, err = ColumnOf[int32](table, "long")
+	assert.ErrorContains(t, err, "column long of type long can't be viewed as int32")
+
+	_, err = table.Column("missing")
+	assert.ErrorContains(t, err, "column missing is not in table T")
+
+	// Values not matching the column's type fail.
+	mismatched := NewTable(base, []Row{NewRow(base, 0, value.Values{value.NewInt(1), value.NewString(""), value.NewNullDateTime(), value.NewNullDynamic()})})
+	_, err = mismatched.Column("long")
+	assert.ErrorContains(t, err, "column long of type long has a value of type int in row 0")
+}
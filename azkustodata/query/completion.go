@@ -0,0 +1,256 @@
+package query
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/google/uuid"
+)
+
+// This file handles the typed content of the secondary tables of v2 results, QueryProperties and
+// QueryCompletionInformation.
+
+const (
+	// QueryPropertiesKind is the kind of the QueryProperties table, which arrives before the first result.
+	QueryPropertiesKind = "QueryProperties"
+	// QueryCompletionInformationKind is the kind of the QueryCompletionInformation table, which arrives after the last
+	// result.
+	QueryCompletionInformationKind = "QueryCompletionInformation"
+)
+
+// QueryProperties is a row of the QueryProperties table.
+type QueryProperties struct {
+	TableId int
+	Key     string
+	Value   map[string]interface{}
+}
+
+// QueryCompletionEvent is a row of the QueryCompletionInformation table, an event reported when the query completed.
+type QueryCompletionEvent struct {
+	Timestamp        time.Time
+	ClientRequestId  string
+	ActivityId       uuid.UUID
+	SubActivityId    uuid.UUID
+	ParentActivityId uuid.UUID
+	// Level is the severity of the event, see the EventLevel constants.
+	Level          int
+	LevelName      string
+	StatusCode     int
+	StatusCodeName string
+	EventType      int
+	// EventTypeName is the type of the event, such as QueryInfo, WorkloadGroup or QueryResourceConsumption.
+	EventTypeName string
+	// Payload is the JSON content of the event.
+	Payload string
+}
+
+// The levels of QueryCompletionEvent, lower being more severe.
+const (
+	EventLevelCritical = 1
+	EventLevelError    = 2
+	EventLevelWarning  = 3
+	EventLevelInfo     = 4
+	EventLevelVerbose  = 5
+	EventLevelStats    = 6
+)
+
+// queryResourceConsumptionEvent is the type of the event holding the resources consumed by the query.
+const queryResourceConsumptionEvent = "QueryResourceConsumption"
+
+// QueryCompletionInformation is the typed content of the QueryCompletionInformation table, which reports how the
+// query completed and what it cost.
+type QueryCompletionInformation struct {
+	// Events are the rows of the table.
+	Events []QueryCompletionEvent
+	// ResourceConsumption holds the resources consumed by the query, or nil if the cluster didn't report them.
+	ResourceConsumption *QueryResourceConsumption
+}
+
+// Errors returns the events at the error or critical level, reporting failures of the query.
+func (q *QueryCompletionInformation) Errors() []QueryCompletionEvent {
+	var events []QueryCompletionEvent
+	for _, e := range q.Events {
+		if e.Level <= EventLevelError {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// Succeeded reports whether all the events have a successful status code.
+func (q *QueryCompletionInformation) Succeeded() bool {
+	for _, e := range q.Events {
+		if e.StatusCode != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Skipped returns the warning events with an error status code, which report the parts of the data a query skipped,
+// such as the unreachable clusters or shards of a BestEffort query. Their Payload describes what was skipped. The results
+// of a query with skipped parts are incomplete.
+func (q *QueryCompletionInformation) Skipped() []QueryCompletionEvent {
+	var events []QueryCompletionEvent
+	for _, e := range q.Events {
+		if e.Level == EventLevelWarning && e.StatusCode != 0 {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// QueryResourceConsumption is the resources consumed by a query, as reported by the QueryResourceConsumption event.
+type QueryResourceConsumption struct {
+	// ExecutionTime is the time the query took on the cluster.
+	ExecutionTime time.Duration
+	ResourceUsage struct {
+		Cache struct {
+			Memory CacheStatistics `json:"memory"`
+			Disk   CacheStatistics `json:"disk"`
+			Shards struct {
+				Hot         ShardCacheStatistics `json:"hot"`
+				Cold        ShardCacheStatistics `json:"cold"`
+				BypassBytes int64                `json:"bypassbytes"`
+			} `json:"shards"`
+		} `json:"cache"`
+		CPU    CPUUsage `json:"cpu"`
+		Memory struct {
+			PeakPerNode int64 `json:"peak_per_node"`
+		} `json:"memory"`
+		Network struct {
+			InterClusterTotalBytes int64 `json:"inter_cluster_total_bytes"`
+			CrossClusterTotalBytes int64 `json:"cross_cluster_total_bytes"`
+		} `json:"network"`
+	} `json:"resource_usage"`
+	InputDatasetStatistics struct {
+		Extents struct {
+			Total              int64     `json:"total"`
+			Scanned            int64     `json:"scanned"`
+			ScannedMinDatetime time.Time `json:"scanned_min_datetime"`
+			ScannedMaxDatetime time.Time `json:"scanned_max_datetime"`
+		} `json:"extents"`
+		Rows struct {
+			Total   int64 `json:"total"`
+			Scanned int64 `json:"scanned"`
+		} `json:"rows"`
+		RowStores struct {
+			ScannedRows       int64 `json:"scanned_rows"`
+			ScannedValuesSize int64 `json:"scanned_values_size"`
+		} `json:"rowstores"`
+		Shards struct {
+			QueriesGeneric     int64 `json:"queries_generic"`
+			QueriesSpecialized int64 `json:"queries_specialized"`
+		} `json:"shards"`
+	} `json:"input_dataset_statistics"`
+	DatasetStatistics []struct {
+		TableRowCount int64 `json:"table_row_count"`
+		TableSize     int64 `json:"table_size"`
+	} `json:"dataset_statistics"`
+}
+
+// UnmarshalJSON reads the ExecutionTime, which is in seconds.
+func (q *QueryResourceConsumption) UnmarshalJSON(b []byte) error {
+	// alias doesn't have the methods of QueryResourceConsumption, so it is unmarshaled as usual.
+	type alias QueryResourceConsumption
+	aux := struct {
+		*alias
+		ExecutionTime float64 `json:"ExecutionTime"`
+	}{alias: (*alias)(q)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	q.ExecutionTime = time.Duration(aux.ExecutionTime * float64(time.Second))
+	return nil
+}
+
+// CacheStatistics counts the hits and misses of a cache.
+type CacheStatistics struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Total  int64 `json:"total"`
+}
+
+// ShardCacheStatistics counts the bytes read from the hot or cold cache of the shards.
+type ShardCacheStatistics struct {
+	HitBytes      int64 `json:"hitbytes"`
+	MissBytes     int64 `json:"missbytes"`
+	RetrieveBytes int64 `json:"retrievebytes"`
+}
+
+// CPUUsage is the CPU time used by a query.
+type CPUUsage struct {
+	User   time.Duration
+	Kernel time.Duration
+	Total  time.Duration
+}
+
+// UnmarshalJSON reads the times, which are Kusto timespans.
+func (c *CPUUsage) UnmarshalJSON(b []byte) error {
+	var aux struct {
+		User   string `json:"user"`
+		Kernel string `json:"kernel"`
+		Total  string `json:"total cpu"`
+	}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		s string
+		d *time.Duration
+	}{{aux.User, &c.User}, {aux.Kernel, &c.Kernel}, {aux.Total, &c.Total}} {
+		if f.s == "" {
+			continue
+		}
+		d, err := value.ParseTimespan(f.s)
+		if err != nil {
+			return err
+		}
+		*f.d = d
+	}
+	return nil
+}
+
+// findTable returns the first table of the given kind, or nil.
+func findTable(d Dataset, kind string) Table {
+	if tables := d.TablesByKind(kind); len(tables) > 0 {
+		return tables[0]
+	}
+	return nil
+}
+
+// datasetQueryProperties returns the rows of the QueryProperties table of a dataset, or nil if it has none.
+func datasetQueryProperties(d Dataset) ([]QueryProperties, error) {
+	t := findTable(d, QueryPropertiesKind)
+	if t == nil {
+		return nil, nil
+	}
+	return ToStructs[QueryProperties](t)
+}
+
+// datasetQueryCompletionInformation returns the content of the QueryCompletionInformation table of a dataset, or nil if
+// it has none.
+func datasetQueryCompletionInformation(d Dataset) (*QueryCompletionInformation, error) {
+	t := findTable(d, QueryCompletionInformationKind)
+	if t == nil {
+		return nil, nil
+	}
+	events, err := ToStructs[QueryCompletionEvent](t)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &QueryCompletionInformation{Events: events}
+	for _, e := range events {
+		if e.EventTypeName != queryResourceConsumptionEvent {
+			continue
+		}
+		info.ResourceConsumption = &QueryResourceConsumption{}
+		if err := json.Unmarshal([]byte(e.Payload), info.ResourceConsumption); err != nil {
+			return nil, errors.ES(d.Op(), errors.KFailedToParse, "could not parse the %s event: %s", queryResourceConsumptionEvent, err)
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,37 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryResourceConsumption(t *testing.T) {
+	var q QueryResourceConsumption
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"ExecutionTime": 1.5,
+		"resource_usage": {"cpu": {"user": "00:00:01.2500000", "kernel": "00:00:00", "total cpu": "00:00:01.2500000"}},
+		"input_dataset_statistics": {"extents": {"total": 10, "scanned": 4, "scanned_min_datetime": "2024-01-01T00:00:00.0000000Z"}}
+	}`), &q))
+
+	assert.Equal(t, 1500*time.Millisecond, q.ExecutionTime)
+	assert.Equal(t, 1250*time.Millisecond, q.ResourceUsage.CPU.User)
+	assert.Equal(t, 1250*time.Millisecond, q.ResourceUsage.CPU.Total)
+	assert.Equal(t, int64(4), q.InputDatasetStatistics.Extents.Scanned)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), q.InputDatasetStatistics.Extents.ScannedMinDatetime)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"resource_usage": {"cpu": {"user": "soon"}}}`), &q))
+
+	info := QueryCompletionInformation{Events: []QueryCompletionEvent{
+		{Level: EventLevelInfo, EventTypeName: "QueryInfo"},
+		{Level: EventLevelError, StatusCode: 1, EventTypeName: "QueryError"},
+		{Level: EventLevelWarning, StatusCode: 2, EventTypeName: "QueryInfo", Payload: `{"Text":"shard skipped"}`},
+		{Level: EventLevelWarning, EventTypeName: "QueryInfo"},
+	}}
+	assert.False(t, info.Succeeded())
+	assert.Equal(t, info.Events[1:2], info.Errors())
+	assert.Equal(t, info.Events[2:3], info.Skipped())
+}
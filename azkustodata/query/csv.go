@@ -0,0 +1,186 @@
+package query
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// CSVOptions configures how results are written with WriteCSV.
+// Fields are quoted as needed, doubling the quotes inside them, as Kusto's CSV ingestion expects, so the results can be
+// ingested back as is. Values are written in the same formats as with ToJSON, dynamic values as their JSON.
+type CSVOptions struct {
+	// Delimiter separates the fields. Zero means a comma, use '\t' for TSV.
+	Delimiter rune
+	// Header writes a first record with the names of the columns.
+	Header bool
+	// Null is written for null values. It defaults to an empty field, which Kusto's CSV ingestion reads as null for all
+	// types but string.
+	Null string
+	// UseCRLF ends the records with \r\n instead of \n.
+	UseCRLF bool
+}
+
+// csvWriter writes rows as CSV records.
+type csvWriter struct {
+	w       *csv.Writer
+	options CSVOptions
+	record  []string
+	op      errors.Op
+}
+
+func newCSVWriter(w io.Writer, op errors.Op, columns []Column, options CSVOptions) (*csvWriter, error) {
+	c := &csvWriter{w: csv.NewWriter(w), options: options, record: make([]string, len(columns)), op: op}
+	if options.Delimiter != 0 {
+		c.w.Comma = options.Delimiter
+	}
+	c.w.UseCRLF = options.UseCRLF
+	if options.Header {
+		for i, col := range columns {
+			c.record[i] = col.Name()
+		}
+		if err := c.w.Write(c.record); err != nil {
+			return nil, errors.E(op, errors.KIO, err)
+		}
+	}
+	return c, nil
+}
+
+func (c *csvWriter) write(row Row) error {
+	for i, v := range row.Values() {
+		field, err := csvField(v, c.options.Null)
+		if err != nil {
+			return errors.ES(c.op, errors.KFailedToParse, "column %s of row %d: %s", row.Columns()[i].Name(), row.Index(), err)
+		}
+		c.record[i] = field
+	}
+	if err := c.w.Write(c.record); err != nil {
+		return errors.E(c.op, errors.KIO, err)
+	}
+	return nil
+}
+
+func (c *csvWriter) flush() error {
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		return errors.E(c.op, errors.KIO, err)
+	}
+	return nil
+}
+
+// writeCSV writes the rows of a table to w as CSV, see CSVOptions.
+func writeCSV(w io.Writer, t Table, options CSVOptions) error {
+	c, err := newCSVWriter(w, t.Op(), t.Columns(), options)
+	if err != nil {
+		return err
+	}
+	// The rows are read with a RowReader, so the rows of tables spilled to disk aren't all read back into memory.
+	r := ReadRows(t)
+	defer r.Close()
+	for r.Next() {
+		if err := c.write(r.Row()); err != nil {
+			return err
+		}
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+	return c.flush()
+}
+
+// WriteCSVIterative writes the rows of an iterative table to w as CSV as they are read, see CSVOptions. It stops at the
+// first error, including ErrRowsReplaced, and skips the remaining rows. It implements IterativeTable.WriteCSV.
+func WriteCSVIterative(w io.Writer, t IterativeTable, options CSVOptions) error {
+	c, err := newCSVWriter(w, t.Op(), t.Columns(), options)
+	if err != nil {
+		t.SkipToEnd()
+		return err
+	}
+	for result := range t.Rows() {
+		if result.Err() == nil {
+			err = c.write(result.Row())
+		} else {
+			err = result.Err()
+		}
+		if err != nil {
+			t.SkipToEnd()
+			return err
+		}
+	}
+	return c.flush()
+}
+
+// csvField returns the CSV field of a value.
+func csvField(v value.Kusto, null string) (string, error) {
+	if v == nil {
+		return null, nil
+	}
+	switch x := v.GetValue().(type) {
+	case string:
+		return x, nil
+	case []byte:
+		if len(x) == 0 {
+			break
+		}
+		return string(x), nil
+	case *bool:
+		if x == nil {
+			break
+		}
+		return strconv.FormatBool(*x), nil
+	case *int32:
+		if x == nil {
+			break
+		}
+		return strconv.FormatInt(int64(*x), 10), nil
+	case *int64:
+		if x == nil {
+			break
+		}
+		return strconv.FormatInt(*x, 10), nil
+	case *float64:
+		if x == nil {
+			break
+		}
+		switch {
+		case math.IsNaN(*x):
+			return "NaN", nil
+		case math.IsInf(*x, 1):
+			return "Infinity", nil
+		case math.IsInf(*x, -1):
+			return "-Infinity", nil
+		}
+		return strconv.FormatFloat(*x, 'g', -1, 64), nil
+	case *time.Time:
+		if x == nil {
+			break
+		}
+		return value.FormatDatetime(*x), nil
+	case *time.Duration:
+		if x == nil {
+			break
+		}
+		return value.TimespanString(*x), nil
+	case *decimal.Decimal:
+		if x == nil {
+			break
+		}
+		return x.String(), nil
+	case *uuid.UUID:
+		if x == nil {
+			break
+		}
+		return x.String(), nil
+	case nil:
+	default:
+		return "", errors.ES(errors.OpUnknown, errors.KFailedToParse, "unsupported value of type %s", v.GetType())
+	}
+	return null, nil
+}
@@ -0,0 +1,60 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCSV(t *testing.T) {
+	ds := NewBaseDataset(context.Background(), errors.OpQuery, "PrimaryResult")
+	base := NewBaseTable(ds, 0, "0", "T", "PrimaryResult", Columns{
+		NewColumn(0, "str", types.String),
+		NewColumn(1, "long", types.Long),
+		NewColumn(2, "dt", types.DateTime),
+		NewColumn(3, "dyn", types.Dynamic),
+		NewColumn(4, "ts", types.Timespan),
+	})
+	table := NewTable(base, []Row{
+		NewRow(base, 0, value.Values{
+			value.NewString("a, \"quoted\"\nvalue"),
+			value.NewLong(-1),
+			value.NewDateTime(time.Date(2020, 3, 4, 14, 5, 1, 310996500, time.UTC)),
+			value.NewDynamic([]byte(`{"a":[1,2]}`)),
+			value.NewTimespan(90 * time.Minute),
+		}),
+		NewRow(base, 1, value.Values{
+			value.NewString(""),
+			value.NewNullLong(),
+			value.NewNullDateTime(),
+			value.NewNullDynamic(),
+			value.NewNullTimespan(),
+		}),
+	})
+
+	var b bytes.Buffer
+	require.NoError(t, table.WriteCSV(&b, CSVOptions{}))
+	assert.Equal(t, "\"a, \"\"quoted\"\"\nvalue\",-1,2020-03-04T14:05:01.3109965Z,\"{\"\"a\"\":[1,2]}\",01:30:00\n,,,,\n", b.String())
+
+	// The records read back as written.
+	records, err := csv.NewReader(&b).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"a, \"quoted\"\nvalue", "-1", "2020-03-04T14:05:01.3109965Z", `{"a":[1,2]}`, "01:30:00"},
+		{"", "", "", "", ""},
+	}, records)
+
+	b.Reset()
+	require.NoError(t, table.WriteCSV(&b, CSVOptions{Delimiter: '\t', Header: true, Null: "null", UseCRLF: true}))
+	assert.Equal(t, "str\tlong\tdt\tdyn\tts\r\n"+
+		"\"a, \"\"quoted\"\"\r\nvalue\"\t-1\t2020-03-04T14:05:01.3109965Z\t\"{\"\"a\"\":[1,2]}\"\t01:30:00\r\n"+
+		"\tnull\tnull\tnull\tnull\r\n", b.String())
+}
@@ -13,11 +13,41 @@ type BaseDataset interface {
 	Op() errors.Op
 
 	PrimaryResultKind() string
+
+	// ClientRequestID returns the x-ms-client-request-id the request was sent with, or "" if unknown.
+	ClientRequestID() string
+	// ActivityID returns the x-ms-activity-id the cluster returned for the request, or "" if unknown.
+	// Together with the client request id, it identifies the request in the cluster's diagnostics.
+	ActivityID() string
 }
 
 type Dataset interface {
 	BaseDataset
 	Tables() []Table
+	// TablesByKind returns the tables of the given kind, such as QueryPropertiesKind or QueryCompletionInformationKind,
+	// in the order they were returned. Tables should be looked up by kind rather than by index, as the cluster may add
+	// tables to its results.
+	TablesByKind(kind string) []Table
+	// PrimaryResults returns the primary result tables, holding the results of the query, as opposed to the secondary
+	// tables describing it. A query has a primary result for each of its tabular expression statements.
+	PrimaryResults() []Table
+	// ToJSON marshals the tables of the dataset to JSON, as an array of objects holding the TableName, TableKind and Data
+	// of each table, the data being in the given format, see JSONFormat.
+	// Datasets also implement json.Marshaler, marshaling to the JSONRows format.
+	ToJSON(format JSONFormat) ([]byte, error)
+	// QueryProperties returns the rows of the QueryProperties table of v2 results, or nil for v1 results.
+	QueryProperties() ([]QueryProperties, error)
+	// QueryCompletionInformation returns the content of the QueryCompletionInformation table of v2 results, such as the
+	// resources the query consumed, or nil for v1 results.
+	QueryCompletionInformation() (*QueryCompletionInformation, error)
+	// Visualizations returns the hints of the render operators of the query on how to chart its results, one for each
+	// primary result that has one, see Visualization.
+	Visualizations() ([]Visualization, error)
+	// PartialQueryError returns the failures reported inside the results, or nil if there were none.
+	PartialQueryError() *PartialQueryError
+	// Close removes the files the rows of the dataset were spilled to, see SpillOptions, after which they can't be read.
+	// It does nothing for datasets held in memory.
+	Close() error
 }
 
 // IterativeDataset represents an iterative result from kusto - where the tables are streamed as they are received from the service.
@@ -2,6 +2,8 @@ package query
 
 import (
 	"context"
+	"time"
+
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
 )
 
@@ -10,6 +12,7 @@ type baseDataset struct {
 	ctx                context.Context
 	op                 errors.Op
 	primaryResultsKind string
+	requestIDs         RequestIDs
 }
 
 func (d *baseDataset) Context() context.Context {
@@ -24,26 +27,169 @@ func (d *baseDataset) PrimaryResultKind() string {
 	return d.primaryResultsKind
 }
 
+func (d *baseDataset) ClientRequestID() string {
+	return d.requestIDs.ClientRequestID
+}
+
+func (d *baseDataset) ActivityID() string {
+	return d.requestIDs.ActivityID
+}
+
+// NewBaseDataset creates a BaseDataset. Its request ids are taken from ctx, see ContextWithRequestIDs.
 func NewBaseDataset(ctx context.Context, op errors.Op, primaryResultsKind string) BaseDataset {
 	return &baseDataset{
 		ctx:                ctx,
 		op:                 op,
 		primaryResultsKind: primaryResultsKind,
+		requestIDs:         RequestIDsFromContext(ctx),
 	}
 }
 
+// RequestIDs identifies the request a result was returned for.
+type RequestIDs struct {
+	// ClientRequestID is the x-ms-client-request-id the request was sent with.
+	ClientRequestID string
+	// ActivityID is the x-ms-activity-id the cluster returned for the request.
+	ActivityID string
+}
+
+type requestIDsKey struct{}
+
+// ContextWithRequestIDs returns a copy of ctx holding ids, for the datasets created with it.
+func ContextWithRequestIDs(ctx context.Context, ids RequestIDs) context.Context {
+	return context.WithValue(ctx, requestIDsKey{}, ids)
+}
+
+// RequestIDsFromContext returns the request ids held by ctx, if any.
+func RequestIDsFromContext(ctx context.Context) RequestIDs {
+	ids, _ := ctx.Value(requestIDsKey{}).(RequestIDs)
+	return ids
+}
+
+// Heartbeat is reported for each frame received while reading the results of a query, so callers can detect stalled
+// connections.
+type Heartbeat struct {
+	// FrameType is the type of the frame, such as "TableFragment", or "TableProgress" for the progress frames of
+	// progressive queries.
+	FrameType string
+	// TableID is the id of the table the frame is about, for table frames.
+	TableID int
+	// Progress is the progress of the query, in percent, for TableProgress frames.
+	Progress float64
+	// Received is when the frame was received.
+	Received time.Time
+}
+
+// Progress is the progress of a progressive query, as reported by the cluster.
+type Progress struct {
+	// TableID is the id of the table being computed.
+	TableID int
+	// Percent is the estimated progress of the query, from 0 to 100.
+	Percent float64
+}
+
+type heartbeatKey struct{}
+
+// ContextWithHeartbeat returns a copy of ctx holding onHeartbeat, to be called by the datasets created with it.
+func ContextWithHeartbeat(ctx context.Context, onHeartbeat func(Heartbeat)) context.Context {
+	return context.WithValue(ctx, heartbeatKey{}, onHeartbeat)
+}
+
+// HeartbeatFromContext returns the heartbeat callback held by ctx, or nil.
+func HeartbeatFromContext(ctx context.Context) func(Heartbeat) {
+	onHeartbeat, _ := ctx.Value(heartbeatKey{}).(func(Heartbeat))
+	return onHeartbeat
+}
+
 type dataset struct {
 	BaseDataset
-	tables []Table
+	tables     []Table
+	partialErr *PartialQueryError
+	spill      *Spill
 }
 
+// NewDataset creates a Dataset. The Spill held by the context of base, if any, is closed with it, see ContextWithSpill.
 func NewDataset(base BaseDataset, tables []Table) Dataset {
 	return &dataset{
 		BaseDataset: base,
 		tables:      tables,
+		spill:       SpillFromContext(base.Context()),
 	}
 }
 
+// NewPartialDataset creates a Dataset of results which reported failures, see PartialQueryError. The error is nil if
+// there are no failures.
+func NewPartialDataset(base BaseDataset, tables []Table, failures []error) (Dataset, *PartialQueryError) {
+	d := &dataset{
+		BaseDataset: base,
+		tables:      tables,
+		partialErr:  NewPartialQueryError(failures),
+		spill:       SpillFromContext(base.Context()),
+	}
+	if d.partialErr != nil {
+		d.partialErr.Dataset = d
+	}
+	return d, d.partialErr
+}
+
 func (d *dataset) Tables() []Table {
 	return d.tables
 }
+
+func (d *dataset) TablesByKind(kind string) []Table {
+	return TablesByKind(d.tables, kind)
+}
+
+func (d *dataset) PrimaryResults() []Table {
+	return PrimaryResults(d.tables)
+}
+
+func (d *dataset) ToJSON(format JSONFormat) ([]byte, error) {
+	return DatasetToJSON(d, format)
+}
+
+func (d *dataset) MarshalJSON() ([]byte, error) {
+	return DatasetToJSON(d, JSONRows)
+}
+
+func (d *dataset) QueryProperties() ([]QueryProperties, error) {
+	return datasetQueryProperties(d)
+}
+
+func (d *dataset) QueryCompletionInformation() (*QueryCompletionInformation, error) {
+	return datasetQueryCompletionInformation(d)
+}
+
+func (d *dataset) Visualizations() ([]Visualization, error) {
+	return datasetVisualizations(d)
+}
+
+func (d *dataset) PartialQueryError() *PartialQueryError {
+	return d.partialErr
+}
+
+func (d *dataset) Close() error {
+	return d.spill.Close()
+}
+
+// TablesByKind returns the tables of the given kind, for the implementations of Dataset.TablesByKind.
+func TablesByKind(tables []Table, kind string) []Table {
+	var result []Table
+	for _, t := range tables {
+		if t.Kind() == kind {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// PrimaryResults returns the primary result tables, for the implementations of Dataset.PrimaryResults.
+func PrimaryResults(tables []Table) []Table {
+	var result []Table
+	for _, t := range tables {
+		if t.IsPrimaryResult() {
+			result = append(result, t)
+		}
+	}
+	return result
+}
@@ -1,15 +1,22 @@
 package query
 
 import (
-	kustoErrors "github.com/Azure/azure-kusto-go/azkustodata/errors"
-	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"database/sql"
 	"reflect"
 	"strings"
 	"sync"
+	"time"
+
+	kustoErrors "github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type fieldMap struct {
 	colNameToFieldName map[string]string
+	// required holds the names of the columns required by the struct.
+	required []string
 }
 
 var typeMapper = map[reflect.Type]fieldMap{}
@@ -27,10 +34,27 @@ func decodeToStruct(cols []Column, row value.Values, p interface{}) error {
 			return err
 		}
 	}
+
+	for _, name := range fields.required {
+		found := false
+		for _, col := range cols {
+			if col.Name() == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return kustoErrors.ES(kustoErrors.OpTableAccess, kustoErrors.KClientArgs, "column %s is required by struct.%s, but is missing from the table", name, fields.colNameToFieldName[name])
+		}
+	}
 	return nil
 }
 
 // newFields takes in the Columns from our row and the reflect.Type of our *struct.
+// A field is decoded from the column with its name, or the name set by its `kusto` tag. The tag can be followed by
+// options, separated by commas: "required" fails the decoding of tables without the column. A field tagged "-" is
+// never decoded, as are unexported fields.
+// Fields implementing sql.Scanner, such as sql.NullInt64 or sql.Null[T], are scanned with the value, see driverValue.
 func newFields(ptr reflect.Type) fieldMap {
 	typeMapperLock.RLock()
 	f, ok := typeMapper[ptr]
@@ -42,12 +66,25 @@ func newFields(ptr reflect.Type) fieldMap {
 		defer typeMapperLock.Unlock()
 		nFields := fieldMap{colNameToFieldName: make(map[string]string, ptr.Elem().NumField())}
 		for i := 0; i < ptr.Elem().NumField(); i++ {
-			field := ptr.Elem().Field(i)
-			if tag := field.Tag.Get("kusto"); strings.TrimSpace(tag) != "" {
-				nFields.colNameToFieldName[tag] = field.Name
-			} else {
-				nFields.colNameToFieldName[field.Name] = field.Name
+			structField := ptr.Elem().Field(i)
+			if !structField.IsExported() {
+				continue
+			}
+			tag := strings.TrimSpace(structField.Tag.Get("kusto"))
+			if tag == "-" {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			name = strings.TrimSpace(name)
+			if name == "" {
+				name = structField.Name
 			}
+			for _, option := range strings.Split(options, ",") {
+				if strings.TrimSpace(option) == "required" {
+					nFields.required = append(nFields.required, name)
+				}
+			}
+			nFields.colNameToFieldName[name] = structField.Name
 		}
 		typeMapper[ptr] = nFields
 		return nFields
@@ -61,14 +98,54 @@ func (f fieldMap) convert(col Column, k value.Kusto, v reflect.Value) error {
 		return nil
 	}
 
-	if fieldName == "-" {
-		return nil
+	field := v.Elem().FieldByName(fieldName)
+	var err error
+	if scanner, ok := field.Addr().Interface().(sql.Scanner); ok && !isNativeType(k, field.Type()) {
+		err = scanner.Scan(driverValue(k))
+	} else {
+		err = k.Convert(field)
 	}
-
-	err := k.Convert(v.Elem().FieldByName(fieldName))
 	if err != nil {
-		return kustoErrors.ES(kustoErrors.OpTableAccess, kustoErrors.KWrongColumnType, "column %s could not store in struct.%s: %s", col.Name(), fieldName, err.Error())
+		return kustoErrors.ES(kustoErrors.OpTableAccess, kustoErrors.KWrongColumnType, "column %s of type %s could not store in struct.%s: %s", col.Name(), col.Type(), fieldName, err.Error())
 	}
 
 	return nil
 }
+
+// isNativeType reports whether t is the Go type of the value, or a pointer to it, which Convert sets directly even if
+// the type is a sql.Scanner, as decimal.Decimal and uuid.UUID are.
+func isNativeType(k value.Kusto, t reflect.Type) bool {
+	native := reflect.TypeOf(k.GetValue())
+	return t == native || (native.Kind() == reflect.Ptr && t == native.Elem())
+}
+
+// driverValue returns the value as one of the types database/sql passes to sql.Scanner: nil, int64, float64, bool,
+// []byte, string or time.Time. Timespans are int64 nanoseconds, decimals and guids are strings.
+func driverValue(k value.Kusto) interface{} {
+	if k.IsNull() {
+		return nil
+	}
+	switch x := k.GetValue().(type) {
+	case *bool:
+		return *x
+	case *int32:
+		return int64(*x)
+	case *int64:
+		return *x
+	case *float64:
+		return *x
+	case *time.Time:
+		return *x
+	case *time.Duration:
+		return int64(*x)
+	case *decimal.Decimal:
+		return x.String()
+	case *uuid.UUID:
+		return x.String()
+	case string:
+		return x
+	case []byte:
+		return x
+	}
+	return k.GetValue()
+}
@@ -0,0 +1,164 @@
+package query
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRow(columns Columns, values ...value.Kusto) Row {
+	return NewRowFromParts(columns, func(name string) Column {
+		for _, c := range columns {
+			if c.Name() == name {
+				return c
+			}
+		}
+		return nil
+	}, 0, values)
+}
+
+func TestToStructTags(t *testing.T) {
+	type tag struct {
+		Name  string `json:"name"`
+		Score float64
+	}
+	type record struct {
+		ID       int64         `kusto:"id,required"`
+		Name     string        `kusto:",required"`
+		Duration time.Duration `kusto:"duration"`
+		Count    *int32
+		Missing  *string
+		Tags     []tag
+		Owner    *tag
+		Attrs    interface{}
+		Flag     bool
+		Ignored  string `kusto:"-"`
+		ignored  string
+	}
+
+	columns := Columns{
+		NewColumn(0, "id", types.Long),
+		NewColumn(1, "Name", types.String),
+		NewColumn(2, "duration", types.Timespan),
+		NewColumn(3, "Count", types.Int),
+		NewColumn(4, "Missing", types.String),
+		NewColumn(5, "Tags", types.Dynamic),
+		NewColumn(6, "Owner", types.Dynamic),
+		NewColumn(7, "Attrs", types.Dynamic),
+		NewColumn(8, "Flag", types.Dynamic),
+		NewColumn(9, "Ignored", types.String),
+		NewColumn(10, "ignored", types.String),
+	}
+	row := testRow(columns,
+		value.NewLong(1),
+		value.NewString("a"),
+		value.NewTimespan(time.Minute),
+		value.NewNullInt(),
+		value.NewString("x"),
+		value.NewDynamic([]byte(`[{"name":"t","Score":1.5}]`)),
+		value.NewDynamic([]byte(`{"name":"o"}`)),
+		value.NewDynamic([]byte(`{"k":[1,2]}`)),
+		value.NewDynamic([]byte(`true`)),
+		value.NewString("not decoded"),
+		value.NewString("not decoded"),
+	)
+
+	var r record
+	require.NoError(t, row.ToStruct(&r))
+	missing := "x"
+	assert.Equal(t, record{
+		ID:       1,
+		Name:     "a",
+		Duration: time.Minute,
+		Missing:  &missing,
+		Tags:     []tag{{Name: "t", Score: 1.5}},
+		Owner:    &tag{Name: "o"},
+		Attrs:    map[string]interface{}{"k": []interface{}{1.0, 2.0}},
+		Flag:     true,
+	}, r)
+
+	// A required column missing from the table fails, naming it.
+	err := testRow(columns[1:2], value.NewString("a")).ToStruct(&r)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "column id is required by struct.ID")
+
+	// A column that can't be stored names the column and its type.
+	err = testRow(Columns{NewColumn(0, "Tags", types.String)}, value.NewString("a")).ToStruct(&r)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "column Tags of type string could not store in struct.Tags")
+}
+
+func TestToStructNullable(t *testing.T) {
+	type record struct {
+		Long     *int64
+		NullLong *int64
+		Str      *string
+		When     *time.Time
+		SQLInt   sql.NullInt64
+		SQLNull  sql.NullInt64
+		SQLStr   sql.NullString
+		SQLTime  sql.NullTime
+		SQLReal  sql.Null[float64]
+		SQLSpan  sql.Null[time.Duration]
+		SQLDec   sql.NullString
+		Dec      decimal.Decimal
+		NullDec  decimal.Decimal
+	}
+
+	columns := Columns{
+		NewColumn(0, "Long", types.Long),
+		NewColumn(1, "NullLong", types.Long),
+		NewColumn(2, "Str", types.String),
+		NewColumn(3, "When", types.DateTime),
+		NewColumn(4, "SQLInt", types.Int),
+		NewColumn(5, "SQLNull", types.Long),
+		NewColumn(6, "SQLStr", types.String),
+		NewColumn(7, "SQLTime", types.DateTime),
+		NewColumn(8, "SQLReal", types.Real),
+		NewColumn(9, "SQLSpan", types.Timespan),
+		NewColumn(10, "SQLDec", types.Decimal),
+		NewColumn(11, "Dec", types.Decimal),
+		NewColumn(12, "NullDec", types.Decimal),
+	}
+	now := time.Date(2020, 3, 4, 14, 5, 1, 0, time.UTC)
+	row := testRow(columns,
+		value.NewLong(0),
+		value.NewNullLong(),
+		value.NewString("a"),
+		value.NewDateTime(now),
+		value.NewInt(2),
+		value.NewNullLong(),
+		value.NewString("b"),
+		value.NewDateTime(now),
+		value.NewReal(1.5),
+		value.NewTimespan(time.Minute),
+		value.NewDecimal(decimal.RequireFromString("1.10")),
+		value.NewDecimal(decimal.RequireFromString("2.5")),
+		value.NewNullDecimal(),
+	)
+
+	var r record
+	require.NoError(t, row.ToStruct(&r))
+	require.NotNil(t, r.Long)
+	assert.Equal(t, int64(0), *r.Long)
+	assert.Nil(t, r.NullLong)
+	require.NotNil(t, r.Str)
+	assert.Equal(t, "a", *r.Str)
+	require.NotNil(t, r.When)
+	assert.Equal(t, now, *r.When)
+	assert.Equal(t, sql.NullInt64{Int64: 2, Valid: true}, r.SQLInt)
+	assert.Equal(t, sql.NullInt64{}, r.SQLNull)
+	assert.Equal(t, sql.NullString{String: "b", Valid: true}, r.SQLStr)
+	assert.Equal(t, sql.NullTime{Time: now, Valid: true}, r.SQLTime)
+	assert.Equal(t, sql.Null[float64]{V: 1.5, Valid: true}, r.SQLReal)
+	assert.Equal(t, sql.Null[time.Duration]{V: time.Minute, Valid: true}, r.SQLSpan)
+	assert.Equal(t, sql.NullString{String: "1.1", Valid: true}, r.SQLDec)
+	assert.True(t, decimal.RequireFromString("2.5").Equal(r.Dec))
+	assert.True(t, r.NullDec.IsZero())
+}
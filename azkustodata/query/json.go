@@ -0,0 +1,181 @@
+package query
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// JSONFormat is the layout of results marshaled to JSON with ToJSON.
+//
+// Values keep their Kusto types: datetimes are RFC3339 strings with the 7 fractional digits of Kusto's ticks, timespans
+// are strings in Kusto's [d.]hh:mm:ss[.fffffff] format, decimals and guids are strings, so they don't lose precision,
+// dynamic values are embedded as JSON, real NaN and infinities are the strings "NaN", "Infinity" and "-Infinity", and
+// nulls are null.
+type JSONFormat int
+
+const (
+	// JSONRows marshals a table as an array of objects, one per row, mapping the column names to the values.
+	JSONRows JSONFormat = iota
+	// JSONColumns marshals a table as an object mapping the column names to the arrays of their values, in column order.
+	JSONColumns
+)
+
+// tableToJSON marshals a table in the given format.
+func tableToJSON(t Table, format JSONFormat) ([]byte, error) {
+	cols := t.Columns()
+	rows := t.Rows()
+	var b []byte
+	var err error
+
+	switch format {
+	case JSONRows:
+		b = append(b, '[')
+		for i, row := range rows {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = append(b, '{')
+			for j, col := range cols {
+				if j > 0 {
+					b = append(b, ',')
+				}
+				b = appendJSONString(b, col.Name())
+				b = append(b, ':')
+				if b, err = appendJSONValue(b, row.Values()[j]); err != nil {
+					return nil, errors.ES(t.Op(), errors.KFailedToParse, "column %s of row %d: %s", col.Name(), i, err)
+				}
+			}
+			b = append(b, '}')
+		}
+		b = append(b, ']')
+	case JSONColumns:
+		b = append(b, '{')
+		for j, col := range cols {
+			if j > 0 {
+				b = append(b, ',')
+			}
+			b = appendJSONString(b, col.Name())
+			b = append(b, ':', '[')
+			for i, row := range rows {
+				if i > 0 {
+					b = append(b, ',')
+				}
+				if b, err = appendJSONValue(b, row.Values()[j]); err != nil {
+					return nil, errors.ES(t.Op(), errors.KFailedToParse, "column %s of row %d: %s", col.Name(), i, err)
+				}
+			}
+			b = append(b, ']')
+		}
+		b = append(b, '}')
+	default:
+		return nil, errors.ES(t.Op(), errors.KClientArgs, "unknown JSON format %d", format)
+	}
+	return b, nil
+}
+
+// DatasetToJSON marshals the tables of a dataset as an array of objects holding the name, kind and data of each table,
+// the data being in the given format. It implements Dataset.ToJSON for the dataset types.
+func DatasetToJSON(d Dataset, format JSONFormat) ([]byte, error) {
+	b := []byte{'['}
+	for i, t := range d.Tables() {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, `{"TableName":`...)
+		b = appendJSONString(b, t.Name())
+		b = append(b, `,"TableKind":`...)
+		b = appendJSONString(b, t.Kind())
+		b = append(b, `,"Data":`...)
+		data, err := tableToJSON(t, format)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, data...)
+		b = append(b, '}')
+	}
+	return append(b, ']'), nil
+}
+
+// appendJSONValue appends the JSON of a value to b.
+func appendJSONValue(b []byte, v value.Kusto) ([]byte, error) {
+	if v == nil {
+		return append(b, "null"...), nil
+	}
+	switch x := v.GetValue().(type) {
+	case string:
+		return appendJSONString(b, x), nil
+	case []byte:
+		if len(x) == 0 {
+			return append(b, "null"...), nil
+		}
+		if !json.Valid(x) {
+			return nil, errors.ES(errors.OpUnknown, errors.KFailedToParse, "dynamic value is not valid JSON")
+		}
+		return append(b, x...), nil
+	case *bool:
+		if x == nil {
+			break
+		}
+		return strconv.AppendBool(b, *x), nil
+	case *int32:
+		if x == nil {
+			break
+		}
+		return strconv.AppendInt(b, int64(*x), 10), nil
+	case *int64:
+		if x == nil {
+			break
+		}
+		return strconv.AppendInt(b, *x, 10), nil
+	case *float64:
+		if x == nil {
+			break
+		}
+		switch {
+		case math.IsNaN(*x):
+			return append(b, `"NaN"`...), nil
+		case math.IsInf(*x, 1):
+			return append(b, `"Infinity"`...), nil
+		case math.IsInf(*x, -1):
+			return append(b, `"-Infinity"`...), nil
+		}
+		return strconv.AppendFloat(b, *x, 'g', -1, 64), nil
+	case *time.Time:
+		if x == nil {
+			break
+		}
+		return appendJSONString(b, value.FormatDatetime(*x)), nil
+	case *time.Duration:
+		if x == nil {
+			break
+		}
+		return appendJSONString(b, value.TimespanString(*x)), nil
+	case *decimal.Decimal:
+		if x == nil {
+			break
+		}
+		return appendJSONString(b, x.String()), nil
+	case *uuid.UUID:
+		if x == nil {
+			break
+		}
+		return appendJSONString(b, x.String()), nil
+	case nil:
+	default:
+		return nil, errors.ES(errors.OpUnknown, errors.KFailedToParse, "unsupported value of type %s", v.GetType())
+	}
+	return append(b, "null"...), nil
+}
+
+func appendJSONString(b []byte, s string) []byte {
+	// Marshaling a string can't fail.
+	quoted, _ := json.Marshal(s)
+	return append(b, quoted...)
+}
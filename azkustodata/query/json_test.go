@@ -0,0 +1,81 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToJSON(t *testing.T) {
+	ds := NewBaseDataset(context.Background(), errors.OpQuery, "PrimaryResult")
+	columns := Columns{
+		NewColumn(0, "dt", types.DateTime),
+		NewColumn(1, "ts", types.Timespan),
+		NewColumn(2, "dec", types.Decimal),
+		NewColumn(3, "guid", types.GUID),
+		NewColumn(4, "dyn", types.Dynamic),
+		NewColumn(5, "real", types.Real),
+		NewColumn(6, "long", types.Long),
+		NewColumn(7, "str", types.String),
+	}
+	base := NewBaseTable(ds, 0, "0", "T", "PrimaryResult", columns)
+	table := NewTable(base, []Row{
+		NewRow(base, 0, value.Values{
+			value.NewDateTime(time.Date(2020, 3, 4, 14, 5, 1, 310996500, time.UTC)),
+			value.NewTimespan(26*time.Hour + 100*time.Nanosecond),
+			value.NewDecimal(decimal.RequireFromString("2.00000000000000000001")),
+			value.NewGUID(uuid.MustParse("74be27de-1e4e-49d9-b579-fe0b331d3642")),
+			value.NewDynamic([]byte(`{"a":[1,"<b>"]}`)),
+			value.NewReal(math.NaN()),
+			value.NewLong(math.MaxInt64),
+			value.NewString("\"é\"\n"),
+		}),
+		NewRow(base, 1, value.Values{
+			value.NewNullDateTime(),
+			value.NewNullTimespan(),
+			value.NewNullDecimal(),
+			value.NewNullGUID(),
+			value.NewNullDynamic(),
+			value.NewReal(1.5),
+			value.NewNullLong(),
+			value.NewString(""),
+		}),
+	})
+
+	rows, err := table.ToJSON(JSONRows)
+	require.NoError(t, err)
+	assert.Equal(t, `[`+
+		`{"dt":"2020-03-04T14:05:01.3109965Z","ts":"1.02:00:00.0000001","dec":"2.00000000000000000001","guid":"74be27de-1e4e-49d9-b579-fe0b331d3642","dyn":{"a":[1,"<b>"]},"real":"NaN","long":9223372036854775807,"str":"\"é\"\n"},`+
+		`{"dt":null,"ts":null,"dec":null,"guid":null,"dyn":null,"real":1.5,"long":null,"str":""}`+
+		`]`, string(rows))
+	assert.True(t, json.Valid(rows))
+
+	cols, err := table.ToJSON(JSONColumns)
+	require.NoError(t, err)
+	assert.Equal(t, `{"dt":["2020-03-04T14:05:01.3109965Z",null],"ts":["1.02:00:00.0000001",null],"dec":["2.00000000000000000001",null],`+
+		`"guid":["74be27de-1e4e-49d9-b579-fe0b331d3642",null],"dyn":[{"a":[1,"<b>"]},null],"real":["NaN",1.5],"long":[9223372036854775807,null],"str":["\"é\"\n",""]}`,
+		string(cols))
+
+	dataset := NewDataset(ds, []Table{table})
+	marshaled, err := dataset.ToJSON(JSONRows)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"TableName":"T","TableKind":"PrimaryResult","Data":`+string(rows)+`}]`, string(marshaled))
+
+	// json.Marshal uses the rows format.
+	viaMarshal, err := json.Marshal(dataset)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(marshaled), string(viaMarshal))
+
+	_, err = table.ToJSON(JSONFormat(42))
+	assert.Error(t, err)
+}
@@ -0,0 +1,51 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PartialQueryError aggregates the failures reported inside the results of a query, after some of its results were
+// sent, such as when a result exceeds the query limits, or all the failures when DeferPartialQueryFailures is set.
+// Such failures are easy to miss, as the request itself succeeds.
+//
+// It is returned by IterativeDataset.ToDataset, and so Client.Query, along with the dataset holding the results read,
+// which may be incomplete. The dataset also returns it from its PartialQueryError method.
+// The failures can be found with errors.As, as *errors.OneApiError for v2 results.
+type PartialQueryError struct {
+	// Failures are the failures reported, without duplicates.
+	Failures []error
+	// Dataset holds the results read despite the failures.
+	Dataset Dataset
+}
+
+// NewPartialQueryError returns a PartialQueryError for failures, dropping the failures with the same message, as
+// failures are reported both at the end of their table and at the end of the dataset. It returns nil if failures is
+// empty.
+func NewPartialQueryError(failures []error) *PartialQueryError {
+	if len(failures) == 0 {
+		return nil
+	}
+	e := &PartialQueryError{}
+	seen := make(map[string]bool, len(failures))
+	for _, f := range failures {
+		if !seen[f.Error()] {
+			seen[f.Error()] = true
+			e.Failures = append(e.Failures, f)
+		}
+	}
+	return e
+}
+
+func (e *PartialQueryError) Error() string {
+	messages := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		messages[i] = f.Error()
+	}
+	return fmt.Sprintf("the query partially failed, %d failure(s): %s", len(e.Failures), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the failures, so they can be found with errors.Is and errors.As.
+func (e *PartialQueryError) Unwrap() []error {
+	return e.Failures
+}
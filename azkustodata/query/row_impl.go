@@ -239,11 +239,11 @@ func ToStructs[T any](data interface{}) ([]T, error) {
 	case Row:
 		rows = []Row{v}
 	case Dataset:
-		tables := v.Tables()
-		if len(tables) == 0 {
+		if len(v.Tables()) == 0 {
 			return nil, errors.ES(errors.OpUnknown, errors.KInternal, "dataset does not contain any tables")
 		}
-		if !tables[0].IsPrimaryResult() {
+		tables := v.PrimaryResults()
+		if len(tables) == 0 {
 			return nil, errors.ES(errors.OpUnknown, errors.KInternal, "dataset contains no primary results")
 		}
 		rows = tables[0].Rows()
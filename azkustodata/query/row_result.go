@@ -1,5 +1,11 @@
 package query
 
+import "errors"
+
+// ErrRowsReplaced is the error of the row result sent when a progressive query replaces the rows of a table: the rows
+// received before it should be discarded, and are replaced by the rows received after it.
+var ErrRowsReplaced = errors.New("the rows received so far were replaced by the following rows of progressive results")
+
 type rowResult struct {
 	row Row
 	err error
@@ -0,0 +1,377 @@
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// DefaultSpillMemoryLimit is the estimated size of the rows kept in memory by a Spill, by default.
+const DefaultSpillMemoryLimit = 256 * 1024 * 1024
+
+// SpillOptions configures the spilling of the rows of a dataset to disk, see Spill.
+type SpillOptions struct {
+	// MemoryLimit is the estimated size, in bytes, of the rows of a dataset kept in memory. The rows read past it are
+	// written to temporary files. Defaults to DefaultSpillMemoryLimit.
+	MemoryLimit int64
+	// Dir is the directory of the temporary files, os.TempDir() if empty.
+	Dir string
+}
+
+// Spill keeps the rows of the tables of a dataset in memory up to a limit, and writes the rows read past it to temporary
+// files, so that results larger than the memory can be read at once. The tables of a dataset share its Spill, which is
+// closed with the dataset, removing its files.
+type Spill struct {
+	options SpillOptions
+
+	lock   sync.Mutex
+	used   int64
+	files  []*os.File
+	closed bool
+}
+
+// NewSpill creates a Spill.
+func NewSpill(options SpillOptions) *Spill {
+	if options.MemoryLimit <= 0 {
+		options.MemoryLimit = DefaultSpillMemoryLimit
+	}
+	return &Spill{options: options}
+}
+
+// reserve accounts for size bytes of rows kept in memory, reporting false if they would exceed the limit.
+func (s *Spill) reserve(size int64) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.used+size > s.options.MemoryLimit {
+		return false
+	}
+	s.used += size
+	return true
+}
+
+// release accounts for size bytes of rows no longer kept in memory.
+func (s *Spill) release(size int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.used -= size
+}
+
+// createFile creates a temporary file, removed when the Spill is closed.
+func (s *Spill) createFile() (*os.File, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return nil, errors.ES(errors.OpQuery, errors.KInternal, "the spill of the dataset is closed")
+	}
+	f, err := os.CreateTemp(s.options.Dir, "kusto-spill-*")
+	if err != nil {
+		return nil, err
+	}
+	s.files = append(s.files, f)
+	return f, nil
+}
+
+// Close removes the temporary files. The rows that were spilled to them can't be read afterwards.
+// It does nothing on a nil Spill, and can be called several times.
+func (s *Spill) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var firstErr error
+	for _, f := range s.files {
+		_ = f.Close()
+		if err := os.Remove(f.Name()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.files = nil
+	return firstErr
+}
+
+type spillKey struct{}
+
+// ContextWithSpill returns a copy of ctx holding spill, for the datasets created with it.
+func ContextWithSpill(ctx context.Context, spill *Spill) context.Context {
+	return context.WithValue(ctx, spillKey{}, spill)
+}
+
+// SpillFromContext returns the Spill held by ctx, or nil.
+func SpillFromContext(ctx context.Context) *Spill {
+	spill, _ := ctx.Value(spillKey{}).(*Spill)
+	return spill
+}
+
+// RowBuffer collects the rows of a table being read, keeping them in memory within the limit of its Spill, and writing
+// them to a temporary file past it. With a nil Spill, all the rows are kept in memory.
+type RowBuffer struct {
+	base  BaseTable
+	spill *Spill
+
+	rows     []Row
+	rowsSize int64
+
+	file    *os.File
+	writer  *bufio.Writer
+	size    int64
+	spilled int
+	line    []byte
+}
+
+// NewRowBuffer creates a RowBuffer for the rows of a table.
+func NewRowBuffer(base BaseTable, spill *Spill) *RowBuffer {
+	return &RowBuffer{base: base, spill: spill}
+}
+
+// Add adds a row after the rows added before.
+func (b *RowBuffer) Add(row Row) error {
+	if b.spill == nil {
+		b.rows = append(b.rows, row)
+		return nil
+	}
+	if b.file == nil {
+		size := rowSize(row)
+		if b.spill.reserve(size) {
+			b.rows = append(b.rows, row)
+			b.rowsSize += size
+			return nil
+		}
+		// Once a table spills, its later rows are spilled too, so that they are read back in order.
+		f, err := b.spill.createFile()
+		if err != nil {
+			return errors.ES(b.base.Op(), errors.KIO, "could not create the file to spill the rows of table %s to: %s", b.base.Name(), err)
+		}
+		b.file, b.writer = f, bufio.NewWriter(f)
+	}
+
+	var err error
+	b.line = b.line[:0]
+	b.line = append(b.line, '[')
+	for i, v := range row.Values() {
+		if i > 0 {
+			b.line = append(b.line, ',')
+		}
+		if b.line, err = appendJSONValue(b.line, v); err != nil {
+			return errors.ES(b.base.Op(), errors.KFailedToParse, "could not spill row %d of table %s: %s", row.Index(), b.base.Name(), err)
+		}
+	}
+	b.line = append(b.line, ']', '\n')
+	if _, err := b.writer.Write(b.line); err != nil {
+		return errors.ES(b.base.Op(), errors.KIO, "could not spill the rows of table %s: %s", b.base.Name(), err)
+	}
+	b.size += int64(len(b.line))
+	b.spilled++
+	return nil
+}
+
+// Reset drops the rows added, such as when a progressive query replaces them.
+func (b *RowBuffer) Reset() error {
+	if b.spill != nil {
+		b.spill.release(b.rowsSize)
+	}
+	b.rows, b.rowsSize = b.rows[:0], 0
+	if b.file != nil {
+		b.writer.Reset(b.file)
+		if err := b.file.Truncate(0); err != nil {
+			return errors.ES(b.base.Op(), errors.KIO, "could not reset the spilled rows of table %s: %s", b.base.Name(), err)
+		}
+		if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+			return errors.ES(b.base.Op(), errors.KIO, "could not reset the spilled rows of table %s: %s", b.base.Name(), err)
+		}
+		b.size, b.spilled = 0, 0
+	}
+	return nil
+}
+
+// Table returns the table of the rows added.
+func (b *RowBuffer) Table() (Table, error) {
+	if b.file == nil {
+		return NewTable(b.base, b.rows), nil
+	}
+	if err := b.writer.Flush(); err != nil {
+		return nil, errors.ES(b.base.Op(), errors.KIO, "could not spill the rows of table %s: %s", b.base.Name(), err)
+	}
+	return &spilledTable{table: table{BaseTable: b.base, rows: b.rows}, file: b.file, size: b.size, spilled: b.spilled}, nil
+}
+
+// rowSize estimates the memory used by a row.
+func rowSize(row Row) int64 {
+	// The row, its values slice, and a pointer and a value for each value.
+	size := int64(64)
+	for _, v := range row.Values() {
+		size += 32
+		switch x := v.GetValue().(type) {
+		case string:
+			size += int64(len(x))
+		case []byte:
+			size += int64(len(x))
+		}
+	}
+	return size
+}
+
+// spilledTable is a table whose first rows are in memory, and the others in a file, see RowBuffer.
+type spilledTable struct {
+	table
+	file    *os.File
+	size    int64
+	spilled int
+}
+
+// Rows reads the spilled rows back into memory, returning all the rows of the table. Use ReadRows to iterate over them
+// without holding them in memory.
+func (t *spilledTable) Rows() []Row {
+	rows := make([]Row, 0, len(t.rows)+t.spilled)
+	r := ReadRows(t)
+	defer r.Close()
+	for r.Next() {
+		rows = append(rows, r.Row())
+	}
+	// Rows can't return an error: a failure, such as reading after the dataset was closed, returns the rows read.
+	return rows
+}
+
+func (t *spilledTable) ToJSON(format JSONFormat) ([]byte, error) {
+	return tableToJSON(t, format)
+}
+
+func (t *spilledTable) MarshalJSON() ([]byte, error) {
+	return tableToJSON(t, JSONRows)
+}
+
+func (t *spilledTable) WriteCSV(w io.Writer, options CSVOptions) error {
+	return writeCSV(w, t, options)
+}
+
+func (t *spilledTable) Column(name string) (ColumnView, error) {
+	return t.views.get(t, name)
+}
+
+// RowReader iterates over the rows of a table, reading the rows spilled to disk as it goes, see SpillOptions, so that
+// tables larger than the memory can be processed. For other tables, it iterates over their rows in memory.
+//
+//	r := query.ReadRows(table)
+//	defer r.Close()
+//	for r.Next() {
+//		process(r.Row())
+//	}
+//	if err := r.Err(); err != nil {
+//		return err
+//	}
+type RowReader struct {
+	table   Table
+	rows    []Row
+	index   int
+	spilled *bufio.Reader
+	row     Row
+	err     error
+}
+
+// ReadRows returns a RowReader over the rows of a table.
+func ReadRows(t Table) *RowReader {
+	r := &RowReader{table: t}
+	if s, ok := t.(*spilledTable); ok {
+		r.rows = s.rows
+		r.spilled = bufio.NewReader(io.NewSectionReader(s.file, 0, s.size))
+	} else {
+		r.rows = t.Rows()
+	}
+	return r
+}
+
+// Next advances to the next row, reporting false at the end of the rows or on failure, see Err.
+func (r *RowReader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	if r.index < len(r.rows) {
+		r.row = r.rows[r.index]
+		r.index++
+		return true
+	}
+	if r.spilled == nil {
+		r.row = nil
+		return false
+	}
+
+	line, err := r.spilled.ReadBytes('\n')
+	if err == io.EOF && len(line) == 0 {
+		r.row, r.spilled = nil, nil
+		return false
+	}
+	if err != nil && err != io.EOF {
+		r.err = errors.ES(r.table.Op(), errors.KIO, "could not read the spilled rows of table %s: %s", r.table.Name(), err)
+		return false
+	}
+	r.row, r.err = decodeSpilledRow(r.table, r.index, line)
+	r.index++
+	return r.err == nil
+}
+
+// Row returns the current row.
+func (r *RowReader) Row() Row {
+	return r.row
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (r *RowReader) Err() error {
+	return r.err
+}
+
+// Close stops the iteration. The file of the table is closed with its dataset.
+func (r *RowReader) Close() error {
+	r.rows, r.spilled, r.row = nil, nil, nil
+	return nil
+}
+
+// decodeSpilledRow decodes a row written by RowBuffer.Add.
+func decodeSpilledRow(t Table, index int, line []byte) (Row, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return nil, errors.ES(t.Op(), errors.KFailedToParse, "could not decode spilled row %d of table %s: %s", index, t.Name(), err)
+	}
+	columns := t.Columns()
+	if len(raw) != len(columns) {
+		return nil, errors.ES(t.Op(), errors.KFailedToParse, "spilled row %d of table %s has %d values, not %d", index, t.Name(), len(raw), len(columns))
+	}
+
+	values := make(value.Values, len(raw))
+	for i, c := range columns {
+		v := value.Default(c.Type())
+		var parsed interface{}
+		if c.Type() == types.Dynamic {
+			// Dynamic values are kept as their JSON, rather than decoded and marshaled again.
+			if string(raw[i]) != "null" {
+				parsed = []byte(raw[i])
+			}
+		} else if err := unmarshalNumber(raw[i], &parsed); err != nil {
+			return nil, errors.ES(t.Op(), errors.KFailedToParse, "could not decode column %s of spilled row %d of table %s: %s", c.Name(), index, t.Name(), err)
+		}
+		if err := v.Unmarshal(parsed); err != nil {
+			return nil, errors.ES(t.Op(), errors.KFailedToParse, "could not decode column %s of spilled row %d of table %s: %s", c.Name(), index, t.Name(), err)
+		}
+		values[i] = v
+	}
+	return NewRow(t, index, values), nil
+}
+
+// unmarshalNumber unmarshals JSON, keeping numbers as json.Number as the values of the responses are.
+func unmarshalNumber(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
@@ -0,0 +1,184 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func spillTestTable() BaseTable {
+	ds := NewBaseDataset(context.Background(), errors.OpQuery, "PrimaryResult")
+	return NewBaseTable(ds, 0, "0", "T", "PrimaryResult", Columns{
+		NewColumn(0, "str", types.String),
+		NewColumn(1, "long", types.Long),
+		NewColumn(2, "int", types.Int),
+		NewColumn(3, "real", types.Real),
+		NewColumn(4, "bool", types.Bool),
+		NewColumn(5, "dt", types.DateTime),
+		NewColumn(6, "ts", types.Timespan),
+		NewColumn(7, "guid", types.GUID),
+		NewColumn(8, "dec", types.Decimal),
+		NewColumn(9, "dyn", types.Dynamic),
+	})
+}
+
+func spillTestRows(base BaseTable, n int) []Row {
+	rows := make([]Row, 0, n)
+	for i := 0; i < n; i++ {
+		values := value.Values{
+			value.NewString("row \"quoted\"\n" + string(rune('a'+i))),
+			value.NewLong(int64(i) << 40),
+			value.NewInt(int32(-i)),
+			value.NewReal(float64(i) + 0.5),
+			value.NewBool(i%2 == 0),
+			value.NewDateTime(time.Date(2024, 1, 2, 3, 4, 5, 1234500, time.UTC).Add(time.Duration(i) * time.Hour)),
+			value.NewTimespan(time.Duration(i) * time.Minute),
+			value.NewGUID(uuid.MustParse("6b4c0ab2-180e-46d8-b97e-593e6aea1e7a")),
+			value.NewDecimal(decimal.RequireFromString("123456789.123456789")),
+			value.NewDynamic([]byte(`{"b":1, "a":[1,2]}`)),
+		}
+		if i == 1 {
+			values = value.Values{
+				value.NewString(""), value.NewNullLong(), value.NewNullInt(), value.NewReal(math.NaN()), value.NewNullBool(),
+				value.NewNullDateTime(), value.NewNullTimespan(), value.NewNullGUID(), value.NewNullDecimal(), value.NewNullDynamic(),
+			}
+		}
+		rows = append(rows, NewRow(base, i, values))
+	}
+	return rows
+}
+
+func TestRowBufferSpill(t *testing.T) {
+	dir := t.TempDir()
+	base := spillTestTable()
+	rows := spillTestRows(base, 5)
+	// The limit leaves room for two rows.
+	spill := NewSpill(SpillOptions{MemoryLimit: 2*rowSize(rows[0]) + 1, Dir: dir})
+
+	b := NewRowBuffer(base, spill)
+	for _, r := range rows {
+		require.NoError(t, b.Add(r))
+	}
+	table, err := b.Table()
+	require.NoError(t, err)
+	require.IsType(t, &spilledTable{}, table)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	r := ReadRows(table)
+	var read []Row
+	for r.Next() {
+		read = append(read, r.Row())
+	}
+	require.NoError(t, r.Err())
+	require.NoError(t, r.Close())
+	require.Len(t, read, len(rows))
+	for i, row := range read {
+		assert.Equal(t, i, row.Index())
+		assert.Equal(t, rows[i].String(), row.String(), "row %d", i)
+	}
+	assert.True(t, math.IsNaN(*read[1].Values()[3].GetValue().(*float64)))
+	assert.JSONEq(t, `{"b":1, "a":[1,2]}`, string(read[4].Values()[9].GetValue().([]byte)))
+
+	assert.Len(t, table.Rows(), len(rows))
+	var csv, expectedCSV bytes.Buffer
+	require.NoError(t, table.WriteCSV(&csv, CSVOptions{}))
+	require.NoError(t, NewTable(base, rows).WriteCSV(&expectedCSV, CSVOptions{}))
+	assert.Equal(t, expectedCSV.String(), csv.String())
+	js, err := table.ToJSON(JSONRows)
+	require.NoError(t, err)
+	expected, err := NewTable(base, rows).ToJSON(JSONRows)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(js))
+
+	require.NoError(t, spill.Close())
+	require.NoError(t, spill.Close())
+	files, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestRowBufferReset(t *testing.T) {
+	base := spillTestTable()
+	rows := spillTestRows(base, 4)
+	spill := NewSpill(SpillOptions{MemoryLimit: rowSize(rows[0]) + 1, Dir: t.TempDir()})
+	defer spill.Close()
+
+	b := NewRowBuffer(base, spill)
+	for _, r := range rows[:3] {
+		require.NoError(t, b.Add(r))
+	}
+	require.NoError(t, b.Reset())
+	// The memory released by the reset is available again.
+	require.NoError(t, b.Add(rows[2]))
+	require.NoError(t, b.Add(rows[3]))
+	table, err := b.Table()
+	require.NoError(t, err)
+
+	read := table.Rows()
+	require.Len(t, read, 2)
+	assert.Equal(t, rows[2].String(), read[0].String())
+	assert.Equal(t, rows[3].String(), read[1].String())
+}
+
+func TestRowBufferInMemory(t *testing.T) {
+	base := spillTestTable()
+	rows := spillTestRows(base, 3)
+
+	for _, spill := range []*Spill{nil, NewSpill(SpillOptions{Dir: t.TempDir()})} {
+		b := NewRowBuffer(base, spill)
+		for _, r := range rows {
+			require.NoError(t, b.Add(r))
+		}
+		table, err := b.Table()
+		require.NoError(t, err)
+		assert.Equal(t, rows, table.Rows())
+
+		r := ReadRows(table)
+		count := 0
+		for r.Next() {
+			assert.Same(t, rows[count], r.Row())
+			count++
+		}
+		assert.Equal(t, len(rows), count)
+		require.NoError(t, spill.Close())
+	}
+}
+
+func TestDatasetCloseSpill(t *testing.T) {
+	dir := t.TempDir()
+	spill := NewSpill(SpillOptions{MemoryLimit: 1, Dir: dir})
+	ds := NewBaseDataset(ContextWithSpill(context.Background(), spill), errors.OpQuery, "PrimaryResult")
+	base := NewBaseTable(ds, 0, "0", "T", "PrimaryResult", Columns{NewColumn(0, "x", types.Long)})
+
+	b := NewRowBuffer(base, SpillFromContext(ds.Context()))
+	require.NoError(t, b.Add(NewRow(base, 0, value.Values{value.NewLong(1)})))
+	table, err := b.Table()
+	require.NoError(t, err)
+
+	dataset := NewDataset(ds, []Table{table})
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	require.NoError(t, dataset.Close())
+	files, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	r := ReadRows(table)
+	assert.False(t, r.Next())
+	assert.Error(t, r.Err())
+}
@@ -0,0 +1,51 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Stall describes a response which hasn't sent data for the frame timeout set with ContextWithStallDetection, such as when
+// a load balancer silently dropped an idle connection.
+type Stall struct {
+	// Waited is how long the reader has been waiting for data.
+	Waited time.Duration
+	// Frames is the number of frames received before the stall.
+	Frames int
+	// LastFrame is when the last frame was received, or the zero time if none was.
+	LastFrame time.Time
+}
+
+// StallHandler is called, from the goroutine watching the response, each time a response stalls for the frame timeout.
+// Returning true keeps waiting for another frame timeout, such as for queries known to send their first results late.
+// Returning false aborts the read, which fails with a *StallError, so the query can be retried.
+type StallHandler func(Stall) bool
+
+// StallError is the error of a read aborted because the response stalled, see StallHandler.
+type StallError struct {
+	Stall
+}
+
+func (e *StallError) Error() string {
+	return fmt.Sprintf("no data was received for %s, after %d frames: the response stalled", e.Waited, e.Frames)
+}
+
+type stallDetection struct {
+	timeout time.Duration
+	onStall StallHandler
+}
+
+type stallDetectionKey struct{}
+
+// ContextWithStallDetection returns a copy of ctx making the datasets created with it call onStall when their response
+// sends no data for timeout. A nil onStall aborts the read at the first stall.
+func ContextWithStallDetection(ctx context.Context, timeout time.Duration, onStall StallHandler) context.Context {
+	return context.WithValue(ctx, stallDetectionKey{}, stallDetection{timeout: timeout, onStall: onStall})
+}
+
+// StallDetectionFromContext returns the frame timeout and stall handler held by ctx, a zero timeout if there are none.
+func StallDetectionFromContext(ctx context.Context) (time.Duration, StallHandler) {
+	s, _ := ctx.Value(stallDetectionKey{}).(stallDetection)
+	return s.timeout, s.onStall
+}
@@ -1,6 +1,10 @@
 package query
 
-import "github.com/Azure/azure-kusto-go/azkustodata/errors"
+import (
+	"io"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+)
 
 type BaseTable interface {
 	Id() string
@@ -16,6 +20,14 @@ type BaseTable interface {
 type Table interface {
 	BaseTable
 	Rows() []Row
+	// ToJSON marshals the rows of the table to JSON in the given format, see JSONFormat.
+	// Tables also implement json.Marshaler, marshaling to the JSONRows format.
+	ToJSON(format JSONFormat) ([]byte, error)
+	// WriteCSV writes the rows of the table to w as CSV, see CSVOptions.
+	WriteCSV(w io.Writer, options CSVOptions) error
+	// Column returns a typed, column-oriented view of the values of a column, see ColumnView and ColumnOf.
+	// The view is built from the rows on first use and cached, so later calls are cheap.
+	Column(name string) (ColumnView, error)
 }
 
 // IterativeTable is a table that returns rows one at a time.
@@ -26,4 +38,7 @@ type IterativeTable interface {
 	// SkipToEnd skips all remaining rows in the table.
 	SkipToEnd() []error
 	ToTable() (Table, error)
+	// WriteCSV writes the rows of the table to w as CSV as they are read, see CSVOptions, so tables of any size are
+	// written in constant memory. Progressive results replacing rows are not supported.
+	WriteCSV(w io.Writer, options CSVOptions) error
 }
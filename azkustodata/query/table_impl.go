@@ -1,6 +1,8 @@
 package query
 
 import (
+	"io"
+
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
 )
 
@@ -72,7 +74,8 @@ func (t *baseTable) Op() errors.Op {
 
 type table struct {
 	BaseTable
-	rows []Row
+	rows  []Row
+	views columnViews
 }
 
 func NewTable(base BaseTable, rows []Row) Table {
@@ -85,3 +88,45 @@ func NewTable(base BaseTable, rows []Row) Table {
 func (t *table) Rows() []Row {
 	return t.rows
 }
+
+func (t *table) ToJSON(format JSONFormat) ([]byte, error) {
+	return tableToJSON(t, format)
+}
+
+func (t *table) MarshalJSON() ([]byte, error) {
+	return tableToJSON(t, JSONRows)
+}
+
+func (t *table) WriteCSV(w io.Writer, options CSVOptions) error {
+	return writeCSV(w, t, options)
+}
+
+func (t *table) Column(name string) (ColumnView, error) {
+	return t.views.get(t, name)
+}
+
+// iterativeTable exposes a table that was read at once as an IterativeTable.
+type iterativeTable struct {
+	Table
+}
+
+// IterativeTableOf returns an IterativeTable returning the rows of a table that was already read.
+func IterativeTableOf(t Table) IterativeTable {
+	return iterativeTable{t}
+}
+
+func (t iterativeTable) ToTable() (Table, error) { return t.Table, nil }
+
+func (t iterativeTable) Rows() <-chan RowResult {
+	rows := t.Table.Rows()
+	ch := make(chan RowResult, len(rows))
+	for _, row := range rows {
+		ch <- RowResultSuccess(row)
+	}
+	close(ch)
+	return ch
+}
+
+func (t iterativeTable) SkipToEnd() []error {
+	return nil
+}
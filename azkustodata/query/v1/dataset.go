@@ -149,6 +149,63 @@ func (d *dataset) Tables() []query.Table {
 	return d.results
 }
 
+// TablesByKind returns the result tables of the given kind. v1 results only have QueryResult tables, the QueryStatus
+// and QueryProperties tables being returned by Status and Info.
+func (d *dataset) TablesByKind(kind string) []query.Table {
+	return query.TablesByKind(d.results, kind)
+}
+
+func (d *dataset) PrimaryResults() []query.Table {
+	return query.PrimaryResults(d.results)
+}
+
+func (d *dataset) ToJSON(format query.JSONFormat) ([]byte, error) {
+	return query.DatasetToJSON(d, format)
+}
+
+func (d *dataset) MarshalJSON() ([]byte, error) {
+	return query.DatasetToJSON(d, query.JSONRows)
+}
+
+// QueryProperties returns nil, as v1 results don't have the QueryProperties table of v2 results. See Info.
+func (d *dataset) QueryProperties() ([]query.QueryProperties, error) {
+	return nil, nil
+}
+
+// QueryCompletionInformation returns nil, as v1 results don't have the QueryCompletionInformation table of v2 results.
+// See Status.
+func (d *dataset) QueryCompletionInformation() (*query.QueryCompletionInformation, error) {
+	return nil, nil
+}
+
+// Visualizations returns the visualizations found in the Info of the results, whose rows match the result tables.
+func (d *dataset) Visualizations() ([]query.Visualization, error) {
+	var visualizations []query.Visualization
+	for i, info := range d.info {
+		if i >= len(d.results) {
+			break
+		}
+		v, err := query.ParseVisualization(int(d.results[i].Index()), []byte(info.Value))
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			visualizations = append(visualizations, *v)
+		}
+	}
+	return visualizations, nil
+}
+
+// PartialQueryError returns nil, as the failures of v1 results are reported in their Status.
+func (d *dataset) PartialQueryError() *query.PartialQueryError {
+	return nil
+}
+
+// Close does nothing, as v1 results are held in memory.
+func (d *dataset) Close() error {
+	return nil
+}
+
 func (d *dataset) Index() []TableIndexRow {
 	return d.index
 }
@@ -85,6 +85,10 @@ func TestDatasetSuccess(t *testing.T) {
 			}
 			assert.EqualValues(t, expectedInfo, ds.Info())
 
+			assert.Equal(t, ds.Tables(), ds.PrimaryResults())
+			assert.Equal(t, ds.Tables(), ds.TablesByKind(PrimaryResultKind))
+			assert.Empty(t, ds.TablesByKind(query.QueryPropertiesKind))
+
 			table1Rows := ds.Tables()[0].Rows()
 			expectedTable1 := []firstTable{
 				{A: 1},
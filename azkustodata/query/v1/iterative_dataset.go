@@ -0,0 +1,51 @@
+package v1
+
+import (
+	"context"
+	"io"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+)
+
+// iterativeDataset exposes a v1 dataset, which is read at once, as a query.IterativeDataset, so the queries of clients
+// using the v1 protocol are read through the same interfaces as v2 results.
+type iterativeDataset struct {
+	query.BaseDataset
+	dataset Dataset
+}
+
+// NewIterativeDatasetFromReader reads a v1 response, and returns it as a query.IterativeDataset.
+func NewIterativeDatasetFromReader(ctx context.Context, op errors.Op, reader io.ReadCloser) (query.IterativeDataset, error) {
+	d, err := NewDatasetFromReader(ctx, op, reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewIterativeDataset(d), nil
+}
+
+// NewIterativeDataset returns a v1 dataset as a query.IterativeDataset.
+func NewIterativeDataset(d Dataset) query.IterativeDataset {
+	return &iterativeDataset{BaseDataset: d, dataset: d}
+}
+
+// Tables returns a channel holding the result tables of the dataset.
+func (d *iterativeDataset) Tables() <-chan query.TableResult {
+	tables := d.dataset.Tables()
+	ch := make(chan query.TableResult, len(tables))
+	for _, t := range tables {
+		ch <- query.TableResultSuccess(query.IterativeTableOf(t))
+	}
+	close(ch)
+	return ch
+}
+
+// ToDataset returns the v1 dataset.
+func (d *iterativeDataset) ToDataset() (query.Dataset, error) {
+	return d.dataset, nil
+}
+
+// Close does nothing, as the response was already read.
+func (d *iterativeDataset) Close() error {
+	return nil
+}
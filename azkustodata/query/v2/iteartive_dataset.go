@@ -5,6 +5,8 @@ import (
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
 	"github.com/Azure/azure-kusto-go/azkustodata/query"
 	"io"
+	"sync"
+	"time"
 )
 
 // DefaultFrameCapacity is the default capacity of the channel that receives frames from the Kusto service. Lower capacity means less memory usage, but might cause the channel to block if the frames are not consumed fast enough.
@@ -26,8 +28,11 @@ type iterativeDataset struct {
 	reader io.ReadCloser
 	// frames is a channel that receives all the frames from the data set as they are parsed.
 	frames chan *EveryFrame
-	// errorChannel is a channel to report errors during the parsing of frames
-	errorChannel chan error
+	// closed is closed when the dataset is closed, to stop reading and decoding the frames.
+	closed    chan struct{}
+	closeOnce sync.Once
+	// readErr is the error that stopped the reading of frames, set before frames is closed.
+	readErr error
 	// results is a channel that sends the parsed results as they are decoded.
 	results chan query.TableResult
 
@@ -43,10 +48,17 @@ func NewIterativeDataset(ctx context.Context, r io.ReadCloser, capacity int, row
 		results:          make(chan query.TableResult, 1),
 		fragmentCapacity: fragmentCapacity,
 		rowCapacity:      rowCapacity,
-		errorChannel: 	  make(chan error, 1),
+		closed:           make(chan struct{}),
 	}
 
-	br, err := prepareReadBuffer(d.reader)
+	var reader io.Reader = d.reader
+	onFrame := heartbeat(query.HeartbeatFromContext(ctx))
+	if timeout, onStall := query.StallDetectionFromContext(ctx); timeout > 0 {
+		sr := newStallReader(d.reader, timeout, onStall, func() { _ = r.Close() })
+		reader, onFrame = sr, sr.onFrame(onFrame)
+	}
+
+	br, err := prepareReadBuffer(reader)
 	if err != nil {
 		d.reader.Close()
 		return nil, err
@@ -54,10 +66,8 @@ func NewIterativeDataset(ctx context.Context, r io.ReadCloser, capacity int, row
 
 	go func() {
 		defer d.reader.Close()
-		err := readFramesIterative(br, d.frames)
-		if err != nil {
-			d.errorChannel <- err
-		}
+		d.readErr = readFramesIterative(br, d.frames, onFrame, d.closed)
+		close(d.frames)
 	}()
 
 	go decodeTables(d)
@@ -69,15 +79,15 @@ func (d *iterativeDataset) getNextFrame() *EveryFrame {
 	var f *EveryFrame = nil
 
 	select {
-	case err := <-d.errorChannel:
-		if err != nil {
-			d.reportError(err)
-		}
+	case <-d.closed:
 		break
 	case <-d.Context().Done():
 		d.reportError(errors.ES(d.Op(), errors.KInternal, "context cancelled"))
 		break
-	case fc := <-d.frames:
+	case fc, ok := <-d.frames:
+		if !ok && d.readErr != nil {
+			d.reportError(d.readErr)
+		}
 		f = fc
 	}
 	return f
@@ -85,7 +95,7 @@ func (d *iterativeDataset) getNextFrame() *EveryFrame {
 
 func (d *iterativeDataset) reportError(err error) {
 	select {
-	case <-d.errorChannel:
+	case <-d.closed:
 		return
 	case d.results <- query.TableResultError(err):
 		return
@@ -94,7 +104,7 @@ func (d *iterativeDataset) reportError(err error) {
 
 func (d *iterativeDataset) sendTable(tb query.IterativeTable) {
 	select {
-	case <-d.errorChannel:
+	case <-d.closed:
 		return
 	case d.results <- query.TableResultSuccess(tb):
 		return
@@ -105,39 +115,56 @@ func (d *iterativeDataset) Tables() <-chan query.TableResult {
 	return d.results
 }
 
+// Close stops reading the results, and closes the response. It can be called several times.
 func (d *iterativeDataset) Close() error {
-	// try to close the error channel, but don't block if it's full
-	// If it's already closed, return
-	select {
-	case e := <-d.errorChannel:
-		if e == nil {
-			return nil
-		}
-	default:
-	}
-	close(d.errorChannel)
-
+	d.closeOnce.Do(func() {
+		close(d.closed)
+		_ = d.reader.Close()
+	})
 	return nil
 }
 
+// ToDataset reads all the tables. If failures were reported inside the results, it returns the dataset of the results
+// read along with a *query.PartialQueryError.
 func (d *iterativeDataset) ToDataset() (query.Dataset, error) {
 	tables := make([]query.Table, 0, len(d.results))
+	var failures []error
+	// On failure, the rows already spilled are removed, as there is no dataset to close.
+	spill := query.SpillFromContext(d.Context())
 
 	defer d.Close()
 
 	for tb := range d.Tables() {
 		if tb.Err() != nil {
+			if _, ok := tb.Err().(*OneApiError); ok {
+				failures = append(failures, tb.Err())
+				continue
+			}
+			_ = spill.Close()
 			return nil, tb.Err()
 		}
 
-		table, err := tb.Table().ToTable()
+		var table query.Table
+		var err error
+		if it, ok := tb.Table().(*iterativeTable); ok {
+			var tableFailures []error
+			table, tableFailures, err = it.toTable()
+			failures = append(failures, tableFailures...)
+		} else {
+			table, err = tb.Table().ToTable()
+		}
 		if err != nil {
+			_ = spill.Close()
 			return nil, err
 		}
 		tables = append(tables, table)
 	}
 
-	return query.NewDataset(d, tables), nil
+	ds, partialErr := query.NewPartialDataset(d, tables, failures)
+	if partialErr != nil {
+		return ds, partialErr
+	}
+	return ds, nil
 }
 
 // decodeTables decodes the frames from the frames channel and sends the results to the results channel.
@@ -150,7 +177,7 @@ func decodeTables(d *iterativeDataset) {
 
 	defer func() {
 		if currentTable != nil {
-			currentTable.finishTable([]OneApiError{})
+			currentTable.finishTable([]OneApiError{}, 0)
 		}
 		close(d.results)
 		_ = d.Close()
@@ -190,8 +217,8 @@ func decodeTables(d *iterativeDataset) {
 				break
 			}
 		} else if prog := f.AsTableProgress(); prog != nil {
-			d.reportError(errors.ES(op, errors.KInternal, "Unexpected TableProgress frame - progressive results are not supported"))
-			break
+			// Progress frames only keep the connection alive, they are reported as heartbeats by the reader.
+			continue
 		} else {
 			// Not a frame we know how to handle
 			d.reportError(errors.ES(op, errors.KInternal, "unknown frame type"))
@@ -222,7 +249,7 @@ func handleDataTable(d *iterativeDataset, queryProperties *query.IterativeTable,
 			d.reportError(err)
 			return false
 		}
-		*queryProperties = iterativeWrapper{res}
+		*queryProperties = query.IterativeTableOf(res)
 	case QueryCompletionInformationKind:
 		if *queryProperties != nil {
 			d.sendTable(*queryProperties)
@@ -233,7 +260,7 @@ func handleDataTable(d *iterativeDataset, queryProperties *query.IterativeTable,
 			d.reportError(err)
 			return false
 		}
-		d.sendTable(iterativeWrapper{res})
+		d.sendTable(query.IterativeTableOf(res))
 
 	default:
 		d.reportError(errors.ES(d.Op(), errors.KInternal, "unknown secondary table - %s %s", dt.TableName(), dt.TableKind()))
@@ -253,7 +280,7 @@ func handleTableCompletion(d *iterativeDataset, tablePtr **iterativeTable, tc Ta
 		d.reportError(err)
 	}
 
-	(*tablePtr).finishTable(tc.OneApiErrors())
+	(*tablePtr).finishTable(tc.OneApiErrors(), tc.RowCount())
 
 	*tablePtr = nil
 
@@ -271,7 +298,7 @@ func handleTableFragment(d *iterativeDataset, table *iterativeTable, tf TableFra
 		d.reportError(err)
 	}
 
-	table.addRawRows(tf.Rows())
+	table.addRawRows(tf.Rows(), tf.TableFragmentType() == dataReplaceFragmentType)
 
 	return true
 }
@@ -308,10 +335,6 @@ func handleDatasetHeader(d *iterativeDataset, header DataSetHeader) bool {
 		d.reportError(errors.ES(d.Op(), errors.KInternal, "results that are not version 2 are not supported"))
 		return false
 	}
-	if header.IsProgressive() {
-		d.reportError(errors.ES(d.Op(), errors.KInternal, "progressive results are not supported"))
-		return false
-	}
 	if !header.IsFragmented() {
 		d.reportError(errors.ES(d.Op(), errors.KInternal, "non-fragmented results are not supported"))
 		return false
@@ -319,3 +342,21 @@ func handleDatasetHeader(d *iterativeDataset, header DataSetHeader) bool {
 
 	return true
 }
+
+// dataReplaceFragmentType is the type of the fragments of progressive results replacing the rows sent before.
+const dataReplaceFragmentType = "DataReplace"
+
+// heartbeat returns the function reporting the frames read to onHeartbeat, or nil if it isn't set.
+func heartbeat(onHeartbeat func(query.Heartbeat)) func(*EveryFrame) {
+	if onHeartbeat == nil {
+		return nil
+	}
+	return func(f *EveryFrame) {
+		onHeartbeat(query.Heartbeat{
+			FrameType: string(f.FrameType()),
+			TableID:   f.TableId(),
+			Progress:  f.TableProgress(),
+			Received:  time.Now(),
+		})
+	}
+}
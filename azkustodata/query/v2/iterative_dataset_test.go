@@ -1,7 +1,9 @@
 package v2
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
 	"github.com/Azure/azure-kusto-go/azkustodata/query"
 	"github.com/Azure/azure-kusto-go/azkustodata/value"
@@ -259,6 +261,105 @@ func TestStreamingDataSet_MultiplePrimaryTables(t *testing.T) {
 	}
 }
 
+func TestStreamingDataSet_QueryCompletionInformation(t *testing.T) {
+	t.Parallel()
+	d, err := defaultDataset(strings.NewReader(twoTables))
+	require.NoError(t, err)
+	full, err := d.ToDataset()
+	require.NoError(t, err)
+
+	props, err := full.QueryProperties()
+	require.NoError(t, err)
+	require.NotEmpty(t, props)
+	assert.Equal(t, "Visualization", props[0].Key)
+
+	// The query has no render operator.
+	visualizations, err := full.Visualizations()
+	require.NoError(t, err)
+	assert.Empty(t, visualizations)
+
+	info, err := full.QueryCompletionInformation()
+	require.NoError(t, err)
+	require.NotNil(t, info)
+	assert.True(t, info.Succeeded())
+	assert.Empty(t, info.Errors())
+	assert.Equal(t, "QueryInfo", info.Events[0].EventTypeName)
+
+	usage := info.ResourceConsumption
+	require.NotNil(t, usage)
+	assert.Equal(t, int64(524384), usage.ResourceUsage.Memory.PeakPerNode)
+	assert.Equal(t, int64(1099), usage.ResourceUsage.Network.InterClusterTotalBytes)
+	assert.Equal(t, time.Duration(0), usage.ResourceUsage.CPU.Total)
+	require.Len(t, usage.DatasetStatistics, 2)
+	assert.Equal(t, int64(43), usage.DatasetStatistics[1].TableSize)
+}
+
+func TestStreamingDataSet_TablesByKind(t *testing.T) {
+	t.Parallel()
+	d, err := defaultDataset(strings.NewReader(twoTables))
+	require.NoError(t, err)
+	full, err := d.ToDataset()
+	require.NoError(t, err)
+
+	primary := full.PrimaryResults()
+	require.Len(t, primary, 2)
+	assert.Equal(t, int64(1), primary[0].Index())
+	assert.Equal(t, int64(2), primary[1].Index())
+	assert.Equal(t, primary, full.TablesByKind(PrimaryResultTableKind))
+
+	props := full.TablesByKind(query.QueryPropertiesKind)
+	require.Len(t, props, 1)
+	assert.Equal(t, query.QueryPropertiesKind, props[0].Kind())
+	require.Len(t, full.TablesByKind(query.QueryCompletionInformationKind), 1)
+	assert.Empty(t, full.TablesByKind("Unknown"))
+
+	// The QueryProperties table comes first, so the rows are decoded from the first primary result rather than table 0.
+	rows, err := query.ToStructs[table1](full)
+	require.NoError(t, err)
+	assert.Equal(t, []table1{{A: 1}, {A: 2}, {A: 3}}, rows)
+}
+
+const progressiveFrames = `[{"FrameType":"DataSetHeader","IsProgressive":true,"Version":"v2.0","IsFragmented":true,"ErrorReportingPlacement":"EndOfTable"}
+,{"FrameType":"TableHeader","TableId":1,"TableKind":"PrimaryResult","TableName":"T","Columns":[{"ColumnName":"A","ColumnType":"int"}]}
+,{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[[1],[2]]}
+,{"FrameType":"TableProgress","TableId":1,"TableProgress":50}
+,{"FrameType":"TableFragment","TableFragmentType":"DataReplace","TableId":1,"Rows":[[3]]}
+,{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[[4]]}
+,{"FrameType":"TableCompletion","TableId":1,"RowCount":2}
+,{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}
+]`
+
+func TestStreamingDataSet_Progressive(t *testing.T) {
+	t.Parallel()
+	d, err := defaultDataset(strings.NewReader(progressiveFrames))
+	require.NoError(t, err)
+
+	var rows []interface{}
+	for tableResult := range d.Tables() {
+		require.NoError(t, tableResult.Err())
+		for rowResult := range tableResult.Table().Rows() {
+			if rowResult.Err() == query.ErrRowsReplaced {
+				rows = append(rows, "replaced")
+				continue
+			}
+			require.NoError(t, rowResult.Err())
+			var row table1
+			require.NoError(t, rowResult.Row().ToStruct(&row))
+			rows = append(rows, row.A, rowResult.Row().Index())
+		}
+	}
+	assert.Equal(t, []interface{}{1, 0, 2, 1, "replaced", 3, 0, 4, 1}, rows)
+
+	d, err = defaultDataset(strings.NewReader(progressiveFrames))
+	require.NoError(t, err)
+	ds, err := d.ToDataset()
+	require.NoError(t, err)
+	require.Len(t, ds.Tables(), 1)
+	structs, err := query.ToStructs[table1](ds.Tables()[0])
+	require.NoError(t, err)
+	assert.Equal(t, []table1{{A: 3}, {A: 4}}, structs)
+}
+
 func TestStreamingDataSet_TableAliases(t *testing.T) {
 	t.Parallel()
 
@@ -346,8 +447,29 @@ func TestStreamingDataSet_PartialErrors_GetAll(t *testing.T) {
 	reader := strings.NewReader(partialErrors)
 	d, err := defaultDataset(reader)
 	assert.NoError(t, err)
-	_, err = d.ToDataset()
+	ds, err := d.ToDataset()
 	assert.ErrorContains(t, err, "LimitsExceeded")
+
+	// The failure, reported at the end of the table and of the dataset, is returned once, with the results read.
+	var partialErr *query.PartialQueryError
+	require.ErrorAs(t, err, &partialErr)
+	require.Len(t, partialErr.Failures, 1)
+	require.NotNil(t, ds)
+	assert.Same(t, partialErr, ds.PartialQueryError())
+	assert.Equal(t, ds, partialErr.Dataset)
+	require.Len(t, ds.Tables(), 1)
+	assert.Len(t, ds.Tables()[0].Rows(), 1)
+
+	var oneApiErr *OneApiError
+	require.ErrorAs(t, err, &oneApiErr)
+	assert.Equal(t, "LimitsExceeded", oneApiErr.ErrorMessage.Code)
+
+	// Results without failures don't have one.
+	d, err = defaultDataset(strings.NewReader(twoTables))
+	require.NoError(t, err)
+	ds, err = d.ToDataset()
+	require.NoError(t, err)
+	assert.Nil(t, ds.PartialQueryError())
 }
 
 func TestStreamingDataSet_FullError(t *testing.T) {
@@ -357,3 +479,58 @@ func TestStreamingDataSet_FullError(t *testing.T) {
 	assert.ErrorContains(t, err, "Bad request")
 	assert.Nil(t, d)
 }
+
+func TestStreamingDataSet_WriteCSV(t *testing.T) {
+	t.Parallel()
+	frames := strings.NewReplacer(`"IsProgressive":true`, `"IsProgressive":false`, `"DataReplace"`, `"DataAppend"`, `"RowCount":2`, `"RowCount":4`).Replace(progressiveFrames)
+	frames = strings.Replace(frames, ",{\"FrameType\":\"TableProgress\",\"TableId\":1,\"TableProgress\":50}\n", "", 1)
+	d, err := defaultDataset(strings.NewReader(frames))
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	for tableResult := range d.Tables() {
+		require.NoError(t, tableResult.Err())
+		require.NoError(t, tableResult.Table().WriteCSV(&b, query.CSVOptions{Header: true}))
+	}
+	assert.Equal(t, "A\n1\n2\n3\n4\n", b.String())
+
+	// Rows replaced by progressive results can't be unwritten.
+	d, err = defaultDataset(strings.NewReader(progressiveFrames))
+	require.NoError(t, err)
+	b.Reset()
+	for tableResult := range d.Tables() {
+		require.NoError(t, tableResult.Err())
+		assert.ErrorIs(t, tableResult.Table().WriteCSV(&b, query.CSVOptions{}), query.ErrRowsReplaced)
+	}
+}
+
+func TestStreamingDataSet_Fragments(t *testing.T) {
+	t.Parallel()
+	frames := `[{"FrameType":"DataSetHeader","IsProgressive":false,"Version":"v2.0","IsFragmented":true,"ErrorReportingPlacement":"EndOfTable"}
+,{"FrameType":"TableHeader","TableId":1,"TableKind":"PrimaryResult","TableName":"T","Columns":[{"ColumnName":"A","ColumnType":"int"}]}
+,{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[[1],[2]]}
+,{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[[3]]}
+,{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[[4],[5]]}
+,{"FrameType":"TableCompletion","TableId":1,"RowCount":%d}
+,{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}
+]`
+
+	d, err := defaultDataset(strings.NewReader(fmt.Sprintf(frames, 5)))
+	require.NoError(t, err)
+	ds, err := d.ToDataset()
+	require.NoError(t, err)
+	require.Len(t, ds.Tables(), 1)
+	var values []int
+	for _, row := range ds.Tables()[0].Rows() {
+		var r table1
+		require.NoError(t, row.ToStruct(&r))
+		values = append(values, r.A)
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, values)
+
+	// A fragment missing from the rows reported by the completion is an error.
+	d, err = defaultDataset(strings.NewReader(fmt.Sprintf(frames, 6)))
+	require.NoError(t, err)
+	_, err = d.ToDataset()
+	assert.ErrorContains(t, err, "table T completed with 6 rows, but 5 rows were received")
+}
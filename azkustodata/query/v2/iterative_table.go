@@ -5,6 +5,7 @@ import (
 	"github.com/Azure/azure-kusto-go/azkustodata/query"
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"io"
 	"sync"
 )
 
@@ -14,14 +15,24 @@ import (
 type iterativeTable struct {
 	query.BaseTable
 	lock     sync.RWMutex
-	rawRows  chan RawRows
+	rawRows  chan fragment
 	rows     chan query.RowResult
 	rowCount int
 	skip     bool
+	// completionRowCount is the number of rows reported by the TableCompletion frame, zero if it didn't report any.
+	completionRowCount int
+	// spill holds the rows of the table past the memory limit when it is read at once, see query.ContextWithSpill.
+	spill *query.Spill
 }
 
-func (t *iterativeTable) addRawRows(rows RawRows) {
-	t.rawRows <- rows
+// fragment holds the rows of a TableFragment frame. When replace is set, they replace the rows received before.
+type fragment struct {
+	rows    RawRows
+	replace bool
+}
+
+func (t *iterativeTable) addRawRows(rows RawRows, replace bool) {
+	t.rawRows <- fragment{rows: rows, replace: replace}
 }
 
 func (t *iterativeTable) RowCount() int {
@@ -56,8 +67,9 @@ func NewIterativeTable(dataset *iterativeDataset, th TableHeader) (query.Iterati
 
 	t := &iterativeTable{
 		BaseTable: baseTable,
-		rawRows:   make(chan RawRows, dataset.fragmentCapacity),
+		rawRows:   make(chan fragment, dataset.fragmentCapacity),
 		rows:      make(chan query.RowResult, dataset.rowCapacity),
+		spill:     query.SpillFromContext(dataset.Context()),
 	}
 
 	go t.readRows()
@@ -93,20 +105,27 @@ func parseRow(r []interface{}, t query.BaseTable, index int) (query.Row, *errors
 	return row, nil
 }
 
-func (t *iterativeTable) finishTable(errors []OneApiError) {
+func (t *iterativeTable) finishTable(errors []OneApiError, rowCount int) {
 	if errors != nil {
 		for _, e := range errors {
 			t.rows <- query.RowResultError(&e)
 		}
 	}
+	// Set before closing rawRows, so readRows sees it once it has read all the fragments.
+	t.completionRowCount = rowCount
 	close(t.rawRows)
 }
 
 const skipError = "skipping row"
 
 func (t *iterativeTable) readRows() {
-	for rows := range t.rawRows {
-		for _, r := range rows {
+	for f := range t.rawRows {
+		if f.replace {
+			t.rows <- query.RowResultError(query.ErrRowsReplaced)
+			// Not setRowCount, as ToTable holds the read lock while reading the rows.
+			t.rowCount = 0
+		}
+		for _, r := range f.rows {
 			if t.Skip() {
 				t.rows <- query.RowResultError(errors.ES(t.Op(), errors.KInternal, skipError))
 			} else {
@@ -121,6 +140,10 @@ func (t *iterativeTable) readRows() {
 		}
 	}
 
+	// A fragment lost on the way would otherwise go unnoticed, the rows of the table arriving in several of them.
+	if t.completionRowCount > 0 && t.rowCount != t.completionRowCount {
+		t.rows <- query.RowResultError(errors.ES(t.Op(), errors.KInternal, "table %s completed with %d rows, but %d rows were received", t.Name(), t.completionRowCount, t.rowCount))
+	}
 	close(t.rows)
 }
 func (t *iterativeTable) Rows() <-chan query.RowResult {
@@ -140,21 +163,55 @@ func (t *iterativeTable) SkipToEnd() []error {
 	return errs
 }
 
+func (t *iterativeTable) WriteCSV(w io.Writer, options query.CSVOptions) error {
+	return query.WriteCSVIterative(w, t, options)
+}
+
 func (t *iterativeTable) ToTable() (query.Table, error) {
+	table, failures, err := t.toTable()
+	if err != nil {
+		return nil, err
+	}
+	if len(failures) > 0 {
+		return nil, failures[0]
+	}
+	return table, nil
+}
+
+// toTable reads the rows of the table, returning the failures reported at its end apart, see query.PartialQueryError.
+func (t *iterativeTable) toTable() (query.Table, []error, error) {
 	t.lock.RLock()
 	defer t.lock.RUnlock()
 	if t.skip {
-		return nil, errors.ES(t.Op(), errors.KInternal, "table is already skipped to the end")
+		return nil, nil, errors.ES(t.Op(), errors.KInternal, "table is already skipped to the end")
 	}
 
-	var rows []query.Row
+	rows := query.NewRowBuffer(t.BaseTable, t.spill)
+	var failures []error
 	for r := range t.rows {
-		if r.Err() != nil {
-			return nil, r.Err()
+		var err error
+		if r.Err() == query.ErrRowsReplaced {
+			err = rows.Reset()
+		} else if _, ok := r.Err().(*OneApiError); ok {
+			failures = append(failures, r.Err())
+		} else if r.Err() != nil {
+			err = r.Err()
 		} else {
-			rows = append(rows, r.Row())
+			err = rows.Add(r.Row())
+		}
+		if err != nil {
+			// The remaining rows are discarded, so that decoding them doesn't block.
+			go func() {
+				for range t.rows {
+				}
+			}()
+			return nil, nil, err
 		}
 	}
 
-	return query.NewTable(t.BaseTable, rows), nil
+	table, err := rows.Table()
+	if err != nil {
+		return nil, nil, err
+	}
+	return table, failures, nil
 }
@@ -28,10 +28,10 @@ func prepareReadBuffer(r io.Reader) (io.Reader, error) {
 
 }
 
-// readFramesIterative reads frames from a reader and sends them to a channel as they are read.
-func readFramesIterative(reader io.Reader, ch chan<- *EveryFrame) error {
-	defer close(ch)
-
+// readFramesIterative reads frames from a reader and sends them to a channel as they are read, calling onFrame, if set,
+// as soon as each one is decoded. It stops once done is closed.
+// It doesn't close the channel, so the caller can record the error before closing it.
+func readFramesIterative(reader io.Reader, ch chan<- *EveryFrame, onFrame func(*EveryFrame), done <-chan struct{}) error {
 	// Crazily enough, json.Decoder always puts THE ENTIRE READER IN MEMORY
 	// So we have to manually split the reader into lines and decode each line with a new decoder
 
@@ -55,6 +55,10 @@ func readFramesIterative(reader io.Reader, ch chan<- *EveryFrame) error {
 		frame := EveryFrame{}
 		err = dec.Decode(&frame)
 
+		// When reading fails, the scanner returns the partial last line; the read error is the one to report.
+		if scanErr := scanner.Err(); err != nil && scanErr != nil {
+			return scanErr
+		}
 		if err != nil {
 			if err == io.EOF {
 				return nil
@@ -62,10 +66,17 @@ func readFramesIterative(reader io.Reader, ch chan<- *EveryFrame) error {
 			return err
 		}
 
-		ch <- &frame
+		if onFrame != nil {
+			onFrame(&frame)
+		}
+		select {
+		case ch <- &frame:
+		case <-done:
+			return nil
+		}
 	}
 
-	return nil
+	return scanner.Err()
 }
 
 func handleKustoJson(line []byte) ([]byte, error) {
@@ -26,11 +26,12 @@ var twoTables string
 var errorText string
 
 func readAndDecodeFrames(src string, ch chan *EveryFrame) error {
+	defer close(ch)
 	br, err := prepareReadBuffer(strings.NewReader(src))
 	if err != nil {
 		return err
 	}
-	err = readFramesIterative(br, ch)
+	err = readFramesIterative(br, ch, nil, nil)
 	if err != nil {
 		return err
 	}
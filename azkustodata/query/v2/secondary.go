@@ -3,37 +3,19 @@ package v2
 import (
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
 	"github.com/Azure/azure-kusto-go/azkustodata/query"
-	"github.com/google/uuid"
-	"time"
 )
 
 // This file handles the parsing of the known secondary tables in v2 datasets.
 
 // QueryProperties represents the query properties table, which arrives before the first result.
-type QueryProperties struct {
-	TableId int
-	Key     string
-	Value   map[string]interface{}
-}
+type QueryProperties = query.QueryProperties
 
 // QueryCompletionInformation represents the query completion information table, which arrives after the last result.
-type QueryCompletionInformation struct {
-	Timestamp        time.Time
-	ClientRequestId  string
-	ActivityId       uuid.UUID
-	SubActivityId    uuid.UUID
-	ParentActivityId uuid.UUID
-	Level            int
-	LevelName        string
-	StatusCode       int
-	StatusCodeName   string
-	EventType        int
-	EventTypeName    string
-	Payload          string
-}
+// See Dataset.QueryCompletionInformation for its typed content.
+type QueryCompletionInformation = query.QueryCompletionEvent
 
-const QueryPropertiesKind = "QueryProperties"
-const QueryCompletionInformationKind = "QueryCompletionInformation"
+const QueryPropertiesKind = query.QueryPropertiesKind
+const QueryCompletionInformationKind = query.QueryCompletionInformationKind
 
 func AsQueryProperties(table query.BaseTable) ([]QueryProperties, error) {
 	if table.Kind() != QueryPropertiesKind {
@@ -0,0 +1,108 @@
+package v2
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+)
+
+// stallReader watches the reads of a response, calling its stall handler when a read waits for data for longer than the
+// frame timeout, and aborting the read if the handler doesn't keep waiting, see query.ContextWithStallDetection.
+// Only the time spent reading counts: a response waiting for its frames to be consumed is not stalled.
+type stallReader struct {
+	r       io.Reader
+	timeout time.Duration
+	onStall query.StallHandler
+	// abort closes the response, to unblock the read.
+	abort func()
+	timer *time.Timer
+
+	lock      sync.Mutex
+	reading   bool
+	started   time.Time
+	frames    int
+	lastFrame time.Time
+	err       *query.StallError
+	// reads counts the reads started, so a stall handler returning after its read ended doesn't abort the next one.
+	reads int
+}
+
+func newStallReader(r io.Reader, timeout time.Duration, onStall query.StallHandler, abort func()) *stallReader {
+	s := &stallReader{r: r, timeout: timeout, onStall: onStall, abort: abort}
+	s.timer = time.AfterFunc(timeout, s.check)
+	s.timer.Stop()
+	return s
+}
+
+func (s *stallReader) Read(p []byte) (int, error) {
+	s.lock.Lock()
+	if s.err != nil {
+		s.lock.Unlock()
+		return 0, s.err
+	}
+	s.reading, s.started = true, time.Now()
+	s.reads++
+	s.timer.Reset(s.timeout)
+	s.lock.Unlock()
+
+	n, err := s.r.Read(p)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reading = false
+	s.timer.Stop()
+	if s.err != nil {
+		return n, s.err
+	}
+	return n, err
+}
+
+// onFrame counts the frames received, before calling next, if set.
+func (s *stallReader) onFrame(next func(*EveryFrame)) func(*EveryFrame) {
+	return func(f *EveryFrame) {
+		s.lock.Lock()
+		s.frames++
+		s.lastFrame = time.Now()
+		s.lock.Unlock()
+		if next != nil {
+			next(f)
+		}
+	}
+}
+
+// check is called by the timer, once a read waited for the frame timeout.
+func (s *stallReader) check() {
+	s.lock.Lock()
+	if !s.reading || s.err != nil {
+		s.lock.Unlock()
+		return
+	}
+	// The timer may fire for a read that ended, after the next one started.
+	if waited := time.Since(s.started); waited < s.timeout {
+		s.timer.Reset(s.timeout - waited)
+		s.lock.Unlock()
+		return
+	}
+	stall := query.Stall{Waited: time.Since(s.started), Frames: s.frames, LastFrame: s.lastFrame}
+	read := s.reads
+	s.lock.Unlock()
+
+	keepWaiting := s.onStall != nil && s.onStall(stall)
+
+	s.lock.Lock()
+	if !s.reading || s.reads != read {
+		// Data arrived while the handler ran. A read started since then has its own timer.
+		s.lock.Unlock()
+		return
+	}
+	if keepWaiting {
+		s.timer.Reset(s.timeout)
+		s.lock.Unlock()
+		return
+	}
+	s.err = &query.StallError{Stall: stall}
+	s.lock.Unlock()
+	s.abort()
+}
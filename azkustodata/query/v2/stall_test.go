@@ -0,0 +1,140 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stalledResponse writes the first frames of progressiveFrames, and then nothing, as a dropped connection would.
+func stalledResponse(t *testing.T) io.ReadCloser {
+	r, w := io.Pipe()
+	lines := strings.SplitAfter(progressiveFrames, "\n")
+	go func() {
+		_, _ = w.Write([]byte(strings.Join(lines[:3], "")))
+	}()
+	t.Cleanup(func() { _ = w.Close() })
+	return r
+}
+
+func TestStallDetection(t *testing.T) {
+	t.Parallel()
+	var stalls []query.Stall
+	var calls atomic.Int32
+	onStall := func(s query.Stall) bool {
+		stalls = append(stalls, s)
+		// Keeps waiting once.
+		return calls.Add(1) == 1
+	}
+	ctx := query.ContextWithStallDetection(context.Background(), 20*time.Millisecond, onStall)
+
+	d, err := NewIterativeDataset(ctx, stalledResponse(t), DefaultFrameCapacity, DefaultRowCapacity, DefaultFragmentCapacity)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := d.ToDataset()
+		done <- err
+	}()
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stalled read wasn't aborted")
+	}
+
+	var stallErr *query.StallError
+	require.True(t, errors.As(err, &stallErr), "%v", err)
+	assert.Equal(t, int32(2), calls.Load())
+	require.Len(t, stalls, 2)
+	assert.Equal(t, 3, stalls[0].Frames)
+	assert.False(t, stalls[0].LastFrame.IsZero())
+	assert.GreaterOrEqual(t, stalls[0].Waited, 20*time.Millisecond)
+	assert.GreaterOrEqual(t, stalls[1].Waited, 40*time.Millisecond)
+	assert.Equal(t, stalls[1], stallErr.Stall)
+}
+
+func TestStallDetectionBeforeFirstFrame(t *testing.T) {
+	t.Parallel()
+	r, w := io.Pipe()
+	defer w.Close()
+	ctx := query.ContextWithStallDetection(context.Background(), 10*time.Millisecond, nil)
+
+	_, err := NewIterativeDataset(ctx, r, DefaultFrameCapacity, DefaultRowCapacity, DefaultFragmentCapacity)
+	var stallErr *query.StallError
+	require.True(t, errors.As(err, &stallErr), "%v", err)
+	assert.Equal(t, 0, stallErr.Frames)
+	assert.True(t, stallErr.LastFrame.IsZero())
+}
+
+func TestStallDetectionSlowConsumer(t *testing.T) {
+	t.Parallel()
+	var stalled atomic.Bool
+	ctx := query.ContextWithStallDetection(context.Background(), 10*time.Millisecond, func(query.Stall) bool {
+		stalled.Store(true)
+		return false
+	})
+
+	// Waiting for the frames to be consumed isn't a stall.
+	d, err := NewIterativeDataset(ctx, io.NopCloser(strings.NewReader(twoTables)), 1, 1, 1)
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	_, err = d.ToDataset()
+	require.NoError(t, err)
+	assert.False(t, stalled.Load())
+}
+
+// chanReader returns the chunks sent on its channel, one per read.
+type chanReader chan []byte
+
+func (c chanReader) Read(p []byte) (int, error) {
+	return copy(p, <-c), nil
+}
+
+func TestStallHandlerOutlivingItsRead(t *testing.T) {
+	t.Parallel()
+	src := make(chanReader)
+	entered, release := make(chan struct{}), make(chan struct{})
+	var calls atomic.Int32
+	var aborted atomic.Bool
+	s := newStallReader(src, 10*time.Millisecond, func(query.Stall) bool {
+		if calls.Add(1) > 1 {
+			return true
+		}
+		close(entered)
+		<-release
+		return false
+	}, func() { aborted.Store(true) })
+
+	reads := make(chan error, 2)
+	read := func() {
+		_, err := s.Read(make([]byte, 8))
+		reads <- err
+	}
+	go read()
+	<-entered
+
+	// The stalled read ends, and the next one starts, while the handler runs.
+	src <- []byte("a")
+	require.NoError(t, <-reads)
+	go read()
+	require.Eventually(t, func() bool {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		return s.reading
+	}, time.Second, time.Millisecond)
+	close(release)
+	// Gives the handler of the first read the time to return, before the second read ends.
+	time.Sleep(50 * time.Millisecond)
+
+	src <- []byte("b")
+	assert.NoError(t, <-reads)
+	assert.False(t, aborted.Load(), "the handler of the previous read aborted the next one")
+}
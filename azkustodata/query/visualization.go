@@ -0,0 +1,202 @@
+package query
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+)
+
+// VisualizationKey is the Key of the QueryProperties rows holding the visualization of a result, see Visualization.
+const VisualizationKey = "Visualization"
+
+// VisualizationType is the type of chart requested by the render operator.
+type VisualizationType string
+
+const (
+	VisualizationAnomalyChart     VisualizationType = "anomalychart"
+	VisualizationAreaChart        VisualizationType = "areachart"
+	VisualizationBarChart         VisualizationType = "barchart"
+	VisualizationCard             VisualizationType = "card"
+	VisualizationColumnChart      VisualizationType = "columnchart"
+	VisualizationLadderChart      VisualizationType = "ladderchart"
+	VisualizationLineChart        VisualizationType = "linechart"
+	VisualizationPieChart         VisualizationType = "piechart"
+	VisualizationPivotChart       VisualizationType = "pivotchart"
+	VisualizationScatterChart     VisualizationType = "scatterchart"
+	VisualizationStackedAreaChart VisualizationType = "stackedareachart"
+	VisualizationTable            VisualizationType = "table"
+	VisualizationTimeChart        VisualizationType = "timechart"
+	VisualizationTimePivot        VisualizationType = "timepivot"
+	VisualizationTreeMap          VisualizationType = "treemap"
+)
+
+// Visualization holds the hints of the render operator ending a query, on how to chart a result, such as
+// `| render timechart with (title="Events", ycolumns=Count)`. The properties not set by the query are empty.
+// See https://learn.microsoft.com/kusto/query/render-operator for their meaning.
+type Visualization struct {
+	// TableId is the id of the primary result to chart, see Table.Index.
+	TableId int
+	// Type is the type of chart.
+	Type VisualizationType
+	// Kind is the variation of the chart, such as "stacked", "stacked100" or "unstacked".
+	Kind  string
+	Title string
+	// XColumn is the column of the x-axis, YColumns the columns of the y-axis, and Series the columns whose values
+	// split the results into series.
+	XColumn        string
+	YColumns       []string
+	Series         []string
+	AnomalyColumns []string
+	XTitle         string
+	YTitle         string
+	// XAxis and YAxis are the scales of the axes, "linear" or "log".
+	XAxis string
+	YAxis string
+	// Legend is "visible" or "hidden".
+	Legend string
+	// YSplit is how multiple y-axes are shown: "none", "axes" or "panels".
+	YSplit        string
+	Accumulate    bool
+	IsQuerySorted bool
+	// Ymin and Ymax are the bounds of the y-axis, NaN if unset.
+	Ymin float64
+	Ymax float64
+	// Xmin and Xmax are the bounds of the x-axis, as a number or a datetime string, or nil if unset.
+	Xmin interface{}
+	Xmax interface{}
+}
+
+// visualizationProperties is the JSON of a visualization in the QueryProperties table.
+type visualizationProperties struct {
+	Visualization  string
+	Kind           string
+	Title          string
+	XColumn        string
+	YColumns       columnList
+	Series         columnList
+	AnomalyColumns columnList
+	XTitle         string
+	YTitle         string
+	XAxis          string
+	YAxis          string
+	Legend         string
+	YSplit         string
+	Accumulate     bool
+	IsQuerySorted  bool
+	Ymin           bound
+	Ymax           bound
+	Xmin           interface{}
+	Xmax           interface{}
+}
+
+// columnList is a list of columns, sent as a comma-separated string or an array.
+type columnList []string
+
+func (c *columnList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*c = list
+		return nil
+	}
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*c = nil
+	if s == nil {
+		return nil
+	}
+	for _, name := range strings.Split(*s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			*c = append(*c, name)
+		}
+	}
+	return nil
+}
+
+// bound is a bound of the y-axis, sent as a number or as the string "NaN" when unset.
+type bound float64
+
+func (b *bound) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		*b = bound(f)
+		return nil
+	}
+	var f *float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	*b = bound(math.NaN())
+	if f != nil {
+		*b = bound(*f)
+	}
+	return nil
+}
+
+// ParseVisualization parses the JSON of the visualization of a table, as found in the Value of the QueryProperties rows
+// with the VisualizationKey key, or in the Info of v1 results. It returns nil if the query has no render operator.
+func ParseVisualization(tableID int, data []byte) (*Visualization, error) {
+	p := visualizationProperties{Ymin: bound(math.NaN()), Ymax: bound(math.NaN())}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, errors.ES(errors.OpQuery, errors.KFailedToParse, "could not parse the visualization of table %d: %s", tableID, err)
+	}
+	if p.Visualization == "" {
+		return nil, nil
+	}
+	return &Visualization{
+		TableId:        tableID,
+		Type:           VisualizationType(p.Visualization),
+		Kind:           p.Kind,
+		Title:          p.Title,
+		XColumn:        p.XColumn,
+		YColumns:       p.YColumns,
+		Series:         p.Series,
+		AnomalyColumns: p.AnomalyColumns,
+		XTitle:         p.XTitle,
+		YTitle:         p.YTitle,
+		XAxis:          p.XAxis,
+		YAxis:          p.YAxis,
+		Legend:         p.Legend,
+		YSplit:         p.YSplit,
+		Accumulate:     p.Accumulate,
+		IsQuerySorted:  p.IsQuerySorted,
+		Ymin:           float64(p.Ymin),
+		Ymax:           float64(p.Ymax),
+		Xmin:           p.Xmin,
+		Xmax:           p.Xmax,
+	}, nil
+}
+
+// datasetVisualizations returns the visualizations of the QueryProperties table of a dataset.
+func datasetVisualizations(d Dataset) ([]Visualization, error) {
+	props, err := d.QueryProperties()
+	if err != nil {
+		return nil, err
+	}
+	var visualizations []Visualization
+	for _, p := range props {
+		if p.Key != VisualizationKey {
+			continue
+		}
+		data, err := json.Marshal(p.Value)
+		if err != nil {
+			return nil, errors.ES(d.Op(), errors.KFailedToParse, "could not parse the visualization of table %d: %s", p.TableId, err)
+		}
+		v, err := ParseVisualization(p.TableId, data)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			visualizations = append(visualizations, *v)
+		}
+	}
+	return visualizations, nil
+}
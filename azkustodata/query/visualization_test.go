@@ -0,0 +1,82 @@
+package query
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVisualization(t *testing.T) {
+	t.Parallel()
+
+	v, err := ParseVisualization(1, []byte(`{"Visualization":"timechart","Title":"Events","XColumn":"Timestamp","Series":null,`+
+		`"YColumns":"Count, Errors","AnomalyColumns":null,"XTitle":null,"YTitle":"Events","XAxis":null,"YAxis":"log",`+
+		`"Legend":"hidden","YSplit":"panels","Accumulate":false,"IsQuerySorted":true,"Kind":"stacked","Ymin":"NaN",`+
+		`"Ymax":100,"Xmin":"2024-01-01T00:00:00Z","Xmax":null}`))
+	require.NoError(t, err)
+	require.NotNil(t, v)
+	assert.Equal(t, 1, v.TableId)
+	assert.Equal(t, VisualizationTimeChart, v.Type)
+	assert.Equal(t, "stacked", v.Kind)
+	assert.Equal(t, "Events", v.Title)
+	assert.Equal(t, "Timestamp", v.XColumn)
+	assert.Equal(t, []string{"Count", "Errors"}, v.YColumns)
+	assert.Nil(t, v.Series)
+	assert.Equal(t, "", v.XTitle)
+	assert.Equal(t, "Events", v.YTitle)
+	assert.Equal(t, "log", v.YAxis)
+	assert.Equal(t, "hidden", v.Legend)
+	assert.Equal(t, "panels", v.YSplit)
+	assert.True(t, v.IsQuerySorted)
+	assert.True(t, math.IsNaN(v.Ymin))
+	assert.Equal(t, 100.0, v.Ymax)
+	assert.Equal(t, "2024-01-01T00:00:00Z", v.Xmin)
+	assert.Nil(t, v.Xmax)
+
+	v, err = ParseVisualization(0, []byte(`{"Visualization":"piechart","Series":["Region","Country"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, VisualizationPieChart, v.Type)
+	assert.Equal(t, []string{"Region", "Country"}, v.Series)
+	assert.True(t, math.IsNaN(v.Ymin))
+	assert.True(t, math.IsNaN(v.Ymax))
+
+	v, err = ParseVisualization(0, []byte(`{"Visualization":null,"Ymin":"NaN","Ymax":"NaN"}`))
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	_, err = ParseVisualization(0, []byte(`{"Visualization":"barchart","Ymin":"low"}`))
+	assert.Error(t, err)
+}
+
+func TestDatasetVisualizations(t *testing.T) {
+	t.Parallel()
+
+	ds := NewBaseDataset(context.Background(), errors.OpQuery, "PrimaryResult")
+	base := NewBaseTable(ds, 0, "0", "@QueryProperties", QueryPropertiesKind, Columns{
+		NewColumn(0, "TableId", types.Int),
+		NewColumn(1, "Key", types.String),
+		NewColumn(2, "Value", types.Dynamic),
+	})
+	rows := []Row{
+		NewRow(base, 0, value.Values{value.NewInt(1), value.NewString(VisualizationKey),
+			value.NewDynamic([]byte(`{"Visualization":"columnchart","XColumn":"Day","YColumns":"Count"}`))}),
+		NewRow(base, 1, value.Values{value.NewInt(2), value.NewString(VisualizationKey),
+			value.NewDynamic([]byte(`{"Visualization":null}`))}),
+		NewRow(base, 2, value.Values{value.NewInt(1), value.NewString("Other"), value.NewDynamic([]byte(`{}`))}),
+	}
+	d := NewDataset(ds, []Table{NewTable(base, rows)})
+
+	visualizations, err := d.Visualizations()
+	require.NoError(t, err)
+	require.Len(t, visualizations, 1)
+	assert.Equal(t, 1, visualizations[0].TableId)
+	assert.Equal(t, VisualizationColumnChart, visualizations[0].Type)
+	assert.Equal(t, "Day", visualizations[0].XColumn)
+	assert.Equal(t, []string{"Count"}, visualizations[0].YColumns)
+}
@@ -4,9 +4,18 @@ package azkustodata
 // it clogs up the main kusto.go file.
 
 import (
+	"errors"
+	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"net/http"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	queryv2 "github.com/Azure/azure-kusto-go/azkustodata/query/v2"
 	"github.com/Azure/azure-kusto-go/azkustodata/value"
 )
 
@@ -20,6 +29,11 @@ type requestProperties struct {
 	User            string         `json:"-"`
 	QueryParameters kql.Parameters `json:"-"`
 	ClientRequestID string         `json:"-"`
+	// Credential and AuthToken override the client's token provider for a single request.
+	Credential azcore.TokenCredential `json:"-"`
+	AuthToken  string                 `json:"-"`
+	// Headers are added to the request's headers.
+	Headers http.Header `json:"-"`
 }
 
 type queryOptions struct {
@@ -28,6 +42,25 @@ type queryOptions struct {
 	v2FrameCapacity    int
 	v2RowCapacity      int
 	v2FragmentCapacity int
+	// serverTimeoutSkew is subtracted from the time left until the context deadline to get the server timeout.
+	serverTimeoutSkew time.Duration
+	// timeout is the client timeout of the call, see Timeout.
+	timeout time.Duration
+	// concurrencyWaitTimeout overrides the client's concurrency limit wait timeout if positive.
+	concurrencyWaitTimeout time.Duration
+	// maxResponseBytes is the maximum size of the response. Zero means no limit.
+	maxResponseBytes int64
+	// onHeartbeat is called for each frame of the response, see KeepAlive.
+	onHeartbeat func(query.Heartbeat)
+	// frameTimeout and onStall detect stalled responses, see FrameTimeout.
+	frameTimeout time.Duration
+	onStall      query.StallHandler
+	// allowControlCommands lets read-only requests send control commands, see AllowControlCommands.
+	allowControlCommands bool
+	// skipResultCache reads the results from the cluster even if they are cached, see SkipResultCache.
+	skipResultCache bool
+	// spill spills the rows of results read at once to disk past a memory limit, see SpillToDisk.
+	spill *query.SpillOptions
 }
 
 const ResultsProgressiveEnabledValue = "results_progressive_enabled"
@@ -35,6 +68,7 @@ const NoRequestTimeoutValue = "norequesttimeout"
 const NoTruncationValue = "notruncation"
 const ServerTimeoutValue = "servertimeout"
 const DeferPartialQueryFailuresValue = "deferpartialqueryfailures"
+const BestEffortValue = "best_effort"
 const MaxMemoryConsumptionPerQueryPerNodeValue = "max_memory_consumption_per_query_per_node"
 const MaxMemoryConsumptionPerIteratorValue = "maxmemoryconsumptionperiterator"
 const MaxOutputColumnsValue = "maxoutputcolumns"
@@ -159,9 +193,77 @@ func Application(appName string) QueryOption {
 	}
 }
 
-// QueryParameters sets the parameters to be used in the query.
+// Credential runs the request under the identity of cred instead of the client's, for services that execute queries
+// on behalf of several identities with the same client. The token is requested for the cluster's scope, and is not
+// cached by the client, so cred should cache its own tokens, as the azidentity credentials do.
+func Credential(cred azcore.TokenCredential) QueryOption {
+	return func(q *queryOptions) error {
+		if cred == nil {
+			return errors.New("credential cannot be nil")
+		}
+		if q.requestProperties.AuthToken != "" {
+			return errors.New("Credential and UserToken cannot be used together")
+		}
+		q.requestProperties.Credential = cred
+		return nil
+	}
+}
+
+// reservedHeaders are set by the client, and can't be set with WithHTTPHeader.
+var reservedHeaders = map[string]string{
+	"Authorization":       "use the Credential or UserToken options instead",
+	ClientRequestIdHeader: "use the ClientRequestID option instead",
+	ApplicationHeader:     "use the Application option instead",
+	UserHeader:            "use the User option instead",
+	"Content-Type":        "it is set by the client",
+	"Content-Encoding":    "it is set by the client",
+	"Accept-Encoding":     "it is set by the client",
+}
+
+// WithHTTPHeader adds a header to the request, such as a gateway routing header, a correlation id or a feature flag.
+// It can be used several times, including with the same key to send several values. Headers set by the client, such as
+// Authorization and the x-ms-* tracing headers, can't be set.
+func WithHTTPHeader(key string, value string) QueryOption {
+	return func(q *queryOptions) error {
+		if key == "" {
+			return errors.New("header key cannot be empty")
+		}
+		key = http.CanonicalHeaderKey(key)
+		for reserved, hint := range reservedHeaders {
+			if key == http.CanonicalHeaderKey(reserved) {
+				return fmt.Errorf("header %s cannot be set, %s", key, hint)
+			}
+		}
+		if q.requestProperties.Headers == nil {
+			q.requestProperties.Headers = http.Header{}
+		}
+		q.requestProperties.Headers.Add(key, value)
+		return nil
+	}
+}
+
+// UserToken runs the request with the given bearer token instead of the client's, for services that execute queries
+// on behalf of several identities with the same client.
+func UserToken(token string) QueryOption {
+	return func(q *queryOptions) error {
+		if token == "" {
+			return errors.New("token cannot be empty")
+		}
+		if q.requestProperties.Credential != nil {
+			return errors.New("Credential and UserToken cannot be used together")
+		}
+		q.requestProperties.AuthToken = token
+		return nil
+	}
+}
+
+// QueryParameters sets the parameters to be used in the query, see kql.Parameters. They are declared in a
+// `declare query_parameters(...)` statement prepended to the query, and their values are sent in the request properties.
 func QueryParameters(queryParameters *kql.Parameters) QueryOption {
 	return func(q *queryOptions) error {
+		if queryParameters == nil {
+			return errors.New("query parameters cannot be nil")
+		}
 		q.requestProperties.QueryParameters = *queryParameters
 		q.requestProperties.Parameters = queryParameters.ToParameterCollection()
 		return nil
@@ -200,6 +302,83 @@ func ResultsProgressiveEnabled() QueryOption {
 	}
 }
 
+// ProgressiveResults enables progressive results, and calls onProgress, if set, with the progress of the query each time
+// the cluster reports it, so long-running queries can show their progress. IterativeQuery returns the rows of the tables
+// as they arrive. When the cluster replaces the rows of a table, such as the results of an aggregation, it sends a row
+// result whose error is query.ErrRowsReplaced: the rows received before it are replaced by the rows received after it.
+// Query only returns the final rows. onProgress is called from the goroutine reading the response, and should return quickly.
+func ProgressiveResults(onProgress func(query.Progress)) QueryOption {
+	return func(q *queryOptions) error {
+		q.requestProperties.Options[ResultsProgressiveEnabledValue] = true
+		if onProgress != nil {
+			q.addHeartbeat(func(h query.Heartbeat) {
+				if h.FrameType == string(queryv2.TableProgressFrameType) {
+					onProgress(query.Progress{TableID: h.TableID, Percent: h.Progress})
+				}
+			})
+		}
+		return nil
+	}
+}
+
+// addHeartbeat adds onHeartbeat to the functions called for each frame of the response.
+func (q *queryOptions) addHeartbeat(onHeartbeat func(query.Heartbeat)) {
+	previous := q.onHeartbeat
+	if previous == nil {
+		q.onHeartbeat = onHeartbeat
+		return
+	}
+	q.onHeartbeat = func(h query.Heartbeat) {
+		previous(h)
+		onHeartbeat(h)
+	}
+}
+
+// KeepAlive enables progressive results, so the cluster sends a progress frame at least every period while the query
+// runs, keeping the connection alive through load balancers that reset idle connections before the first results arrive.
+// If onHeartbeat is set, it is called for each frame received by Query and IterativeQuery, including the progress frames,
+// so callers can detect stalled queries. It is called from the goroutine reading the response, and should return quickly.
+// See ProgressiveResults for how the rows of progressive results are returned.
+func KeepAlive(period time.Duration, onHeartbeat func(query.Heartbeat)) QueryOption {
+	return func(q *queryOptions) error {
+		if period <= 0 {
+			return fmt.Errorf("keep-alive period must be positive, got %s", period)
+		}
+		q.requestProperties.Options[ResultsProgressiveEnabledValue] = true
+		q.requestProperties.Options[QueryResultsProgressiveUpdatePeriodValue] = value.TimespanString(period)
+		if onHeartbeat != nil {
+			q.addHeartbeat(onHeartbeat)
+		}
+		return nil
+	}
+}
+
+// FrameTimeout detects stalled responses, such as when a load balancer silently dropped an idle connection, which would
+// otherwise block the reading of the results until the call times out. When Query or IterativeQuery wait for data for
+// longer than timeout, onStall is called with the details of the stall. If it returns false, or is nil, the read is
+// aborted and fails with a *query.StallError, so the query can be retried. If it returns true, the read waits for
+// another timeout, and so on.
+// The time spent waiting for the results to be consumed doesn't count. Combined with KeepAlive, with a period well below
+// timeout, the cluster sends frames regularly even while long queries run, so only hung connections stall.
+func FrameTimeout(timeout time.Duration, onStall query.StallHandler) QueryOption {
+	return func(q *queryOptions) error {
+		if timeout <= 0 {
+			return fmt.Errorf("frame timeout must be positive, got %s", timeout)
+		}
+		q.frameTimeout = timeout
+		q.onStall = onStall
+		return nil
+	}
+}
+
+// serverTimeoutSkew sets the client's server timeout skew on the call, see WithServerTimeoutSkew.
+func serverTimeoutSkew(skew time.Duration) QueryOption {
+	return func(q *queryOptions) error {
+		q.serverTimeoutSkew = skew
+		return nil
+	}
+}
+
 // ServerTimeout overrides the default request timeout.
 func ServerTimeout(d time.Duration) QueryOption {
 	return func(q *queryOptions) error {
@@ -220,6 +399,7 @@ func CustomQueryOption(paramName string, i interface{}) QueryOption {
 }
 
 // DeferPartialQueryFailures disables reporting partial query failures as part of the result set.
+// Query returns the failures reported inside the results as a *query.PartialQueryError, along with the results.
 func DeferPartialQueryFailures() QueryOption {
 	return func(q *queryOptions) error {
 		q.requestProperties.Options[DeferPartialQueryFailuresValue] = true
@@ -227,6 +407,17 @@ func DeferPartialQueryFailures() QueryOption {
 	}
 }
 
+// BestEffort lets a query succeed when parts of the data it reads can't be, such as unreachable clusters or shards of
+// cross-cluster and union queries, which are skipped. The skipped parts are reported by the Skipped method of the
+// QueryCompletionInformation of the results, so callers preferring availability over completeness know how partial
+// their results are.
+func BestEffort() QueryOption {
+	return func(q *queryOptions) error {
+		q.requestProperties.Options[BestEffortValue] = true
+		return nil
+	}
+}
+
 // MaxMemoryConsumptionPerQueryPerNode overrides the default maximum amount of memory a whole query
 // may allocate per node.
 func MaxMemoryConsumptionPerQueryPerNode(i uint64) QueryOption {
@@ -517,7 +708,25 @@ func QueryTakeMaxRecords(i int64) QueryOption {
 	}
 }
 
-// QueryConsistency Controls query consistency
+// The consistency levels of QueryConsistency and WithQueryConsistency.
+const (
+	// QueryConsistencyStrong runs the query on the admin node, which always has the latest metadata. It is the default.
+	QueryConsistencyStrong = "strongconsistency"
+	// QueryConsistencyWeak runs the query on any node, which may not have the latest metadata yet, spreading the load of
+	// the queries over the cluster.
+	QueryConsistencyWeak = "weakconsistency"
+	// QueryConsistencyAffinitizedWeak is weak consistency, with the same query text always run on the same node, so it
+	// benefits from its cache.
+	QueryConsistencyAffinitizedWeak = "affinitizedweakconsistency"
+	// QueryConsistencyDatabaseAffinitizedWeak is weak consistency, with the queries of a database always run on the same
+	// node, so they see its metadata in order.
+	QueryConsistencyDatabaseAffinitizedWeak = "databaseaffinitizedweakconsistency"
+)
+
+// QueryConsistency controls the consistency of the query, one of the QueryConsistency constants, overriding the client's
+// default set with WithQueryConsistency. Weak consistency lets the cluster run read-heavy workloads on any node,
+// possibly a few minutes behind the latest metadata.
+// See https://learn.microsoft.com/kusto/concepts/query-consistency.
 func QueryConsistency(c string) QueryOption {
 	return func(q *queryOptions) error {
 		q.requestProperties.Options[QueryConsistencyValue] = c
@@ -575,6 +784,8 @@ func RequestImpersonationDisabled() QueryOption {
 }
 
 // RequestReadonly If specified, indicates that the request can't write anything.
+// The client also refuses to send control commands, statements starting with '.', in read-only requests, unless the
+// AllowControlCommands option is set. Use WithRequestReadonly to make all the requests of a client read-only.
 func RequestReadonly() QueryOption {
 	return func(q *queryOptions) error {
 		q.requestProperties.Options[RequestReadonlyValue] = true
@@ -582,6 +793,31 @@ func RequestReadonly() QueryOption {
 	}
 }
 
+// AllowControlCommands lets a read-only request send a control command, such as a `.show` command. The cluster still
+// refuses the commands that would write anything.
+func AllowControlCommands() QueryOption {
+	return func(q *queryOptions) error {
+		q.allowControlCommands = true
+		return nil
+	}
+}
+
+// isControlCommand returns whether a statement is a control command, starting with '.' after any blank lines and
+// comments.
+func isControlCommand(stmt string) bool {
+	for {
+		stmt = strings.TrimLeftFunc(stmt, unicode.IsSpace)
+		if !strings.HasPrefix(stmt, "//") {
+			return strings.HasPrefix(stmt, ".")
+		}
+		_, rest, found := strings.Cut(stmt, "\n")
+		if !found {
+			return false
+		}
+		stmt = rest
+	}
+}
+
 // RequestRemoteEntitiesDisabled If specified, indicates that the request can't access remote databases and clusters.
 func RequestRemoteEntitiesDisabled() QueryOption {
 	return func(q *queryOptions) error {
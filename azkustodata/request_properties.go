@@ -0,0 +1,121 @@
+package azkustodata
+
+import "time"
+
+// ClientRequestProperties are the documented client request properties of a query or command, set on a call with the
+// RequestProperties option. Zero fields are not sent, so the cluster's defaults apply.
+// See https://learn.microsoft.com/kusto/api/rest/request-properties for their meaning.
+type ClientRequestProperties struct {
+	// ServerTimeout is the servertimeout of the call. See also NoRequestTimeout, and WithServerTimeoutSkew for the default.
+	ServerTimeout time.Duration
+	// NoRequestTimeout sets the request timeout to its maximum value.
+	NoRequestTimeout bool
+	// NoTruncation disables the truncation of the results.
+	NoTruncation bool
+	// TruncationMaxRecords is the maximum number of records a query returns before the results are truncated.
+	TruncationMaxRecords int64
+	// TruncationMaxSize is the maximum size, in bytes, of the results of a query before they are truncated.
+	TruncationMaxSize int64
+	// QueryTakeMaxRecords limits the results of a query to this number of records.
+	QueryTakeMaxRecords int64
+	// DeferPartialQueryFailures disables reporting partial query failures as part of the results.
+	DeferPartialQueryFailures bool
+	// BestEffort skips the parts of the data that can't be read, such as unreachable shards, see BestEffort.
+	BestEffort bool
+	// MaxMemoryConsumptionPerQueryPerNode is the maximum amount of memory, in bytes, a query may allocate per node.
+	MaxMemoryConsumptionPerQueryPerNode uint64
+	// MaxMemoryConsumptionPerIterator is the maximum amount of memory, in bytes, a query operator may allocate.
+	MaxMemoryConsumptionPerIterator uint64
+	// MaxOutputColumns is the maximum number of columns a query may produce.
+	MaxOutputColumns int
+	// QueryDateTimeScopeColumn, QueryDateTimeScopeFrom and QueryDateTimeScopeTo filter the query on a datetime column.
+	QueryDateTimeScopeColumn string
+	QueryDateTimeScopeFrom   time.Time
+	QueryDateTimeScopeTo     time.Time
+	// QueryDataScope is whether the query applies to all data, or only to the hot cache.
+	QueryDataScope DataScope
+	// QueryConsistency is the consistency of the query, such as "strongconsistency" or "weakconsistency".
+	QueryConsistency string
+	// QueryNow overrides the value returned by now() in the query.
+	QueryNow time.Time
+	// QueryResultsCacheMaxAge is the maximum age of the cached results the cluster may return.
+	QueryResultsCacheMaxAge time.Duration
+	// ResultsProgressiveEnabled enables progressive results, see also KeepAlive.
+	ResultsProgressiveEnabled bool
+	// QueryFanoutNodesPercent and QueryFanoutThreadsPercent are the percentages of nodes and threads to fan the query
+	// out to.
+	QueryFanoutNodesPercent   int
+	QueryFanoutThreadsPercent int
+	// RequestAppName and RequestDescription are reported in `.show queries`.
+	RequestAppName     string
+	RequestDescription string
+	// RequestUser is the user reported in `.show queries`.
+	RequestUser string
+	// RequestReadonly prevents the request from writing anything.
+	RequestReadonly bool
+	// RequestCalloutDisabled, RequestExternalTableDisabled, RequestRemoteEntitiesDisabled and
+	// RequestSandboxedExecutionDisabled prevent the request from using callouts, external tables, remote entities and
+	// sandboxes.
+	RequestCalloutDisabled            bool
+	RequestExternalTableDisabled      bool
+	RequestRemoteEntitiesDisabled     bool
+	RequestSandboxedExecutionDisabled bool
+	// Options holds properties that don't have a field, by name, as set by CustomQueryOption.
+	Options map[string]interface{}
+}
+
+// RequestProperties sets the client request properties of the call. It can be combined with the other options, the
+// last option setting a property wins.
+func RequestProperties(p ClientRequestProperties) QueryOption {
+	return func(q *queryOptions) error {
+		for _, o := range p.options() {
+			if err := o(q); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// options returns the options setting the non-zero properties.
+func (p ClientRequestProperties) options() []QueryOption {
+	var options []QueryOption
+	add := func(set bool, o QueryOption) {
+		if set {
+			options = append(options, o)
+		}
+	}
+	add(p.ServerTimeout > 0, ServerTimeout(p.ServerTimeout))
+	add(p.NoRequestTimeout, NoRequestTimeout())
+	add(p.NoTruncation, NoTruncation())
+	add(p.TruncationMaxRecords > 0, TruncationMaxRecords(p.TruncationMaxRecords))
+	add(p.TruncationMaxSize > 0, TruncationMaxSize(p.TruncationMaxSize))
+	add(p.QueryTakeMaxRecords > 0, QueryTakeMaxRecords(p.QueryTakeMaxRecords))
+	add(p.DeferPartialQueryFailures, DeferPartialQueryFailures())
+	add(p.BestEffort, BestEffort())
+	add(p.MaxMemoryConsumptionPerQueryPerNode > 0, MaxMemoryConsumptionPerQueryPerNode(p.MaxMemoryConsumptionPerQueryPerNode))
+	add(p.MaxMemoryConsumptionPerIterator > 0, MaxMemoryConsumptionPerIterator(p.MaxMemoryConsumptionPerIterator))
+	add(p.MaxOutputColumns > 0, MaxOutputColumns(p.MaxOutputColumns))
+	add(p.QueryDateTimeScopeColumn != "", QueryDateTimeScopeColumn(p.QueryDateTimeScopeColumn))
+	add(!p.QueryDateTimeScopeFrom.IsZero(), QueryDateTimeScopeFrom(p.QueryDateTimeScopeFrom))
+	add(!p.QueryDateTimeScopeTo.IsZero(), QueryDateTimeScopeTo(p.QueryDateTimeScopeTo))
+	add(p.QueryDataScope != nil, QueryDataScope(p.QueryDataScope))
+	add(p.QueryConsistency != "", QueryConsistency(p.QueryConsistency))
+	add(!p.QueryNow.IsZero(), QueryNow(p.QueryNow))
+	add(p.QueryResultsCacheMaxAge > 0, QueryResultsCacheMaxAge(p.QueryResultsCacheMaxAge))
+	add(p.ResultsProgressiveEnabled, ResultsProgressiveEnabled())
+	add(p.QueryFanoutNodesPercent > 0, QueryFanoutNodesPercent(p.QueryFanoutNodesPercent))
+	add(p.QueryFanoutThreadsPercent > 0, QueryFanoutThreadsPercent(p.QueryFanoutThreadsPercent))
+	add(p.RequestAppName != "", RequestAppName(p.RequestAppName))
+	add(p.RequestDescription != "", RequestDescription(p.RequestDescription))
+	add(p.RequestUser != "", RequestUser(p.RequestUser))
+	add(p.RequestReadonly, RequestReadonly())
+	add(p.RequestCalloutDisabled, RequestCalloutDisabled())
+	add(p.RequestExternalTableDisabled, RequestExternalTableDisabled())
+	add(p.RequestRemoteEntitiesDisabled, RequestRemoteEntitiesDisabled())
+	add(p.RequestSandboxedExecutionDisabled, RequestSandboxedExecutionDisabled())
+	for name, value := range p.Options {
+		options = append(options, CustomQueryOption(name, value))
+	}
+	return options
+}
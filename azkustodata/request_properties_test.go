@@ -0,0 +1,83 @@
+package azkustodata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestProperties(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		properties ClientRequestProperties
+		options    []QueryOption
+		expected   map[string]interface{}
+	}{
+		{
+			name:     "Empty",
+			expected: map[string]interface{}{},
+		},
+		{
+			name: "Fields",
+			properties: ClientRequestProperties{
+				ServerTimeout:                       10 * time.Minute,
+				NoTruncation:                        true,
+				TruncationMaxRecords:                100,
+				DeferPartialQueryFailures:           true,
+				BestEffort:                          true,
+				MaxMemoryConsumptionPerQueryPerNode: 1 << 30,
+				QueryDateTimeScopeColumn:            "Timestamp",
+				QueryDateTimeScopeFrom:              now,
+				QueryDataScope:                      DSHotCache,
+				QueryNow:                            now,
+				RequestReadonly:                     true,
+				ResultsProgressiveEnabled:           true,
+				Options:                             map[string]interface{}{"query_language": "sql"},
+			},
+			expected: map[string]interface{}{
+				ServerTimeoutValue:                       "00:10:00",
+				NoTruncationValue:                        true,
+				TruncationMaxRecordsValue:                int64(100),
+				DeferPartialQueryFailuresValue:           true,
+				BestEffortValue:                          true,
+				MaxMemoryConsumptionPerQueryPerNodeValue: uint64(1 << 30),
+				QueryDateTimeScopeColumnValue:            "Timestamp",
+				QueryDateTimeScopeFromValue:              "2024-01-02T03:04:05Z",
+				QueryDatascopeValue:                      "hotcache",
+				QueryNowValue:                            "2024-01-02T03:04:05Z",
+				RequestReadonlyValue:                     true,
+				ResultsProgressiveEnabledValue:           true,
+				QueryLanguageValue:                       "sql",
+			},
+		},
+		{
+			name:       "LastOptionWins",
+			properties: ClientRequestProperties{TruncationMaxSize: 10, RequestAppName: "app"},
+			options:    []QueryOption{TruncationMaxSize(20)},
+			expected: map[string]interface{}{
+				TruncationMaxSizeValue: int64(20),
+				RequestAppNameValue:    "app",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			opts, err := setQueryOptions(context.Background(), errors.OpQuery, kql.New("test"), queryCall,
+				append([]QueryOption{NoRequestTimeout(), RequestProperties(tt.properties)}, tt.options...)...)
+			require.NoError(t, err)
+
+			delete(opts.requestProperties.Options, NoRequestTimeoutValue)
+			assert.Equal(t, tt.expected, opts.requestProperties.Options)
+		})
+	}
+}
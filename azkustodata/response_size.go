@@ -0,0 +1,71 @@
+package azkustodata
+
+import (
+	"fmt"
+	"io"
+)
+
+// ResultTooLargeError is returned when reading a response was aborted because it exceeded the MaxResponseBytes limit.
+type ResultTooLargeError struct {
+	// Limit is the maximum size of the response, in bytes.
+	Limit int64
+}
+
+func (e *ResultTooLargeError) Error() string {
+	return fmt.Sprintf("result too large: the response exceeded the limit of %d bytes, consider limiting the results of the query, for example with `| take`", e.Limit)
+}
+
+// WithMaxResponseBytes sets the maximum size, in bytes, of the responses to queries and commands. Reading a response is
+// aborted once it exceeds the limit, and the call fails with a *ResultTooLargeError, instead of running out of memory when a
+// query returns more rows than expected. It can be overridden for a single call with the MaxResponseBytes query option.
+// Zero, the default, means no limit.
+func WithMaxResponseBytes(limit int64) Option {
+	return func(c *Client) {
+		if limit < 0 {
+			limit = 0
+		}
+		c.maxResponseBytes = limit
+	}
+}
+
+// MaxResponseBytes overrides the client's maximum response size for this call, see WithMaxResponseBytes. Zero means no
+// limit.
+func MaxResponseBytes(limit int64) QueryOption {
+	return func(q *queryOptions) error {
+		if limit < 0 {
+			return fmt.Errorf("max response bytes cannot be negative, got %d", limit)
+		}
+		q.maxResponseBytes = limit
+		return nil
+	}
+}
+
+// limitedReader reads a response body, and closes it with a *ResultTooLargeError once more than limit bytes were read.
+type limitedReader struct {
+	io.ReadCloser
+	limit int64
+	read  int64
+	err   error
+}
+
+func newLimitedReader(body io.ReadCloser, limit int64) io.ReadCloser {
+	if limit <= 0 {
+		return body
+	}
+	return &limitedReader{ReadCloser: body, limit: limit}
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	if r.read > r.limit {
+		// Closing the body aborts the download of the rest of the response.
+		_ = r.ReadCloser.Close()
+		r.err = &ResultTooLargeError{Limit: r.limit}
+		return n - int(r.read-r.limit), r.err
+	}
+	return n, err
+}
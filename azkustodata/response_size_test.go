@@ -0,0 +1,47 @@
+package azkustodata
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const responseSizeTestHeader = `[{"FrameType":"DataSetHeader","IsProgressive":false,"Version":"v2.0","IsFragmented":true,"ErrorReportingPlacement":"EndOfTable"}
+,{"FrameType":"TableHeader","TableId":1,"TableKind":"PrimaryResult","TableName":"T","Columns":[{"ColumnName":"x","ColumnType":"long"}]}
+`
+
+func TestMaxResponseBytes(t *testing.T) {
+	rows := strings.Repeat("[1],", 10000)
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/rest/query":
+			_, _ = w.Write([]byte(responseSizeTestHeader + `,{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[` + rows + "[1]]}\n"))
+		default:
+			_, _ = w.Write([]byte(verifyTestShowVersion))
+		}
+	})
+
+	client := newTestKustoClient(t, srv, WithMaxResponseBytes(int64(len(responseSizeTestHeader)+100)))
+
+	var tooLarge *ResultTooLargeError
+	_, err := client.Query(context.Background(), "db", kql.New("T"))
+	require.True(t, errors.As(err, &tooLarge), "unexpected error: %v", err)
+	assert.Equal(t, int64(len(responseSizeTestHeader)+100), tooLarge.Limit)
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"), MaxResponseBytes(10))
+	require.True(t, errors.As(err, &tooLarge), "unexpected error: %v", err)
+	assert.Equal(t, int64(10), tooLarge.Limit)
+
+	// The limit can be lifted for a single call.
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"), MaxResponseBytes(0))
+	require.NoError(t, err)
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"), MaxResponseBytes(-1))
+	assert.Error(t, err)
+}
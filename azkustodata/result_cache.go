@@ -0,0 +1,245 @@
+package azkustodata
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+)
+
+const (
+	// defaultResultCacheMaxEntries is the number of results kept by the result cache, by default.
+	defaultResultCacheMaxEntries = 100
+	// defaultResultCacheTTL is how long results are served from the result cache, by default.
+	defaultResultCacheTTL = time.Minute
+)
+
+// ResultCacheOptions configures the in-process cache of query results, see WithResultCache.
+type ResultCacheOptions struct {
+	// MaxEntries is the number of results kept, the least recently used ones being evicted first. Defaults to 100.
+	MaxEntries int
+	// TTL is how long a result is served from the cache after it was read from the cluster. Defaults to 1 minute.
+	TTL time.Duration
+	// ServerMaxAge, if positive, is sent as the query_results_cache_max_age request property of the queries that don't
+	// set QueryResultsCacheMaxAge, so the cluster may also return results from its own cache.
+	ServerMaxAge time.Duration
+}
+
+// WithResultCache caches the results of Query in the process, so identical queries, such as those of dashboards
+// refreshing, don't all reach the cluster. Results are keyed by database, statement, with its whitespace normalized,
+// parameters and the other request properties, such as QueryNow or RequestUser. Failed queries, queries run with
+// SpillToDisk and queries sent with their own Credential or UserToken aren't cached, and SkipResultCache bypasses the
+// cache for a call.
+// A cached dataset is returned to all the callers of the same query, which must not modify it. IterativeQuery and Mgmt
+// are not cached.
+func WithResultCache(options ResultCacheOptions) Option {
+	return func(c *Client) {
+		c.resultCache = newResultCache(options)
+	}
+}
+
+// SkipResultCache reads the results of a query from the cluster, even if they are in the cache set with
+// WithResultCache. The results read replace the cached ones.
+func SkipResultCache() QueryOption {
+	return func(q *queryOptions) error {
+		q.skipResultCache = true
+		return nil
+	}
+}
+
+type resultCacheKey struct {
+	db         string
+	statement  string
+	parameters string
+	// properties holds the other request properties that change the results, such as the request options and user.
+	properties string
+}
+
+// resultCacheIgnoredOptions are the request options that don't change the results of a query. The servertimeout
+// follows the deadline of each call, and the server cache hint only allows older results.
+var resultCacheIgnoredOptions = map[string]bool{ServerTimeoutValue: true, QueryResultsCacheMaxAgeValue: true}
+
+// newResultCacheKey returns the key of the results of a query.
+func newResultCacheKey(db string, kqlQuery Statement, properties *requestProperties) (resultCacheKey, error) {
+	parameters, err := json.Marshal(properties.Parameters)
+	if err != nil {
+		return resultCacheKey{}, errors.ES(errors.OpQuery, errors.KClientArgs, "could not marshal the query parameters: %s", err).SetNoRetry()
+	}
+
+	options := make(map[string]interface{}, len(properties.Options))
+	for k, v := range properties.Options {
+		if !resultCacheIgnoredOptions[k] {
+			options[k] = v
+		}
+	}
+	// Maps are marshaled with their keys sorted, so equal properties have equal keys.
+	others, err := json.Marshal(struct {
+		Options     map[string]interface{}
+		Application string
+		User        string
+		Headers     http.Header
+	}{options, properties.Application, properties.User, properties.Headers})
+	if err != nil {
+		return resultCacheKey{}, errors.ES(errors.OpQuery, errors.KClientArgs, "could not marshal the request properties: %s", err).SetNoRetry()
+	}
+
+	return resultCacheKey{db: db, statement: normalizeStatement(kqlQuery.String()), parameters: string(parameters), properties: string(others)}, nil
+}
+
+type resultCacheEntry struct {
+	key     resultCacheKey
+	dataset query.Dataset
+	expires time.Time
+}
+
+// resultCache is a LRU cache of query results, with a TTL.
+type resultCache struct {
+	options ResultCacheOptions
+	now     func() time.Time
+
+	lock    sync.Mutex
+	entries map[resultCacheKey]*list.Element
+	// lru holds the *resultCacheEntry, the most recently used first.
+	lru *list.List
+}
+
+func newResultCache(options ResultCacheOptions) *resultCache {
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = defaultResultCacheMaxEntries
+	}
+	if options.TTL <= 0 {
+		options.TTL = defaultResultCacheTTL
+	}
+	return &resultCache{options: options, now: time.Now, entries: make(map[resultCacheKey]*list.Element), lru: list.New()}
+}
+
+// get returns the cached result of a query, if it hasn't expired.
+func (r *resultCache) get(key resultCacheKey) (query.Dataset, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	elem, ok := r.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*resultCacheEntry)
+	if !r.now().Before(entry.expires) {
+		r.lru.Remove(elem)
+		delete(r.entries, key)
+		return nil, false
+	}
+	r.lru.MoveToFront(elem)
+	return entry.dataset, true
+}
+
+// put caches the result of a query, evicting the least recently used result if the cache is full.
+func (r *resultCache) put(key resultCacheKey, ds query.Dataset) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	entry := &resultCacheEntry{key: key, dataset: ds, expires: r.now().Add(r.options.TTL)}
+	if elem, ok := r.entries[key]; ok {
+		elem.Value = entry
+		r.lru.MoveToFront(elem)
+		return
+	}
+	r.entries[key] = r.lru.PushFront(entry)
+	if r.lru.Len() > r.options.MaxEntries {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.entries, oldest.Value.(*resultCacheEntry).key)
+	}
+}
+
+// cachedQuery returns the result of a query from the cache, or runs it with run and caches its result.
+func (c *Client) cachedQuery(ctx context.Context, db string, kqlQuery Statement, options []QueryOption, run func() (query.Dataset, error)) (query.Dataset, error) {
+	opts, err := setQueryOptions(ctx, errors.OpQuery, kqlQuery, queryCall, options...)
+	if err != nil {
+		return nil, err
+	}
+	// Spilled results are removed by their caller closing them, so they can't be shared. The results of a request sent
+	// with its own identity may not be visible to the client's, nor to other identities.
+	if opts.spill != nil || opts.requestProperties.Credential != nil || opts.requestProperties.AuthToken != "" {
+		return run()
+	}
+	key, err := newResultCacheKey(c.database(db), kqlQuery, opts.requestProperties)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.skipResultCache {
+		if ds, ok := c.resultCache.get(key); ok {
+			return ds, nil
+		}
+	}
+	ds, err := run()
+	if err != nil {
+		return ds, err
+	}
+	c.resultCache.put(key, ds)
+	return ds, nil
+}
+
+// normalizeStatement trims a statement and collapses its runs of whitespace outside of string literals and comments to a
+// single space, so statements differing only in their formatting share their cached results.
+func normalizeStatement(stmt string) string {
+	var b strings.Builder
+	b.Grow(len(stmt))
+	runes := []rune(strings.TrimSpace(stmt))
+	space := false
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+
+		switch {
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			// A comment runs to the end of the line, which is kept as it ends the comment.
+			for ; i < len(runes) && runes[i] != '\n'; i++ {
+				b.WriteRune(runes[i])
+			}
+			if i < len(runes) {
+				b.WriteByte('\n')
+			}
+		case r == '`' && i+2 < len(runes) && runes[i+1] == '`' && runes[i+2] == '`':
+			// Multi-line strings run to the next ```, their whitespace and new lines included.
+			b.WriteString("```")
+			for i += 3; i < len(runes); i++ {
+				if runes[i] == '`' && i+2 < len(runes) && runes[i+1] == '`' && runes[i+2] == '`' {
+					b.WriteString("```")
+					i += 2
+					break
+				}
+				b.WriteRune(runes[i])
+			}
+		case r == '\'' || r == '"':
+			// Verbatim strings, prefixed with @, have no escapes.
+			verbatim := i > 0 && runes[i-1] == '@'
+			b.WriteRune(r)
+			for i++; i < len(runes); i++ {
+				b.WriteRune(runes[i])
+				if runes[i] == '\\' && !verbatim && i+1 < len(runes) {
+					i++
+					b.WriteRune(runes[i])
+				} else if runes[i] == r {
+					break
+				}
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
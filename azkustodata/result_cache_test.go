@@ -0,0 +1,163 @@
+package azkustodata
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeStatement(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		stmt string
+		want string
+	}{
+		{stmt: "  T\n|  take\t1 \n", want: "T | take 1"},
+		{stmt: "T | where s == 'a  b'", want: "T | where s == 'a  b'"},
+		{stmt: `T | where s == "a\"  b"   | take 1`, want: `T | where s == "a\"  b" | take 1`},
+		{stmt: `T | where s == @'C:\'   | take 1`, want: `T | where s == @'C:\' | take 1`},
+		{stmt: "T // it's   a comment\n  | take 1", want: "T // it's   a comment\n | take 1"},
+		{stmt: "print s = ```a  \n  b```   | take 1", want: "print s = ```a  \n  b``` | take 1"},
+		{stmt: "print s = ```a ` '  b```\n| take 1", want: "print s = ```a ` '  b``` | take 1"},
+		{stmt: "T | where s == h'a  b'  | take 1", want: "T | where s == h'a  b' | take 1"},
+		{stmt: `T | where s == @"C:\  x"   | take 1`, want: `T | where s == @"C:\  x" | take 1`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, normalizeStatement(tt.stmt), tt.stmt)
+	}
+}
+
+func TestResultCacheEviction(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newResultCache(ResultCacheOptions{MaxEntries: 2, TTL: time.Minute})
+	cache.now = func() time.Time { return now }
+	ds := query.NewDataset(query.NewBaseDataset(context.Background(), 0, "PrimaryResult"), nil)
+	a, b, c := resultCacheKey{statement: "a"}, resultCacheKey{statement: "b"}, resultCacheKey{statement: "c"}
+
+	cache.put(a, ds)
+	cache.put(b, ds)
+	_, ok := cache.get(a)
+	assert.True(t, ok)
+
+	// b is the least recently used.
+	cache.put(c, ds)
+	_, ok = cache.get(b)
+	assert.False(t, ok)
+	_, ok = cache.get(a)
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = cache.get(c)
+	assert.False(t, ok)
+}
+
+func TestWithResultCache(t *testing.T) {
+	var lock sync.Mutex
+	var maxAges []interface{}
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Properties struct {
+				Options map[string]interface{} `json:"Options"`
+			} `json:"properties"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		lock.Lock()
+		maxAges = append(maxAges, msg.Properties.Options[QueryResultsCacheMaxAgeValue])
+		lock.Unlock()
+		_, _ = w.Write([]byte(keepAliveTestResponse))
+	})
+
+	client := newTestKustoClient(t, srv, WithResultCache(ResultCacheOptions{ServerMaxAge: 5 * time.Minute}))
+	ctx := context.Background()
+
+	first, err := client.Query(ctx, "db", kql.New("T | take 2"))
+	require.NoError(t, err)
+	second, err := client.Query(ctx, "db", kql.New("T\n| take 2"))
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	// Other databases and parameters are cached apart.
+	_, err = client.Query(ctx, "other", kql.New("T | take 2"))
+	require.NoError(t, err)
+	_, err = client.Query(ctx, "db", kql.New("T | take 2"), QueryParameters(kql.NewParameters().AddString("p", "v")))
+	require.NoError(t, err)
+
+	skipped, err := client.Query(ctx, "db", kql.New("T | take 2"), SkipResultCache(), QueryResultsCacheMaxAge(time.Hour))
+	require.NoError(t, err)
+	assert.NotSame(t, first, skipped)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []interface{}{"00:05:00", "00:05:00", "00:05:00", "01:00:00"}, maxAges)
+}
+
+func TestResultCacheKeyedByRequest(t *testing.T) {
+	var lock sync.Mutex
+	var authorizations []string
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		lock.Unlock()
+		_, _ = w.Write([]byte(keepAliveTestResponse))
+	})
+
+	client := newTestKustoClient(t, srv, WithResultCache(ResultCacheOptions{}))
+	ctx := context.Background()
+	stmt := kql.New("T | take 2")
+	requests := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(authorizations)
+	}
+
+	// Each call's deadline sets its servertimeout, which doesn't change the results.
+	first, err := client.Query(ctx, "db", stmt)
+	require.NoError(t, err)
+	deadlineCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	cached, err := client.Query(deadlineCtx, "db", stmt)
+	require.NoError(t, err)
+	assert.Same(t, first, cached)
+	assert.Equal(t, 1, requests())
+
+	// Request properties changing the results are cached apart.
+	now, err := client.Query(ctx, "db", stmt, QueryNow(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	require.NoError(t, err)
+	assert.NotSame(t, first, now)
+	user, err := client.Query(ctx, "db", stmt, RequestUser("other"))
+	require.NoError(t, err)
+	assert.NotSame(t, first, user)
+	assert.Equal(t, 3, requests())
+
+	// Requests sent with their own identity are never cached, nor served from the cache.
+	a, err := client.Query(ctx, "db", stmt, Credential(&fakeCredential{}))
+	require.NoError(t, err)
+	b, err := client.Query(ctx, "db", stmt, Credential(&fakeCredential{}))
+	require.NoError(t, err)
+	token, err := client.Query(ctx, "db", stmt, UserToken("user-token"))
+	require.NoError(t, err)
+	assert.NotSame(t, first, a)
+	assert.NotSame(t, a, b)
+	assert.NotSame(t, first, token)
+	assert.Equal(t, 6, requests())
+	lock.Lock()
+	assert.Equal(t, "Bearer user-token", authorizations[5])
+	lock.Unlock()
+
+	again, err := client.Query(ctx, "db", stmt)
+	require.NoError(t, err)
+	assert.Same(t, first, again)
+	assert.Equal(t, 6, requests())
+}
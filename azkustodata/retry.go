@@ -0,0 +1,124 @@
+package azkustodata
+
+import (
+	"context"
+	stdErrors "errors"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+)
+
+// RetryPolicy configures how Query and Mgmt calls are retried on transient failures, such as network errors and 5xx
+// responses. Retries back off exponentially, with full jitter: the n-th retry waits a random duration between zero and
+// BaseDelay * 2^(n-1), capped at MaxDelay.
+//
+// Only requests that are safe to repeat are retried: queries, and management commands that don't change state, which are
+// the ones starting with ".show". Set RetryAllCommands to retry every management command.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one. Values lower than 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled for each following retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries.
+	MaxDelay time.Duration
+	// RetryableStatusCodes are the HTTP status codes that are retried. Defaults to 500, 502, 503 and 504 when empty.
+	RetryableStatusCodes []int
+	// ShouldRetry, if set, decides which errors are retried instead of RetryableStatusCodes.
+	ShouldRetry func(err error) bool
+	// RetryAllCommands retries management commands that may change state, such as ".ingest inline" or ".append", which
+	// can be applied more than once if the failed attempt reached the cluster.
+	RetryAllCommands bool
+}
+
+// defaultRetryableStatusCodes are the status codes retried when a RetryPolicy doesn't set its own.
+var defaultRetryableStatusCodes = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// DefaultRetryPolicy returns a RetryPolicy making up to 3 attempts, starting with a 1 second delay, capped at 30 seconds.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+// WithRetryPolicy configures the client to retry Query and Mgmt calls that fail with transient errors.
+// By default, calls are not retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = &policy
+	}
+}
+
+// retryable returns whether the request running the given query or command may be retried.
+func (p *RetryPolicy) retryable(execType int, query string) bool {
+	if p == nil || p.MaxAttempts < 2 {
+		return false
+	}
+	if execType == execQuery || p.RetryAllCommands {
+		return true
+	}
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(query)), ".show")
+}
+
+// shouldRetry returns whether err, returned by the given attempt (counting from 1), should be retried.
+func (p *RetryPolicy) shouldRetry(ctx context.Context, attempt int, err error) bool {
+	if attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+	if p.ShouldRetry != nil {
+		return p.ShouldRetry(err)
+	}
+
+	var httpErr *errors.HttpError
+	if stdErrors.As(err, &httpErr) {
+		codes := p.RetryableStatusCodes
+		if len(codes) == 0 {
+			codes = defaultRetryableStatusCodes
+		}
+		for _, code := range codes {
+			if httpErr.StatusCode == code {
+				return errors.Retry(&httpErr.KustoError)
+			}
+		}
+		return false
+	}
+
+	// Network errors, the request may not have reached the cluster at all.
+	var kustoErr *errors.Error
+	return stdErrors.As(err, &kustoErr) && kustoErr.Kind == errors.KHTTPError
+}
+
+// delay returns how long to wait before the given retry (counting from 1).
+func (p *RetryPolicy) delay(retry int) time.Duration {
+	backoff := p.MaxDelay
+	if shift := retry - 1; shift < 32 {
+		if d := p.BaseDelay << shift; d > 0 && (p.MaxDelay <= 0 || d < p.MaxDelay) {
+			backoff = d
+		}
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
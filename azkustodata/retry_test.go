@@ -0,0 +1,83 @@
+package azkustodata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFlakyTestServer returns a server that fails the first failures requests to the mgmt endpoint with code.
+func newFlakyTestServer(failures int32, code int, attempts *atomic.Int32) *httptest.Server {
+	return httptest.NewTLSServer(testKustoHandler(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= failures {
+			w.WriteHeader(code)
+			_, _ = w.Write([]byte(`{"error": {"code": "ServiceUnavailable", "message": "try again"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(verifyTestShowVersion))
+	}))
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	withAllCommands := policy
+	withAllCommands.RetryAllCommands = true
+	withPredicate := policy
+	withPredicate.ShouldRetry = func(err error) bool { return false }
+
+	tests := []struct {
+		name         string
+		policy       *RetryPolicy
+		command      string
+		failures     int32
+		code         int
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{name: "NoPolicy", command: ".show version", failures: 1, code: http.StatusServiceUnavailable, wantAttempts: 1, wantErr: true},
+		{name: "Recovers", policy: &policy, command: ".show version", failures: 2, code: http.StatusServiceUnavailable, wantAttempts: 3},
+		{name: "Exhausted", policy: &policy, command: ".show version", failures: 3, code: http.StatusBadGateway, wantAttempts: 3, wantErr: true},
+		{name: "NotRetryableStatus", policy: &policy, command: ".show version", failures: 1, code: http.StatusBadRequest, wantAttempts: 1, wantErr: true},
+		{name: "StateChangingCommand", policy: &policy, command: ".append T <| print 1", failures: 1, code: http.StatusServiceUnavailable, wantAttempts: 1, wantErr: true},
+		{name: "RetryAllCommands", policy: &withAllCommands, command: ".append T <| print 1", failures: 1, code: http.StatusServiceUnavailable, wantAttempts: 2},
+		{name: "Predicate", policy: &withPredicate, command: ".show version", failures: 1, code: http.StatusServiceUnavailable, wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := newFlakyTestServer(tt.failures, tt.code, &attempts)
+			defer srv.Close()
+
+			var options []Option
+			if tt.policy != nil {
+				options = append(options, WithRetryPolicy(*tt.policy))
+			}
+			client := newTestKustoClient(t, srv, options...)
+
+			_, err := client.Mgmt(context.Background(), "db", kql.New("").AddUnsafe(tt.command))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantAttempts, attempts.Load())
+		})
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for retry := 1; retry <= 70; retry++ {
+		d := policy.delay(retry)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		assert.LessOrEqual(t, d, time.Second)
+	}
+	assert.LessOrEqual(t, policy.delay(1), 100*time.Millisecond)
+}
@@ -0,0 +1,187 @@
+package azkustodata
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+)
+
+// RowIterator iterates over the rows of the primary results of a query, decoding them as they are read from the
+// connection. Only a bounded number of frames and rows are buffered, see V2FrameCapacity, V2FragmentCapacity and
+// V2RowCapacity, so results of any size can be processed in constant memory.
+//
+//	rows, err := client.QueryRows(ctx, "database", query)
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//	for rows.Next() {
+//		row := rows.Row()
+//		...
+//	}
+//	if err := rows.Err(); err != nil {
+//		return err
+//	}
+//
+// A RowIterator must be closed once done with, and is not safe for concurrent use.
+type RowIterator struct {
+	dataset query.IterativeDataset
+	table   query.IterativeTable
+	rows    <-chan query.RowResult
+	row     query.Row
+	err     error
+	closed  bool
+}
+
+// QueryRows runs a query, and returns an iterator over the rows of its primary results.
+// Progressive results replacing rows, see ProgressiveResults, are not supported and fail the iteration.
+func (c *Client) QueryRows(ctx context.Context, db string, kqlQuery Statement, options ...QueryOption) (*RowIterator, error) {
+	dataset, err := c.IterativeQuery(ctx, db, kqlQuery, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &RowIterator{dataset: dataset}, nil
+}
+
+// Next advances to the next row, and returns false once there are no more rows, or an error occurred. Err returns the error.
+func (r *RowIterator) Next() bool {
+	r.row = nil
+	if r.err != nil || r.closed {
+		return false
+	}
+	for {
+		if r.rows != nil {
+			result, ok := <-r.rows
+			if ok {
+				if result.Err() != nil {
+					r.err = result.Err()
+					if r.err == query.ErrRowsReplaced {
+						r.err = fmt.Errorf("QueryRows doesn't support progressive results: %w", r.err)
+					}
+					return false
+				}
+				r.row = result.Row()
+				return true
+			}
+			r.table, r.rows = nil, nil
+		}
+
+		tableResult, ok := <-r.dataset.Tables()
+		if !ok {
+			return false
+		}
+		if tableResult.Err() != nil {
+			r.err = tableResult.Err()
+			return false
+		}
+		if tableResult.Table().IsPrimaryResult() {
+			r.table, r.rows = tableResult.Table(), tableResult.Table().Rows()
+		}
+	}
+}
+
+// Row returns the current row.
+func (r *RowIterator) Row() query.Row {
+	return r.row
+}
+
+// Table returns the table of the current row.
+func (r *RowIterator) Table() query.IterativeTable {
+	return r.table
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (r *RowIterator) Err() error {
+	return r.err
+}
+
+// Close stops the iteration, and closes the response if it wasn't fully read. It can be called several times.
+func (r *RowIterator) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	err := r.dataset.Close()
+	// Once the response is closed, the frames and rows already decoded are discarded, so the goroutines decoding them end.
+	go func(rows <-chan query.RowResult, tables <-chan query.TableResult) {
+		if rows != nil {
+			for range rows {
+			}
+		}
+		for tableResult := range tables {
+			if tableResult.Table() != nil {
+				for range tableResult.Table().Rows() {
+				}
+			}
+		}
+	}(r.rows, r.dataset.Tables())
+	r.table, r.rows, r.row = nil, nil, nil
+	return err
+}
+
+// StructIterator iterates over the rows of the primary results of a query, decoded into structs of type T as with
+// query.ToStructs. Like RowIterator, it buffers a bounded number of rows, so results of any size can be processed in
+// constant memory.
+//
+//	events, err := azkustodata.IterateStructs[Event](ctx, client, "database", query)
+//	if err != nil {
+//		return err
+//	}
+//	defer events.Close()
+//	for events.Next() {
+//		event := events.Value()
+//		...
+//	}
+//	if err := events.Err(); err != nil {
+//		return err
+//	}
+//
+// A StructIterator must be closed once done with, and is not safe for concurrent use.
+type StructIterator[T any] struct {
+	rows  *RowIterator
+	value T
+	err   error
+}
+
+// IterateStructs runs a query, and returns an iterator decoding the rows of its primary results into structs of type T
+// one at a time, instead of reading the full dataset first.
+func IterateStructs[T any](ctx context.Context, client *Client, db string, kqlQuery Statement, options ...QueryOption) (*StructIterator[T], error) {
+	rows, err := client.QueryRows(ctx, db, kqlQuery, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &StructIterator[T]{rows: rows}, nil
+}
+
+// Next decodes the next row, and returns false once there are no more rows, or an error occurred. Err returns the error.
+func (s *StructIterator[T]) Next() bool {
+	var zero T
+	s.value = zero
+	if s.err != nil || !s.rows.Next() {
+		return false
+	}
+	if err := s.rows.Row().ToStruct(&s.value); err != nil {
+		s.err = err
+		return false
+	}
+	return true
+}
+
+// Value returns the current struct.
+func (s *StructIterator[T]) Value() T {
+	return s.value
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (s *StructIterator[T]) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.rows.Err()
+}
+
+// Close stops the iteration, and closes the response if it wasn't fully read. It can be called several times.
+func (s *StructIterator[T]) Close() error {
+	return s.rows.Close()
+}
@@ -0,0 +1,104 @@
+package azkustodata
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rowsTestResponse returns a response with two primary tables of the given number of fragments of 100 rows.
+func rowsTestResponse(fragments int) string {
+	var b strings.Builder
+	b.WriteString(`[{"FrameType":"DataSetHeader","IsProgressive":false,"Version":"v2.0","IsFragmented":true,"ErrorReportingPlacement":"EndOfTable"}` + "\n")
+	for table := 1; table <= 2; table++ {
+		fmt.Fprintf(&b, `,{"FrameType":"TableHeader","TableId":%d,"TableKind":"PrimaryResult","TableName":"T%d","Columns":[{"ColumnName":"x","ColumnType":"long"}]}`+"\n", table, table)
+		for f := 0; f < fragments; f++ {
+			rows := make([]string, 100)
+			for i := range rows {
+				rows[i] = fmt.Sprintf("[%d]", f*100+i)
+			}
+			fmt.Fprintf(&b, `,{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":%d,"Rows":[%s]}`+"\n", table, strings.Join(rows, ","))
+		}
+		fmt.Fprintf(&b, `,{"FrameType":"TableCompletion","TableId":%d,"RowCount":%d}`+"\n", table, fragments*100)
+	}
+	b.WriteString(`,{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}` + "\n]")
+	return b.String()
+}
+
+func TestQueryRows(t *testing.T) {
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/rest/query" {
+			_, _ = w.Write([]byte(rowsTestResponse(100)))
+		}
+	})
+
+	client := newTestKustoClient(t, srv)
+
+	rows, err := client.QueryRows(context.Background(), "db", kql.New("T"), V2RowCapacity(10), V2FrameCapacity(2))
+	require.NoError(t, err)
+	count := map[string]int{}
+	for rows.Next() {
+		x, err := rows.Row().LongByIndex(0)
+		require.NoError(t, err)
+		require.Equal(t, int64(count[rows.Table().Name()]), *x)
+		count[rows.Table().Name()]++
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	assert.Equal(t, map[string]int{"T1": 10000, "T2": 10000}, count)
+	assert.False(t, rows.Next())
+
+	// Closing the iterator early closes the response, ending the call.
+	rows, err = client.QueryRows(context.Background(), "db", kql.New("T"), V2RowCapacity(10), V2FrameCapacity(2))
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.True(t, rows.Next())
+	}
+	require.NoError(t, rows.Close())
+	require.NoError(t, rows.Close())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, client.Shutdown(ctx))
+}
+
+func TestIterateStructs(t *testing.T) {
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/rest/query" {
+			_, _ = w.Write([]byte(rowsTestResponse(10)))
+		}
+	})
+
+	client := newTestKustoClient(t, srv)
+
+	type record struct {
+		X int64 `kusto:"x"`
+	}
+	records, err := IterateStructs[record](context.Background(), client, "db", kql.New("T"), V2RowCapacity(10))
+	require.NoError(t, err)
+	count := 0
+	for records.Next() {
+		require.Equal(t, int64(count%1000), records.Value().X)
+		count++
+	}
+	require.NoError(t, records.Err())
+	require.NoError(t, records.Close())
+	assert.Equal(t, 2000, count)
+
+	// Decoding errors stop the iteration.
+	type wrongType struct {
+		X time.Time `kusto:"x"`
+	}
+	wrong, err := IterateStructs[wrongType](context.Background(), client, "db", kql.New("T"))
+	require.NoError(t, err)
+	defer wrong.Close()
+	assert.False(t, wrong.Next())
+	assert.ErrorContains(t, wrong.Err(), "column x of type long")
+}
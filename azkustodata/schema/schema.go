@@ -0,0 +1,142 @@
+/*
+Package schema retrieves and models the schema of Kusto databases: their tables, with the columns' types, docstrings and
+folders, materialized views and functions, as reported by `.show database schema as json`. It can be used to validate
+queries or generate code against a live schema.
+
+	db, err := schema.GetDatabaseSchema(ctx, client, "database")
+	if err != nil {
+		return err
+	}
+	if table, ok := db.Tables["StormEvents"]; ok {
+		for _, column := range table.Columns {
+			fmt.Println(column.Name, column.Type)
+		}
+	}
+*/
+package schema
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/management"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+)
+
+// Database is the schema of a database.
+type Database struct {
+	Name string
+	// Tables, ExternalTables, MaterializedViews and Functions are keyed by name.
+	Tables            map[string]*Table
+	ExternalTables    map[string]*Table
+	MaterializedViews map[string]*MaterializedView
+	Functions         map[string]*Function
+	// MajorVersion and MinorVersion are the version of the schema, which changes with each of its changes.
+	MajorVersion int
+	MinorVersion int
+}
+
+// Table is the schema of a table.
+type Table struct {
+	Name      string
+	Folder    string
+	DocString string
+	// Columns are the columns of the table, in order.
+	Columns []Column `json:"OrderedColumns"`
+}
+
+// Column returns the column with the given name.
+func (t *Table) Column(name string) (Column, bool) {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// Column is a column of a table.
+type Column struct {
+	Name string
+	// Type is the Kusto type of the column.
+	Type      types.Column `json:"CslType"`
+	DocString string
+}
+
+// MaterializedView is the schema of a materialized view.
+type MaterializedView struct {
+	Table
+	// Source is the table the view aggregates, and Query the aggregation.
+	Source string
+	Query  string
+}
+
+// Function is a stored function.
+type Function struct {
+	Name            string
+	InputParameters []Parameter
+	Body            string
+	Folder          string
+	DocString       string
+}
+
+// Parameter is a parameter of a function. Tabular parameters have Columns instead of a Type.
+type Parameter struct {
+	Name            string
+	Type            types.Column `json:"CslType"`
+	CslDefaultValue string
+	Columns         []Column
+}
+
+// databaseSchemaRow is the row of the results of `.show database schema as json`.
+type databaseSchemaRow struct {
+	DatabaseSchema string
+}
+
+// GetDatabaseSchema returns the schema of a database.
+func GetDatabaseSchema(ctx context.Context, client management.Client, db string, options ...azkustodata.QueryOption) (*Database, error) {
+	cmd := kql.New(".show database ").AddUnsafe(kql.NormalizeName(db)).AddLiteral(" schema as json")
+	// The command only reads, so it can run on read-only clients.
+	ds, err := client.Mgmt(ctx, db, cmd, append([]azkustodata.QueryOption{azkustodata.AllowControlCommands()}, options...)...)
+	if err != nil {
+		return nil, err
+	}
+	var rows []databaseSchemaRow
+	if tables := ds.Tables(); len(tables) > 0 {
+		if rows, err = query.ToStructs[databaseSchemaRow](tables[0]); err != nil {
+			return nil, err
+		}
+	}
+	if len(rows) == 0 {
+		return nil, errors.ES(errors.OpMgmt, errors.KInternal, "the schema of database %s was not returned", db)
+	}
+
+	var schema struct {
+		Databases map[string]*Database
+	}
+	if err := json.Unmarshal([]byte(rows[0].DatabaseSchema), &schema); err != nil {
+		return nil, errors.ES(errors.OpMgmt, errors.KFailedToParse, "could not parse the schema of database %s: %s", db, err)
+	}
+	// The database is keyed by its name, which may differ in case or be a pretty name, so the only one is taken.
+	for _, database := range schema.Databases {
+		return database, nil
+	}
+	return nil, errors.ES(errors.OpMgmt, errors.KInternal, "the schema of database %s was not returned", db)
+}
+
+// GetTableSchema returns the schema of a table.
+func GetTableSchema(ctx context.Context, client management.Client, db string, table string, options ...azkustodata.QueryOption) (*Table, error) {
+	s, err := management.ShowTableSchema(ctx, client, db, table, options...)
+	if err != nil {
+		return nil, err
+	}
+	t := &Table{Name: s.TableName, Folder: s.Folder, DocString: s.DocString, Columns: make([]Column, 0, len(s.Columns))}
+	for _, c := range s.Columns {
+		t.Columns = append(t.Columns, Column{Name: c.Name, Type: c.Type, DocString: c.DocString})
+	}
+	return t, nil
+}
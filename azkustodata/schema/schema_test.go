@@ -0,0 +1,93 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const databaseSchema = `{"Plugins":[],"Databases":{"Samples":{"Name":"Samples","MajorVersion":12,"MinorVersion":3,
+"Tables":{"StormEvents":{"Name":"StormEvents","EntityType":"Table","Folder":"Storm","DocString":"US storms",
+"OrderedColumns":[{"Name":"StartTime","Type":"System.DateTime","CslType":"datetime"},{"Name":"State","Type":"System.String","CslType":"string","DocString":"US state"}]}},
+"ExternalTables":{},
+"MaterializedViews":{"DailyStorms":{"Name":"DailyStorms","Source":"StormEvents","Query":"StormEvents | summarize count() by bin(StartTime, 1d)",
+"OrderedColumns":[{"Name":"StartTime","Type":"System.DateTime","CslType":"datetime"},{"Name":"count_","Type":"System.Int64","CslType":"long"}]}},
+"Functions":{"StormsIn":{"Name":"StormsIn","InputParameters":[{"Name":"state","Type":"System.String","CslType":"string","CslDefaultValue":"\"TEXAS\""}],
+"Body":"{ StormEvents | where State == state }","Folder":"","DocString":""}}}}}`
+
+// fakeClient returns the v1 response of each command.
+type fakeClient map[string]string
+
+func (f fakeClient) Mgmt(ctx context.Context, _ string, kqlQuery azkustodata.Statement, _ ...azkustodata.QueryOption) (v1.Dataset, error) {
+	response, ok := f[kqlQuery.String()]
+	if !ok {
+		return nil, errors.ES(errors.OpMgmt, errors.KHTTPError, "unexpected command %s", kqlQuery.String())
+	}
+	return v1.NewDatasetFromReader(ctx, errors.OpMgmt, io.NopCloser(strings.NewReader(response)))
+}
+
+// singleStringResponse returns a v1 response with a single string cell.
+func singleStringResponse(t *testing.T, column string, s string) string {
+	cell, err := json.Marshal(s)
+	require.NoError(t, err)
+	return `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"` + column + `","ColumnType":"string"}],"Rows":[[` + string(cell) + `]]}]}`
+}
+
+func TestGetDatabaseSchema(t *testing.T) {
+	client := fakeClient{".show database Samples schema as json": singleStringResponse(t, "DatabaseSchema", databaseSchema)}
+
+	db, err := GetDatabaseSchema(context.Background(), client, "Samples")
+	require.NoError(t, err)
+	assert.Equal(t, "Samples", db.Name)
+	assert.Equal(t, 12, db.MajorVersion)
+
+	table := db.Tables["StormEvents"]
+	require.NotNil(t, table)
+	assert.Equal(t, &Table{
+		Name:      "StormEvents",
+		Folder:    "Storm",
+		DocString: "US storms",
+		Columns:   []Column{{Name: "StartTime", Type: types.DateTime}, {Name: "State", Type: types.String, DocString: "US state"}},
+	}, table)
+	column, ok := table.Column("State")
+	assert.True(t, ok)
+	assert.Equal(t, types.String, column.Type)
+	_, ok = table.Column("Missing")
+	assert.False(t, ok)
+
+	view := db.MaterializedViews["DailyStorms"]
+	require.NotNil(t, view)
+	assert.Equal(t, "StormEvents", view.Source)
+	assert.Equal(t, []Column{{Name: "StartTime", Type: types.DateTime}, {Name: "count_", Type: types.Long}}, view.Columns)
+
+	function := db.Functions["StormsIn"]
+	require.NotNil(t, function)
+	assert.Equal(t, []Parameter{{Name: "state", Type: types.String, CslDefaultValue: `"TEXAS"`}}, function.InputParameters)
+	assert.Empty(t, db.ExternalTables)
+
+	_, err = GetDatabaseSchema(context.Background(), client, "Other")
+	assert.Error(t, err)
+}
+
+func TestGetTableSchema(t *testing.T) {
+	tableSchema := `{"Name":"StormEvents","OrderedColumns":[{"Name":"State","Type":"System.String","CslType":"string"}]}`
+	cell, err := json.Marshal(tableSchema)
+	require.NoError(t, err)
+	client := fakeClient{".show table StormEvents schema as json": `{"Tables":[{"TableName":"Table_0","Columns":[` +
+		`{"ColumnName":"TableName","ColumnType":"string"},{"ColumnName":"Schema","ColumnType":"string"},{"ColumnName":"DatabaseName","ColumnType":"string"},` +
+		`{"ColumnName":"Folder","ColumnType":"string"},{"ColumnName":"DocString","ColumnType":"string"}],` +
+		`"Rows":[["StormEvents",` + string(cell) + `,"Samples","Storm",""]]}]}`}
+
+	table, err := GetTableSchema(context.Background(), client, "Samples", "StormEvents")
+	require.NoError(t, err)
+	assert.Equal(t, &Table{Name: "StormEvents", Folder: "Storm", Columns: []Column{{Name: "State", Type: types.String}}}, table)
+}
@@ -0,0 +1,66 @@
+package azkustodata
+
+import (
+	"context"
+	stdErrors "errors"
+	"sync"
+)
+
+// ErrClientShutdown is returned, wrapped, by calls made after Client.Shutdown was called.
+var ErrClientShutdown = stdErrors.New("client is shut down")
+
+// Shutdown gracefully shuts down the client: calls made after it fail with an error wrapping ErrClientShutdown, and it
+// waits for the calls in progress to end, including the reading of the results of IterativeQuery and RawV2, before
+// closing the client like Close. If ctx is done first, Shutdown closes the client anyway and returns ctx's error.
+func (c *Client) Shutdown(ctx context.Context) error {
+	var err error
+	if c.conn != nil {
+		err = c.conn.shutdown(ctx)
+	}
+	if closeErr := c.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// inFlight tracks the calls in progress on a connection, so it can be shut down once they end.
+type inFlight struct {
+	lock     sync.Mutex
+	shutdown bool
+	calls    sync.WaitGroup
+}
+
+// begin registers a call, and returns the function to call once it ended. It fails once the connection is shut down.
+func (f *inFlight) begin() (func(), error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.shutdown {
+		return nil, ErrClientShutdown
+	}
+	f.calls.Add(1)
+	var once sync.Once
+	return func() { once.Do(f.calls.Done) }, nil
+}
+
+// wait rejects new calls, and waits for the calls in progress to end, or for ctx to be done.
+func (f *inFlight) wait(ctx context.Context) error {
+	f.lock.Lock()
+	f.shutdown = true
+	f.lock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.calls.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Conn) shutdown(ctx context.Context) error {
+	return c.inFlight.wait(ctx)
+}
@@ -0,0 +1,38 @@
+package azkustodata
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown(t *testing.T) {
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(verifyTestShowVersion))
+	})
+
+	client := newTestKustoClient(t, srv)
+
+	// Calls that ended don't hold the shutdown.
+	_, err := client.Mgmt(context.Background(), "db", kql.New(".show version"))
+	require.NoError(t, err)
+
+	// A call whose results are still being read does.
+	body, err := client.RawV2(context.Background(), "db", kql.New("T"), nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, client.Shutdown(ctx), context.DeadlineExceeded)
+
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".show version"))
+	assert.ErrorIs(t, err, ErrClientShutdown)
+
+	require.NoError(t, body.Close())
+	assert.NoError(t, client.Shutdown(context.Background()))
+}
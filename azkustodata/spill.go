@@ -0,0 +1,25 @@
+package azkustodata
+
+import (
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+)
+
+// SpillToDisk keeps the rows of the results read at once, by Query or the ToDataset method of IterativeQuery results, in
+// memory up to options.MemoryLimit, and writes the rows read past it to temporary files, so batch jobs can read results
+// larger than the memory. The spilled rows are read back as they are iterated over with query.ReadRows, while the Rows
+// method of a spilled table reads them all back into memory.
+// The files are removed by closing the dataset, which must be done once its rows were processed:
+//
+//	dataset, err := client.Query(ctx, "database", query, azkustodata.SpillToDisk(query.SpillOptions{MemoryLimit: 512 << 20}))
+//	if err != nil {
+//		return err
+//	}
+//	defer dataset.Close()
+//
+// Results read with the v1 protocol are not spilled.
+func SpillToDisk(options query.SpillOptions) QueryOption {
+	return func(q *queryOptions) error {
+		q.spill = &options
+		return nil
+	}
+}
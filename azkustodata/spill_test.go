@@ -0,0 +1,53 @@
+package azkustodata
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpillToDisk(t *testing.T) {
+	requests := 0
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(keepAliveTestResponse))
+	})
+
+	client := newTestKustoClient(t, srv, WithResultCache(ResultCacheOptions{}))
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	ds, err := client.Query(ctx, "db", kql.New("T"), SpillToDisk(query.SpillOptions{MemoryLimit: 1, Dir: dir}))
+	require.NoError(t, err)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "the rows of the primary result are spilled")
+
+	tables := ds.PrimaryResults()
+	require.Len(t, tables, 1)
+	r := query.ReadRows(tables[0])
+	var values []int64
+	for r.Next() {
+		x, err := r.Row().LongByName("x")
+		require.NoError(t, err)
+		values = append(values, *x)
+	}
+	require.NoError(t, r.Err())
+	assert.Equal(t, []int64{1, 2}, values)
+
+	require.NoError(t, ds.Close())
+	files, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	// Spilled results aren't cached, as closing them would break the other callers.
+	_, err = client.Query(ctx, "db", kql.New("T"), SpillToDisk(query.SpillOptions{Dir: dir}))
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+}
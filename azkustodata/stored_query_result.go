@@ -0,0 +1,124 @@
+package azkustodata
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+)
+
+// StoredQueryResultOptions configures a stored query result, see CreateStoredQueryResult.
+type StoredQueryResultOptions struct {
+	// ExpiresAfter is how long the cluster keeps the stored query result. Zero uses the cluster's default of one day.
+	ExpiresAfter time.Duration
+}
+
+// CreateStoredQueryResult runs a query, and stores its results on the cluster under the given name, replacing any stored
+// query result with the same name. A RowNum column numbering the rows from 1 is added to the results, so they can be paged
+// through with a Paginator without rerunning the query. To keep the pages in a stable order, the query should sort its
+// results.
+// See https://learn.microsoft.com/kusto/management/stored-query-results for the limits of stored query results.
+func (c *Client) CreateStoredQueryResult(ctx context.Context, db string, name string, kqlQuery Statement, options StoredQueryResultOptions, queryOptions ...QueryOption) error {
+	if name == "" {
+		return errors.ES(errors.OpMgmt, errors.KClientArgs, "the stored query result name cannot be empty").SetNoRetry()
+	}
+	cmd := kql.New(".set-or-replace stored_query_result ").AddUnsafe(kql.NormalizeName(name)).AddLiteral(" with (previewCount = 0")
+	if options.ExpiresAfter > 0 {
+		cmd.AddLiteral(", expiresAfter = ").AddTimespan(options.ExpiresAfter)
+	}
+	cmd.AddLiteral(") <|\n").AddUnsafe(kqlQuery.String()).AddLiteral("\n| serialize RowNum = row_number()")
+
+	_, err := c.Mgmt(ctx, db, cmd, queryOptions...)
+	return err
+}
+
+// DropStoredQueryResult deletes a stored query result before it expires.
+func (c *Client) DropStoredQueryResult(ctx context.Context, db string, name string, queryOptions ...QueryOption) error {
+	if name == "" {
+		return errors.ES(errors.OpMgmt, errors.KClientArgs, "the stored query result name cannot be empty").SetNoRetry()
+	}
+	_, err := c.Mgmt(ctx, db, kql.New(".drop stored_query_result ").AddUnsafe(kql.NormalizeName(name)), queryOptions...)
+	return err
+}
+
+// Paginator pages through a stored query result created with CreateStoredQueryResult. Each page is a query reading a range
+// of rows of the stored results, so pages are cheap, and can be fetched long after the results were stored.
+//
+//	pages, err := client.NewPaginator("database", "name", 100)
+//	if err != nil {
+//		return err
+//	}
+//	for pages.More() {
+//		page, err := pages.NextPage(ctx)
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// A Paginator is not safe for concurrent use.
+type Paginator struct {
+	client   *Client
+	db       string
+	name     string
+	pageSize int
+	options  []QueryOption
+
+	page int
+	done bool
+}
+
+// NewPaginator returns a Paginator over the stored query result with the given name, returning pages of pageSize rows.
+// The options are used for the query fetching each page.
+func (c *Client) NewPaginator(db string, name string, pageSize int, options ...QueryOption) (*Paginator, error) {
+	if name == "" {
+		return nil, errors.ES(errors.OpQuery, errors.KClientArgs, "the stored query result name cannot be empty").SetNoRetry()
+	}
+	if pageSize <= 0 {
+		return nil, errors.ES(errors.OpQuery, errors.KClientArgs, "the page size must be positive, got %d", pageSize).SetNoRetry()
+	}
+	return &Paginator{client: c, db: db, name: name, pageSize: pageSize, options: options}, nil
+}
+
+// More returns false once a page with fewer rows than the page size was returned. When the number of rows is a multiple of
+// the page size, the last page is empty.
+func (p *Paginator) More() bool {
+	return !p.done
+}
+
+// NextPage fetches the next page, the primary result table of the query reading its rows, including the RowNum column.
+func (p *Paginator) NextPage(ctx context.Context) (query.Table, error) {
+	if p.done {
+		return nil, errors.ES(errors.OpQuery, errors.KClientArgs, "no more pages").SetNoRetry()
+	}
+	table, err := p.Page(ctx, p.page)
+	if err != nil {
+		return nil, err
+	}
+	p.page++
+	p.done = len(table.Rows()) < p.pageSize
+	return table, nil
+}
+
+// Page fetches the page with the given index, from 0, without changing the position of NextPage.
+func (p *Paginator) Page(ctx context.Context, index int) (query.Table, error) {
+	if index < 0 {
+		return nil, errors.ES(errors.OpQuery, errors.KClientArgs, "the page index cannot be negative, got %d", index).SetNoRetry()
+	}
+	from := int64(index)*int64(p.pageSize) + 1
+	to := from + int64(p.pageSize) - 1
+	q := kql.New("stored_query_result(").AddString(p.name).
+		AddLiteral(")\n| where RowNum between (").AddLong(from).AddLiteral(" .. ").AddLong(to).
+		AddLiteral(")\n| order by RowNum asc")
+
+	ds, err := p.client.Query(ctx, p.db, q, p.options...)
+	if err != nil {
+		return nil, err
+	}
+	if tables := ds.PrimaryResults(); len(tables) > 0 {
+		return tables[0], nil
+	}
+	return nil, errors.ES(errors.OpQuery, errors.KInternal, "the response of the page query has no primary result")
+}
@@ -0,0 +1,96 @@
+package azkustodata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rowNumRange = regexp.MustCompile(`RowNum between \(long\((\d+)\) \.\. long\((\d+)\)\)`)
+
+// storedQueryResultResponse returns a page of a stored query result of total rows.
+func storedQueryResultResponse(t *testing.T, csl string, total int64) string {
+	m := rowNumRange.FindStringSubmatch(csl)
+	require.NotNil(t, m, csl)
+	from, err := strconv.ParseInt(m[1], 10, 64)
+	require.NoError(t, err)
+	to, err := strconv.ParseInt(m[2], 10, 64)
+	require.NoError(t, err)
+
+	var rows []string
+	for i := from; i <= to && i <= total; i++ {
+		rows = append(rows, fmt.Sprintf("[%d]", i))
+	}
+	return `[{"FrameType":"DataSetHeader","IsProgressive":false,"Version":"v2.0","IsFragmented":true,"ErrorReportingPlacement":"EndOfTable"}
+,{"FrameType":"TableHeader","TableId":1,"TableKind":"PrimaryResult","TableName":"PrimaryResult","Columns":[{"ColumnName":"RowNum","ColumnType":"long"}]}
+,{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[` + strings.Join(rows, ",") + `]}
+,{"FrameType":"TableCompletion","TableId":1,"RowCount":` + strconv.Itoa(len(rows)) + `}
+,{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}
+]`
+}
+
+func TestPaginator(t *testing.T) {
+	var lock sync.Mutex
+	var commands []string
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			CSL string `json:"csl"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		if r.URL.Path == "/v2/rest/query" {
+			_, _ = w.Write([]byte(storedQueryResultResponse(t, msg.CSL, 7)))
+			return
+		}
+		lock.Lock()
+		commands = append(commands, msg.CSL)
+		lock.Unlock()
+		_, _ = w.Write([]byte(verifyTestShowVersion))
+	})
+
+	client := newTestKustoClient(t, srv)
+	ctx := context.Background()
+
+	require.NoError(t, client.CreateStoredQueryResult(ctx, "db", "results", kql.New("T | order by x"), StoredQueryResultOptions{ExpiresAfter: time.Hour}))
+	require.NoError(t, client.DropStoredQueryResult(ctx, "db", "results"))
+	assert.Equal(t, []string{
+		".set-or-replace stored_query_result results with (previewCount = 0, expiresAfter = timespan(01:00:00.0000000)) <|\nT | order by x\n| serialize RowNum = row_number()",
+		".drop stored_query_result results",
+	}, commands)
+
+	pages, err := client.NewPaginator("db", "results", 3)
+	require.NoError(t, err)
+	var rowNums [][]int64
+	for pages.More() {
+		page, err := pages.NextPage(ctx)
+		require.NoError(t, err)
+		var nums []int64
+		for _, row := range page.Rows() {
+			n, err := row.LongByIndex(0)
+			require.NoError(t, err)
+			nums = append(nums, *n)
+		}
+		rowNums = append(rowNums, nums)
+	}
+	assert.Equal(t, [][]int64{{1, 2, 3}, {4, 5, 6}, {7}}, rowNums)
+	_, err = pages.NextPage(ctx)
+	assert.Error(t, err)
+
+	page, err := pages.Page(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, page.Rows(), 3)
+
+	_, err = client.NewPaginator("db", "results", 0)
+	assert.Error(t, err)
+	assert.Error(t, client.CreateStoredQueryResult(ctx, "db", "", kql.New("T"), StoredQueryResultOptions{}))
+}
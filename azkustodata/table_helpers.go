@@ -0,0 +1,96 @@
+package azkustodata
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+)
+
+// Count returns the number of rows of a table matching a filter, such as kql.New("State == ").AddString(state), or
+// all its rows if filter is nil.
+func (c *Client) Count(ctx context.Context, db string, table string, filter Statement, options ...QueryOption) (int64, error) {
+	q := kql.New("").AddTable(table)
+	if filter != nil {
+		q.AddLiteral("\n| where ").AddUnsafe(filter.String())
+	}
+	q.AddLiteral("\n| count")
+
+	ds, err := c.Query(ctx, db, q, options...)
+	if err != nil {
+		return 0, err
+	}
+	result, err := primaryResult(ds)
+	if err != nil {
+		return 0, err
+	}
+	rows := result.Rows()
+	if len(rows) == 0 {
+		return 0, errors.ES(errors.OpQuery, errors.KInternal, "the count of table %s returned no row", table)
+	}
+	count, err := rows[0].LongByIndex(0)
+	if err != nil {
+		return 0, err
+	}
+	if count == nil {
+		return 0, nil
+	}
+	return *count, nil
+}
+
+// Sample returns up to n rows of a table, picked arbitrarily with the sample operator.
+func (c *Client) Sample(ctx context.Context, db string, table string, n int64, options ...QueryOption) (query.Table, error) {
+	if n <= 0 {
+		return nil, errors.ES(errors.OpQuery, errors.KClientArgs, "the number of rows to sample must be positive, got %d", n).SetNoRetry()
+	}
+	q := kql.New("").AddTable(table).AddLiteral("\n| sample ").AddUnsafe(strconv.FormatInt(n, 10))
+	ds, err := c.Query(ctx, db, q, options...)
+	if err != nil {
+		return nil, err
+	}
+	return primaryResult(ds)
+}
+
+// Exists returns whether a database has a table, among those the caller has access to.
+func (c *Client) Exists(ctx context.Context, db string, table string, options ...QueryOption) (bool, error) {
+	cmd := kql.New(".show tables\n| where TableName == ").AddString(table).AddLiteral("\n| count")
+	// The command only reads, so it can run on read-only clients.
+	ds, err := c.Mgmt(ctx, db, cmd, append([]QueryOption{AllowControlCommands()}, options...)...)
+	if err != nil {
+		return false, err
+	}
+	tables := ds.Tables()
+	if len(tables) == 0 || len(tables[0].Rows()) == 0 {
+		return false, errors.ES(errors.OpMgmt, errors.KInternal, "the command %q returned no row", cmd.String())
+	}
+	count, err := tables[0].Rows()[0].LongByIndex(0)
+	if err != nil {
+		return false, err
+	}
+	return count != nil && *count > 0, nil
+}
+
+// Count returns the number of rows of a table of the database matching a filter, see Client.Count.
+func (d *Database) Count(ctx context.Context, table string, filter Statement, options ...QueryOption) (int64, error) {
+	return d.client.Count(ctx, d.name, table, filter, d.withOptions(options)...)
+}
+
+// Sample returns up to n rows of a table of the database, see Client.Sample.
+func (d *Database) Sample(ctx context.Context, table string, n int64, options ...QueryOption) (query.Table, error) {
+	return d.client.Sample(ctx, d.name, table, n, d.withOptions(options)...)
+}
+
+// Exists returns whether the database has a table, see Client.Exists.
+func (d *Database) Exists(ctx context.Context, table string, options ...QueryOption) (bool, error) {
+	return d.client.Exists(ctx, d.name, table, d.withOptions(options)...)
+}
+
+// primaryResult returns the primary result table of a dataset.
+func primaryResult(ds query.Dataset) (query.Table, error) {
+	if tables := ds.PrimaryResults(); len(tables) > 0 {
+		return tables[0], nil
+	}
+	return nil, errors.ES(errors.OpQuery, errors.KInternal, "the response of the query has no primary result")
+}
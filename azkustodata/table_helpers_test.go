@@ -0,0 +1,79 @@
+package azkustodata
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableHelpers(t *testing.T) {
+	csl := make(chan string, 10)
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			CSL string `json:"csl"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		csl <- msg.CSL
+		if r.URL.Path == "/v1/rest/mgmt" {
+			count := "0"
+			if strings.Contains(msg.CSL, `"Events"`) {
+				count = "1"
+			}
+			_, _ = w.Write([]byte(`{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"Count","ColumnType":"long"}],"Rows":[[` + count + `]]}]}`))
+			return
+		}
+		// The first row of the response is the count.
+		_, _ = w.Write([]byte(keepAliveTestResponse))
+	})
+
+	client := newTestKustoClient(t, srv)
+	ctx := context.Background()
+
+	count, err := client.Count(ctx, "db", "my table", kql.New("State == ").AddString("TEXAS\" | take 1"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, "[\"my table\"]\n| where State == \"TEXAS\\\" | take 1\"\n| count", <-csl)
+
+	_, err = client.Database("db").Count(ctx, "Events", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Events\n| count", <-csl)
+
+	table, err := client.Sample(ctx, "db", "Events", 10)
+	require.NoError(t, err)
+	assert.Len(t, table.Rows(), 2)
+	assert.Equal(t, "Events\n| sample 10", <-csl)
+
+	_, err = client.Sample(ctx, "db", "Events", 0)
+	assert.Error(t, err)
+
+	exists, err := client.Exists(ctx, "db", "Events")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, ".show tables\n| where TableName == \"Events\"\n| count", <-csl)
+
+	exists, err = client.Database("db").Exists(ctx, "Missing")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestExistsReadonly(t *testing.T) {
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"Count","ColumnType":"long"}],"Rows":[[1]]}]}`))
+	})
+
+	client := newTestKustoClient(t, srv, WithRequestReadonly())
+
+	exists, err := client.Exists(context.Background(), "db", "Events")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Other control commands are still refused.
+	_, err = client.Mgmt(context.Background(), "db", kql.New(".drop table Events"))
+	assert.ErrorContains(t, err, "control commands are not allowed in read-only requests")
+}
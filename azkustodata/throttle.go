@@ -0,0 +1,127 @@
+package azkustodata
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+)
+
+// defaultThrottlingBudget is how long a request waits in total for the cluster to stop throttling it, by default.
+const defaultThrottlingBudget = time.Minute
+
+// throttlingBackoff is the backoff used when the cluster doesn't say how long to wait, and no RetryPolicy is set.
+var throttlingBackoff = RetryPolicy{BaseDelay: time.Second, MaxDelay: 30 * time.Second}
+
+// ThrottledError is returned when the cluster kept throttling a request until the throttling budget ran out.
+// It wraps the error of the last throttled attempt.
+type ThrottledError struct {
+	// StatusCode is the HTTP status code of the last response, usually 429.
+	StatusCode int
+	// Code, Type and Message are the error code, exception type and message of the Kusto error, if the cluster sent one.
+	Code    string
+	Type    string
+	Message string
+	// RetryAfter is how long the cluster asked to wait before sending the request again, zero if it didn't say.
+	RetryAfter time.Duration
+	// Attempts is the number of throttled attempts.
+	Attempts int
+	// Waited is the total time spent waiting between the attempts.
+	Waited time.Duration
+	// Err is the error of the last attempt.
+	Err error
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("request throttled by the cluster (%d attempts, waited %s, retry after %s): %s", e.Attempts, e.Waited, e.RetryAfter, e.Err)
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return e.Err
+}
+
+// WithThrottlingBudget sets how long, in total, a request waits for the cluster to stop throttling it before a
+// *ThrottledError is returned. The cluster's Retry-After is honored when it sends one. Defaults to 1 minute, zero disables
+// waiting.
+func WithThrottlingBudget(budget time.Duration) Option {
+	return func(c *Client) {
+		c.throttlingBudget = budget
+	}
+}
+
+// newThrottledError returns the details of err if it is a throttling response.
+func newThrottledError(err error) (*ThrottledError, bool) {
+	var httpErr *errors.HttpError
+	if !stdErrors.As(err, &httpErr) {
+		return nil, false
+	}
+
+	throttled := &ThrottledError{StatusCode: httpErr.StatusCode, Err: err}
+	if m := httpErr.UnmarshalREST(); m != nil {
+		if details, ok := m["error"].(map[string]interface{}); ok {
+			throttled.Code, _ = details["code"].(string)
+			throttled.Type, _ = details["@type"].(string)
+			throttled.Message, _ = details["@message"].(string)
+			if throttled.Message == "" {
+				throttled.Message, _ = details["message"].(string)
+			}
+		}
+	}
+	if !errors.IsThrottled(err) {
+		return nil, false
+	}
+	throttled.RetryAfter = parseRetryAfter(httpErr.Header.Get("Retry-After"), time.Now())
+	return throttled, true
+}
+
+// parseRetryAfter parses a Retry-After header, in either its delay-seconds or HTTP-date form.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// throttleState tracks the throttled attempts of a request against its budget.
+type throttleState struct {
+	budget   time.Duration
+	attempts int
+	waited   time.Duration
+}
+
+// wait records a throttled attempt, and returns how long to wait before the next one, or a negative duration if the
+// budget doesn't allow it. throttled is updated with the totals so far.
+func (s *throttleState) wait(throttled *ThrottledError, policy *RetryPolicy) time.Duration {
+	s.attempts++
+	throttled.Attempts = s.attempts
+	throttled.Waited = s.waited
+
+	wait := throttled.RetryAfter
+	if wait <= 0 {
+		if policy == nil {
+			policy = &throttlingBackoff
+		}
+		wait = policy.delay(s.attempts)
+	}
+	if s.waited+wait > s.budget {
+		return -1
+	}
+	s.waited += wait
+	return wait
+}
@@ -0,0 +1,110 @@
+package azkustodata
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: 0},
+		{value: "5", want: 5 * time.Second},
+		{value: " 30 ", want: 30 * time.Second},
+		{value: "-1", want: 0},
+		{value: "Mon, 01 Jan 2024 12:00:10 GMT", want: 10 * time.Second},
+		{value: "Mon, 01 Jan 2024 11:00:00 GMT", want: 0},
+		{value: "soon", want: 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, parseRetryAfter(tt.value, now), tt.value)
+	}
+}
+
+func TestThrottling(t *testing.T) {
+	const throttledBody = `{"error": {"code": "LimitsExceeded", "message": "Request is throttled.", "@type": "Kusto.DataNode.Exceptions.ControlCommandThrottledException", "@message": "The control command was aborted due to throttling.", "@permanent": false}}`
+	fastBackoff := WithRetryPolicy(RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	tests := []struct {
+		name           string
+		code           int
+		retryAfter     string
+		body           string
+		failures       int32
+		options        []Option
+		wantAttempts   int32
+		wantThrottled  bool
+		wantRetryAfter time.Duration
+	}{
+		{name: "TooManyRequests", code: http.StatusTooManyRequests, retryAfter: "0", failures: 2, options: []Option{fastBackoff}, wantAttempts: 3},
+		{name: "KustoThrottlingError", code: http.StatusInternalServerError, body: throttledBody, failures: 1, options: []Option{fastBackoff}, wantAttempts: 2},
+		{name: "RetryAfterOverBudget", code: http.StatusTooManyRequests, retryAfter: "120", failures: 1, wantAttempts: 1, wantThrottled: true, wantRetryAfter: 2 * time.Minute},
+		{name: "NoBudget", code: http.StatusTooManyRequests, retryAfter: "1", failures: 1, options: []Option{WithThrottlingBudget(0)}, wantAttempts: 1, wantThrottled: true, wantRetryAfter: time.Second},
+		{name: "NotThrottled", code: http.StatusBadRequest, failures: 1, wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) <= tt.failures {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(tt.code)
+					_, _ = w.Write([]byte(tt.body))
+					return
+				}
+				_, _ = w.Write([]byte(verifyTestShowVersion))
+			})
+
+			client := newTestKustoClient(t, srv, tt.options...)
+
+			// State changing commands are still sent again when throttled, as they were never run.
+			_, err := client.Mgmt(context.Background(), "db", kql.New(".append T <| print 1"))
+			assert.Equal(t, tt.wantAttempts, attempts.Load())
+
+			var throttled *ThrottledError
+			if tt.wantThrottled {
+				require.True(t, errors.As(err, &throttled), "unexpected error type %T: %v", err, err)
+				assert.Equal(t, tt.code, throttled.StatusCode)
+				assert.Equal(t, tt.wantRetryAfter, throttled.RetryAfter)
+				assert.Equal(t, 1, throttled.Attempts)
+			} else if tt.code == http.StatusBadRequest {
+				assert.Error(t, err)
+				assert.False(t, errors.As(err, &throttled))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestThrottledErrorDetails(t *testing.T) {
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error": {"code": "TooManyRequests", "message": "Too many requests", "@type": "Kusto.Common.Svc.Exceptions.QueryThrottledException", "@message": "Query was throttled"}}`))
+	})
+
+	client := newTestKustoClient(t, srv, WithThrottlingBudget(5*time.Millisecond), WithRetryPolicy(RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}))
+
+	_, err := client.Query(context.Background(), "db", kql.New("T"))
+	var throttled *ThrottledError
+	require.True(t, errors.As(err, &throttled), "unexpected error type %T: %v", err, err)
+	assert.Equal(t, "TooManyRequests", throttled.Code)
+	assert.Equal(t, "Kusto.Common.Svc.Exceptions.QueryThrottledException", throttled.Type)
+	assert.Equal(t, "Query was throttled", throttled.Message)
+	assert.Greater(t, throttled.Attempts, 1)
+	assert.LessOrEqual(t, throttled.Waited, 5*time.Millisecond)
+}
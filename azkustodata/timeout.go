@@ -0,0 +1,105 @@
+package azkustodata
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/value"
+)
+
+// maxServerTimeout is the largest servertimeout the cluster accepts.
+const maxServerTimeout = time.Hour
+
+// WithTimeout sets the default timeout of the calls of the client, after which the client stops waiting for their
+// response and returns a *TimeoutError. The Timeout option overrides it for a call. Unless the call sets ServerTimeout
+// or NoRequestTimeout, the timeout is also sent as the servertimeout of the call, minus the skew set with
+// WithServerTimeoutSkew and up to the cluster's maximum of one hour, so the cluster stops running the call at the same
+// time. Without a timeout, calls only end with their context, and the cluster times them out after 4 minutes for
+// queries, or one hour for management commands.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// Timeout sets the timeout of the call, overriding the one set with WithTimeout. Zero disables the client's timeout
+// for the call.
+func Timeout(timeout time.Duration) QueryOption {
+	return func(q *queryOptions) error {
+		q.timeout = timeout
+		return nil
+	}
+}
+
+// TimeoutError is returned by calls that timed out, either on the client or on the cluster. It wraps the error the
+// call failed with, such as a *errors.HttpError.
+type TimeoutError struct {
+	// Server is true if the cluster timed the call out, once its servertimeout elapsed, and false if the client stopped
+	// waiting for the response, once the timeout set with WithTimeout or Timeout elapsed.
+	Server bool
+	// Timeout is the timeout that elapsed.
+	Timeout time.Duration
+
+	err error
+}
+
+func (e *TimeoutError) Error() string {
+	side := "client"
+	if e.Server {
+		side = "server"
+	}
+	return fmt.Sprintf("the call timed out on the %s after %s: %s", side, e.Timeout, e.err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.err
+}
+
+// withCallTimeout returns the context of a call with its timeout, if it has one.
+func withCallTimeout(ctx context.Context, options *queryOptions) (context.Context, context.CancelFunc) {
+	if options.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, options.timeout)
+}
+
+// timeoutError returns a *TimeoutError wrapping err if the call failed because its client or server timeout elapsed,
+// or err otherwise. parent is the context of the call before its timeout was set.
+func timeoutError(op errors.Op, parent context.Context, ctx context.Context, options *queryOptions, err error) error {
+	if err == nil || stdErrors.As(err, new(*TimeoutError)) {
+		return err
+	}
+	if options.timeout > 0 && parent.Err() == nil && stdErrors.Is(ctx.Err(), context.DeadlineExceeded) {
+		if _, ok := err.(*errors.Error); !ok {
+			err = errors.E(op, errors.KTimeout, err).SetNoRetry()
+		}
+		return &TimeoutError{Timeout: options.timeout, err: err}
+	}
+	if errors.IsServerTimeout(err) {
+		timeout, _ := options.requestProperties.Options[ServerTimeoutValue].(string)
+		d, _ := value.ParseTimespan(timeout)
+		return &TimeoutError{Server: true, Timeout: d, err: err}
+	}
+	return err
+}
+
+// timeoutReader returns a *TimeoutError when reading the response of a call fails because its timeout elapsed.
+type timeoutReader struct {
+	io.ReadCloser
+	op      errors.Op
+	parent  context.Context
+	ctx     context.Context
+	options *queryOptions
+}
+
+func (r timeoutReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = timeoutError(r.op, r.parent, r.ctx, r.options, err)
+	}
+	return n, err
+}
@@ -0,0 +1,87 @@
+package azkustodata
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/Azure/azure-kusto-go/azkustodata/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get(ClientRequestIdHeader) == "slow":
+			select {
+			case <-r.Context().Done():
+			case <-done:
+			}
+		case r.Header.Get(ClientRequestIdHeader) == "server":
+			w.WriteHeader(http.StatusGatewayTimeout)
+			_, _ = w.Write([]byte(`{"error": {"code": "BadRequest", "message": "timed out", "@type": "Kusto.Data.Exceptions.KustoRequestTimeoutException"}}`))
+		default:
+			_, _ = w.Write([]byte(keepAliveTestResponse))
+		}
+	})
+	defer close(done)
+
+	client := newTestKustoClient(t, srv, WithTimeout(50*time.Millisecond))
+	ctx := context.Background()
+
+	_, err := client.Query(ctx, "db", kql.New("T"), ClientRequestID("slow"))
+	var timeoutErr *TimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.False(t, timeoutErr.Server)
+	assert.Equal(t, 50*time.Millisecond, timeoutErr.Timeout)
+
+	_, err = client.Mgmt(ctx, "db", kql.New(".show tables"), ClientRequestID("slow"), Timeout(20*time.Millisecond))
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.False(t, timeoutErr.Server)
+	assert.Equal(t, 20*time.Millisecond, timeoutErr.Timeout)
+
+	_, err = client.Query(ctx, "db", kql.New("T"), ClientRequestID("server"), Timeout(0), ServerTimeout(time.Minute))
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.True(t, timeoutErr.Server)
+	assert.Equal(t, time.Minute, timeoutErr.Timeout)
+	var httpErr *errors.HttpError
+	assert.ErrorAs(t, err, &httpErr, "the error of the call is wrapped")
+
+	_, err = client.Query(ctx, "db", kql.New("T"))
+	require.NoError(t, err)
+}
+
+func TestFrameTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// The first frames are sent, and then nothing, as over a dropped connection.
+		_, _ = w.Write([]byte(strings.SplitAfter(keepAliveTestResponse, "\n")[0]))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	})
+	defer close(done)
+
+	client := newTestKustoClient(t, srv)
+
+	var stalls []query.Stall
+	_, err := client.Query(context.Background(), "db", kql.New("T"), FrameTimeout(20*time.Millisecond, func(s query.Stall) bool {
+		stalls = append(stalls, s)
+		return false
+	}))
+	var stallErr *query.StallError
+	require.ErrorAs(t, err, &stallErr)
+	require.Len(t, stalls, 1)
+	assert.Equal(t, stalls[0], stallErr.Stall)
+
+	_, err = client.Query(context.Background(), "db", kql.New("T"), FrameTimeout(0, nil))
+	assert.Error(t, err)
+}
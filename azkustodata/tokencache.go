@@ -0,0 +1,187 @@
+package azkustodata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+)
+
+const (
+	// defaultTokenRefreshWindow is how long before a cached token expires it is refreshed in the background.
+	defaultTokenRefreshWindow = 5 * time.Minute
+	// tokenRefreshTimeout bounds a background refresh, as it is not tied to any caller's context.
+	tokenRefreshTimeout = time.Minute
+	// tokenCacheSweepInterval is how often expired tokens are evicted from the shared cache.
+	tokenCacheSweepInterval = time.Minute
+)
+
+// sharedTokenCache holds the tokens of all the clients in the process, so clients for the same cluster and identity
+// don't each acquire their own.
+var sharedTokenCache sync.Map // tokenCacheKey -> *cachedToken
+
+// lastTokenCacheSweep is when expired tokens were last evicted from sharedTokenCache, in Unix nanoseconds.
+var lastTokenCacheSweep atomic.Int64
+
+// sweepTokenCache evicts the expired tokens from the shared cache, so identities that are no longer used don't stay
+// in memory. It does nothing if the cache was swept less than tokenCacheSweepInterval before now.
+func sweepTokenCache(now time.Time) {
+	last := lastTokenCacheSweep.Load()
+	if now.UnixNano()-last < int64(tokenCacheSweepInterval) || !lastTokenCacheSweep.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	sharedTokenCache.Range(func(key, value any) bool {
+		if value.(*cachedToken).expired(now) {
+			sharedTokenCache.CompareAndDelete(key, value)
+		}
+		return true
+	})
+}
+
+type tokenCacheKey struct {
+	scopes   string
+	identity string
+}
+
+type cachedToken struct {
+	lock       sync.Mutex
+	token      azcore.AccessToken
+	refreshing bool
+	// fetching is closed when the in-flight fetch of a missing or expired token completes, nil if there is none.
+	fetching chan struct{}
+}
+
+// get returns the cached token if it is still valid. If it is about to expire, a background refresh is started and the
+// current token is returned, so callers don't wait for the refresh. Only when there is no valid token does the caller
+// acquire one itself.
+// Concurrent callers wait for a single fetch instead of all fetching one, but the lock is not held while fetching, so a
+// waiter can still give up when its own context is done - an interactive login can take a while.
+func (c *cachedToken) get(ctx context.Context, refreshWindow time.Duration, fetch func(context.Context) (azcore.AccessToken, error)) (azcore.AccessToken, error) {
+	for {
+		c.lock.Lock()
+		now := time.Now()
+
+		if now.Before(c.token.ExpiresOn) {
+			token := c.token
+			if now.Before(token.ExpiresOn.Add(-refreshWindow)) || c.refreshing {
+				c.lock.Unlock()
+				return token, nil
+			}
+
+			c.refreshing = true
+			c.lock.Unlock()
+			go c.refresh(fetch)
+			return token, nil
+		}
+
+		if done := c.fetching; done != nil {
+			c.lock.Unlock()
+			select {
+			case <-done:
+				// If that fetch failed, the next iteration fetches with this caller's context.
+				continue
+			case <-ctx.Done():
+				return azcore.AccessToken{}, ctx.Err()
+			}
+		}
+
+		done := make(chan struct{})
+		c.fetching = done
+		c.lock.Unlock()
+
+		token, err := fetch(ctx)
+
+		c.lock.Lock()
+		c.fetching = nil
+		if err == nil {
+			c.token = token
+		}
+		c.lock.Unlock()
+		close(done)
+
+		if err != nil {
+			return azcore.AccessToken{}, err
+		}
+		return token, nil
+	}
+}
+
+// expired reports whether the token has expired and isn't being fetched or refreshed.
+func (c *cachedToken) expired(now time.Time) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return !now.Before(c.token.ExpiresOn) && c.fetching == nil && !c.refreshing
+}
+
+func (c *cachedToken) refresh(fetch func(context.Context) (azcore.AccessToken, error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenRefreshTimeout)
+	defer cancel()
+
+	token, err := fetch(ctx)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.refreshing = false
+	// On failure, the current token is kept, and the next caller inside the refresh window will try again.
+	if err == nil {
+		c.token = token
+	}
+}
+
+// credentialIdentity returns a string identifying the identity the builder authenticates as, to be used as part of the
+// shared token cache key. Secrets are hashed, never stored as is.
+// An empty string is returned when the identity can't be derived from the builder alone (user supplied credentials and
+// callbacks, or an account picked at login), in which case the tokens are not shared.
+// The tokens of on-behalf-of identities are not shared either, as a service has one per end user, which would
+// otherwise fill the cache.
+func (kcsb *ConnectionStringBuilder) credentialIdentity() string {
+	if kcsb.TokenCredential != nil || kcsb.ClientAssertionCallback != nil {
+		return ""
+	}
+	if !isEmpty(kcsb.UserToken) || !isEmpty(kcsb.ApplicationToken) || !isEmpty(kcsb.OnBehalfOfUserAssertion) {
+		return ""
+	}
+	if kcsb.PromptSelectAccount {
+		return ""
+	}
+
+	fields := []string{
+		kcsb.AuthorityId,
+		kcsb.ApplicationClientId,
+		kcsb.AadUserID,
+		hashSecret([]byte(kcsb.Password)),
+		hashSecret([]byte(kcsb.ApplicationKey)),
+		kcsb.ApplicationCertificatePath,
+		hashSecret(kcsb.ApplicationCertificateBytes),
+		kcsb.KeyVaultURL,
+		kcsb.KeyVaultCertificateName,
+		kcsb.ManagedServiceIdentity,
+		kcsb.ManagedIdentityObjectID,
+		kcsb.ManagedIdentityResourceID,
+		kcsb.FederationTokenFilePath,
+		kcsb.RedirectURL,
+		kcsb.LoginHint,
+		kcsb.DomainHint,
+		kcsb.TokenCachePersistenceName,
+		kcsb.AzCliSubscription,
+		strings.Join(kcsb.AdditionallyAllowedTenants, ","),
+		fmt.Sprintf("%t|%t|%t|%t|%t|%t|%t|%t", kcsb.AzCli, kcsb.AzdCli, kcsb.MsiAuthentication, kcsb.WorkloadAuthentication, kcsb.InteractiveLogin,
+			kcsb.DeviceCodeLogin, kcsb.DefaultAuth, kcsb.EnvironmentAuth),
+	}
+	return strings.Join(fields, "|")
+}
+
+func hashSecret(secret []byte) string {
+	if len(secret) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(secret)
+	return hex.EncodeToString(sum[:])
+}
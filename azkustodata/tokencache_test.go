@@ -0,0 +1,174 @@
+package azkustodata
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingCredential struct {
+	calls    atomic.Int32
+	lifetime time.Duration
+}
+
+func (c *countingCredential) GetToken(_ context.Context, _ policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	n := c.calls.Add(1)
+	return azcore.AccessToken{Token: string(rune('a' + n - 1)), ExpiresOn: time.Now().Add(c.lifetime)}, nil
+}
+
+func newCachedTestProvider(cred azcore.TokenCredential, identity string) *TokenProvider {
+	return &TokenProvider{
+		tokenCred:   cred,
+		tokenScheme: BEARER_TYPE,
+		scopes:      []string{"https://cluster/.default"},
+		identity:    identity,
+	}
+}
+
+func TestTokenCacheSharedBetweenProviders(t *testing.T) {
+	cred := &countingCredential{lifetime: time.Hour}
+	first := newCachedTestProvider(cred, t.Name())
+	second := newCachedTestProvider(cred, t.Name())
+
+	token, _, err := first.AcquireToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "a", token)
+
+	token, _, err = second.AcquireToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "a", token)
+	assert.EqualValues(t, 1, cred.calls.Load())
+}
+
+func TestTokenCacheDisabled(t *testing.T) {
+	cred := &countingCredential{lifetime: time.Hour}
+	tkp := newCachedTestProvider(cred, t.Name())
+	tkp.cacheDisabled = true
+
+	for i := 0; i < 2; i++ {
+		_, _, err := tkp.AcquireToken(context.Background())
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 2, cred.calls.Load())
+}
+
+func TestTokenCacheNoIdentity(t *testing.T) {
+	cred := &countingCredential{lifetime: time.Hour}
+	tkp := newCachedTestProvider(cred, "")
+
+	for i := 0; i < 2; i++ {
+		_, _, err := tkp.AcquireToken(context.Background())
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 2, cred.calls.Load())
+}
+
+func TestTokenCacheProactiveRefresh(t *testing.T) {
+	cred := &countingCredential{lifetime: 10 * time.Minute}
+	tkp := newCachedTestProvider(cred, t.Name())
+	tkp.refreshWindow = 15 * time.Minute
+
+	// The token is already inside the refresh window, so the cached token is returned while a refresh runs in the background.
+	token, _, err := tkp.AcquireToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "a", token)
+
+	token, _, err = tkp.AcquireToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "a", token)
+
+	require.Eventually(t, func() bool {
+		token, _, err := tkp.AcquireToken(context.Background())
+		return err == nil && token != "a"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCredentialIdentity(t *testing.T) {
+	first := NewConnectionStringBuilder("https://endpoint").WithAadAppKey("clientID", "secret1", "tenantID")
+	second := NewConnectionStringBuilder("https://endpoint").WithAadAppKey("clientID", "secret2", "tenantID")
+
+	assert.NotEqual(t, first.credentialIdentity(), second.credentialIdentity())
+	assert.NotContains(t, first.credentialIdentity(), "secret1")
+	assert.Equal(t, "", NewConnectionStringBuilder("https://endpoint").WithTokenCredential(&fakeCredential{}).credentialIdentity())
+
+	// A service has one on-behalf-of identity per end user, and an account picked at login isn't known beforehand.
+	assert.Equal(t, "", NewConnectionStringBuilder("https://endpoint").WithOnBehalfOf("tenantID", "clientID", "secret", "assertion").credentialIdentity())
+	assert.Equal(t, "", NewConnectionStringBuilder("https://endpoint").
+		WithInteractiveLoginOptions("tenantID", InteractiveLoginOptions{SelectAccount: true}).credentialIdentity())
+
+	contoso := NewConnectionStringBuilder("https://endpoint").WithInteractiveLoginOptions("tenantID", InteractiveLoginOptions{DomainHint: "contoso.com"})
+	fabrikam := NewConnectionStringBuilder("https://endpoint").WithInteractiveLoginOptions("tenantID", InteractiveLoginOptions{DomainHint: "fabrikam.com"})
+	assert.NotEqual(t, contoso.credentialIdentity(), fabrikam.credentialIdentity())
+}
+
+func TestTokenCacheSweep(t *testing.T) {
+	defer lastTokenCacheSweep.Store(0)
+
+	cred := &countingCredential{lifetime: time.Hour}
+	tkp := newCachedTestProvider(cred, t.Name())
+	_, _, err := tkp.AcquireToken(context.Background())
+	require.NoError(t, err)
+
+	key := tokenCacheKey{scopes: "https://cluster/.default", identity: t.Name()}
+	_, ok := sharedTokenCache.Load(key)
+	require.True(t, ok)
+
+	// Tokens that are still valid are kept.
+	sweepTokenCache(time.Now().Add(30 * time.Minute))
+	_, ok = sharedTokenCache.Load(key)
+	assert.True(t, ok)
+
+	sweepTokenCache(time.Now().Add(2 * time.Hour))
+	_, ok = sharedTokenCache.Load(key)
+	assert.False(t, ok)
+}
+
+type blockingCredential struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (c *blockingCredential) GetToken(ctx context.Context, _ policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.calls.Add(1)
+	select {
+	case <-c.release:
+		return azcore.AccessToken{Token: "a", ExpiresOn: time.Now().Add(time.Hour)}, nil
+	case <-ctx.Done():
+		return azcore.AccessToken{}, ctx.Err()
+	}
+}
+
+func TestTokenCacheSingleFetch(t *testing.T) {
+	cred := &blockingCredential{release: make(chan struct{})}
+	tkp := newCachedTestProvider(cred, t.Name())
+
+	first := make(chan error, 1)
+	go func() {
+		_, _, err := tkp.AcquireToken(context.Background())
+		first <- err
+	}()
+	require.Eventually(t, func() bool { return cred.calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	// A caller waiting for the in-flight fetch is not blocked past its own context.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err := tkp.AcquireToken(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	second := make(chan string, 1)
+	go func() {
+		token, _, _ := tkp.AcquireToken(context.Background())
+		second <- token
+	}()
+
+	close(cred.release)
+	require.NoError(t, <-first)
+	assert.Equal(t, "a", <-second)
+	assert.EqualValues(t, 1, cred.calls.Load())
+}
@@ -3,9 +3,11 @@ package azkustodata
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/Azure/azure-kusto-go/azkustodata/utils"
 
@@ -14,16 +16,40 @@ import (
 )
 
 type TokenProvider struct {
-	tokenCred   azcore.TokenCredential                  //Holds the received token credential as per the authorization
-	tokenScheme string                                  //Contains token scheme for tokenprovider
-	customToken string                                  //Holds the custom auth token to be used for authorization
-	initOnce    utils.OnceWithInit[*tokenWrapperResult] //To ensure tokenprovider will be initialized only once while aquiring token
-	scopes      []string                                //Contains scopes of the auth token
-	http        atomic.Value                            //Contains the http client to be used for token provider
+	tokenCred     azcore.TokenCredential                  //Holds the received token credential as per the authorization
+	tokenScheme   string                                  //Contains token scheme for tokenprovider
+	customToken   string                                  //Holds the custom auth token to be used for authorization
+	initOnce      utils.OnceWithInit[*tokenWrapperResult] //To ensure tokenprovider will be initialized only once while aquiring token
+	scopes        []string                                //Contains scopes of the auth token
+	http          atomic.Value                            //Contains the http client to be used for token provider
+	identity      string                                  //Identifies the credential in the shared token cache, empty if it can't be shared
+	cacheDisabled bool                                    //Disables the shared token cache for this provider
+	refreshWindow time.Duration                           //How long before expiry a cached token is refreshed
+	events        *TokenProviderEvents                    //Callbacks invoked around token acquisition, may be nil
+	metrics       Metrics                                 //Receives the latency of token acquisitions, may be nil
+	logger        Logger                                  //Receives the logs of token acquisitions, may be nil
+	tokenScope    string                                  //Overrides the scope derived from the cloud info, also used for per-request credentials
+	configErr     error                                   //Set when the builder's authentication settings are invalid, returned instead of a token
+}
+
+// TokenProviderEvents holds callbacks that are invoked whenever a token is acquired from the credential, for example to
+// emit metrics on auth latency and refresh failures. They are not invoked when a token is served from the cache.
+// All the callbacks are optional. They may be called concurrently, including from background refreshes, and should return quickly.
+type TokenProviderEvents struct {
+	// OnTokenRequested is called before a token is requested from the credential.
+	OnTokenRequested func()
+	// OnTokenAcquired is called after a token was acquired, with the time it took and the token's expiry.
+	OnTokenAcquired func(duration time.Duration, expiresOn time.Time)
+	// OnTokenError is called when the credential failed to acquire a token.
+	OnTokenError func(err error)
 }
 
 // tokenProvider need to be received as reference, to reflect updations to the structs
 func (tkp *TokenProvider) AcquireToken(ctx context.Context) (string, string, error) {
+	if tkp.configErr != nil {
+		return "", "", tkp.configErr
+	}
+
 	if !isEmpty(tkp.customToken) {
 		return tkp.customToken, tkp.tokenScheme, nil
 	}
@@ -36,7 +62,7 @@ func (tkp *TokenProvider) AcquireToken(ctx context.Context) (string, string, err
 	}
 
 	if tkp.tokenCred != nil {
-		token, err := tkp.tokenCred.GetToken(ctx, policy.TokenRequestOptions{Scopes: tkp.scopes})
+		token, err := tkp.getToken(ctx)
 		if err != nil {
 			return "", "", err
 		}
@@ -46,8 +72,63 @@ func (tkp *TokenProvider) AcquireToken(ctx context.Context) (string, string, err
 	return "", "", fmt.Errorf("Error: No token info present in token provider")
 }
 
+func (tkp *TokenProvider) getToken(ctx context.Context) (azcore.AccessToken, error) {
+	fetch := func(ctx context.Context) (azcore.AccessToken, error) {
+		return tkp.fetchToken(ctx)
+	}
+
+	if tkp.cacheDisabled || isEmpty(tkp.identity) {
+		return fetch(ctx)
+	}
+
+	refreshWindow := tkp.refreshWindow
+	if refreshWindow <= 0 {
+		refreshWindow = defaultTokenRefreshWindow
+	}
+
+	sweepTokenCache(time.Now())
+	key := tokenCacheKey{scopes: strings.Join(tkp.scopes, " "), identity: tkp.identity}
+	entry, _ := sharedTokenCache.LoadOrStore(key, &cachedToken{})
+	return entry.(*cachedToken).get(ctx, refreshWindow, fetch)
+}
+
+// fetchToken acquires a token from the credential, invoking the events and reporting the metrics around it.
+func (tkp *TokenProvider) fetchToken(ctx context.Context) (azcore.AccessToken, error) {
+	events := tkp.events
+	if events == nil {
+		events = &TokenProviderEvents{}
+	}
+
+	if events.OnTokenRequested != nil {
+		events.OnTokenRequested()
+	}
+	start := time.Now()
+	token, err := tkp.tokenCred.GetToken(ctx, policy.TokenRequestOptions{Scopes: tkp.scopes})
+	duration := time.Since(start)
+	if tkp.metrics != nil {
+		tkp.metrics.ObserveAuthLatency(ctx, duration, err)
+	}
+	if tkp.logger != nil {
+		if err != nil {
+			tkp.logger.Log(ctx, slog.LevelWarn, "failed to acquire token", "scopes", tkp.scopes, "duration", duration, "error", err)
+		} else {
+			tkp.logger.Log(ctx, slog.LevelInfo, "acquired token", "scopes", tkp.scopes, "duration", duration, "expiresOn", token.ExpiresOn)
+		}
+	}
+	if err != nil {
+		if events.OnTokenError != nil {
+			events.OnTokenError(err)
+		}
+		return token, err
+	}
+	if events.OnTokenAcquired != nil {
+		events.OnTokenAcquired(duration, token.ExpiresOn)
+	}
+	return token, nil
+}
+
 func (tkp *TokenProvider) AuthorizationRequired() bool {
-	return !(tkp.initOnce == nil && tkp.tokenCred == nil && isEmpty(tkp.customToken))
+	return tkp.configErr != nil || !(tkp.initOnce == nil && tkp.tokenCred == nil && isEmpty(tkp.customToken))
 }
 
 type tokenWrapperResult struct {
@@ -85,16 +166,22 @@ func tokenWrapper(kcsb *ConnectionStringBuilder, http func() *http.Client, f fun
 		return nil, err
 	}
 
+	return &tokenWrapperResult{
+		credential: credential,
+		scopes:     tokenScopes(ci, kcsb.TokenScope),
+	}, nil
+}
+
+// tokenScopes returns the scopes tokens are requested for: tokenScope if set, otherwise the cluster's resource.
+func tokenScopes(ci *CloudInfo, tokenScope string) []string {
+	if !isEmpty(tokenScope) {
+		return []string{tokenScope}
+	}
 	resourceURI := ci.KustoServiceResourceID
 	if ci.LoginMfaRequired {
 		resourceURI = strings.Replace(resourceURI, ".kusto.", ".kustomfa.", 1)
 	}
-	scopes := []string{fmt.Sprintf("%s/.default", resourceURI)}
-
-	return &tokenWrapperResult{
-		credential: credential,
-		scopes:     scopes,
-	}, nil
+	return []string{fmt.Sprintf("%s/.default", resourceURI)}
 }
 
 func getCommonCloudInfo(kcsb *ConnectionStringBuilder, http func() *http.Client) (*CloudInfo, *azcore.ClientOptions, string, error) {
@@ -107,13 +194,12 @@ func getCommonCloudInfo(kcsb *ConnectionStringBuilder, http func() *http.Client)
 	if err != nil {
 		return nil, nil, "", err
 	}
-	cliOpts := kcsb.ClientOptions
-	appClientId := kcsb.ApplicationClientId
-	if cliOpts == nil {
-		cliOpts = &azcore.ClientOptions{
-			Transport: client,
-		}
+	// Copy the options, as the cloud specific settings below must not leak to other clusters sharing the same options.
+	cliOpts := &azcore.ClientOptions{}
+	if kcsb.ClientOptions != nil {
+		*cliOpts = *kcsb.ClientOptions
 	}
+	appClientId := kcsb.ApplicationClientId
 	if cliOpts.Transport == nil {
 		cliOpts.Transport = client
 	}
@@ -4,7 +4,12 @@ import (
 	"context"
 	"github.com/stretchr/testify/require"
 	"os"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -49,3 +54,116 @@ func TestAcquireTokenErr(t *testing.T) {
 	}
 
 }
+
+type fakeCredential struct {
+	scopes []string
+}
+
+func (f *fakeCredential) GetToken(_ context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	f.scopes = options.Scopes
+	return azcore.AccessToken{Token: "fake-token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestAcquireTokenWithTokenCredential(t *testing.T) {
+	s := newTestServ()
+	defer s.close()
+	s.code = 200
+	s.payload = []byte(testCloudMetadataWithResource("https://kusto.custom.net"))
+
+	cred := &fakeCredential{}
+	tkp, err := NewConnectionStringBuilder(s.urlStr()).WithTokenCredential(cred).newTokenProvider()
+	require.NoError(t, err)
+	tkp.SetHttp(s.http.Client())
+
+	token, scheme, err := tkp.AcquireToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "fake-token", token)
+	assert.Equal(t, BEARER_TYPE, scheme)
+	assert.Equal(t, []string{"https://kusto.custom.net/.default"}, cred.scopes)
+}
+
+func TestAcquireTokenWithTokenScope(t *testing.T) {
+	s := newTestServ()
+	defer s.close()
+	s.code = 200
+	s.payload = []byte(testCloudMetadataWithResource("https://kusto.custom.net"))
+
+	cred := &fakeCredential{}
+	tkp, err := NewConnectionStringBuilder(s.urlStr()).WithTokenCredential(cred).WithTokenScope("https://proxy.contoso.com/.default").newTokenProvider()
+	require.NoError(t, err)
+	tkp.SetHttp(s.http.Client())
+
+	_, _, err = tkp.AcquireToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://proxy.contoso.com/.default"}, cred.scopes)
+
+	assert.Panics(t, func() { NewConnectionStringBuilder(s.urlStr()).WithTokenScope("") })
+}
+
+func TestAcquireTokenWithEnvironmentAuth(t *testing.T) {
+	s := newTestServ()
+	defer s.close()
+	s.code = 200
+	s.payload = []byte(testCloudMetadata)
+
+	t.Setenv("AZURE_TENANT_ID", "")
+	t.Setenv("AZURE_CLIENT_ID", "")
+	tkp, err := NewConnectionStringBuilder(s.urlStr()).WithEnvironmentAuth().newTokenProvider()
+	require.NoError(t, err)
+	tkp.SetHttp(s.http.Client())
+
+	_, err = tkp.initOnce.DoWithInit()
+	assert.ErrorContains(t, err, "from the environment")
+
+	t.Setenv("AZURE_TENANT_ID", "tenantID")
+	t.Setenv("AZURE_CLIENT_ID", "clientID")
+	t.Setenv("AZURE_CLIENT_SECRET", "secret")
+	tkp, err = NewConnectionStringBuilder(s.urlStr()).WithEnvironmentAuth().newTokenProvider()
+	require.NoError(t, err)
+	tkp.SetHttp(s.http.Client())
+
+	_, err = tkp.initOnce.DoWithInit()
+	assert.NoError(t, err)
+	assert.NotNil(t, tkp.tokenCred)
+}
+
+func TestTokenProviderEvents(t *testing.T) {
+	var requested, acquired, failed atomic.Int32
+	var expiry atomic.Value
+	events := &TokenProviderEvents{
+		OnTokenRequested: func() { requested.Add(1) },
+		OnTokenAcquired: func(duration time.Duration, expiresOn time.Time) {
+			acquired.Add(1)
+			expiry.Store(expiresOn)
+		},
+		OnTokenError: func(err error) { failed.Add(1) },
+	}
+
+	tkp := newCachedTestProvider(&countingCredential{lifetime: time.Hour}, t.Name())
+	tkp.events = events
+	for i := 0; i < 2; i++ {
+		_, _, err := tkp.AcquireToken(context.Background())
+		require.NoError(t, err)
+	}
+	// The second token is served from the cache, without calling the credential.
+	assert.EqualValues(t, 1, requested.Load())
+	assert.EqualValues(t, 1, acquired.Load())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiry.Load().(time.Time), time.Minute)
+
+	tkp = newCachedTestProvider(failingCredential{}, "")
+	tkp.events = events
+	_, _, err := tkp.AcquireToken(context.Background())
+	assert.Error(t, err)
+	assert.EqualValues(t, 2, requested.Load())
+	assert.EqualValues(t, 1, failed.Load())
+}
+
+func TestWithTokenProviderEvents(t *testing.T) {
+	events := &TokenProviderEvents{}
+	kcsb := NewConnectionStringBuilder("https://endpoint").WithAzCli().WithTokenProviderEvents(events)
+
+	tkp, err := kcsb.newTokenProvider()
+	require.NoError(t, err)
+	assert.Same(t, events, tkp.events)
+	assert.Panics(t, func() { kcsb.WithTokenProviderEvents(nil) })
+}
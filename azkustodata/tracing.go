@@ -0,0 +1,134 @@
+package azkustodata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/internal/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the client's spans.
+const tracerName = "github.com/Azure/azure-kusto-go/azkustodata"
+
+// ActivityIdHeader is the response header holding the id of the request's activity on the cluster.
+const ActivityIdHeader = "x-ms-activity-id"
+
+// Span attribute keys, besides the OpenTelemetry database semantic conventions.
+const (
+	attrClientRequestID = attribute.Key("kusto.client_request_id")
+	attrActivityID      = attribute.Key("kusto.activity_id")
+)
+
+// StatementRecording controls how the query or command text is recorded in the db.statement attribute of spans.
+type StatementRecording int
+
+const (
+	// StatementFull records the text as is, truncated to TracingOptions.MaxStatementLength.
+	StatementFull StatementRecording = iota
+	// StatementHashed records the hex encoded SHA-256 hash of the text, so identical statements can be correlated
+	// without recording literals that may be sensitive.
+	StatementHashed
+	// StatementOmitted doesn't record the text.
+	StatementOmitted
+)
+
+// defaultMaxStatementLength is the default length statements are truncated to.
+const defaultMaxStatementLength = 1024
+
+// TracingOptions configures the OpenTelemetry spans of a client.
+type TracingOptions struct {
+	// TracerProvider creates the client's tracer. Defaults to the global provider, see otel.GetTracerProvider.
+	TracerProvider trace.TracerProvider
+	// Statement controls how the query or command text is recorded. Defaults to StatementFull.
+	Statement StatementRecording
+	// MaxStatementLength is the length, in bytes, StatementFull truncates the text to. Defaults to 1024, negative
+	// values disable truncation.
+	MaxStatementLength int
+}
+
+// WithTracing configures the client to create an OpenTelemetry span for each Query and Mgmt call, with the
+// db.system, db.name and db.statement attributes, the client request id and the activity id of the request on the
+// cluster. The span ends once the response headers are received.
+func WithTracing(options TracingOptions) Option {
+	return func(c *Client) {
+		c.tracing = newTracing(options)
+	}
+}
+
+type tracing struct {
+	tracer             trace.Tracer
+	statement          StatementRecording
+	maxStatementLength int
+}
+
+func newTracing(options TracingOptions) *tracing {
+	provider := options.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	maxLength := options.MaxStatementLength
+	if maxLength == 0 {
+		maxLength = defaultMaxStatementLength
+	}
+	return &tracing{
+		tracer:             provider.Tracer(tracerName, trace.WithInstrumentationVersion(version.Kusto)),
+		statement:          options.Statement,
+		maxStatementLength: maxLength,
+	}
+}
+
+func (t *tracing) statementAttribute(statement string) (attribute.KeyValue, bool) {
+	switch t.statement {
+	case StatementOmitted:
+		return attribute.KeyValue{}, false
+	case StatementHashed:
+		sum := sha256.Sum256([]byte(statement))
+		return attribute.String("db.statement", hex.EncodeToString(sum[:])), true
+	}
+	if t.maxStatementLength > 0 && len(statement) > t.maxStatementLength {
+		statement = statement[:t.maxStatementLength]
+	}
+	return attribute.String("db.statement", statement), true
+}
+
+// start starts the span of a call. It returns a nil span if tracing is disabled.
+func (t *tracing) start(ctx context.Context, callType callType, db string, statement string) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	operation := callType.operation()
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "kusto"),
+		attribute.String("db.name", db),
+		attribute.String("db.operation", operation),
+	}
+	if attr, ok := t.statementAttribute(statement); ok {
+		attrs = append(attrs, attr)
+	}
+	return t.tracer.Start(ctx, "kusto."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// end ends the span of a call, with the ids of the request and its result.
+func (t *tracing) end(span trace.Span, headers http.Header, responseHeaders http.Header, err error) {
+	if span == nil {
+		return
+	}
+	if id := headers.Get(ClientRequestIdHeader); id != "" {
+		span.SetAttributes(attrClientRequestID.String(id))
+	}
+	if id := responseHeaders.Get(ActivityIdHeader); id != "" {
+		span.SetAttributes(attrActivityID.String(id))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,88 @@
+package azkustodata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]string {
+	attrs := map[attribute.Key]string{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	return attrs
+}
+
+func TestTracing(t *testing.T) {
+	srv := newTestKustoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(ActivityIdHeader, "activity-1")
+		if r.URL.Path == "/v2/rest/query" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(verifyTestShowVersion))
+	})
+
+	command := ".show version"
+	sum := sha256.Sum256([]byte(command))
+	tests := []struct {
+		name          string
+		options       TracingOptions
+		wantStatement string
+	}{
+		{name: "Full", wantStatement: command},
+		{name: "Truncated", options: TracingOptions{MaxStatementLength: 5}, wantStatement: ".show"},
+		{name: "Hashed", options: TracingOptions{Statement: StatementHashed}, wantStatement: hex.EncodeToString(sum[:])},
+		{name: "Omitted", options: TracingOptions{Statement: StatementOmitted}},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tt.options.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+			client := newTestKustoClient(t, srv, WithTracing(tt.options))
+
+			_, err := client.Mgmt(context.Background(), "db", kql.New(".show version"), ClientRequestID("request-1"))
+			require.NoError(t, err)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "kusto.mgmt", spans[0].Name())
+			assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+			attrs := spanAttributes(spans[0])
+			assert.Equal(t, "kusto", attrs["db.system"])
+			assert.Equal(t, "db", attrs["db.name"])
+			assert.Equal(t, "request-1", attrs["kusto.client_request_id"])
+			assert.Equal(t, "activity-1", attrs["kusto.activity_id"])
+			statement, ok := attrs["db.statement"]
+			assert.Equal(t, tt.wantStatement != "", ok)
+			assert.Equal(t, tt.wantStatement, statement)
+		})
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		client := newTestKustoClient(t, srv, WithTracing(TracingOptions{TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))}))
+
+		_, err := client.Query(context.Background(), "db", kql.New("T"))
+		require.Error(t, err)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "kusto.query", spans[0].Name())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+	})
+}
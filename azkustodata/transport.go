@@ -0,0 +1,141 @@
+package azkustodata
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// transportOptions are the settings of the http transport New creates when no http client or transport is passed in.
+type transportOptions struct {
+	proxy     *ProxyOptions
+	tlsConfig *tls.Config
+	pool      *ConnectionPoolOptions
+}
+
+// ProxyOptions configures the proxy the client connects through, instead of the one set in the HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY environment variables.
+type ProxyOptions struct {
+	// URL is the address of the proxy, for example "http://proxy.contoso.com:8080" or "socks5://proxy.contoso.com:1080".
+	URL string
+	// Username and Password authenticate with the proxy. They override any credentials in URL.
+	Username string
+	Password string
+	// NoProxy lists the hosts that are connected to directly, in the NO_PROXY format: host names, domain suffixes
+	// such as ".contoso.com", IP addresses and CIDR ranges, optionally with a port.
+	NoProxy []string
+}
+
+func (p ProxyOptions) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, errors.ES(errors.OpServConn, errors.KClientArgs, "invalid proxy URL: %s", err).SetNoRetry()
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, errors.ES(errors.OpServConn, errors.KClientArgs, "unsupported proxy URL scheme %q, must be http, https, socks5 or socks5h", u.Scheme).SetNoRetry()
+	}
+	if p.Username != "" || p.Password != "" {
+		u.User = url.UserPassword(p.Username, p.Password)
+	}
+
+	cfg := httpproxy.Config{
+		HTTPProxy:  u.String(),
+		HTTPSProxy: u.String(),
+		NoProxy:    strings.Join(p.NoProxy, ","),
+	}
+	proxy := cfg.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}, nil
+}
+
+// WithProxy configures the client to connect through the given proxy.
+// It is ignored if an http client or transport is passed in with WithHttpClient or WithTransport.
+func WithProxy(proxy ProxyOptions) Option {
+	return func(c *Client) {
+		c.transport.proxy = &proxy
+	}
+}
+
+// WithTLSConfig configures the TLS settings of the client's connections, such as custom root CAs for environments that
+// intercept TLS, client certificates for mutual TLS to a gateway, or the minimum TLS version.
+// It is ignored if an http client or transport is passed in with WithHttpClient or WithTransport.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) {
+		c.transport.tlsConfig = config.Clone()
+	}
+}
+
+// ConnectionPoolOptions tunes the connection pool of the client's transport, for example for high-QPS services.
+// Zero values keep the http.DefaultTransport settings.
+type ConnectionPoolOptions struct {
+	// MaxIdleConnsPerHost is the number of idle connections kept per host, 2 by default.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits the number of connections per host, in any state. Unlimited by default.
+	MaxConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept before it is closed, 90 seconds by default.
+	IdleConnTimeout time.Duration
+	// KeepAlive is the interval between TCP keep-alive probes, 30 seconds by default. A negative value disables them.
+	KeepAlive time.Duration
+}
+
+// WithConnectionPool tunes the connection pool of the client's transport.
+// It is ignored if an http client or transport is passed in with WithHttpClient or WithTransport.
+func WithConnectionPool(pool ConnectionPoolOptions) Option {
+	return func(c *Client) {
+		c.transport.pool = &pool
+	}
+}
+
+func (p ConnectionPoolOptions) apply(transport *http.Transport) {
+	if p.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = p.MaxIdleConnsPerHost
+		// The overall limit would otherwise cap the per host one.
+		if transport.MaxIdleConns != 0 && transport.MaxIdleConns < p.MaxIdleConnsPerHost {
+			transport.MaxIdleConns = p.MaxIdleConnsPerHost
+		}
+	}
+	if p.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = p.MaxConnsPerHost
+	}
+	if p.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = p.IdleConnTimeout
+	}
+	if p.KeepAlive != 0 {
+		// Matches the dialer of http.DefaultTransport, apart from the keep-alive.
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: p.KeepAlive}
+		transport.DialContext = dialer.DialContext
+	}
+}
+
+// newTransport returns the transport of the default http client, or nil to use http.DefaultTransport if no settings
+// were changed.
+func (o transportOptions) newTransport() (http.RoundTripper, error) {
+	if o == (transportOptions{}) {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if o.proxy != nil {
+		proxy, err := o.proxy.proxyFunc()
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = proxy
+	}
+	if o.tlsConfig != nil {
+		transport.TLSClientConfig = o.tlsConfig
+	}
+	if o.pool != nil {
+		o.pool.apply(transport)
+	}
+	return transport, nil
+}
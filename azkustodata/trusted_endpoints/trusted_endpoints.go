@@ -64,6 +64,8 @@ type TrustedEndpoints struct {
 	matchers          map[string]*FastSuffixMatcher
 	additionalMatcher *FastSuffixMatcher
 	overrideMatcher   func(string) bool
+	// parent is consulted first by the endpoints returned from WithTrustedHosts, so they also trust whatever it trusts.
+	parent *TrustedEndpoints
 }
 
 type MatchRule struct {
@@ -71,6 +73,12 @@ type MatchRule struct {
 	exact  bool
 }
 
+// NewMatchRule Creates a rule that trusts the given host name when exact is true, or any host name ending with suffix
+// otherwise, e.g. ".privatelink.contoso.com" for the Private Link endpoints of a custom DNS zone.
+func NewMatchRule(suffix string, exact bool) MatchRule {
+	return MatchRule{suffix: strings.ToLower(suffix), exact: exact}
+}
+
 type FastSuffixMatcher struct {
 	suffixLength int
 	rules        map[string][]MatchRule
@@ -178,6 +186,16 @@ func (trusted *TrustedEndpoints) AddTrustedHosts(rules []MatchRule, replace bool
 	return err
 }
 
+// WithTrustedHosts Returns endpoints that trust the given rules in addition to everything trusted is trusting, including
+// rules added to trusted later on. It is used to extend the trusted hosts for a single client, without affecting others.
+func (trusted *TrustedEndpoints) WithTrustedHosts(rules []MatchRule) (*TrustedEndpoints, error) {
+	matcher, err := newFastSuffixMatcher(rules)
+	if err != nil {
+		return nil, err
+	}
+	return &TrustedEndpoints{additionalMatcher: matcher, parent: trusted}, nil
+}
+
 // ValidateTrustedEndpoint Validates the endpoint uri trusted
 func (trusted *TrustedEndpoints) ValidateTrustedEndpoint(endpoint string, loginEndpoint string) error {
 	u, err := url.Parse(endpoint)
@@ -185,7 +203,7 @@ func (trusted *TrustedEndpoints) ValidateTrustedEndpoint(endpoint string, loginE
 		return err
 	}
 
-	host := u.Host
+	host := u.Hostname()
 	if host == "" {
 		host = endpoint
 	}
@@ -217,6 +235,10 @@ func (trusted *TrustedEndpoints) validateHostnameIsTrusted(host string, loginEnd
 		return nil
 	}
 
+	if trusted.parent != nil && trusted.parent.validateHostnameIsTrusted(host, loginEndpoint) == nil {
+		return nil
+	}
+
 	// Either check the override matcher OR the matcher:
 	override := trusted.overrideMatcher
 	if override != nil && override(host) {
@@ -255,3 +255,23 @@ func TestWellTrustedEndpoints_AdditionalWebsites(t *testing.T) {
 		require.NoError(t, err)
 	}
 }
+
+func TestWellTrustedEndpoints_WithTrustedHosts(t *testing.T) {
+	_, err := Instance.WithTrustedHosts(nil)
+	require.Error(t, err)
+
+	trusted, err := Instance.WithTrustedHosts([]MatchRule{NewMatchRule(".PrivateLink.Contoso.com", false), NewMatchRule("proxy.contoso.com", true)})
+	require.NoError(t, err)
+
+	for _, endpoint := range []string{"https://mycluster.privatelink.contoso.com", "https://proxy.contoso.com", "https://kusto.kusto.windows.net"} {
+		require.NoError(t, trusted.ValidateTrustedEndpoint(endpoint, defaultPublicLoginUrl), endpoint)
+	}
+	require.Error(t, trusted.ValidateTrustedEndpoint("https://other.proxy.contoso.com", defaultPublicLoginUrl))
+	// The rules only apply to the derived endpoints.
+	require.Error(t, Instance.ValidateTrustedEndpoint("https://mycluster.privatelink.contoso.com", defaultPublicLoginUrl))
+
+	// Rules added to the parent later on are trusted as well.
+	require.NoError(t, Instance.AddTrustedHosts([]MatchRule{NewMatchRule(".someotherdomain4.net", false)}, false))
+	defer Instance.AddTrustedHosts(nil, true)
+	require.NoError(t, trusted.ValidateTrustedEndpoint("https://some.someotherdomain4.net", defaultPublicLoginUrl))
+}
@@ -8,10 +8,34 @@ import (
 )
 
 // DateTime represents a Kusto datetime type.  DateTime implements Kusto.
+// Kusto datetimes have a resolution of a tick, 100ns, which time.Time holds exactly. Ticks returns them as Kusto
+// counts them.
 type DateTime struct {
 	pointerValue[time.Time]
 }
 
+// dateTimeFormat has the 7 fractional digits of Kusto's ticks.
+const dateTimeFormat = "2006-01-02T15:04:05.0000000Z"
+
+// ticksEpoch is the time ticks are counted from, 0001-01-01, as .NET and Kusto do.
+var ticksEpoch = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// FormatDatetime formats a time in UTC, in RFC3339 with the 7 fractional digits of Kusto's ticks, such as
+// 2024-01-02T03:04:05.1234567Z, so it round-trips through Kusto. Precision below a tick is dropped.
+func FormatDatetime(t time.Time) string {
+	return t.UTC().Format(dateTimeFormat)
+}
+
+// DateTimeFromTicks creates a DateTime from a number of ticks since 0001-01-01, as returned by Ticks.
+func DateTimeFromTicks(ticks int64) *DateTime {
+	sec := ticks / int64(time.Second/tick)
+	rem := ticks % int64(time.Second/tick)
+	if rem < 0 {
+		sec, rem = sec-1, rem+int64(time.Second/tick)
+	}
+	return NewDateTime(time.Unix(ticksEpoch.Unix()+sec, rem*int64(tick)).UTC())
+}
+
 // NewDateTime creates a new DateTime.
 func NewDateTime(v time.Time) *DateTime {
 	return &DateTime{newPointerValue[time.Time](&v)}
@@ -39,6 +63,16 @@ func (d *DateTime) Marshal() string {
 	return d.value.Format(time.RFC3339Nano)
 }
 
+// Ticks returns the number of ticks since 0001-01-01 of the datetime, as Kusto stores it, and false if it is null.
+func (d *DateTime) Ticks() (int64, bool) {
+	if d.value == nil {
+		return 0, false
+	}
+	t := d.value.UTC()
+	sec := t.Unix() - ticksEpoch.Unix()
+	return sec*int64(time.Second/tick) + int64(t.Nanosecond())/int64(tick), true
+}
+
 // Unmarshal unmarshals i into DateTime. i must be a string representing RFC3339Nano or nil.
 func (d *DateTime) Unmarshal(i interface{}) error {
 	if i == nil {
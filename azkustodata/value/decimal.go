@@ -1,6 +1,7 @@
 package value
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"github.com/shopspring/decimal"
@@ -9,6 +10,9 @@ import (
 )
 
 // Decimal represents a Kusto decimal type.  Decimal implements Kusto.
+// Values are held in an arbitrary-precision decimal.Decimal, parsed from the exact text Kusto returns, so they don't
+// lose precision. String returns the same value in plain notation without trailing zeros, so "1.50" is "1.5" and
+// "-2.5e-10" is "-0.00000000025", and Rat and Float64 convert it.
 type Decimal struct {
 	pointerValue[decimal.Decimal]
 }
@@ -25,12 +29,23 @@ func DecimalFromFloat(f float64) *Decimal {
 	return NewDecimal(decimal.NewFromFloat(f))
 }
 
+// DecimalFromString returns the decimal of s, or a null decimal if s isn't a valid decimal. Use ParseDecimal to get
+// the error instead.
 func DecimalFromString(s string) *Decimal {
-	dec, err := decimal.NewFromString(s)
+	dec, err := ParseDecimal(s)
 	if err != nil {
 		return NewNullDecimal()
 	}
-	return NewDecimal(dec)
+	return dec
+}
+
+// ParseDecimal parses a decimal, exactly, from its text, such as "1.5" or "-2.5e-10".
+func ParseDecimal(s string) (*Decimal, error) {
+	dec, err := decimal.NewFromString(s)
+	if err != nil {
+		return nil, parseError(&Decimal{}, s, err)
+	}
+	return NewDecimal(dec), nil
 }
 
 func (*Decimal) isKustoVal() {}
@@ -43,15 +58,37 @@ func (d *Decimal) ParseFloat(base int, prec uint, mode big.RoundingMode) (f *big
 	return big.ParseFloat(d.value.String(), base, prec, mode)
 }
 
-// Unmarshal unmarshals i into Decimal. i must be a string representing a decimal type or nil.
+// Rat returns the exact value of the decimal as a rational number.
+func (d *Decimal) Rat() (*big.Rat, error) {
+	if d.value == nil {
+		return nil, parseError(d, nil, fmt.Errorf("nil value"))
+	}
+	return d.value.Rat(), nil
+}
+
+// Float64 returns the float64 nearest to the value of the decimal, which may not be exact.
+func (d *Decimal) Float64() (float64, error) {
+	if d.value == nil {
+		return 0, parseError(d, nil, fmt.Errorf("nil value"))
+	}
+	f, _ := d.value.Float64()
+	return f, nil
+}
+
+// Unmarshal unmarshals i into Decimal. i must be a string or a json.Number representing a decimal type or nil.
 func (d *Decimal) Unmarshal(i interface{}) error {
 	if i == nil {
 		d.value = nil
 		return nil
 	}
 
-	v, ok := i.(string)
-	if !ok {
+	var v string
+	switch x := i.(type) {
+	case string:
+		v = x
+	case json.Number:
+		v = x.String()
+	default:
 		return convertError(d, i)
 	}
 
@@ -71,11 +108,39 @@ func (d *Decimal) Convert(v reflect.Value) error {
 		return nil
 	}
 
-	if v.Type().Kind() == reflect.String {
+	switch v.Type() {
+	case reflect.TypeOf(&big.Rat{}):
+		if d.value == nil {
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			v.Set(reflect.ValueOf(d.value.Rat()))
+		}
+		return nil
+	case reflect.TypeOf(&big.Float{}):
+		if d.value == nil {
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			f, _, err := d.ParseFloat(10, 128, big.ToNearestEven)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(f))
+		}
+		return nil
+	}
+
+	switch v.Type().Kind() {
+	case reflect.String:
 		if d.value != nil {
 			v.SetString(d.value.String())
 		}
 		return nil
+	case reflect.Float32, reflect.Float64:
+		if d.value != nil {
+			f, _ := d.value.Float64()
+			v.SetFloat(f)
+		}
+		return nil
 	}
 
 	return convertError(d, v)
@@ -1,10 +1,13 @@
 package value
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"reflect"
+	"strconv"
+	"strings"
 )
 
 // Dynamic represents a Kusto dynamic type.  Dynamic implements Kusto.
@@ -30,7 +33,7 @@ func (d *Dynamic) String() string {
 }
 
 func DynamicFromInterface(v interface{}) *Dynamic {
-	marshal, err := json.Marshal(v)
+	marshal, err := MarshalDynamic(v)
 	if err != nil {
 		return NewNullDynamic()
 	}
@@ -38,6 +41,18 @@ func DynamicFromInterface(v interface{}) *Dynamic {
 	return NewDynamic(marshal)
 }
 
+// MarshalDynamic returns the JSON encoding of v, as the value of a dynamic. Unlike json.Marshal, it doesn't escape HTML
+// characters, so strings keep their text as is.
+func MarshalDynamic(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
 func (*Dynamic) isKustoVal() {}
 
 // Unmarshal unmarshal's i into Dynamic. i must be a string, []byte, map[string]interface{}, []interface{}, other JSON serializable value or nil.
@@ -104,6 +119,15 @@ func (d *Dynamic) Convert(v reflect.Value) error {
 		}
 
 		valueToSet = structPtr.Elem()
+	case t.Kind() == reflect.Interface || t.Kind() == reflect.Bool ||
+		(t.Kind() >= reflect.Int && t.Kind() <= reflect.Float64):
+		// A dynamic holding a scalar, or any value for an interface{}, decoded as with json.Unmarshal.
+		ptr := reflect.New(t)
+		if err := json.Unmarshal(d.Value, ptr.Interface()); err != nil {
+			return fmt.Errorf("Could not unmarshal type dynamic into receiver: %s", err)
+		}
+
+		valueToSet = ptr.Elem()
 	default:
 		return fmt.Errorf("Column was type Kusto.Dynamic, receiver had base Kind %s ", t.Kind())
 	}
@@ -119,6 +143,163 @@ func (d *Dynamic) Convert(v reflect.Value) error {
 	return nil
 }
 
+// IsNull reports whether the value is null.
+func (d *Dynamic) IsNull() bool {
+	return d.Value == nil
+}
+
+// Get returns the JSON of the value, and false if it is null.
+func (d *Dynamic) Get() ([]byte, bool) {
+	return d.Value, d.Value != nil
+}
+
+// Decode unmarshals the JSON of the value into v, as json.Unmarshal does. A null value leaves v unchanged.
+func (d *Dynamic) Decode(v interface{}) error {
+	if d.Value == nil {
+		return nil
+	}
+	if err := json.Unmarshal(d.Value, v); err != nil {
+		return fmt.Errorf("could not decode dynamic value into %T: %s", v, err)
+	}
+	return nil
+}
+
+// AsMap returns the value as a map, if it is a property bag. Nested values are decoded as with json.Unmarshal into an
+// interface{}. A null value returns a nil map.
+func (d *Dynamic) AsMap() (map[string]interface{}, error) {
+	var m map[string]interface{}
+	if err := d.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AsArray returns the value as a slice, if it is an array. Nested values are decoded as with json.Unmarshal into an
+// interface{}. A null value returns a nil slice.
+func (d *Dynamic) AsArray() ([]interface{}, error) {
+	var a []interface{}
+	if err := d.Decode(&a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetPath returns the value at a path inside the value, with Kusto's syntax for dynamic accessors: properties
+// separated by dots, array indexes in brackets, negative ones counting from the end, and properties quoted in brackets
+// when they aren't identifiers, such as a.b[2] or a["b.c"][-1]. As in Kusto, a path that doesn't exist, such as a missing
+// property, an index out of range, or indexing a value of the wrong type, returns a null value.
+func (d *Dynamic) GetPath(path string) (*Dynamic, error) {
+	segments, err := parseDynamicPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := json.RawMessage(d.Value)
+	for _, segment := range segments {
+		if current == nil {
+			break
+		}
+		current, err = segment.get(current)
+		if err != nil {
+			return nil, fmt.Errorf("could not get path %q of dynamic value: %s", path, err)
+		}
+	}
+
+	if current == nil || bytes.Equal(current, []byte("null")) {
+		return NewNullDynamic(), nil
+	}
+	return NewDynamic(current), nil
+}
+
+// dynamicPathSegment is a property, or an array index if isIndex is set, of a path inside a dynamic value.
+type dynamicPathSegment struct {
+	property string
+	index    int
+	isIndex  bool
+}
+
+// get returns the JSON of the segment inside v, or nil if it doesn't exist.
+func (s dynamicPathSegment) get(v json.RawMessage) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(v)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	if s.isIndex {
+		if trimmed[0] != '[' {
+			return nil, nil
+		}
+		var a []json.RawMessage
+		if err := json.Unmarshal(trimmed, &a); err != nil {
+			return nil, err
+		}
+		i := s.index
+		if i < 0 {
+			i += len(a)
+		}
+		if i < 0 || i >= len(a) {
+			return nil, nil
+		}
+		return a[i], nil
+	}
+
+	if trimmed[0] != '{' {
+		return nil, nil
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &m); err != nil {
+		return nil, err
+	}
+	return m[s.property], nil
+}
+
+// parseDynamicPath splits a path such as a.b[2]["c d"] into its segments.
+func parseDynamicPath(path string) ([]dynamicPathSegment, error) {
+	var segments []dynamicPathSegment
+	rest := path
+	for first := true; rest != "" || first; first = false {
+		switch {
+		case rest != "" && rest[0] == '[':
+			body := rest[1:]
+			if body != "" && (body[0] == '"' || body[0] == '\'') {
+				closing := strings.IndexByte(body[1:], body[0])
+				if closing < 0 || !strings.HasPrefix(body[closing+2:], "]") {
+					return nil, fmt.Errorf("dynamic path %q has an unclosed quoted property", path)
+				}
+				segments = append(segments, dynamicPathSegment{property: body[1 : closing+1]})
+				rest = body[closing+3:]
+				continue
+			}
+			end := strings.IndexByte(body, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("dynamic path %q has an unclosed bracket", path)
+			}
+			i, err := strconv.Atoi(strings.TrimSpace(body[:end]))
+			if err != nil {
+				return nil, fmt.Errorf("dynamic path %q has an invalid index %q", path, body[:end])
+			}
+			segments = append(segments, dynamicPathSegment{index: i, isIndex: true})
+			rest = body[end+1:]
+		case first || rest[0] == '.':
+			if !first {
+				rest = rest[1:]
+			}
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("dynamic path %q has an empty property", path)
+			}
+			segments = append(segments, dynamicPathSegment{property: rest[:end]})
+			rest = rest[end:]
+		default:
+			return nil, fmt.Errorf("dynamic path %q is missing a dot before %q", path, rest)
+		}
+	}
+	return segments, nil
+}
+
 // GetType returns the type of the value.
 func (d *Dynamic) GetType() types.Column {
 	return types.Dynamic
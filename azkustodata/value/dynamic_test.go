@@ -210,3 +210,64 @@ func TestDynamicConverterNegative(t *testing.T) {
 
 	}
 }
+
+func TestDynamicAccessors(t *testing.T) {
+	d := value.NewDynamic([]byte(`{"a":{"b":[1,"two",{"c":true}]},"d.e":"dotted","f]":null,"n":2.5}`))
+
+	m, err := d.AsMap()
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, m["n"])
+	_, err = d.AsArray()
+	assert.Error(t, err)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"a.b[1]", `"two"`},
+		{"a.b[-1].c", `true`},
+		{"a['b'][0]", `1`},
+		{`["d.e"]`, `"dotted"`},
+		{`['f]']`, ``},
+		{"a.missing", ``},
+		{"a.b[3]", ``},
+		{"a.b.c", ``},
+		{"n[0]", ``},
+		{"missing.deeper[0]", ``},
+	}
+	for _, test := range tests {
+		got, err := d.GetPath(test.path)
+		assert.NoError(t, err, test.path)
+		assert.Equal(t, test.want, got.String(), test.path)
+		assert.Equal(t, test.want == "", got.IsNull(), test.path)
+	}
+
+	for _, path := range []string{"", "a..b", ".a", "a[", "a[x]", "a['b]", "a[0]b"} {
+		_, err := d.GetPath(path)
+		assert.Error(t, err, path)
+	}
+
+	b, err := d.GetPath("a.b")
+	assert.NoError(t, err)
+	arr, err := b.AsArray()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{1.0, "two", map[string]interface{}{"c": true}}, arr)
+
+	var s struct {
+		A struct {
+			B []interface{} `json:"b"`
+		} `json:"a"`
+	}
+	assert.NoError(t, d.Decode(&s))
+	assert.Len(t, s.A.B, 3)
+	var x int
+	assert.Error(t, d.Decode(&x))
+
+	null := value.NewNullDynamic()
+	m, err = null.AsMap()
+	assert.NoError(t, err)
+	assert.Nil(t, m)
+	got, err := null.GetPath("a")
+	assert.NoError(t, err)
+	assert.True(t, got.IsNull())
+}
@@ -63,6 +63,16 @@ func (s *String) GetValue() interface{} {
 	return s.Value
 }
 
+// IsNull always returns false, as Kusto doesn't tell null strings from empty ones.
+func (s *String) IsNull() bool {
+	return false
+}
+
+// Get returns the value of the string, which is never null.
+func (s *String) Get() (string, bool) {
+	return s.Value, true
+}
+
 // GetType returns the type of the value.
 func (s *String) GetType() types.Column {
 	return types.String
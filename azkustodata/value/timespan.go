@@ -3,6 +3,8 @@ package value
 import (
 	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
+	"github.com/shopspring/decimal"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
@@ -24,6 +26,7 @@ func NewNullTimespan() *Timespan {
 	return &Timespan{newPointerValue[time.Duration](nil)}
 }
 
+// TimespanFromString returns the timespan of s, see ParseTimespan for the accepted formats.
 func TimespanFromString(s string) (*Timespan, error) {
 	t := &Timespan{}
 	err := t.Unmarshal(s)
@@ -36,69 +39,58 @@ func TimespanFromString(s string) (*Timespan, error) {
 // Marshal marshals the Timespan into a Kusto compatible string. The string is the contant invariant(c)
 // format. See https://docs.microsoft.com/en-us/dotnet/standard/base-types/standard-timespan-format-strings .
 func (t *Timespan) Marshal() string {
-	const (
-		day = 24 * time.Hour
-	)
-
 	if t.value == nil {
 		return "00:00:00"
 	}
 
-	// val is used to track the duration value as we move our parts of our time into our string format.
-	// For example, after we write to our string the number of days that value had, we remove those days
-	// from the duration. We continue doing this until val only holds values < 10 millionth of a second (tick)
-	// as that is the lowest precision in our string representation.
-	val := *t.value
-
+	sign, days, clock, ticks := splitTimespan(*t.value)
 	sb := strings.Builder{}
-
-	// Add a - sign if we have a negative value. Convert our value to positive for easier processing.
-	if val < 0 {
-		sb.WriteString("-")
-		val = val * -1
-	}
-
+	sb.WriteString(sign)
 	// Only include the day if the duration is 1+ days.
-	days := val / day
-	val = val - (days * day)
 	if days > 0 {
-		sb.WriteString(fmt.Sprintf("%d.", int(days)))
+		sb.WriteString(fmt.Sprintf("%d.", days))
 	}
-
-	// Add our hours:minutes:seconds section.
-	hours := val / time.Hour
-	val = val - (hours * time.Hour)
-	minutes := val / time.Minute
-	val = val - (minutes * time.Minute)
-	seconds := val / time.Second
-	val = val - (seconds * time.Second)
-	sb.WriteString(fmt.Sprintf("%02d:%02d:%02d", int(hours), int(minutes), int(seconds)))
-
-	// Add our sub-second string representation that is proceeded with a ".".
-	milliseconds := val / time.Millisecond
-	val = val - (milliseconds * time.Millisecond)
-	ticks := val / tick
-	if milliseconds > 0 || ticks > 0 {
-		sb.WriteString(fmt.Sprintf(".%03d%d", milliseconds, ticks))
+	sb.WriteString(clock)
+	if ticks > 0 {
+		// Remove any trailing 0's of the fraction.
+		sb.WriteString(strings.TrimRight(fmt.Sprintf(".%07d", ticks), "0"))
 	}
+	return sb.String()
+}
 
-	// Remove any trailing 0's.
-	str := strings.TrimRight(sb.String(), "0")
-	if strings.HasSuffix(str, ":") {
-		str = str + "00"
+// splitTimespan splits a duration into its sign, its days, its hh:mm:ss and its remaining ticks. Precision below a
+// tick is dropped.
+func splitTimespan(d time.Duration) (sign string, days uint64, clock string, ticks uint64) {
+	// The absolute value is unsigned, so that the smallest duration doesn't overflow.
+	abs := uint64(d)
+	if d < 0 {
+		sign = "-"
+		abs = uint64(-d)
 	}
+	days = abs / uint64(day)
+	abs %= uint64(day)
+	hours := abs / uint64(time.Hour)
+	abs %= uint64(time.Hour)
+	minutes := abs / uint64(time.Minute)
+	abs %= uint64(time.Minute)
+	seconds := abs / uint64(time.Second)
+	abs %= uint64(time.Second)
+	return sign, days, fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds), abs / uint64(tick)
+}
 
-	return str
+// FormatTimespan formats a duration in the [-][d.]hh:mm:ss.fffffff format of Kusto's timespan literals, such as
+// timespan(1.02:03:04.0000005).
+func FormatTimespan(d time.Duration) string {
+	sign, days, clock, ticks := splitTimespan(d)
+	if days > 0 {
+		return fmt.Sprintf("%s%d.%s.%07d", sign, days, clock, ticks)
+	}
+	return fmt.Sprintf("%s%s.%07d", sign, clock, ticks)
 }
 
 // Unmarshal unmarshals i into Timespan. i must be a string representing a Values timespan or nil.
+// See ParseTimespan for the accepted formats.
 func (t *Timespan) Unmarshal(i interface{}) error {
-	const (
-		hoursIndex   = 0
-		minutesIndex = 1
-		secondsIndex = 2
-	)
-
 	if i == nil {
 		t.value = nil
 		return nil
@@ -109,138 +101,157 @@ func (t *Timespan) Unmarshal(i interface{}) error {
 		return convertError(t, i)
 	}
 
-	negative := false
-	if len(v) > 1 {
-		if string(v[0]) == "-" {
-			negative = true
-			v = v[1:]
-		}
+	d, err := ParseTimespan(v)
+	if err != nil {
+		return parseError(t, v, err)
 	}
+	t.value = &d
+	return nil
+}
 
-	sp := strings.Split(v, ":")
-	if len(sp) != 3 {
-		return parseError(v, sp, fmt.Errorf("value to unmarshal into Timespan does not seem to fit format '00:00:00', where values are decimal(%s)", v))
-	}
+var day = 24 * time.Hour
 
-	var sum time.Duration
+// timespanUnits maps the units of Kusto's timespan literals to their duration.
+var timespanUnits = map[string]time.Duration{
+	"d": day, "day": day, "days": day,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"s": time.Second, "sec": time.Second, "second": time.Second, "seconds": time.Second,
+	"ms": time.Millisecond, "milli": time.Millisecond, "millis": time.Millisecond, "millisecond": time.Millisecond, "milliseconds": time.Millisecond,
+	"microsecond": time.Microsecond, "microseconds": time.Microsecond,
+	"tick": tick, "ticks": tick,
+}
 
-	d, err := t.unmarshalDaysHours(sp[hoursIndex])
-	if err != nil {
-		return parseError(v, sp, err)
+// ParseTimespan parses a Kusto timespan. It accepts the [-][d.]hh:mm:ss[.fffffff] format of query results and of
+// FormatTimespan, and Kusto's timespan literals: a number followed by a unit, such as 2d, 1.5h, 30m, 10s, 100ms,
+// 10microseconds or 1tick, where a number without a unit is a number of days. Both may be wrapped in time(...) or
+// timespan(...), and be negative. Precision below a nanosecond is rounded.
+func ParseTimespan(s string) (time.Duration, error) {
+	v := strings.TrimSpace(s)
+	for _, prefix := range []string{"timespan(", "time("} {
+		if strings.HasPrefix(v, prefix) && strings.HasSuffix(v, ")") {
+			v = strings.TrimSpace(v[len(prefix) : len(v)-1])
+			break
+		}
 	}
-	sum += d
 
-	d, err = t.unmarshalMinutes(sp[minutesIndex])
-	if err != nil {
-		return parseError(v, sp, err)
+	negative := strings.HasPrefix(v, "-")
+	if negative {
+		v = v[1:]
 	}
-	sum += d
 
-	d, err = t.unmarshalSeconds(sp[secondsIndex])
+	var d decimal.Decimal
+	var err error
+	if strings.Contains(v, ":") {
+		d, err = parseClockTimespan(v)
+	} else {
+		d, err = parseLiteralTimespan(v)
+	}
 	if err != nil {
-		return parseError(v, sp, err)
+		return 0, fmt.Errorf("timespan %q is invalid: %s", s, err)
 	}
 
-	sum += d
-
 	if negative {
-		sum = sum * time.Duration(-1)
+		d = d.Neg()
+	}
+	d = d.Round(0)
+	if d.GreaterThan(decimal.NewFromInt(math.MaxInt64)) || d.LessThan(decimal.NewFromInt(math.MinInt64)) {
+		return 0, fmt.Errorf("timespan %q is out of range", s)
 	}
+	return time.Duration(d.IntPart()), nil
+}
 
-	t.value = &sum
-	return nil
+// parseLiteralTimespan parses a number followed by a unit into nanoseconds.
+func parseLiteralTimespan(v string) (decimal.Decimal, error) {
+	i := strings.IndexFunc(v, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	number, unit := v, ""
+	if i >= 0 {
+		number, unit = v[:i], v[i:]
+	}
+	multiplier, ok := timespanUnits[strings.ToLower(unit)]
+	if unit == "" {
+		multiplier, ok = day, true
+	}
+	if !ok {
+		return decimal.Decimal{}, fmt.Errorf("unknown unit %q", unit)
+	}
+	n, err := parseTimespanNumber(number)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return n.Mul(decimal.NewFromInt(int64(multiplier))), nil
 }
 
-var day = 24 * time.Hour
+// parseClockTimespan parses [d.]hh:mm:ss[.fffffff] into nanoseconds.
+func parseClockTimespan(v string) (decimal.Decimal, error) {
+	sp := strings.Split(v, ":")
+	if len(sp) != 3 {
+		return decimal.Decimal{}, fmt.Errorf("it does not fit the format [d.]hh:mm:ss[.fffffff]")
+	}
 
-func (t *Timespan) unmarshalDaysHours(s string) (time.Duration, error) {
-	sp := strings.Split(s, ".")
-	switch len(sp) {
-	case 1:
-		hours, err := strconv.Atoi(s)
-		if err != nil {
-			return 0, fmt.Errorf("timespan's hours/day field was incorrect, was %s: %s", s, err)
+	var days, hours int64
+	var err error
+	if d, h, ok := strings.Cut(sp[0], "."); ok {
+		if days, err = parseTimespanField("days", d, -1); err != nil {
+			return decimal.Decimal{}, err
 		}
-		return time.Duration(hours) * time.Hour, nil
-	case 2:
-		days, err := strconv.Atoi(sp[0])
-		if err != nil {
-			return 0, fmt.Errorf("timespan's hours/day field was incorrect, was %s", s)
+		if hours, err = parseTimespanField("hours", h, 23); err != nil {
+			return decimal.Decimal{}, err
 		}
-		hours, err := strconv.Atoi(sp[1])
-		if err != nil {
-			return 0, fmt.Errorf("timespan's hours/day field was incorrect, was %s", s)
-		}
-		return time.Duration(days)*day + time.Duration(hours)*time.Hour, nil
+	} else if hours, err = parseTimespanField("hours", sp[0], -1); err != nil {
+		return decimal.Decimal{}, err
+	}
+	// The minutes can have a fraction, as in 01.00, which is ignored.
+	min, minFraction, _ := strings.Cut(sp[1], ".")
+	if strings.Trim(minFraction, "0123456789") != "" {
+		return decimal.Decimal{}, fmt.Errorf("its fraction of minutes %q is not a number", minFraction)
+	}
+	minutes, err := parseTimespanField("minutes", min, 59)
+	if err != nil {
+		return decimal.Decimal{}, err
 	}
-	return 0, fmt.Errorf("timespan's hours/days field did not have the requisite '.'s, was %s", s)
-}
-
-func (t *Timespan) unmarshalMinutes(s string) (time.Duration, error) {
-	s = strings.Split(s, ".")[0] // We can have 01 or 01.00 or 59, but nothing comes behind the .
 
-	minutes, err := strconv.Atoi(s)
+	sec, fraction, hasFraction := strings.Cut(sp[2], ".")
+	seconds, err := parseTimespanField("seconds", sec, 59)
 	if err != nil {
-		return 0, fmt.Errorf("timespan's minutes field was incorrect, was %s", s)
+		return decimal.Decimal{}, err
 	}
-	if minutes < 0 || minutes > 59 {
-		return 0, fmt.Errorf("timespan's minutes field was incorrect, was %s", s)
+	if hasFraction && (len(fraction) == 0 || len(fraction) > 9 || strings.Trim(fraction, "0123456789") != "") {
+		return decimal.Decimal{}, fmt.Errorf("its fraction of seconds %q is not 1 to 9 digits", fraction)
 	}
-	return time.Duration(minutes) * time.Minute, nil
+
+	total := decimal.NewFromInt(days).Mul(decimal.NewFromInt(int64(day))).
+		Add(decimal.NewFromInt(hours).Mul(decimal.NewFromInt(int64(time.Hour)))).
+		Add(decimal.NewFromInt(minutes * int64(time.Minute))).
+		Add(decimal.NewFromInt(seconds * int64(time.Second)))
+	if hasFraction {
+		f, _ := decimal.NewFromString("0." + fraction)
+		total = total.Add(f.Mul(decimal.NewFromInt(int64(time.Second))))
+	}
+	return total, nil
 }
 
-// unmarshalSeconds deals with this crazy output format. Instead of having some multiplier, the number
-// of precision characters behind the decimal indicates your multiplier. This can be between 0 and 7, but
-// really only has 3, 4 and 7. There is something called a tick, which is 100 Nanoseconds and the precision
-// at len 4 is 100 * Microsecond (don't know if that has a name).
-func (t *Timespan) unmarshalSeconds(s string) (time.Duration, error) {
-	// "03" = 3 * time.Second
-	// "00.099" = 99 * time.Millisecond
-	// "03.0123" == 3 * time.Second + 12300 * time.Microsecond
-	sp := strings.Split(s, ".")
-	switch len(sp) {
-	case 1:
-		seconds, err := strconv.Atoi(s)
-		if err != nil {
-			return 0, fmt.Errorf("timespan's seconds field was incorrect, was %s", s)
-		}
-		return time.Duration(seconds) * time.Second, nil
-	case 2:
-		seconds, err := strconv.Atoi(sp[0])
-		if err != nil {
-			return 0, fmt.Errorf("timespan's seconds field was incorrect, was %s", s)
-		}
-		n, err := strconv.Atoi(sp[1])
-		if err != nil {
-			return 0, fmt.Errorf("timespan's seconds field was incorrect, was %s", s)
-		}
-		var prec time.Duration
-		switch len(sp[1]) {
-		case 1:
-			prec = time.Duration(n) * (100 * time.Millisecond)
-		case 2:
-			prec = time.Duration(n) * (10 * time.Millisecond)
-		case 3:
-			prec = time.Duration(n) * time.Millisecond
-		case 4:
-			prec = time.Duration(n) * 100 * time.Microsecond
-		case 5:
-			prec = time.Duration(n) * 10 * time.Microsecond
-		case 6:
-			prec = time.Duration(n) * time.Microsecond
-		case 7:
-			prec = time.Duration(n) * tick
-		case 8:
-			prec = time.Duration(n) * (10 * time.Nanosecond)
-		case 9:
-			prec = time.Duration(n) * time.Nanosecond
-		default:
-			return 0, fmt.Errorf("timespan's seconds field did not have 1-9 numbers after the decimal, had %v", s)
-		}
+// parseTimespanField parses a field of digits, up to max if it isn't negative.
+func parseTimespanField(name string, s string, max int64) (int64, error) {
+	if s == "" || strings.Trim(s, "0123456789") != "" {
+		return 0, fmt.Errorf("its %s field %q is not a number", name, s)
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("its %s field %q is out of range", name, s)
+	}
+	if max >= 0 && n > max {
+		return 0, fmt.Errorf("its %s field %q is over %d", name, s, max)
+	}
+	return n, nil
+}
 
-		return time.Duration(seconds)*time.Second + prec, nil
+// parseTimespanNumber parses the unsigned number of a timespan literal, such as 1, 1.5 or .5.
+func parseTimespanNumber(s string) (decimal.Decimal, error) {
+	if s == "" || s == "." || strings.Count(s, ".") > 1 {
+		return decimal.Decimal{}, fmt.Errorf("%q is not a number", s)
 	}
-	return 0, fmt.Errorf("timespan's seconds field did not have the requisite '.'s, was %s", s)
+	return decimal.NewFromString(s)
 }
 
 // Convert Timespan into reflect value.
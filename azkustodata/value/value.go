@@ -65,6 +65,20 @@ func (p *pointerValue[T]) Ptr() *T {
 	return p.value
 }
 
+// IsNull reports whether the value is null.
+func (p *pointerValue[T]) IsNull() bool {
+	return p.value == nil
+}
+
+// Get returns the value, and false if it is null, in which case the value is the zero value.
+func (p *pointerValue[T]) Get() (T, bool) {
+	if p.value == nil {
+		var zero T
+		return zero, false
+	}
+	return *p.value, true
+}
+
 func convertError(expected interface{}, actual interface{}) error {
 	if ref, ok := actual.(reflect.Value); ok {
 		return errors.ES(errors.OpTableAccess, errors.KWrongColumnType, "column with type '%T' had value that was %v", expected, ref.Type())
@@ -137,6 +151,8 @@ type Kusto interface {
 	GetValue() interface{}
 	GetType() types.Column
 	Unmarshal(interface{}) error
+	// IsNull reports whether the value is null. Strings are never null, an empty string is the same as a null one.
+	IsNull() bool
 }
 
 func Default(t types.Column) Kusto {
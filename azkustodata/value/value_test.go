@@ -3,10 +3,13 @@ package value
 import (
 	"encoding/json"
 	"math"
+	"math/big"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
@@ -504,6 +507,8 @@ func TestTimespan(t *testing.T) {
 		{i: "00:00:00", want: *NewTimespan(time.Duration(0))},
 		{i: "00:00:03", want: *NewTimespan(3 * time.Second)},
 		{i: "00:04:03", want: *NewTimespan(4*time.Minute + 3*time.Second)},
+		{i: "00:04:30", want: *NewTimespan(4*time.Minute + 30*time.Second)},
+		{i: "10:00:00", want: *NewTimespan(10 * time.Hour)},
 		{i: "02:04:03", want: *NewTimespan(2*time.Hour + 4*time.Minute + 3*time.Second)},
 		{i: "00:00:00.099", want: *NewTimespan(99 * time.Millisecond)},
 		{i: "02:04:03.0123", want: *NewTimespan(2*time.Hour + 4*time.Minute + 3*time.Second + 12300*time.Microsecond)},
@@ -512,9 +517,17 @@ func TestTimespan(t *testing.T) {
 		{i: "-01.00:00:00", want: *NewTimespan(-24 * time.Hour)},
 		{i: "-02.04:05:07", want: *NewTimespan(time.Duration(-1) * (2*24*time.Hour + 4*time.Hour + 5*time.Minute + 7*time.Second))},
 		{i: "00.00:00.00:00.000", want: *NewTimespan(time.Duration(0))},
+		{desc: "fraction of minutes has digits", i: "00:01.5x:00", err: true},
+		{desc: "hours are under a day with days", i: "1.24:00:00", err: true},
+		{desc: "minutes are under an hour", i: "00:60:00", err: true},
+		{desc: "fraction has digits", i: "00:00:00.", err: true},
+		{desc: "unknown unit", i: "1y", err: true},
+		{desc: "out of range", i: "106752.00:00:00", err: true},
 		{i: "02.04:05:07.789", want: *NewTimespan(2*24*time.Hour + 4*time.Hour + 5*time.Minute + 7*time.Second + 789*time.Millisecond)},
 		{i: "03.00:00:00.111", want: *NewTimespan(3*24*time.Hour + 111*time.Millisecond)},
 		{i: "03.00:00:00.111", want: *NewTimespan(3*24*time.Hour + 111*time.Millisecond)},
+		{i: "00:00:00.0000001", want: *NewTimespan(100 * time.Nanosecond)},
+		{i: "00:00:00.0010005", want: *NewTimespan(time.Millisecond + 500*time.Nanosecond)},
 		{i: "364.23:59:59.9999999", want: *NewTimespan(364*day + 23*time.Hour + 59*time.Minute + 59*time.Second + 9999999*100*time.Nanosecond)},
 	}
 
@@ -545,6 +558,104 @@ func TestTimespan(t *testing.T) {
 	}
 }
 
+func TestParseTimespan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s    string
+		want time.Duration
+	}{
+		{"1.02:03:04.5", day + 2*time.Hour + 3*time.Minute + 4*time.Second + 500*time.Millisecond},
+		{"-1.02:03:04.0000005", -(day + 2*time.Hour + 3*time.Minute + 4*time.Second + 500*time.Nanosecond)},
+		{"25:00:00", 25 * time.Hour},
+		{"00:01.50:00", time.Minute},
+		{"2d", 2 * day},
+		{"1.5h", 90 * time.Minute},
+		{"30m", 30 * time.Minute},
+		{"30min", 30 * time.Minute},
+		{"10s", 10 * time.Second},
+		{"100ms", 100 * time.Millisecond},
+		{"10microseconds", 10 * time.Microsecond},
+		{"1tick", tick},
+		{"-3ticks", -3 * tick},
+		{".5d", 12 * time.Hour},
+		{"2", 2 * day},
+		{"time(1d)", day},
+		{"time(-1.00:00:00)", -day},
+		{"timespan(00:01:00.0000000)", time.Minute},
+		{"106751.23:47:16.854775807", math.MaxInt64},
+		{"-106751.23:47:16.854775808", math.MinInt64},
+	}
+
+	for _, test := range tests {
+		got, err := ParseTimespan(test.s)
+		assert.NoError(t, err, test.s)
+		assert.Equal(t, test.want, got, test.s)
+	}
+
+	for _, s := range []string{"", "d", "1..5h", "time(", "1:2", "-", "1.2.3:00:00"} {
+		_, err := ParseTimespan(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestFormatTimespan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		d       time.Duration
+		want    string
+		marshal string
+	}{
+		{0, "00:00:00.0000000", "00:00:00"},
+		{time.Minute + tick, "00:01:00.0000001", "00:01:00.0000001"},
+		{2*day + time.Hour + 500*time.Millisecond, "2.01:00:00.5000000", "2.01:00:00.5"},
+		{-(day + time.Second), "-1.00:00:01.0000000", "-1.00:00:01"},
+		{-time.Millisecond, "-00:00:00.0010000", "-00:00:00.001"},
+		{math.MinInt64, "-106751.23:47:16.8547758", "-106751.23:47:16.8547758"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want, FormatTimespan(test.d))
+		assert.Equal(t, test.marshal, TimespanString(test.d))
+		// Formatting drops precision below a tick.
+		parsed, err := ParseTimespan(FormatTimespan(test.d))
+		assert.NoError(t, err)
+		assert.Equal(t, test.d/tick*tick, parsed)
+	}
+}
+
+func TestDateTimeTicks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		t     time.Time
+		ticks int64
+		s     string
+	}{
+		{time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC), 0, "0001-01-01T00:00:00.0000000Z"},
+		{time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), 621355968000000000, "1970-01-01T00:00:00.0000000Z"},
+		{time.Date(2020, 3, 4, 14, 5, 1, 310996500, time.UTC), 637189275013109965, "2020-03-04T14:05:01.3109965Z"},
+		{time.Date(9999, 12, 31, 23, 59, 59, 999999900, time.UTC), 3155378975999999999, "9999-12-31T23:59:59.9999999Z"},
+		{time.Date(2020, 1, 1, 2, 0, 0, 100, time.FixedZone("", 2*3600)), 637134336000000001, "2020-01-01T00:00:00.0000001Z"},
+	}
+
+	for _, test := range tests {
+		ticks, ok := NewDateTime(test.t).Ticks()
+		assert.True(t, ok)
+		assert.Equal(t, test.ticks, ticks, test.s)
+		got, _ := DateTimeFromTicks(ticks).Get()
+		assert.True(t, test.t.Equal(got), test.s)
+		assert.Equal(t, test.s, FormatDatetime(test.t))
+	}
+
+	_, ok := NewNullDateTime().Ticks()
+	assert.False(t, ok)
+
+	// Formatting drops precision below a tick, and keeps trailing zeros.
+	assert.Equal(t, "2020-01-01T00:00:00.1000000Z", FormatDatetime(time.Date(2020, 1, 1, 0, 0, 0, 100000099, time.UTC)))
+}
+
 func removeLeadingZeros(s string) string {
 	if len(s) == 0 {
 		return s
@@ -574,6 +685,8 @@ func TestDecimal(t *testing.T) {
 		{desc: "Conversion of '1.',", i: "1.", want: *DecimalFromString("1.")},
 		{desc: "Conversion of '0.1',", i: "0.1", want: *DecimalFromString("0.1")},
 		{desc: "Conversion of '3.07',", i: "3.07", want: *DecimalFromString("3.07")},
+		{desc: "Conversion of json.Number", i: json.Number("1.00000000000000000000000000000001"), want: *DecimalFromString("1.00000000000000000000000000000001")},
+		{desc: "cannot be an invalid string", i: "1.2.3", err: true},
 	}
 
 	for _, test := range tests {
@@ -601,3 +714,76 @@ func timeMustParse(layout string, p string) time.Time {
 	}
 	return t
 }
+
+func TestIsNull(t *testing.T) {
+	t.Parallel()
+
+	long := NewLong(0)
+	assert.False(t, long.IsNull())
+	v, ok := long.Get()
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), v)
+
+	null := NewNullLong()
+	assert.True(t, null.IsNull())
+	_, ok = null.Get()
+	assert.False(t, ok)
+
+	assert.False(t, NewString("").IsNull())
+	assert.True(t, NewNullDynamic().IsNull())
+	_, ok = NewDynamic([]byte(`{}`)).Get()
+	assert.True(t, ok)
+
+	for _, column := range []types.Column{types.Bool, types.Int, types.Long, types.Real, types.Decimal, types.Dynamic, types.DateTime, types.Timespan, types.GUID} {
+		assert.True(t, Default(column).IsNull(), column)
+	}
+}
+
+func TestDecimalConversions(t *testing.T) {
+	t.Parallel()
+
+	const exact = "-79228162514264.337593543950335"
+	d, err := ParseDecimal(exact)
+	assert.NoError(t, err)
+	assert.Equal(t, exact, d.String())
+
+	r, err := d.Rat()
+	assert.NoError(t, err)
+	want, _ := new(big.Rat).SetString(exact)
+	assert.Equal(t, 0, want.Cmp(r))
+
+	f, err := d.Float64()
+	assert.NoError(t, err)
+	assert.Equal(t, -79228162514264.34, f)
+
+	var s struct {
+		Rat   *big.Rat
+		Float float64
+		Str   string
+	}
+	assert.NoError(t, d.Convert(reflect.ValueOf(&s.Rat).Elem()))
+	assert.Equal(t, 0, want.Cmp(s.Rat))
+	assert.NoError(t, d.Convert(reflect.ValueOf(&s.Float).Elem()))
+	assert.Equal(t, f, s.Float)
+	assert.NoError(t, d.Convert(reflect.ValueOf(&s.Str).Elem()))
+	assert.Equal(t, exact, s.Str)
+
+	null := NewNullDecimal()
+	_, err = null.Rat()
+	assert.Error(t, err)
+	_, err = null.Float64()
+	assert.Error(t, err)
+	assert.NoError(t, null.Convert(reflect.ValueOf(&s.Rat).Elem()))
+	assert.Nil(t, s.Rat)
+
+	// String keeps the value, not the text it was parsed from.
+	for text, want := range map[string]string{"1.50": "1.5", "-2.5e-10": "-0.00000000025", "1e3": "1000", ".1": "0.1"} {
+		d, err := ParseDecimal(text)
+		assert.NoError(t, err)
+		assert.Equal(t, want, d.String(), text)
+	}
+
+	_, err = ParseDecimal("abc")
+	assert.Error(t, err)
+	assert.True(t, DecimalFromString("abc").IsNull())
+}
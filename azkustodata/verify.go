@@ -0,0 +1,117 @@
+package azkustodata
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	kustoErrors "github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/Azure/azure-kusto-go/azkustodata/kql"
+)
+
+// AuthFailure classifies why VerifyAuth failed.
+type AuthFailure int
+
+const (
+	// AuthFailureOther is a failure that doesn't fit any other kind, see the wrapped error for details.
+	AuthFailureOther AuthFailure = iota
+	// AuthFailureDNS means the host name of the cluster or of the identity provider could not be resolved.
+	AuthFailureDNS
+	// AuthFailureTLS means the TLS handshake failed, usually because the server certificate isn't trusted.
+	AuthFailureTLS
+	// AuthFailureNetwork means the connection failed or timed out.
+	AuthFailureNetwork
+	// AuthFailureCredential means a token could not be acquired with the configured credential.
+	AuthFailureCredential
+	// AuthFailureUnauthorized means the cluster rejected the token (HTTP 401).
+	AuthFailureUnauthorized
+	// AuthFailureForbidden means the identity is authenticated, but has no access to the cluster (HTTP 403).
+	AuthFailureForbidden
+)
+
+func (f AuthFailure) String() string {
+	switch f {
+	case AuthFailureDNS:
+		return "DNS"
+	case AuthFailureTLS:
+		return "TLS"
+	case AuthFailureNetwork:
+		return "Network"
+	case AuthFailureCredential:
+		return "Credential"
+	case AuthFailureUnauthorized:
+		return "Unauthorized"
+	case AuthFailureForbidden:
+		return "Forbidden"
+	}
+	return "Other"
+}
+
+// VerifyAuthError is returned by VerifyAuth when the cluster can't be reached or the credential isn't accepted.
+type VerifyAuthError struct {
+	// Failure classifies the error.
+	Failure AuthFailure
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *VerifyAuthError) Error() string {
+	return fmt.Sprintf("auth verification failed (%s): %s", e.Failure, e.Err)
+}
+
+func (e *VerifyAuthError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyAuth checks that the cluster can be reached and that it accepts the client's credential, by acquiring a token
+// and running the lightweight `.show version` command. It is meant to be called at startup, so services fail fast
+// instead of discovering broken connectivity or auth on the first real query. Failures are returned as a *VerifyAuthError.
+func (c *Client) VerifyAuth(ctx context.Context) error {
+	if tkp := c.auth.TokenProvider; tkp != nil && tkp.AuthorizationRequired() {
+		tkp.SetHttp(c.http)
+		if _, _, err := tkp.AcquireToken(ctx); err != nil {
+			return newVerifyAuthError(err, AuthFailureCredential)
+		}
+	}
+
+	// The command only reads, so it can run on read-only clients.
+	if _, err := c.Mgmt(ctx, "", kql.New(".show version"), AllowControlCommands()); err != nil {
+		return newVerifyAuthError(err, AuthFailureOther)
+	}
+	return nil
+}
+
+// newVerifyAuthError classifies err, using fallback when it isn't a network, TLS or HTTP status error.
+func newVerifyAuthError(err error, fallback AuthFailure) *VerifyAuthError {
+	var (
+		dnsErr       *net.DNSError
+		certErr      *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		recordErr    tls.RecordHeaderError
+		httpErr      *kustoErrors.HttpError
+		netErr       net.Error
+	)
+
+	failure := fallback
+	switch {
+	case errors.As(err, &dnsErr):
+		failure = AuthFailureDNS
+	case errors.As(err, &certErr), errors.As(err, &authorityErr), errors.As(err, &hostnameErr), errors.As(err, &invalidErr),
+		errors.As(err, &recordErr):
+		failure = AuthFailureTLS
+	case errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnauthorized:
+		failure = AuthFailureUnauthorized
+	case errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusForbidden:
+		failure = AuthFailureForbidden
+	case errors.As(err, &netErr):
+		failure = AuthFailureNetwork
+	}
+
+	return &VerifyAuthError{Failure: failure, Err: err}
+}
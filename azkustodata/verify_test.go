@@ -0,0 +1,91 @@
+package azkustodata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/require"
+	"github.com/tj/assert"
+)
+
+const verifyTestShowVersion = `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"BuildVersion","DataType":"String","ColumnType":"string"}],"Rows":[["1.0.0"]]}]}`
+
+type failingCredential struct{}
+
+func (failingCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{}, fmt.Errorf("invalid client secret")
+}
+
+// verifyTestHandler answers every request with mgmtCode, and the result of .show version if it is 200.
+func verifyTestHandler(mgmtCode int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(mgmtCode)
+		if mgmtCode == http.StatusOK {
+			_, _ = w.Write([]byte(verifyTestShowVersion))
+		}
+	}
+}
+
+func TestVerifyAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		mgmtCode int
+		cred     azcore.TokenCredential
+		trusted  bool
+		want     AuthFailure
+		wantOK   bool
+	}{
+		{name: "Success", mgmtCode: http.StatusOK, cred: &fakeCredential{}, trusted: true, wantOK: true},
+		{name: "Unauthorized", mgmtCode: http.StatusUnauthorized, cred: &fakeCredential{}, trusted: true, want: AuthFailureUnauthorized},
+		{name: "Forbidden", mgmtCode: http.StatusForbidden, cred: &fakeCredential{}, trusted: true, want: AuthFailureForbidden},
+		{name: "Credential", mgmtCode: http.StatusOK, cred: failingCredential{}, trusted: true, want: AuthFailureCredential},
+		{name: "TLS", mgmtCode: http.StatusOK, cred: &fakeCredential{}, trusted: false, want: AuthFailureTLS},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestKustoServer(t, verifyTestHandler(tt.mgmtCode))
+
+			httpClient := &http.Client{}
+			if tt.trusted {
+				httpClient = srv.Client()
+			}
+			client, err := New(NewConnectionStringBuilder(srv.URL).WithTokenCredential(tt.cred), WithHttpClient(httpClient))
+			require.NoError(t, err)
+			defer client.Close()
+
+			err = client.VerifyAuth(context.Background())
+			if tt.wantOK {
+				assert.NoError(t, err)
+				return
+			}
+
+			var verifyErr *VerifyAuthError
+			require.True(t, errors.As(err, &verifyErr), "unexpected error type %T: %v", err, err)
+			assert.Equal(t, tt.want, verifyErr.Failure)
+		})
+	}
+}
+
+func TestVerifyAuthReadonly(t *testing.T) {
+	srv := newTestKustoServer(t, verifyTestHandler(http.StatusOK))
+	client := newTestKustoClient(t, srv, WithRequestReadonly())
+	assert.NoError(t, client.VerifyAuth(context.Background()))
+}
+
+func TestVerifyAuthDNS(t *testing.T) {
+	client, err := New(NewConnectionStringBuilder("https://cluster.invalid").WithTokenCredential(&fakeCredential{}))
+	require.NoError(t, err)
+	defer client.Close()
+
+	err = client.VerifyAuth(context.Background())
+
+	var verifyErr *VerifyAuthError
+	require.True(t, errors.As(err, &verifyErr), "unexpected error type %T: %v", err, err)
+	assert.Equal(t, AuthFailureDNS, verifyErr.Failure)
+}
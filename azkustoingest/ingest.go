@@ -9,6 +9,7 @@ import (
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/queued"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	"io"
 )
 
@@ -33,8 +34,12 @@ type Ingestion struct {
 
 	withoutEndpointCorrection    bool
 	customIngestConnectionString *azkustodata.ConnectionStringBuilder
+	clientOptions                []azkustodata.Option
 	applicationForTracing        string
 	clientVersionForTracing      string
+	tracer                       trace.Tracer
+	metrics                      azkustodata.Metrics
+	logger                       azkustodata.Logger
 }
 
 // New is a constructor for Ingestion.
@@ -50,7 +55,7 @@ func New(kcsb *azkustodata.ConnectionStringBuilder, options ...Option) (*Ingesti
 	i.applicationForTracing = clientDetails.ApplicationForTracing()
 	i.clientVersionForTracing = clientDetails.ClientVersionForTracing()
 
-	client, err := azkustodata.New(kcsb)
+	client, err := azkustodata.New(kcsb, i.clientOptions...)
 	if err != nil {
 		return nil, err
 	}
@@ -59,7 +64,7 @@ func New(kcsb *azkustodata.ConnectionStringBuilder, options ...Option) (*Ingesti
 }
 
 func newFromClient(client QueryClient, i *Ingestion) (*Ingestion, error) {
-	mgr, err := resources.New(client)
+	mgr, err := resources.New(client, resources.WithLogger(i.logger))
 	if err != nil {
 		client.Close()
 		return nil, err
@@ -137,7 +142,12 @@ func (i *Ingestion) prepForIngestion(ctx context.Context, options []FileOption,
 // FromFile allows uploading a data file for Kusto from either a local path or a blobstore URI path.
 // This method is thread-safe.
 func (i *Ingestion) FromFile(ctx context.Context, fPath string, options ...FileOption) (*Result, error) {
-	return i.fromFile(ctx, fPath, options, i.newProp())
+	return traceIngestion(ctx, i.tracer, queuedKind, i.db, i.table, func(ctx context.Context) (*Result, error) {
+		size := localFileSize(fPath)
+		result, err := i.fromFile(ctx, fPath, options, i.newProp())
+		observeIngestBytes(ctx, i.metrics, result, err, size)
+		return result, err
+	})
 }
 
 // fromFile is an internal function to allow managed streaming to pass a properties object to the ingestion.
@@ -180,7 +190,12 @@ func (i *Ingestion) fromFile(ctx context.Context, fPath string, options []FileOp
 // ingested after all data in the reader is processed. Content should not use compression as the content will be
 // compressed with gzip. This method is thread-safe.
 func (i *Ingestion) FromReader(ctx context.Context, reader io.Reader, options ...FileOption) (*Result, error) {
-	return i.fromReader(ctx, reader, options, i.newProp())
+	return traceIngestion(ctx, i.tracer, queuedKind, i.db, i.table, func(ctx context.Context) (*Result, error) {
+		counter := &countingReader{r: reader}
+		result, err := i.fromReader(ctx, counter, options, i.newProp())
+		observeIngestBytes(ctx, i.metrics, result, err, counter.n)
+		return result, err
+	})
 }
 
 // fromReader is an internal function to allow managed streaming to pass a properties object to the ingestion.
@@ -209,6 +224,12 @@ func (i *Ingestion) newProp() properties.All {
 	}
 }
 
+// VerifyAuth checks that the data management endpoint can be reached and accepts the client's credential.
+// See azkustodata.Client.VerifyAuth.
+func (i *Ingestion) VerifyAuth(ctx context.Context) error {
+	return i.client.VerifyAuth(ctx)
+}
+
 func (i *Ingestion) Close() error {
 	i.mgr.Close()
 	err := i.client.Close()
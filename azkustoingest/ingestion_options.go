@@ -1,8 +1,11 @@
 package azkustoingest
 
 import (
+	"crypto/tls"
 	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"net"
+	"net/http"
 	"strings"
 )
 
@@ -50,6 +53,52 @@ func WithCustomIngestConnectionString(kcsb *azkustodata.ConnectionStringBuilder)
 	}
 }
 
+// WithClientOptions configures the Kusto client the ingest client creates with the given options.
+func WithClientOptions(options ...azkustodata.Option) Option {
+	return func(s *Ingestion) {
+		s.clientOptions = append(s.clientOptions, options...)
+	}
+}
+
+// WithHttpClient configures the ingest client to send all of its requests with the given http client, including the
+// blob uploads and queue messages of queued ingestion.
+func WithHttpClient(client *http.Client) Option {
+	return WithClientOptions(azkustodata.WithHttpClient(client))
+}
+
+// WithTransport configures the ingest client to send all of its requests with the given azcore transport, including the
+// blob uploads and queue messages of queued ingestion.
+func WithTransport(transport policy.Transporter) Option {
+	return WithClientOptions(azkustodata.WithTransport(transport))
+}
+
+// WithProxy configures the ingest client to connect through the given proxy, including for the blob uploads and queue
+// messages of queued ingestion.
+func WithProxy(proxy azkustodata.ProxyOptions) Option {
+	return WithClientOptions(azkustodata.WithProxy(proxy))
+}
+
+// WithTLSConfig configures the TLS settings of the ingest client's connections, including for the blob uploads and queue
+// messages of queued ingestion.
+func WithTLSConfig(config *tls.Config) Option {
+	return WithClientOptions(azkustodata.WithTLSConfig(config))
+}
+
+// WithConnectionPool tunes the connection pool of the ingest client's transport, which is also used for the blob uploads
+// and queue messages of queued ingestion.
+func WithConnectionPool(pool azkustodata.ConnectionPoolOptions) Option {
+	return WithClientOptions(azkustodata.WithConnectionPool(pool))
+}
+
+// WithLogger sets the logger the client writes its logs to, including the refreshes of the ingestion resources.
+// See azkustodata.WithLogger.
+func WithLogger(logger azkustodata.Logger) Option {
+	return func(s *Ingestion) {
+		s.clientOptions = append(s.clientOptions, azkustodata.WithLogger(logger))
+		s.logger = logger
+	}
+}
+
 func getOptions(options []Option) *Ingestion {
 	s := &Ingestion{}
 	for _, o := range options {
@@ -3,6 +3,7 @@ package azkustoingest
 import (
 	"github.com/Azure/azure-kusto-go/azkustodata"
 	"github.com/stretchr/testify/assert"
+	"net/http"
 	"testing"
 )
 
@@ -258,3 +259,35 @@ func TestCtorOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestHttpClientOption(t *testing.T) {
+	httpClient := &http.Client{}
+	kcsb := azkustodata.NewConnectionStringBuilder("https://ingest-help.kusto.windows.net")
+
+	queued, err := New(kcsb, WithHttpClient(httpClient))
+	assert.NoError(t, err)
+	assert.Same(t, httpClient, queued.client.HttpClient())
+
+	streaming, err := NewStreaming(kcsb, WithHttpClient(httpClient))
+	assert.NoError(t, err)
+	assert.Same(t, httpClient, streaming.client.HttpClient())
+
+	managed, err := NewManaged(kcsb, WithTransport(httpClient))
+	assert.NoError(t, err)
+	assert.Same(t, httpClient, managed.queued.client.HttpClient())
+	assert.Same(t, httpClient, managed.streaming.client.HttpClient())
+}
+
+func TestProxyOption(t *testing.T) {
+	kcsb := azkustodata.NewConnectionStringBuilder("https://ingest-help.kusto.windows.net")
+
+	queued, err := New(kcsb, WithProxy(azkustodata.ProxyOptions{URL: "http://proxy.contoso.com:8080"}))
+	assert.NoError(t, err)
+
+	// The storage clients of queued ingestion share the Kusto client's http client, and so its proxy.
+	req, err := http.NewRequest(http.MethodPut, "https://account.blob.core.windows.net/container/blob", nil)
+	assert.NoError(t, err)
+	proxy, err := queued.client.HttpClient().Transport.(*http.Transport).Proxy(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://proxy.contoso.com:8080", proxy.String())
+}
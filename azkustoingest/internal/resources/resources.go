@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/url"
 	"strings"
 	"sync"
@@ -117,11 +118,25 @@ type Manager struct {
 	authLock                 sync.Mutex
 	fetchLock                sync.Mutex
 	rankedStorageAccount     *RankedStorageAccountSet
+	logger                   azkustodata.Logger
+}
+
+// Option is an optional argument to New().
+type Option func(m *Manager)
+
+// WithLogger sets the logger the manager logs the refreshes of the ingestion resources to.
+func WithLogger(logger azkustodata.Logger) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
 }
 
 // New is the constructor for Manager.
-func New(client mgmter) (*Manager, error) {
+func New(client mgmter, options ...Option) (*Manager, error) {
 	m := &Manager{client: client, done: make(chan struct{}), rankedStorageAccount: newDefaultRankedStorageAccountSet()}
+	for _, o := range options {
+		o(m)
+	}
 	m.authLock = sync.Mutex{}
 	m.fetchLock = sync.Mutex{}
 
@@ -156,7 +171,9 @@ func (m *Manager) renewResources() {
 			count += tickDuration
 			if count >= fetchInterval {
 				count = 0 * time.Second
-				m.fetchRetry(context.Background())
+				if err := m.fetchRetry(context.Background()); err != nil {
+					m.log(context.Background(), slog.LevelWarn, "failed to refresh ingestion resources", "error", err)
+				}
 			}
 		case <-m.done:
 			tick.Stop()
@@ -209,6 +226,7 @@ func (m *Manager) AuthContext(ctx context.Context) (string, error) {
 
 	m.kustoToken = tokens[0]
 	m.authTokenCacheExpiration = time.Now().UTC().Add(time.Hour)
+	m.log(ctx, slog.LevelDebug, "refreshed kusto identity token", "expiresOn", m.authTokenCacheExpiration)
 	return tokens[0].AuthContext, nil
 }
 
@@ -331,6 +349,8 @@ func (m *Manager) fetch(ctx context.Context) error {
 	}
 
 	m.resources.Store(ingest)
+	m.log(ctx, slog.LevelDebug, "fetched ingestion resources", "containers", len(ingest.Containers), "queues", len(ingest.Queues),
+		"tables", len(ingest.Tables))
 
 	m.lastFetchTime.Store(time.Now().UTC())
 
@@ -355,6 +375,7 @@ func (m *Manager) fetchRetry(ctx context.Context) error {
 			if attempts > retryCount {
 				return fmt.Errorf("failed to fetch ingestion resources: %w", err)
 			}
+			m.log(ctx, slog.LevelInfo, "failed to fetch ingestion resources, retrying", "attempt", attempts, "error", err)
 			time.Sleep(10 * time.Second)
 			continue
 		}
@@ -362,6 +383,12 @@ func (m *Manager) fetchRetry(ctx context.Context) error {
 	}
 }
 
+func (m *Manager) log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if m.logger != nil {
+		m.logger.Log(ctx, level, msg, args...)
+	}
+}
+
 func initBackoff() backoff.BackOff {
 	exp := backoff.NewExponentialBackOff()
 	exp.InitialInterval = defaultInitialInterval
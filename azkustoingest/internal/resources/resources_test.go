@@ -1,12 +1,15 @@
 package resources
 
 import (
+	"bytes"
 	"context"
+	"log/slog"
 	"testing"
 
 	v1 "github.com/Azure/azure-kusto-go/azkustodata/query/v1"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/Azure/azure-kusto-go/azkustodata/types"
 	"github.com/Azure/azure-kusto-go/azkustodata/value"
@@ -233,3 +236,14 @@ func TestResources(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchLogs(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	manager := &Manager{client: SuccessfulFakeResources(), rankedStorageAccount: newDefaultRankedStorageAccountSet(), logger: logger}
+
+	require.NoError(t, manager.fetch(context.Background()))
+	assert.Contains(t, buf.String(), `msg="fetched ingestion resources" containers=1 queues=1 tables=0`)
+}
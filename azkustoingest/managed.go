@@ -3,11 +3,14 @@ package azkustoingest
 import (
 	"bytes"
 	"context"
+	stdErrors "errors"
 	"fmt"
 	"github.com/Azure/azure-kusto-go/azkustodata"
 	"github.com/Azure/azure-kusto-go/azkustoingest/ingestoptions"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/queued"
 	"io"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-kusto-go/azkustodata/errors"
@@ -63,28 +66,59 @@ func newManagedFromClients(queued *Ingestion, streaming *Streaming) *Managed {
 	}
 }
 
+// streamingDisabledErrors are parts of the codes and types of the errors of tables and clusters on which streaming
+// ingestion is disabled.
+var streamingDisabledErrors = []string{"StreamingIngestionPolicyNotEnabled", "StreamingIngestionDisabled"}
+
+// shouldFallBackToQueued reports whether a streaming failure won't be solved by retrying, but will be by queued
+// ingestion: the data is too large, the cluster is throttling streaming ingestion, or streaming ingestion is disabled.
+func shouldFallBackToQueued(err error) bool {
+	var httpErr *errors.HttpError
+	if stdErrors.As(err, &httpErr) && httpErr.StatusCode == http.StatusRequestEntityTooLarge {
+		return true
+	}
+	if errors.IsThrottled(err) {
+		return true
+	}
+	if oneApiErr, ok := errors.AsOneApiError(err); ok {
+		for _, m := range oneApiErr.ErrorMessage.Inner() {
+			for _, disabled := range streamingDisabledErrors {
+				if strings.Contains(m.Code, disabled) || strings.Contains(m.Type, disabled) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // Attempts to stream with retries, on success - return res,nil.
 // If failed permanently - return err,nil.
-// If failed transiently - return nil,nil.
+// If failed transiently, or with an error queued ingestion avoids - return nil,nil.
 func (m *Managed) streamWithRetries(ctx context.Context, payloadProvider func() io.Reader, props properties.All, isBlobUri bool) (*Result, error) {
 	var result *Result
 
 	hasCustomId := props.Streaming.ClientRequestId != ""
 	i := 0
-	managedUuid := uuid.New().String()
+	fallBack := false
 
 	actualBackoff := backoff.WithContext(backoff.WithMaxRetries(props.ManagedStreaming.Backoff, retryCount), ctx)
 
 	var err error = nil
 	err = backoff.Retry(func() error {
 		if !hasCustomId {
-			props.Streaming.ClientRequestId = fmt.Sprintf("KGC.executeManagedStreamingIngest;%s;%d", managedUuid, i)
+			// The source ID is shared with the queued ingestion falling back, so both can be tracked by it.
+			props.Streaming.ClientRequestId = fmt.Sprintf("KGC.executeManagedStreamingIngest;%s;%d", props.Source.ID, i)
 		}
 		result, err = streamImpl(m.streaming.streamConn, ctx, payloadProvider(), props, isBlobUri)
 		i++
 		if err != nil {
+			if shouldFallBackToQueued(err) {
+				fallBack = true
+				return backoff.Permanent(err)
+			}
 			if e, ok := err.(*errors.Error); ok {
-				if errors.Retry(e) {
+				if errors.Retry(e) && !errors.IsPermanent(e) {
 					return err
 				} else {
 					return backoff.Permanent(err)
@@ -100,7 +134,7 @@ func (m *Managed) streamWithRetries(ctx context.Context, payloadProvider func()
 		return result, nil
 	}
 
-	if errors.Retry(err) {
+	if fallBack || (errors.Retry(err) && !errors.IsPermanent(err)) {
 		// Caller should fallback to queued
 		return nil, nil
 	}
@@ -109,6 +143,15 @@ func (m *Managed) streamWithRetries(ctx context.Context, payloadProvider func()
 }
 
 func (m *Managed) FromFile(ctx context.Context, fPath string, options ...FileOption) (*Result, error) {
+	return traceIngestion(ctx, m.queued.tracer, managedKind, m.queued.db, m.queued.table, func(ctx context.Context) (*Result, error) {
+		size := localFileSize(fPath)
+		result, err := m.fromFile(ctx, fPath, options)
+		observeIngestBytes(ctx, m.queued.metrics, result, err, size)
+		return result, err
+	})
+}
+
+func (m *Managed) fromFile(ctx context.Context, fPath string, options []FileOption) (*Result, error) {
 	props := m.newProp()
 	file, err, local := prepFileAndProps(fPath, &props, options, ManagedClient)
 	if err != nil {
@@ -156,6 +199,15 @@ func shouldUseQueuedIngestBySize(compression ingestoptions.CompressionType, file
 }
 
 func (m *Managed) FromReader(ctx context.Context, reader io.Reader, options ...FileOption) (*Result, error) {
+	return traceIngestion(ctx, m.queued.tracer, managedKind, m.queued.db, m.queued.table, func(ctx context.Context) (*Result, error) {
+		counter := &countingReader{r: reader}
+		result, err := m.fromReader(ctx, counter, options)
+		observeIngestBytes(ctx, m.queued.metrics, result, err, counter.n)
+		return result, err
+	})
+}
+
+func (m *Managed) fromReader(ctx context.Context, reader io.Reader, options []FileOption) (*Result, error) {
 	props := m.newProp()
 
 	for _, prop := range options {
@@ -204,17 +256,32 @@ func (m *Managed) newProp() properties.All {
 	exp.InitialInterval = defaultInitialInterval
 	exp.Multiplier = defaultMultiplier
 
+	// The same source ID identifies the data whether it is streamed or queued.
+	sourceID := uuid.New()
 	return properties.All{
 		Ingestion: properties.Ingestion{
+			ID:           sourceID,
 			DatabaseName: m.streaming.db,
 			TableName:    m.streaming.table,
 		},
+		Source: properties.SourceOptions{
+			ID: sourceID,
+		},
 		ManagedStreaming: properties.ManagedStreaming{
 			Backoff: exp,
 		},
 	}
 }
 
+// VerifyAuth checks that both the cluster, used for streaming, and its data management endpoint, used for queued
+// ingestion, can be reached and accept the client's credential. See azkustodata.Client.VerifyAuth.
+func (m *Managed) VerifyAuth(ctx context.Context) error {
+	if err := m.streaming.VerifyAuth(ctx); err != nil {
+		return err
+	}
+	return m.queued.VerifyAuth(ctx)
+}
+
 func (m *Managed) Close() error {
 	return errors.TryCombinedError(m.queued.Close(), m.streaming.Close())
 }
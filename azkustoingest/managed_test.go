@@ -13,6 +13,7 @@ import (
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/resources"
 	"github.com/cenkalti/backoff/v4"
 	"io"
+	"net/http"
 	"strings"
 	"testing"
 	"time"
@@ -33,6 +34,26 @@ func failIfQueuedCalled(t *testing.T, _ context.Context, _ string, query azkusto
 	return nil, nil
 }
 
+// allowQueuedCalls answers the calls made by queued ingestion.
+func allowQueuedCalls(t *testing.T, ctx context.Context, db string, query azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error) {
+	// .get ingestion resources is always called in the ctor
+	if query.String() == ".get ingestion resources" {
+		return resources.SuccessfulFakeResources().Mgmt(ctx, db, query, options...)
+	}
+	if query.String() == ".get kusto identity token" {
+		return nil, nil
+	}
+
+	require.Fail(t, "Unexpected queued ingest call")
+	return nil, nil
+}
+
+// streamingHttpError returns the error of a streaming ingestion request the cluster answered with statusCode and body.
+func streamingHttpError(statusCode int, body string) error {
+	httpErr := errors.HTTP(errors.OpIngestStream, http.StatusText(statusCode), statusCode, io.NopCloser(strings.NewReader(body)), "error from Kusto endpoint")
+	return errors.E(errors.OpIngestStream, errors.KHTTPError, fmt.Errorf("streaming ingestion failed: %w", httpErr))
+}
+
 func TestManaged(t *testing.T) {
 	t.Parallel()
 
@@ -44,6 +65,19 @@ func TestManaged(t *testing.T) {
 	bigFilePath, bigReader := bigCsvFileAndReader()
 	bigData, _ := initFile(t, bigReader)
 	counter := 0
+	// streamedSourceID is the source ID of the last streaming attempt, which queued ingestion keeps when falling back.
+	streamedSourceID := ""
+	fallBackOnReader := func(t *testing.T, ctx context.Context, reader io.Reader, props properties.All) (string, error) {
+		counter++
+		assert.Equal(t, streamedSourceID, props.Source.ID.String())
+		assert.Equal(t, props.Source.ID, props.Ingestion.ID)
+		all, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, compressedBytes, all)
+		return "", nil
+	}
+	permanentErr := streamingHttpError(http.StatusBadRequest,
+		`{"error":{"code":"BadRequest_EntityMappingNotFound","message":"Request is invalid and cannot be executed.","@permanent":true}}`)
 
 	someBlobPath := "https://some-blob.blob.core.windows.net/some-container/some-blob;Managed_Identity="
 
@@ -258,6 +292,58 @@ func TestManaged(t *testing.T) {
 			expectedCounter: 4,
 			expectedStatus:  Queued,
 		},
+		{
+			name:    "TestStreamingPolicyNotEnabled",
+			options: []FileOption{},
+			onStreamIngest: func(t *testing.T, ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string,
+				clientRequestId string, isBlobUri bool) error {
+				streamedSourceID = strings.Split(clientRequestId, ";")[1]
+				return streamingHttpError(http.StatusBadRequest, `{"error":{"code":"BadRequest_StreamingIngestionPolicyNotEnabled",`+
+					`"message":"Request is invalid and cannot be executed.","@type":"Kusto.DataNode.Exceptions.StreamingIngestionPolicyNotEnabledException",`+
+					`"@permanent":true}}`)
+			},
+			onMgmt:          allowQueuedCalls,
+			onReader:        fallBackOnReader,
+			expectedCounter: 2,
+			expectedStatus:  Queued,
+		},
+		{
+			name:    "TestThrottled",
+			options: []FileOption{},
+			onStreamIngest: func(t *testing.T, ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string,
+				clientRequestId string, isBlobUri bool) error {
+				streamedSourceID = strings.Split(clientRequestId, ";")[1]
+				return streamingHttpError(http.StatusTooManyRequests, `{"error":{"code":"TooManyRequests","message":"Request is throttled."}}`)
+			},
+			onMgmt:          allowQueuedCalls,
+			onReader:        fallBackOnReader,
+			expectedCounter: 2,
+			expectedStatus:  Queued,
+		},
+		{
+			name:    "TestRequestTooLarge",
+			options: []FileOption{},
+			onStreamIngest: func(t *testing.T, ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string,
+				clientRequestId string, isBlobUri bool) error {
+				streamedSourceID = strings.Split(clientRequestId, ";")[1]
+				return streamingHttpError(http.StatusRequestEntityTooLarge, "")
+			},
+			onMgmt:          allowQueuedCalls,
+			onReader:        fallBackOnReader,
+			expectedCounter: 2,
+			expectedStatus:  Queued,
+		},
+		{
+			name:    "TestPermanentHttpError",
+			options: []FileOption{},
+			onStreamIngest: func(t *testing.T, ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string,
+				clientRequestId string, isBlobUri bool) error {
+				return permanentErr
+			},
+			expectedError:   permanentErr,
+			onMgmt:          failIfQueuedCalled,
+			expectedCounter: 1,
+		},
 		{
 			name:      "TestBigFile",
 			options:   []FileOption{},
@@ -445,3 +531,40 @@ func initFile(t *testing.T, reader *bytes.Reader) ([]byte, []byte) {
 	require.NoError(t, err)
 	return data, compressedBytes
 }
+
+func TestManagedVerifyAuth(t *testing.T) {
+	t.Parallel()
+
+	authErr := &azkustodata.VerifyAuthError{Failure: azkustodata.AuthFailureForbidden, Err: fmt.Errorf("forbidden")}
+
+	tests := []struct {
+		name         string
+		queuedErr    error
+		streamingErr error
+		want         error
+	}{
+		{name: "Success"},
+		{name: "QueuedFails", queuedErr: authErr, want: authErr},
+		{name: "StreamingFails", streamingErr: authErr, want: authErr},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			queuedClient := newMockClient()
+			queuedClient.authErr = tt.queuedErr
+			streamingClient := newMockClient()
+			streamingClient.authErr = tt.streamingErr
+
+			queued, err := newFromClient(queuedClient, &Ingestion{})
+			require.NoError(t, err)
+			streaming, err := newStreamingFromClient(streamingClient, &Ingestion{})
+			require.NoError(t, err)
+			managed := newManagedFromClients(queued, streaming)
+
+			assert.Equal(t, tt.queuedErr, queued.VerifyAuth(context.Background()))
+			assert.Equal(t, tt.streamingErr, streaming.VerifyAuth(context.Background()))
+			assert.Equal(t, tt.want, managed.VerifyAuth(context.Background()))
+		})
+	}
+}
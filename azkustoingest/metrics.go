@@ -0,0 +1,51 @@
+package azkustoingest
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustoingest/internal/queued"
+)
+
+// WithMetrics sets the Metrics the client reports its measurements to, including the bytes it ingests.
+// See azkustodata.WithMetrics.
+func WithMetrics(metrics azkustodata.Metrics) Option {
+	return func(s *Ingestion) {
+		s.clientOptions = append(s.clientOptions, azkustodata.WithMetrics(metrics))
+		s.metrics = metrics
+	}
+}
+
+// countingReader counts the bytes read from an io.Reader.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// localFileSize returns the size of fPath if it is a local file, or -1 otherwise.
+func localFileSize(fPath string) int64 {
+	if local, err := queued.IsLocalPath(fPath); err != nil || !local {
+		return -1
+	}
+	info, err := os.Stat(fPath)
+	if err != nil {
+		return -1
+	}
+	return info.Size()
+}
+
+// observeIngestBytes reports the size of a successful ingestion, if it is known.
+func observeIngestBytes(ctx context.Context, metrics azkustodata.Metrics, result *Result, err error, size int64) {
+	if metrics == nil || err != nil || result == nil || size < 0 {
+		return
+	}
+	metrics.ObserveIngestBytes(ctx, result.record.Database, result.record.Table, size)
+}
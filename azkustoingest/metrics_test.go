@@ -0,0 +1,56 @@
+package azkustoingest
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ingestBytesMetrics struct {
+	azkustodata.NoopMetrics
+	observed []string
+	bytes    []int64
+}
+
+func (m *ingestBytesMetrics) ObserveIngestBytes(_ context.Context, db string, table string, bytes int64) {
+	m.observed = append(m.observed, db+"/"+table)
+	m.bytes = append(m.bytes, bytes)
+}
+
+func TestIngestBytesMetrics(t *testing.T) {
+	t.Parallel()
+
+	filePath, reader := csvFileAndReader()
+	metrics := &ingestBytesMetrics{}
+	i := &Ingestion{}
+	WithMetrics(metrics)(i)
+	require.Len(t, i.clientOptions, 1)
+
+	streaming := Streaming{
+		db:     "db",
+		table:  "table",
+		client: mockClient{endpoint: "https://test.kusto.windows.net"},
+		streamConn: fakeStreamIngestor{
+			onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+				_, err := io.Copy(io.Discard, payload)
+				return err
+			},
+		},
+		metrics: metrics,
+	}
+
+	_, err := streaming.FromReader(context.Background(), reader)
+	require.NoError(t, err)
+	_, err = streaming.FromFile(context.Background(), filePath)
+	require.NoError(t, err)
+	_, err = streaming.FromFile(context.Background(), "https://account.blob.core.windows.net/container/file.csv")
+	require.NoError(t, err)
+
+	// Blobs aren't read by the client, so their size isn't reported.
+	assert.Equal(t, []string{"db/table", "db/table"}, metrics.observed)
+	assert.Equal(t, []int64{reader.Size(), reader.Size()}, metrics.bytes)
+}
@@ -14,6 +14,7 @@ type mockClient struct {
 	endpoint string
 	auth     azkustodata.Authorization
 	onMgmt   func(ctx context.Context, db string, query azkustodata.Statement, options ...azkustodata.QueryOption) (v1.Dataset, error)
+	authErr  error
 }
 
 func (m mockClient) Query(_ context.Context, _ string, _ azkustodata.Statement, _ ...azkustodata.QueryOption) (query.Dataset, error) {
@@ -39,6 +40,10 @@ func (m mockClient) Auth() azkustodata.Authorization {
 	return m.auth
 }
 
+func (m mockClient) VerifyAuth(_ context.Context) error {
+	return m.authErr
+}
+
 func (m mockClient) Endpoint() string {
 	return m.endpoint
 }
@@ -18,4 +18,5 @@ type QueryClient interface {
 	IterativeQuery(ctx context.Context, db string, query azkustodata.Statement, options ...azkustodata.QueryOption) (query.IterativeDataset, error)
 	HttpClient() *http.Client
 	ClientDetails() *azkustodata.ClientDetails
+	VerifyAuth(ctx context.Context) error
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/properties"
 	"github.com/Azure/azure-kusto-go/azkustoingest/internal/queued"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type streamIngestor interface {
@@ -28,6 +29,8 @@ type Streaming struct {
 	table      string
 	client     QueryClient
 	streamConn streamIngestor
+	tracer     trace.Tracer
+	metrics    azkustodata.Metrics
 }
 
 type blobUri struct {
@@ -46,7 +49,7 @@ func NewStreaming(kcsb *azkustodata.ConnectionStringBuilder, options ...Option)
 		kcsb = &newKcsb
 	}
 
-	client, err := azkustodata.New(kcsb)
+	client, err := azkustodata.New(kcsb, o.clientOptions...)
 	if err != nil {
 		return nil, err
 	}
@@ -66,6 +69,8 @@ func newStreamingFromClient(client QueryClient, o *Ingestion) (*Streaming, error
 		table:      o.table,
 		client:     client,
 		streamConn: streamConn,
+		tracer:     o.tracer,
+		metrics:    o.metrics,
 	}
 
 	return i, nil
@@ -74,6 +79,15 @@ func newStreamingFromClient(client QueryClient, o *Ingestion) (*Streaming, error
 // FromFile allows uploading a data file for Kusto from either a local path or a blobstore URI path.
 // This method is thread-safe.
 func (i *Streaming) FromFile(ctx context.Context, fPath string, options ...FileOption) (*Result, error) {
+	return traceIngestion(ctx, i.tracer, streamingKind, i.db, i.table, func(ctx context.Context) (*Result, error) {
+		size := localFileSize(fPath)
+		result, err := i.fromFile(ctx, fPath, options)
+		observeIngestBytes(ctx, i.metrics, result, err, size)
+		return result, err
+	})
+}
+
+func (i *Streaming) fromFile(ctx context.Context, fPath string, options []FileOption) (*Result, error) {
 	props := i.newProp()
 	file, err, local := prepFileAndProps(fPath, &props, options, StreamingClient)
 
@@ -124,10 +138,19 @@ func prepFileAndProps(fPath string, props *properties.All, options []FileOption,
 	return file, nil, true
 }
 
-// FromReader allows uploading a data file for Kusto from an io.Reader. The content is uploaded to Blobstore and
-// ingested after all data in the reader is processed. Content should not use compression as the content will be
-// compressed with gzip. This method is thread-safe.
+// FromReader streams the data of an io.Reader to the engine, after all data in the reader is processed. Content should
+// not use compression as the content will be compressed with gzip. Streaming ingestion is limited to 4MB of data before
+// compression, use Managed to fall back to queued ingestion for larger data. This method is thread-safe.
 func (i *Streaming) FromReader(ctx context.Context, reader io.Reader, options ...FileOption) (*Result, error) {
+	return traceIngestion(ctx, i.tracer, streamingKind, i.db, i.table, func(ctx context.Context) (*Result, error) {
+		counter := &countingReader{r: reader}
+		result, err := i.fromReader(ctx, counter, options)
+		observeIngestBytes(ctx, i.metrics, result, err, counter.n)
+		return result, err
+	})
+}
+
+func (i *Streaming) fromReader(ctx context.Context, reader io.Reader, options []FileOption) (*Result, error) {
 	props := i.newProp()
 
 	for _, prop := range options {
@@ -141,6 +164,20 @@ func (i *Streaming) FromReader(ctx context.Context, reader io.Reader, options ..
 }
 
 func streamImpl(c streamIngestor, ctx context.Context, payload io.Reader, props properties.All, isBlobUri bool) (*Result, error) {
+	if !isBlobUri {
+		// The engine rejects streaming requests of more than 4MB of data, before compression. Data which is already
+		// compressed is checked on its compressed size, as its original size is unknown.
+		buf, err := io.ReadAll(io.LimitReader(payload, maxStreamingSize+1))
+		if err != nil {
+			return nil, errors.E(errors.OpIngestStream, errors.KIO, err)
+		}
+		if int64(len(buf)) > maxStreamingSize {
+			return nil, errors.ES(errors.OpIngestStream, errors.KClientArgs,
+				"streaming ingestion is limited to %d bytes of data before compression, use queued or managed ingestion for larger data", maxStreamingSize)
+		}
+		payload = bytes.NewReader(buf)
+	}
+
 	compress := queued.ShouldCompress(&props, ingestoptions.CTUnknown)
 	if compress && !isBlobUri {
 		payload = gzip.Compress(payload)
@@ -186,6 +223,12 @@ func (i *Streaming) newProp() properties.All {
 	}
 }
 
+// VerifyAuth checks that the cluster can be reached and accepts the client's credential.
+// See azkustodata.Client.VerifyAuth.
+func (i *Streaming) VerifyAuth(ctx context.Context) error {
+	return i.client.VerifyAuth(ctx)
+}
+
 func (i *Streaming) Close() error {
 	return i.streamConn.Close()
 }
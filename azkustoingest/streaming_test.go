@@ -7,6 +7,7 @@ import (
 	"github.com/Azure/azure-kusto-go/azkustodata"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -223,3 +224,43 @@ func TestStreaming(t *testing.T) {
 	}
 
 }
+
+func TestStreamingSizeLimit(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	calls := 0
+	streaming := Streaming{
+		db:    "defaultDb",
+		table: "defaultTable",
+		client: mockClient{
+			endpoint: "https://test.kusto.windows.net",
+			auth:     azkustodata.Authorization{},
+		},
+		streamConn: fakeStreamIngestor{
+			onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+				calls++
+				_, err := io.Copy(io.Discard, payload)
+				return err
+			},
+		},
+	}
+
+	// The limit applies to the data before compression, even though it compresses well below it.
+	tooBig := strings.Repeat("a", int(maxStreamingSize)+1)
+	_, err := streaming.FromReader(ctx, strings.NewReader(tooBig))
+	require.Error(t, err)
+	assert.Equal(t, errors.KClientArgs, err.(*errors.Error).Kind)
+
+	filePath := filepath.Join(t.TempDir(), "big.csv")
+	require.NoError(t, os.WriteFile(filePath, []byte(tooBig), 0o600))
+	_, err = streaming.FromFile(ctx, filePath)
+	require.Error(t, err)
+	assert.Equal(t, errors.KClientArgs, err.(*errors.Error).Kind)
+	assert.Equal(t, 0, calls)
+
+	result, err := streaming.FromReader(ctx, strings.NewReader(tooBig[1:]))
+	require.NoError(t, err)
+	assert.Equal(t, StatusCode("Success"), result.record.Status)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,63 @@
+package azkustoingest
+
+import (
+	"context"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the ingestion spans.
+const tracerName = "github.com/Azure/azure-kusto-go/azkustoingest"
+
+// Kinds of ingestion, recorded in the kusto.ingestion.kind attribute of spans.
+const (
+	queuedKind    = "queued"
+	streamingKind = "streaming"
+	managedKind   = "managed"
+)
+
+// WithTracing configures the client to create an OpenTelemetry span for each FromFile and FromReader call, in
+// addition to the spans of the underlying azkustodata client. See azkustodata.WithTracing.
+func WithTracing(options azkustodata.TracingOptions) Option {
+	return func(s *Ingestion) {
+		s.clientOptions = append(s.clientOptions, azkustodata.WithTracing(options))
+		provider := options.TracerProvider
+		if provider == nil {
+			provider = otel.GetTracerProvider()
+		}
+		s.tracer = provider.Tracer(tracerName)
+	}
+}
+
+// traceIngestion runs ingest within a "kusto.ingest" span, if tracer isn't nil.
+// The target database and table default to db and table, and are updated from the result on success.
+func traceIngestion(ctx context.Context, tracer trace.Tracer, kind, db, table string, ingest func(ctx context.Context) (*Result, error)) (*Result, error) {
+	if tracer == nil {
+		return ingest(ctx)
+	}
+
+	ctx, span := tracer.Start(ctx, "kusto.ingest", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "kusto"),
+		attribute.String("kusto.ingestion.kind", kind),
+	))
+	defer span.End()
+
+	result, err := ingest(ctx)
+	if result != nil {
+		db, table = result.record.Database, result.record.Table
+		if result.record.IngestionSourceID != uuid.Nil {
+			span.SetAttributes(attribute.String("kusto.ingestion.source_id", result.record.IngestionSourceID.String()))
+		}
+	}
+	span.SetAttributes(attribute.String("db.name", db), attribute.String("kusto.table", table))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return result, err
+}
@@ -0,0 +1,73 @@
+package azkustoingest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/azkustodata"
+	"github.com/Azure/azure-kusto-go/azkustodata/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		options   []FileOption
+		err       error
+		wantDb    string
+		wantTable string
+	}{
+		{name: "Success", options: []FileOption{Database("db"), Table("table")}, wantDb: "db", wantTable: "table"},
+		{name: "Failure", err: errors.E(errors.OpIngestStream, errors.KHTTPError, fmt.Errorf("error")), wantDb: "defaultDb", wantTable: "defaultTable"},
+	}
+	for _, tt := range tests {
+		tt := tt // Capture
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := tracetest.NewSpanRecorder()
+			i := &Ingestion{}
+			WithTracing(azkustodata.TracingOptions{TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))})(i)
+			require.Len(t, i.clientOptions, 1)
+
+			streaming := Streaming{
+				db:     "defaultDb",
+				table:  "defaultTable",
+				client: mockClient{endpoint: "https://test.kusto.windows.net"},
+				streamConn: fakeStreamIngestor{
+					onStreamIngest: func(ctx context.Context, db, table string, payload io.Reader, format azkustodata.DataFormatForStreaming, mappingName string, clientRequestId string, isBlobUri bool) error {
+						return tt.err
+					},
+				},
+				tracer: i.tracer,
+			}
+
+			_, err := streaming.FromReader(context.Background(), strings.NewReader("a,b"), tt.options...)
+			assert.Equal(t, tt.err, err)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "kusto.ingest", spans[0].Name())
+			attrs := spans[0].Attributes()
+			assert.Contains(t, attrs, attribute.String("db.system", "kusto"))
+			assert.Contains(t, attrs, attribute.String("kusto.ingestion.kind", streamingKind))
+			assert.Contains(t, attrs, attribute.String("db.name", tt.wantDb))
+			assert.Contains(t, attrs, attribute.String("kusto.table", tt.wantTable))
+			if tt.err != nil {
+				assert.Equal(t, codes.Error, spans[0].Status().Code)
+			} else {
+				assert.Equal(t, codes.Unset, spans[0].Status().Code)
+			}
+		})
+	}
+}